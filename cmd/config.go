@@ -48,6 +48,11 @@ func (c ConfigRelatedOptions) Parse(out io.Writer, component string, config inte
 			}
 			if u.Fragment != "" {
 				u.Path = fmt.Sprintf("%s/%s", u.Path, u.Fragment)
+			} else if hostname, err := os.Hostname(); err == nil {
+				// Let the orchestrator select a named configuration
+				query := u.Query()
+				query.Set("hostname", hostname)
+				u.RawQuery = query.Encode()
 			}
 			resp, err := http.Get(u.String())
 			if err != nil {
@@ -256,7 +256,9 @@ module2:
 }
 
 func TestHTTPConfiguration(t *testing.T) {
+	var gotHostname string
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHostname = r.URL.Query().Get("hostname")
 		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
 		fmt.Fprint(w, `---
 module1:
@@ -307,6 +309,9 @@ module2:
 	if diff := helpers.Diff(parsed, expected); diff != "" {
 		t.Errorf("Parse() (-got, +want):\n%s", diff)
 	}
+	if hostname, _ := os.Hostname(); gotHostname != hostname {
+		t.Errorf("Parse() sent hostname %q instead of %q", gotHostname, hostname)
+	}
 }
 
 func TestUnused(t *testing.T) {
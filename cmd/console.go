@@ -4,6 +4,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -97,6 +98,23 @@ func consoleStart(r *reporter.Reporter, config ConsoleConfiguration, checkOnly b
 	if err != nil {
 		return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
 	}
+	var sqlClickHouseComponent *clickhousedb.Component
+	if len(config.Console.SQL.AllowedUsers) > 0 {
+		// Ad-hoc SQL queries use a dedicated user with restricted grants.
+		if config.Console.SQL.Username == "" {
+			return errors.New("SQL endpoint requires a dedicated ClickHouse user")
+		}
+		sqlConfiguration := config.ClickHouse
+		sqlConfiguration.Username = config.Console.SQL.Username
+		sqlConfiguration.Password = config.Console.SQL.Password
+		sqlClickHouseComponent, err = clickhousedb.New(r, sqlConfiguration, clickhousedb.Dependencies{
+			Daemon: daemonComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize ClickHouse component for SQL queries: %w", err)
+		}
+		defer sqlClickHouseComponent.Close()
+	}
 	authenticationComponent, err := authentication.New(r, config.Auth)
 	if err != nil {
 		return fmt.Errorf("unable to initialize authentication component: %w", err)
@@ -110,12 +128,13 @@ func consoleStart(r *reporter.Reporter, config ConsoleConfiguration, checkOnly b
 		return fmt.Errorf("unable to initialize schema component: %w", err)
 	}
 	consoleComponent, err := console.New(r, config.Console, console.Dependencies{
-		Daemon:       daemonComponent,
-		HTTP:         httpComponent,
-		ClickHouseDB: clickhouseComponent,
-		Auth:         authenticationComponent,
-		Database:     databaseComponent,
-		Schema:       schemaComponent,
+		Daemon:          daemonComponent,
+		HTTP:            httpComponent,
+		ClickHouseDB:    clickhouseComponent,
+		SQLClickHouseDB: sqlClickHouseComponent,
+		Auth:            authenticationComponent,
+		Database:        databaseComponent,
+		Schema:          schemaComponent,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to initialize console component: %w", err)
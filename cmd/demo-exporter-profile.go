@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/helpers/yaml"
+	"akvorado/common/reporter"
+	"akvorado/demoexporter/flows"
+	"akvorado/demoexporter/profile"
+)
+
+type demoExporterProfileOptions struct {
+	ConfigRelatedOptions
+	Profile profile.Options
+}
+
+// DemoExporterProfileOptions stores the command-line option values for the
+// demo exporter profile command.
+var DemoExporterProfileOptions demoExporterProfileOptions
+
+var demoExporterProfileCmd = &cobra.Command{
+	Use:   "demo-exporter-profile CONFIG",
+	Short: "Generate a demo exporter configuration from collected flows",
+	Long: `Query the flows stored in ClickHouse to generate a flow configuration
+for the demo exporter reproducing the observed traffic: daily shape, top
+AS numbers, countries, protocols and ports. Addresses are anonymized. The
+configuration is the one of the console service.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		config := ConsoleConfiguration{}
+		DemoExporterProfileOptions.Path = args[0]
+		if err := DemoExporterProfileOptions.Parse(cmd.ErrOrStderr(), "console", &config); err != nil {
+			return err
+		}
+
+		r, err := reporter.New(config.Reporting)
+		if err != nil {
+			return fmt.Errorf("unable to initialize reporter: %w", err)
+		}
+		daemonComponent, err := daemon.New(r)
+		if err != nil {
+			return fmt.Errorf("unable to initialize daemon component: %w", err)
+		}
+		clickhouseComponent, err := clickhousedb.New(r, config.ClickHouse, clickhousedb.Dependencies{
+			Daemon: daemonComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize ClickHouse component: %w", err)
+		}
+		defer clickhouseComponent.Close()
+
+		flowsConfig, err := profile.Generate(context.Background(), clickhouseComponent,
+			DemoExporterProfileOptions.Profile)
+		if err != nil {
+			return err
+		}
+		return printDemoExporterProfile(cmd, flowsConfig)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(demoExporterProfileCmd)
+	defaults := profile.DefaultOptions()
+	options := &DemoExporterProfileOptions.Profile
+	demoExporterProfileCmd.Flags().DurationVarP(&options.Period, "period", "p", defaults.Period,
+		"Period to analyze")
+	demoExporterProfileCmd.Flags().IntVarP(&options.Top, "top", "n", defaults.Top,
+		"Number of flow configurations to generate")
+	demoExporterProfileCmd.Flags().Float64VarP(&options.Scale, "scale", "s", defaults.Scale,
+		"Scale factor to apply to the observed rate")
+	demoExporterProfileCmd.Flags().StringVarP(&options.Target, "target", "t", defaults.Target,
+		"Target for the generated flows")
+	demoExporterProfileCmd.Flags().IntVar(&options.ExternalIfIndex, "external-if-index", defaults.ExternalIfIndex,
+		"Interface index for external traffic")
+	demoExporterProfileCmd.Flags().IntVar(&options.InternalIfIndex, "internal-if-index", defaults.InternalIfIndex,
+		"Interface index for internal traffic")
+}
+
+// printDemoExporterProfile validates the generated flow configuration and
+// outputs it as the flows section of a demo exporter configuration.
+func printDemoExporterProfile(cmd *cobra.Command, config flows.Configuration) error {
+	if err := helpers.Validate.Struct(config); err != nil {
+		return fmt.Errorf("invalid generated configuration:\n%w", err)
+	}
+	output, err := yaml.Marshal(struct {
+		Flows flows.Configuration
+	}{config})
+	if err != nil {
+		return fmt.Errorf("unable to dump configuration: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "# Generated from flows of the last %s\n---\n",
+		DemoExporterProfileOptions.Profile.Period.Truncate(time.Hour))
+	cmd.OutOrStdout().Write(output)
+	return nil
+}
@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/helpers"
+	"akvorado/demoexporter/flows"
+)
+
+func TestDemoExporterProfileOutput(t *testing.T) {
+	config := flows.Configuration{
+		SamplingRate: 1000,
+		Target:       "127.0.0.1:2055",
+		Flows: []flows.FlowConfiguration{
+			{
+				PerSecond:  30.5,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				PeakHour:   20 * time.Hour,
+				Multiplier: 3,
+				SrcNet:     netip.MustParsePrefix("216.58.206.0/24"),
+				DstNet:     netip.MustParsePrefix("192.0.2.0/24"),
+				SrcAS:      []uint32{15169},
+				DstAS:      []uint32{64501},
+				SrcPort:    []uint16{443},
+				DstPort:    []uint16{0},
+				Protocol:   []string{"tcp"},
+				Size:       1300,
+			},
+		},
+	}
+	buf := new(bytes.Buffer)
+	cmd := &cobra.Command{}
+	cmd.SetOut(buf)
+	if err := printDemoExporterProfile(cmd, config); err != nil {
+		t.Fatalf("printDemoExporterProfile() error:\n%+v", err)
+	}
+
+	// The output should be usable as is by the demo exporter.
+	path := filepath.Join(t.TempDir(), "demo-exporter.yaml")
+	buf.WriteString(`snmp:
+  name: exporter1.example.com
+  interfaces:
+    10: "Transit: Google"
+    20: "core"
+`)
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	got := DemoExporterConfiguration{}
+	options := ConfigRelatedOptions{Path: path}
+	if err := options.Parse(new(bytes.Buffer), "demo-exporter", &got); err != nil {
+		t.Fatalf("Parse() error:\n%+v", err)
+	}
+	if diff := helpers.Diff(got.Flows, config); diff != "" {
+		t.Fatalf("Parse() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestDemoExporterProfileInvalid(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.SetOut(new(bytes.Buffer))
+	if err := printDemoExporterProfile(cmd, flows.Configuration{}); err == nil {
+		t.Fatal("printDemoExporterProfile() did not error")
+	}
+}
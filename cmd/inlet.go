@@ -16,11 +16,14 @@ import (
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
+	"akvorado/inlet/archive"
+	"akvorado/inlet/capture"
 	"akvorado/inlet/core"
 	"akvorado/inlet/flow"
 	"akvorado/inlet/kafka"
 	"akvorado/inlet/metadata"
 	"akvorado/inlet/metadata/provider/snmp"
+	"akvorado/inlet/relay"
 	"akvorado/inlet/routing"
 	"akvorado/inlet/routing/provider/bmp"
 )
@@ -33,8 +36,11 @@ type InletConfiguration struct {
 	Metadata  metadata.Configuration
 	Routing   routing.Configuration
 	Kafka     kafka.Configuration
+	Archive   archive.Configuration
+	Capture   capture.Configuration
 	Core      core.Configuration
 	Schema    schema.Configuration
+	Relay     relay.Configuration
 }
 
 // Reset resets the configuration for the inlet command to its default value.
@@ -46,8 +52,11 @@ func (c *InletConfiguration) Reset() {
 		Metadata:  metadata.DefaultConfiguration(),
 		Routing:   routing.DefaultConfiguration(),
 		Kafka:     kafka.DefaultConfiguration(),
+		Archive:   archive.DefaultConfiguration(),
+		Capture:   capture.DefaultConfiguration(),
 		Core:      core.DefaultConfiguration(),
 		Schema:    schema.DefaultConfiguration(),
+		Relay:     relay.DefaultConfiguration(),
 	}
 	c.Metadata.Providers = []metadata.ProviderConfiguration{{Config: snmp.DefaultConfiguration()}}
 	c.Routing.Provider.Config = bmp.DefaultConfiguration()
@@ -107,10 +116,25 @@ func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool)
 	if err != nil {
 		return fmt.Errorf("unable to initialize schema component: %w", err)
 	}
+	if config.Relay.Enable {
+		return relayStart(r, config, checkOnly, daemonComponent, httpComponent, schemaComponent)
+	}
+	var captureComponent *capture.Component
+	if config.Capture.Enable {
+		captureComponent, err = capture.New(r, config.Capture, capture.Dependencies{
+			Daemon: daemonComponent,
+			HTTP:   httpComponent,
+			Schema: schemaComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize capture component: %w", err)
+		}
+	}
 	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
-		Daemon: daemonComponent,
-		HTTP:   httpComponent,
-		Schema: schemaComponent,
+		Daemon:  daemonComponent,
+		HTTP:    httpComponent,
+		Schema:  schemaComponent,
+		Capture: captureComponent,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to initialize flow component: %w", err)
@@ -121,25 +145,40 @@ func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool)
 	if err != nil {
 		return fmt.Errorf("unable to initialize metadata component: %w", err)
 	}
+	var kafkaComponent *kafka.Component
+	if !config.Archive.Enable || !config.Archive.Exclusive {
+		kafkaComponent, err = kafka.New(r, config.Kafka, kafka.Dependencies{
+			Daemon: daemonComponent,
+			Schema: schemaComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize Kafka component: %w", err)
+		}
+	}
+	var archiveComponent *archive.Component
+	if config.Archive.Enable {
+		archiveComponent, err = archive.New(r, config.Archive, archive.Dependencies{
+			Daemon: daemonComponent,
+			Schema: schemaComponent,
+		})
+		if err != nil {
+			return fmt.Errorf("unable to initialize archive component: %w", err)
+		}
+	}
 	routingComponent, err := routing.New(r, config.Routing, routing.Dependencies{
 		Daemon: daemonComponent,
+		Kafka:  kafkaComponent,
 	})
 	if err != nil {
 		return fmt.Errorf("unable to initialize routing component: %w", err)
 	}
-	kafkaComponent, err := kafka.New(r, config.Kafka, kafka.Dependencies{
-		Daemon: daemonComponent,
-		Schema: schemaComponent,
-	})
-	if err != nil {
-		return fmt.Errorf("unable to initialize Kafka component: %w", err)
-	}
 	coreComponent, err := core.New(r, config.Core, core.Dependencies{
 		Daemon:   daemonComponent,
 		Flow:     flowComponent,
 		Metadata: metadataComponent,
 		Routing:  routingComponent,
 		Kafka:    kafkaComponent,
+		Archive:  archiveComponent,
 		HTTP:     httpComponent,
 		Schema:   schemaComponent,
 	})
@@ -160,10 +199,60 @@ func inletStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool)
 	components := []interface{}{
 		httpComponent,
 		metadataComponent,
+	}
+	if kafkaComponent != nil {
+		components = append(components, kafkaComponent)
+	}
+	if archiveComponent != nil {
+		components = append(components, archiveComponent)
+	}
+	if captureComponent != nil {
+		components = append(components, captureComponent)
+	}
+	components = append(components,
 		routingComponent,
-		kafkaComponent,
 		coreComponent,
 		flowComponent,
+	)
+	return StartStopComponents(r, daemonComponent, components)
+}
+
+// relayStart starts the inlet in relay mode: flows are not decoded but
+// forwarded to a central inlet. Metadata, routing and core components are not
+// needed in this case.
+func relayStart(r *reporter.Reporter, config InletConfiguration, checkOnly bool,
+	daemonComponent daemon.Component, httpComponent *httpserver.Component, schemaComponent *schema.Component,
+) error {
+	relayComponent, err := relay.New(r, config.Relay, relay.Dependencies{
+		Daemon: daemonComponent,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to initialize relay component: %w", err)
+	}
+	flowComponent, err := flow.New(r, config.Flow, flow.Dependencies{
+		Daemon: daemonComponent,
+		HTTP:   httpComponent,
+		Schema: schemaComponent,
+		Relay:  relayComponent,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to initialize flow component: %w", err)
+	}
+
+	// Expose some information and metrics
+	addCommonHTTPHandlers(r, "inlet", httpComponent)
+	versionMetrics(r)
+
+	// If we only asked for a check, stop here.
+	if checkOnly {
+		return nil
+	}
+
+	// Start all the components.
+	components := []interface{}{
+		httpComponent,
+		relayComponent,
+		flowComponent,
 	}
 	return StartStopComponents(r, daemonComponent, components)
 }
@@ -0,0 +1,147 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"akvorado/common/reporter"
+	"akvorado/inlet/metadata"
+)
+
+type metadataProbeOptions struct {
+	ConfigRelatedOptions
+	Provider bool
+	Timeout  time.Duration
+}
+
+// MetadataProbeOptions stores the command-line option values for the
+// metadata probe command.
+var MetadataProbeOptions metadataProbeOptions
+
+var metadataProbeCmd = &cobra.Command{
+	Use:   "metadata-probe CONFIG EXPORTER IFINDEX...",
+	Short: "Query metadata for an exporter",
+	Long: `Query the metadata providers configured for the inlet service for the
+provided exporter and interface indexes, bypassing any cache. This is
+useful to check the credentials for a new exporter. The configuration is
+the one of the inlet service, or a single provider configuration with
+--provider.`,
+	Args: cobra.MinimumNArgs(3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		exporterIP, err := netip.ParseAddr(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid exporter IP %q: %w", args[1], err)
+		}
+		ifIndexes := []uint{}
+		for _, arg := range args[2:] {
+			ifIndex, err := strconv.ParseUint(arg, 10, 32)
+			if err != nil {
+				return fmt.Errorf("invalid interface index %q: %w", arg, err)
+			}
+			ifIndexes = append(ifIndexes, uint(ifIndex))
+		}
+
+		config := InletConfiguration{}
+		MetadataProbeOptions.Path = args[0]
+		if MetadataProbeOptions.Provider {
+			providerConfig := metadata.ProviderConfiguration{}
+			if err := MetadataProbeOptions.Parse(cmd.OutOrStdout(), "inlet", &providerConfig); err != nil {
+				return err
+			}
+			config.Reset()
+			config.Metadata.Providers = []metadata.ProviderConfiguration{providerConfig}
+		} else if err := MetadataProbeOptions.Parse(cmd.OutOrStdout(), "inlet", &config); err != nil {
+			return err
+		}
+
+		r, err := reporter.New(config.Reporting)
+		if err != nil {
+			return fmt.Errorf("unable to initialize reporter: %w", err)
+		}
+		results := metadata.Probe(context.Background(), r, config.Metadata,
+			exporterIP, ifIndexes, MetadataProbeOptions.Timeout)
+		return printProbeResults(cmd.OutOrStdout(), results, ifIndexes)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(metadataProbeCmd)
+	metadataProbeCmd.Flags().BoolVarP(&MetadataProbeOptions.Provider, "provider", "p", false,
+		"Configuration is a single provider configuration")
+	metadataProbeCmd.Flags().DurationVarP(&MetadataProbeOptions.Timeout, "timeout", "t", 10*time.Second,
+		"Maximum time to wait for each provider")
+}
+
+// printProbeResults displays the results of a probe. It returns an error if
+// no provider was able to answer for all interfaces.
+func printProbeResults(out io.Writer, results []metadata.ProbeResult, ifIndexes []uint) error {
+	if len(results) == 0 {
+		return errors.New("no metadata provider configured")
+	}
+	for idx, result := range results {
+		if idx > 0 {
+			fmt.Fprintln(out)
+		}
+		fmt.Fprintf(out, "Provider:  %s\n", result.Provider)
+		keys := make([]string, 0, len(result.Description))
+		for key := range result.Description {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			fmt.Fprintf(out, "%-10s %s\n", key+":", result.Description[key])
+		}
+		fmt.Fprintf(out, "Duration:  %s\n", result.Duration.Round(time.Millisecond))
+		switch {
+		case result.Skipped:
+			fmt.Fprintln(out, "Status:    skipped, exporter not handled by this provider")
+			continue
+		case result.Err != nil:
+			fmt.Fprintf(out, "Status:    error, %s\n", result.Err)
+		default:
+			fmt.Fprintln(out, "Status:    ok")
+		}
+		// Use the first answered interface in query order to get a stable
+		// exporter name.
+		for _, ifIndex := range ifIndexes {
+			if answer, ok := result.Answers[ifIndex]; ok {
+				fmt.Fprintf(out, "Exporter:  %s\n", answer.Exporter.Name)
+				break
+			}
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "IFINDEX\tNAME\tDESCRIPTION\tSPEED")
+		for _, ifIndex := range ifIndexes {
+			answer, ok := result.Answers[ifIndex]
+			if !ok {
+				fmt.Fprintf(w, "%d\t-\t-\t-\n", ifIndex)
+				continue
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\n", ifIndex,
+				answer.Interface.Name, answer.Interface.Description, answer.Interface.Speed)
+		}
+		w.Flush()
+	}
+	last := results[len(results)-1]
+	switch {
+	case last.Skipped:
+		return errors.New("no metadata provider handled the exporter")
+	case last.Err != nil:
+		return fmt.Errorf("unable to query metadata: %w", last.Err)
+	case len(last.Answers) < len(ifIndexes):
+		return errors.New("some interfaces are missing")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestMetadataProbe(t *testing.T) {
+	dir := t.TempDir()
+	inletConfig := filepath.Join(dir, "inlet.yaml")
+	if err := os.WriteFile(inletConfig, []byte(`---
+metadata:
+  providers:
+    - type: static
+      exporters:
+        192.0.2.0/24:
+          name: nodefault
+          ifindexes:
+            10:
+              name: Gi10
+              description: 10th interface
+              speed: 1000
+    - type: static
+      exporters:
+        2001:db8:1::/48:
+          name: default
+          default:
+            name: Default0
+            description: Default interface
+            speed: 1000
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	providerConfig := filepath.Join(dir, "provider.yaml")
+	if err := os.WriteFile(providerConfig, []byte(`---
+type: snmp
+credentials:
+  ::/0:
+    user-name: alfred
+    authentication-protocol: SHA
+    authentication-passphrase: hello
+agents:
+  192.0.2.1: 192.0.2.10
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	gnmiConfig := filepath.Join(dir, "gnmi.yaml")
+	if err := os.WriteFile(gnmiConfig, []byte(`---
+type: gnmi
+timeout: 200ms
+ports:
+  ::/0: 1
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+
+	cases := []struct {
+		Description string
+		Args        []string
+		Error       bool
+		Expected    []string
+	}{
+		{
+			Description: "first provider",
+			Args:        []string{inletConfig, "192.0.2.1", "10", "11"},
+			Expected: []string{
+				"Provider:  static",
+				"Status:    ok",
+				"Exporter:  nodefault",
+				"IFINDEX  NAME  DESCRIPTION     SPEED",
+				"10       Gi10  10th interface  1000",
+				"11                             0",
+			},
+		}, {
+			Description: "second provider",
+			Args:        []string{inletConfig, "2001:db8:1::1", "10"},
+			Expected: []string{
+				"Provider:  static",
+				"Status:    skipped, exporter not handled by this provider",
+				"",
+				"Provider:  static",
+				"Status:    ok",
+				"Exporter:  default",
+				"IFINDEX  NAME      DESCRIPTION        SPEED",
+				"10       Default0  Default interface  1000",
+			},
+		}, {
+			Description: "no provider",
+			Args:        []string{inletConfig, "198.51.100.1", "10"},
+			Error:       true,
+		}, {
+			Description: "provider snippet",
+			Args:        []string{"--provider", "--timeout", "100ms", providerConfig, "192.0.2.1", "10"},
+			Error:       true,
+			Expected: []string{
+				"Provider:  snmp",
+				"agent:     192.0.2.10:161",
+				"credentials: SNMPv3, user alfred, authentication sha, privacy none",
+			},
+		}, {
+			Description: "background provider error",
+			Args:        []string{"--provider", "--timeout", "1s", gnmiConfig, "127.0.0.1", "10"},
+			Error:       true,
+			Expected: []string{
+				"Provider:  gnmi",
+				"target:    127.0.0.1:1",
+				"transport: TLS",
+				"Status:    error, context deadline exceeded (last error: [::ffff:127.0.0.1]:1: context deadline exceeded)",
+			},
+		}, {
+			Description: "invalid interface index",
+			Args:        []string{inletConfig, "192.0.2.1", "eth0"},
+			Error:       true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			MetadataProbeOptions = metadataProbeOptions{}
+			root := RootCmd
+			buf := new(bytes.Buffer)
+			root.SetOut(buf)
+			root.SetErr(new(bytes.Buffer))
+			root.SetArgs(append([]string{"metadata-probe"}, tc.Args...))
+			err := root.Execute()
+			if err != nil && !tc.Error {
+				t.Fatalf("`metadata-probe` error:\n%+v", err)
+			} else if err == nil && tc.Error {
+				t.Fatal("`metadata-probe` did not error")
+			}
+			if tc.Expected == nil {
+				return
+			}
+			// Durations are not stable. Only the first lines are checked.
+			got := []string{}
+			for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+				if !strings.HasPrefix(line, "Duration:") {
+					got = append(got, strings.TrimRight(line, " "))
+				}
+			}
+			if len(got) > len(tc.Expected) {
+				got = got[:len(tc.Expected)]
+			}
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("`metadata-probe` (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
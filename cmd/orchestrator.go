@@ -7,7 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/cobra"
 
@@ -36,6 +39,19 @@ type OrchestratorConfiguration struct {
 	Inlet        []InletConfiguration        `validate:"dive"`
 	Console      []ConsoleConfiguration      `validate:"dive"`
 	DemoExporter []DemoExporterConfiguration `validate:"dive"`
+	// NamedInlets are inlet configurations which can be selected by name or
+	// by attributes of the inlet host. They are merged on top of the
+	// configuration provided in InletBase. Names cannot be integers as they
+	// would be mistaken for an index.
+	NamedInlets map[string]NamedInletConfiguration `validate:"dive,keys,required,notindex,endkeys"`
+}
+
+// NamedInletConfiguration is a named inlet configuration.
+type NamedInletConfiguration struct {
+	// Selector tells how to select this configuration from host attributes.
+	Selector orchestrator.Selector
+	// Configuration is the effective inlet configuration.
+	Configuration InletConfiguration
 }
 
 // Reset resets the configuration of the orchestrator command to its default value.
@@ -55,6 +71,7 @@ func (c *OrchestratorConfiguration) Reset() {
 		Inlet:        []InletConfiguration{inletConfiguration},
 		Console:      []ConsoleConfiguration{consoleConfiguration},
 		DemoExporter: []DemoExporterConfiguration{},
+		NamedInlets:  map[string]NamedInletConfiguration{},
 	}
 }
 
@@ -79,10 +96,26 @@ components and centralizes configuration of the various other components.`,
 		OrchestratorOptions.BeforeDump = func() {
 			// Override some parts of the configuration
 			config.ClickHouse.Kafka.Configuration = config.Kafka.Configuration
+			config.Kafka.RoutesTopic = config.ClickHouse.RouteHistoryTTL > 0
+			for _, inlet := range config.Inlet {
+				if len(inlet.Core.NetworkRules) > 0 {
+					config.Schema.NetworkAttributesFromRoutes = true
+				}
+			}
+			for _, named := range config.NamedInlets {
+				if len(named.Configuration.Core.NetworkRules) > 0 {
+					config.Schema.NetworkAttributesFromRoutes = true
+				}
+			}
 			for idx := range config.Inlet {
 				config.Inlet[idx].Kafka.Configuration = config.Kafka.Configuration
 				config.Inlet[idx].Schema = config.Schema
 			}
+			for name, named := range config.NamedInlets {
+				named.Configuration.Kafka.Configuration = config.Kafka.Configuration
+				named.Configuration.Schema = config.Schema
+				config.NamedInlets[name] = named
+			}
 			for idx := range config.Console {
 				config.Console[idx].ClickHouse = config.ClickHouse.Configuration
 				config.Console[idx].Schema = config.Schema
@@ -160,6 +193,10 @@ func orchestratorStart(r *reporter.Reporter, config OrchestratorConfiguration, c
 	for idx := range config.Inlet {
 		orchestratorComponent.RegisterConfiguration(orchestrator.InletService, config.Inlet[idx])
 	}
+	for name, named := range config.NamedInlets {
+		orchestratorComponent.RegisterNamedConfiguration(orchestrator.InletService,
+			name, named.Selector, named.Configuration)
+	}
 	for idx := range config.Console {
 		orchestratorComponent.RegisterConfiguration(orchestrator.ConsoleService, config.Console[idx])
 	}
@@ -267,6 +304,109 @@ func OrchestratorConfigurationUnmarshallerHook() mapstructure.DecodeHookFunc {
 	}
 }
 
+// NamedInletsUnmarshallerHook merges the inlet base configuration into each
+// named inlet configuration. Maps are merged recursively while other values
+// (including lists) from the named configuration replace the ones from the base
+// configuration.
+func NamedInletsUnmarshallerHook() mapstructure.DecodeHookFunc {
+	return func(from, to reflect.Value) (interface{}, error) {
+		if from.Kind() != reflect.Map || from.IsNil() || to.Type() != reflect.TypeOf(OrchestratorConfiguration{}) {
+			return from.Interface(), nil
+		}
+
+		var baseKey, namedKey *reflect.Value
+		fromKeys := from.MapKeys()
+		for i, k := range fromKeys {
+			k = helpers.ElemOrIdentity(k)
+			if k.Kind() != reflect.String {
+				return from.Interface(), nil
+			}
+			if helpers.MapStructureMatchName(k.String(), "InletBase") {
+				baseKey = &fromKeys[i]
+			} else if helpers.MapStructureMatchName(k.String(), "NamedInlets") {
+				namedKey = &fromKeys[i]
+			}
+		}
+		if baseKey == nil {
+			return from.Interface(), nil
+		}
+		base := helpers.ElemOrIdentity(from.MapIndex(*baseKey))
+		from.SetMapIndex(*baseKey, reflect.Value{})
+		if base.Kind() != reflect.Map {
+			return nil, errors.New("inlet base configuration should be a map")
+		}
+		if namedKey == nil {
+			return from.Interface(), nil
+		}
+		named := helpers.ElemOrIdentity(from.MapIndex(*namedKey))
+		if named.Kind() != reflect.Map {
+			return from.Interface(), nil
+		}
+		for _, name := range named.MapKeys() {
+			namedInlet := helpers.ElemOrIdentity(named.MapIndex(name))
+			if namedInlet.Kind() != reflect.Map {
+				continue
+			}
+			var configurationKey *reflect.Value
+			namedInletKeys := namedInlet.MapKeys()
+			for i, k := range namedInletKeys {
+				k = helpers.ElemOrIdentity(k)
+				if k.Kind() == reflect.String && helpers.MapStructureMatchName(k.String(), "Configuration") {
+					configurationKey = &namedInletKeys[i]
+				}
+			}
+			if configurationKey == nil {
+				namedInlet.SetMapIndex(reflect.ValueOf("configuration"), base)
+				continue
+			}
+			namedInlet.SetMapIndex(*configurationKey, reflect.ValueOf(
+				mergeRawConfigurations(base, helpers.ElemOrIdentity(namedInlet.MapIndex(*configurationKey)))))
+		}
+		return from.Interface(), nil
+	}
+}
+
+// mergeRawConfigurations merges overlay on top of base. Keys are compared as
+// done by mapstructure. Only maps are merged, other values from overlay
+// replace the ones from base.
+func mergeRawConfigurations(base, overlay reflect.Value) interface{} {
+	if !overlay.IsValid() {
+		if !base.IsValid() {
+			return nil
+		}
+		return base.Interface()
+	}
+	if base.Kind() != reflect.Map || overlay.Kind() != reflect.Map {
+		return overlay.Interface()
+	}
+	result := map[string]interface{}{}
+	for _, k := range base.MapKeys() {
+		result[fmt.Sprint(helpers.ElemOrIdentity(k).Interface())] = base.MapIndex(k).Interface()
+	}
+outer:
+	for _, k := range overlay.MapKeys() {
+		key := fmt.Sprint(helpers.ElemOrIdentity(k).Interface())
+		value := helpers.ElemOrIdentity(overlay.MapIndex(k))
+		for existing := range result {
+			if helpers.MapStructureMatchName(existing, strings.ReplaceAll(key, "-", "")) {
+				result[existing] = mergeRawConfigurations(
+					helpers.ElemOrIdentity(reflect.ValueOf(result[existing])), value)
+				continue outer
+			}
+		}
+		result[key] = value.Interface()
+	}
+	return result
+}
+
+// isNotIndex validates a configuration name cannot be parsed as an index.
+func isNotIndex(fl validator.FieldLevel) bool {
+	_, err := strconv.Atoi(fl.Field().String())
+	return err != nil
+}
+
 func init() {
 	helpers.RegisterMapstructureUnmarshallerHook(OrchestratorConfigurationUnmarshallerHook())
+	helpers.RegisterMapstructureUnmarshallerHook(NamedInletsUnmarshallerHook())
+	helpers.Validate.RegisterValidation("notindex", isNotIndex)
 }
@@ -108,3 +108,27 @@ func TestOrchestrator(t *testing.T) {
 		t.Errorf("`orchestrator` error:\n%+v", err)
 	}
 }
+
+func TestOrchestratorNumericNamedInlet(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(config, []byte(`---
+named-inlets:
+  "01":
+    selector:
+      hostnames:
+        - inlet-*
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	root := RootCmd
+	buf := new(bytes.Buffer)
+	root.SetOut(buf)
+	root.SetArgs([]string{"orchestrator", "--check", config})
+	err := root.Execute()
+	if err == nil {
+		t.Fatal("`orchestrator` did not error")
+	}
+	if !strings.Contains(err.Error(), "'notindex' tag") {
+		t.Fatalf("`orchestrator` error:\n%+v", err)
+	}
+}
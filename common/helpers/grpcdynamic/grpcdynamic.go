@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package grpcdynamic helps to query gRPC services whose messages are handled
+// dynamically from an embedded protobuf definition. There is no generated code
+// to keep in sync with the definition.
+package grpcdynamic
+
+import (
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"akvorado/common/helpers"
+)
+
+// Parse parses the provided protobuf definition and returns the descriptors
+// for the requested messages, in the same order.
+func Parse(name, definition string, messages ...protoreflect.Name) ([]protoreflect.MessageDescriptor, error) {
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(map[string]string{
+			name: definition,
+		}),
+	}
+	files, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", name, err)
+	}
+	all := files[0].UnwrapFile().Messages()
+	result := make([]protoreflect.MessageDescriptor, 0, len(messages))
+	for _, message := range messages {
+		md := all.ByName(message)
+		if md == nil {
+			return nil, fmt.Errorf("cannot find message %s in %s", message, name)
+		}
+		result = append(result, md)
+	}
+	return result, nil
+}
+
+// NewClient creates a gRPC client for the provided target. TLS is used when
+// enabled in the provided configuration.
+func NewClient(target string, config helpers.TLSConfiguration) (*grpc.ClientConn, error) {
+	securityOption := grpc.WithTransportCredentials(insecure.NewCredentials())
+	tlsConfig, err := config.MakeTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		securityOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig))
+	}
+	return grpc.NewClient(target, securityOption)
+}
@@ -0,0 +1,53 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package grpcdynamic
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+const definition = `syntax = "proto3";
+package test;
+message Request { string name = 1; }
+message Response { repeated uint32 values = 1; }
+`
+
+func TestParse(t *testing.T) {
+	messages, err := Parse("test.proto", definition, "Response", "Request")
+	if err != nil {
+		t.Fatalf("Parse() error:\n%+v", err)
+	}
+	got := []string{}
+	for _, md := range messages {
+		got = append(got, string(md.FullName()))
+	}
+	if diff := helpers.Diff(got, []string{"test.Response", "test.Request"}); diff != "" {
+		t.Fatalf("Parse() (-got, +want):\n%s", diff)
+	}
+
+	if _, err := Parse("test.proto", definition, "Unknown"); err == nil {
+		t.Fatal("Parse(Unknown) did not error")
+	}
+	if _, err := Parse("test.proto", "message {", "Request"); err == nil {
+		t.Fatal("Parse(invalid) did not error")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	conn, err := NewClient("127.0.0.1:1", helpers.TLSConfiguration{})
+	if err != nil {
+		t.Fatalf("NewClient() error:\n%+v", err)
+	}
+	conn.Close()
+
+	if _, err := NewClient("127.0.0.1:1", helpers.TLSConfiguration{
+		Enable: true,
+		CAFile: "/nonexistent/ca.pem",
+		Verify: true,
+	}); err == nil {
+		t.Fatal("NewClient(invalid TLS) did not error")
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package rotatingfile helps to write data to a set of files in a directory.
+// Files are named after their creation time and have a ".part" suffix while
+// being written.
+package rotatingfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"gopkg.in/tomb.v2"
+)
+
+// PartSuffix is the suffix of a file being written.
+const PartSuffix = ".part"
+
+// Directory creates and lists files in a directory. Names are made of a
+// prefix, the creation time, and an extension, like
+// capture-20250304T100000.000000Z.pcapng.
+type Directory struct {
+	path      string
+	prefix    string
+	extension string
+	nameRegex *regexp.Regexp
+
+	// Only accessed by Create
+	lastCreated time.Time
+}
+
+// File is a file being written. Data is written to the file with the
+// ".part" suffix until it is committed.
+type File struct {
+	*os.File
+	// Name is the final name of the file.
+	Name string
+	// Path is the final path of the file.
+	Path string
+	// Created is the creation time of the file.
+	Created time.Time
+}
+
+// New creates a new directory handler. The extension should include the
+// leading dot.
+func New(path, prefix, extension string) *Directory {
+	return &Directory{
+		path:      path,
+		prefix:    prefix,
+		extension: extension,
+		nameRegex: regexp.MustCompile(fmt.Sprintf(`^%s\d{8}T\d{6}\.\d{6}Z%s$`,
+			regexp.QuoteMeta(prefix), regexp.QuoteMeta(extension))),
+	}
+}
+
+// Create creates a new file named after the provided time. File names are
+// unique and ordered: if a file was already created at the same time or later,
+// the time is adjusted. This should not be called concurrently.
+func (d *Directory) Create(now time.Time) (*File, error) {
+	now = now.UTC().Truncate(time.Microsecond)
+	if !now.After(d.lastCreated) {
+		now = d.lastCreated.Add(time.Microsecond)
+	}
+	d.lastCreated = now
+	name := fmt.Sprintf("%s%s%s", d.prefix, now.Format("20060102T150405.000000Z"), d.extension)
+	path := filepath.Join(d.path, name)
+	file, err := os.OpenFile(path+PartSuffix, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &File{
+		File:    file,
+		Name:    name,
+		Path:    path,
+		Created: now,
+	}, nil
+}
+
+// Match tells if the provided name is the name of a completed file.
+func (d *Directory) Match(name string) bool {
+	return d.nameRegex.MatchString(name)
+}
+
+// List returns the names of the completed files, oldest first.
+func (d *Directory) List() ([]string, error) {
+	entries, err := os.ReadDir(d.path)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && d.Match(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	slices.SortFunc(names, strings.Compare)
+	return names, nil
+}
+
+// Commit closes the file, after syncing it if requested, and removes its
+// ".part" suffix. When provided, beforeRename is called once the file is
+// closed. If it fails, the file keeps its suffix.
+func (f *File) Commit(sync bool, beforeRename func() error) error {
+	if sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if beforeRename != nil {
+		if err := beforeRename(); err != nil {
+			return err
+		}
+	}
+	return os.Rename(f.Path+PartSuffix, f.Path)
+}
+
+// Abort closes and removes the file.
+func (f *File) Abort() {
+	f.Close()
+	os.Remove(f.Path + PartSuffix)
+}
+
+// WriteFileAtomically writes a file using a temporary file with the ".part"
+// suffix, syncing it if requested.
+func WriteFileAtomically(path string, content []byte, sync bool) error {
+	tmp := path + PartSuffix
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(content); err != nil {
+		file.Close()
+		return err
+	}
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Run calls write for each item received from the queue and tick every second
+// until the tomb is dying. The queue is then drained before calling done. It
+// is expected to be run in its own goroutine.
+func Run[T any](t *tomb.Tomb, clk clock.Clock, queue <-chan T, write func(T), tick func(), done func()) {
+	ticker := clk.Ticker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.Dying():
+			for {
+				select {
+				case item := <-queue:
+					write(item)
+				default:
+					done()
+					return
+				}
+			}
+		case item := <-queue:
+			write(item)
+		case <-ticker.C:
+			tick()
+		}
+	}
+}
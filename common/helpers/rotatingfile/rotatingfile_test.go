@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package rotatingfile_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/helpers"
+	"akvorado/common/helpers/rotatingfile"
+)
+
+func TestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	d := rotatingfile.New(dir, "test-", ".txt")
+	now := time.Date(2025, time.March, 4, 10, 0, 0, 0, time.UTC)
+
+	// Create three files, two of them at the same time
+	var files []*rotatingfile.File
+	for _, ts := range []time.Time{now, now, now.Add(-time.Second)} {
+		f, err := d.Create(ts)
+		if err != nil {
+			t.Fatalf("Create() error:\n%+v", err)
+		}
+		files = append(files, f)
+	}
+	if _, err := files[0].WriteString("hello\n"); err != nil {
+		t.Fatalf("WriteString() error:\n%+v", err)
+	}
+	expected := []string{
+		"test-20250304T100000.000000Z.txt.part",
+		"test-20250304T100000.000001Z.txt.part",
+		"test-20250304T100000.000002Z.txt.part",
+	}
+	if diff := helpers.Diff(rotatingfile.ListFiles(t, dir), expected); diff != "" {
+		t.Fatalf("Create() (-got, +want):\n%s", diff)
+	}
+
+	// Commit the first one, abort the second one, fail the third one
+	if err := files[0].Commit(true, nil); err != nil {
+		t.Fatalf("Commit() error:\n%+v", err)
+	}
+	files[1].Abort()
+	errFail := errors.New("fail")
+	if err := files[2].Commit(false, func() error { return errFail }); !errors.Is(err, errFail) {
+		t.Fatalf("Commit() error:\n%+v", err)
+	}
+	expected = []string{
+		"test-20250304T100000.000000Z.txt",
+		"test-20250304T100000.000002Z.txt.part",
+	}
+	if diff := helpers.Diff(rotatingfile.ListFiles(t, dir), expected); diff != "" {
+		t.Fatalf("Commit() (-got, +want):\n%s", diff)
+	}
+	content, _ := os.ReadFile(filepath.Join(dir, expected[0]))
+	if diff := helpers.Diff(string(content), "hello\n"); diff != "" {
+		t.Fatalf("ReadFile() (-got, +want):\n%s", diff)
+	}
+
+	// Only completed files are listed
+	os.WriteFile(filepath.Join(dir, "test-20250304T090000.000000Z.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "other-20250304T090000.000000Z.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "test-20250304T090000.000000Z.csv"), nil, 0o644)
+	got, err := d.List()
+	if err != nil {
+		t.Fatalf("List() error:\n%+v", err)
+	}
+	expected = []string{
+		"test-20250304T090000.000000Z.txt",
+		"test-20250304T100000.000000Z.txt",
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("List() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.json")
+	for _, content := range []string{"first", "second"} {
+		if err := rotatingfile.WriteFileAtomically(path, []byte(content), true); err != nil {
+			t.Fatalf("WriteFileAtomically() error:\n%+v", err)
+		}
+		got, _ := os.ReadFile(path)
+		if diff := helpers.Diff(string(got), content); diff != "" {
+			t.Fatalf("WriteFileAtomically() (-got, +want):\n%s", diff)
+		}
+	}
+	if diff := helpers.Diff(rotatingfile.ListFiles(t, filepath.Dir(path)), []string{"manifest.json"}); diff != "" {
+		t.Fatalf("WriteFileAtomically() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var tb tomb.Tomb
+	mockClock := clock.NewMock()
+	queue := make(chan int, 10)
+	got := []int{}
+	ticks := 0
+	done := make(chan struct{})
+	tb.Go(func() error {
+		rotatingfile.Run(&tb, mockClock, queue,
+			func(item int) { got = append(got, item) },
+			func() { ticks++ },
+			func() { close(done) })
+		return nil
+	})
+	queue <- 1
+	time.Sleep(10 * time.Millisecond)
+	mockClock.Add(time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	// Items still in the queue are processed before stopping
+	queue <- 2
+	queue <- 3
+	tb.Kill(nil)
+	<-done
+	tb.Wait()
+	if ticks != 1 {
+		t.Errorf("Run() ticked %d times, expected 1", ticks)
+	}
+	if diff := helpers.Diff(got, []int{1, 2, 3}); diff != "" {
+		t.Errorf("Run() (-got, +want):\n%s", diff)
+	}
+}
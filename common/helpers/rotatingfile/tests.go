@@ -0,0 +1,25 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+//go:build !release
+
+package rotatingfile
+
+import (
+	"os"
+	"testing"
+)
+
+// ListFiles returns all the files in the provided directory, sorted by name.
+func ListFiles(t *testing.T, directory string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		t.Fatalf("ReadDir() error:\n%+v", err)
+	}
+	files := []string{}
+	for _, entry := range entries {
+		files = append(files, entry.Name())
+	}
+	return files
+}
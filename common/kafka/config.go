@@ -25,6 +25,19 @@ type Configuration struct {
 	Version Version
 	// TLS defines TLS configuration
 	TLS TLSAndSASLConfiguration
+	// Standby is an ordered list of standby Kafka clusters. They share the
+	// same topic as the primary cluster.
+	Standby []ClusterConfiguration `validate:"dive"`
+}
+
+// ClusterConfiguration defines how we connect to a standby Kafka cluster.
+type ClusterConfiguration struct {
+	// Brokers is the list of brokers to connect to.
+	Brokers []string `validate:"min=1,dive,listen"`
+	// Version is the version of Kafka we assume to work
+	Version Version
+	// TLS defines TLS configuration
+	TLS TLSAndSASLConfiguration
 }
 
 // TLSAndSASLConfiguration defines TLS configuration.
@@ -53,6 +66,37 @@ func DefaultConfiguration() Configuration {
 	}
 }
 
+// DefaultClusterConfiguration represents the default configuration for a
+// standby Kafka cluster.
+func DefaultClusterConfiguration() ClusterConfiguration {
+	defaultConfiguration := DefaultConfiguration()
+	return ClusterConfiguration{
+		Version: defaultConfiguration.Version,
+		TLS:     defaultConfiguration.TLS,
+	}
+}
+
+// Clusters returns the configuration for each Kafka cluster, starting with
+// the primary one, followed by the standby ones.
+func (c Configuration) Clusters() []Configuration {
+	clusters := []Configuration{c}
+	clusters[0].Standby = nil
+	for _, standby := range c.Standby {
+		clusters = append(clusters, Configuration{
+			Topic:   c.Topic,
+			Brokers: standby.Brokers,
+			Version: standby.Version,
+			TLS:     standby.TLS,
+		})
+	}
+	return clusters
+}
+
+func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultClusterConfiguration()))
+}
+
 // Version represents a supported version of Kafka
 type Version sarama.KafkaVersion
 
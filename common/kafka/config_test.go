@@ -164,3 +164,100 @@ func TestTLSConfiguration(t *testing.T) {
 		},
 	})
 }
+
+func TestStandbyConfiguration(t *testing.T) {
+	helpers.TestConfigurationDecode(t, helpers.ConfigurationDecodeCases{
+		{
+			Description: "standby clusters",
+			Initial:     func() interface{} { return DefaultConfiguration() },
+			Configuration: func() interface{} {
+				return gin.H{
+					"brokers": []string{"kafka1:9092"},
+					"standby": []gin.H{
+						{
+							"brokers": []string{"kafka2:9092", "kafka3:9092"},
+						}, {
+							"brokers": []string{"kafka4:9093"},
+							"version": "3.5.0",
+							"tls": gin.H{
+								"enable": true,
+								"verify": true,
+							},
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				Topic:   "flows",
+				Brokers: []string{"kafka1:9092"},
+				Version: Version(sarama.V2_8_1_0),
+				TLS: TLSAndSASLConfiguration{
+					TLSConfiguration: helpers.TLSConfiguration{
+						Verify: true,
+					},
+				},
+				Standby: []ClusterConfiguration{
+					{
+						Brokers: []string{"kafka2:9092", "kafka3:9092"},
+						Version: Version(sarama.V2_8_1_0),
+						TLS: TLSAndSASLConfiguration{
+							TLSConfiguration: helpers.TLSConfiguration{
+								Verify: true,
+							},
+						},
+					}, {
+						Brokers: []string{"kafka4:9093"},
+						Version: Version(sarama.V3_5_0_0),
+						TLS: TLSAndSASLConfiguration{
+							TLSConfiguration: helpers.TLSConfiguration{
+								Enable: true,
+								Verify: true,
+							},
+						},
+					},
+				},
+			},
+		}, {
+			Description: "standby cluster without brokers",
+			Initial:     func() interface{} { return DefaultConfiguration() },
+			Configuration: func() interface{} {
+				return gin.H{
+					"standby": []gin.H{
+						{"version": "3.5.0"},
+					},
+				}
+			},
+			Error: true,
+		},
+	})
+}
+
+func TestClusters(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Standby = []ClusterConfiguration{
+		{
+			Brokers: []string{"kafka2:9092"},
+			Version: Version(sarama.V3_5_0_0),
+		},
+	}
+	got := config.Clusters()
+	expected := []Configuration{
+		{
+			Topic:   "flows",
+			Brokers: []string{"127.0.0.1:9092"},
+			Version: Version(sarama.V2_8_1_0),
+			TLS: TLSAndSASLConfiguration{
+				TLSConfiguration: helpers.TLSConfiguration{
+					Verify: true,
+				},
+			},
+		}, {
+			Topic:   "flows",
+			Brokers: []string{"kafka2:9092"},
+			Version: Version(sarama.V3_5_0_0),
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Clusters() (-got, +want):\n%s", diff)
+	}
+}
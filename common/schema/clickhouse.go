@@ -98,20 +98,6 @@ func (schema Schema) clickhouseIterate(fn func(Column), options ...ClickHouseTab
 	}
 }
 
-// ClickHouseSortingKeys returns the list of sorting keys, prefixed by the primary keys.
-func (schema Schema) ClickHouseSortingKeys() []string {
-	cols := schema.ClickHousePrimaryKeys()
-	for _, column := range schema.Columns() {
-		if column.ClickHouseNotSortingKey || column.ClickHouseMainOnly {
-			continue
-		}
-		if !slices.Contains(cols, column.Name) {
-			cols = append(cols, column.Name)
-		}
-	}
-	return cols
-}
-
 // ClickHousePrimaryKeys returns the list of primary keys.
 func (schema Schema) ClickHousePrimaryKeys() []string {
 	cols := []string{}
@@ -23,6 +23,11 @@ type Configuration struct {
 	Materialize []ColumnKey
 	// CustomDictionaries allows enrichment of flows with custom metadata
 	CustomDictionaries map[string]CustomDict `validate:"dive"`
+	// NetworkAttributesFromRoutes tells if the inlet provides the network
+	// role, site, and tenant from BGP routes. The networks configuration is
+	// then only used when the inlet does not provide them. The orchestrator
+	// enables it when network rules are configured for an inlet.
+	NetworkAttributesFromRoutes bool
 }
 
 // CustomDict represents a single custom dictionary
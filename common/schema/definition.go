@@ -79,6 +79,8 @@ const (
 	DictionaryTCP string = "tcp"
 	// DictionaryUDP is the name of the UDP clickhouse dictionary
 	DictionaryUDP string = "udp"
+	// DictionaryMACVendors is the name of the MAC vendors clickhouse dictionary
+	DictionaryMACVendors string = "macvendors"
 )
 
 // revive:disable
@@ -172,6 +174,12 @@ const (
 	ColumnMPLS2ndLabel
 	ColumnMPLS3rdLabel
 	ColumnMPLS4thLabel
+	ColumnDstExitRouter
+	ColumnDstExitSite
+	ColumnDstExitPeer
+	ColumnDuplicate
+	ColumnSrcMACVendor
+	ColumnDstMACVendor
 
 	// ColumnLast points to after the last static column, custom dictionaries
 	// (dynamic columns) come after ColumnLast
@@ -488,6 +496,26 @@ END`,
 				ClickHouseMainOnly: true,
 			},
 			{Key: ColumnSrcMAC, Disabled: true, Group: ColumnGroupL2, ClickHouseType: "UInt64"},
+			{
+				Key:            ColumnSrcMACVendor,
+				Depends:        []ColumnKey{ColumnSrcMAC},
+				Disabled:       true,
+				Group:          ColumnGroupL2,
+				ParserType:     "string",
+				ClickHouseType: "LowCardinality(String)",
+				ClickHouseAlias: fmt.Sprintf(`dictGetOrDefault('%s', 'vendor', toUInt32(bitShiftRight(SrcMAC, 24)), '')`,
+					DictionaryMACVendors),
+			},
+			{
+				Key:            ColumnDstMACVendor,
+				Depends:        []ColumnKey{ColumnDstMAC},
+				Disabled:       true,
+				Group:          ColumnGroupL2,
+				ParserType:     "string",
+				ClickHouseType: "LowCardinality(String)",
+				ClickHouseAlias: fmt.Sprintf(`dictGetOrDefault('%s', 'vendor', toUInt32(bitShiftRight(DstMAC, 24)), '')`,
+					DictionaryMACVendors),
+			},
 			{Key: ColumnIPTTL, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt8"},
 			{Key: ColumnIPTos, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt8"},
 			{Key: ColumnIPFragmentID, Disabled: true, Group: ColumnGroupL3L4, ParserType: "uint", ClickHouseType: "UInt32"},
@@ -570,6 +598,35 @@ END`,
 				ClickHouseAlias:    "MPLSLabels[4]",
 				ParserType:         "uint",
 			},
+			{
+				Key:                     ColumnDstExitRouter,
+				Disabled:                true,
+				ParserType:              "string",
+				ClickHouseType:          "LowCardinality(String)",
+				ClickHouseNotSortingKey: true,
+			},
+			{
+				Key:                     ColumnDstExitSite,
+				Disabled:                true,
+				ParserType:              "string",
+				ClickHouseType:          "LowCardinality(String)",
+				ClickHouseNotSortingKey: true,
+			},
+			{
+				Key:                     ColumnDstExitPeer,
+				Disabled:                true,
+				ParserType:              "ip",
+				ClickHouseType:          "LowCardinality(IPv6)",
+				ClickHouseCodec:         "ZSTD(1)",
+				ClickHouseNotSortingKey: true,
+			},
+			{
+				Key:                     ColumnDuplicate,
+				Disabled:                true,
+				ParserType:              "uint",
+				ClickHouseType:          "UInt8",
+				ClickHouseNotSortingKey: true,
+			},
 		},
 	}.finalize()
 }
@@ -657,8 +714,15 @@ func (schema Schema) finalize() Schema {
 	}
 	schema.columns = ncolumns
 
-	// Set Protobuf index and type
+	// Set Protobuf index and type. Columns without an index get one after the
+	// existing ones.
 	protobufIndex := 1
+	for _, column := range schema.columns {
+		protobufIndex = max(protobufIndex, int(column.ProtobufIndex)+1)
+		for _, column := range column.ClickHouseTransformFrom {
+			protobufIndex = max(protobufIndex, int(column.ProtobufIndex)+1)
+		}
+	}
 	ncolumns = []Column{}
 	for _, column := range schema.columns {
 		pcolumns := []*Column{&column}
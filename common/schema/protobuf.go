@@ -248,6 +248,43 @@ func (column *Column) appendDebug(bf *FlowMessage, value interface{}) {
 	}
 }
 
+// ProtobufLookup returns the first value appended to the protobuf
+// representation of a flow for the provided column. Varints are returned as
+// uint64 and other values as []byte. This should be called before
+// `ProtobufMarshal`. As the whole representation may be scanned, this is not
+// efficient.
+func (schema *Schema) ProtobufLookup(bf *FlowMessage, columnKey ColumnKey) (interface{}, bool) {
+	column, ok := schema.LookupColumnByKey(columnKey)
+	if !ok || column.ProtobufIndex <= 0 || bf.protobuf == nil ||
+		!bf.protobufSet.Test(uint(column.ProtobufIndex)) {
+		return nil, false
+	}
+	buf := bf.protobuf[maxSizeVarint:]
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return nil, false
+		}
+		buf = buf[n:]
+		if num == column.ProtobufIndex {
+			switch typ {
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(buf)
+				return v, n >= 0
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(buf)
+				return v, n >= 0
+			}
+		}
+		n = protowire.ConsumeFieldValue(num, typ, buf)
+		if n < 0 {
+			return nil, false
+		}
+		buf = buf[n:]
+	}
+	return nil, false
+}
+
 // Bytes returns protobuf bytes. The flow should have been processed by
 // `ProtobufMarshal` first.
 func (bf *FlowMessage) Bytes() []byte {
@@ -240,3 +240,31 @@ func BenchmarkProtobufMarshal(b *testing.B) {
 		c.ProtobufMarshal(bf)
 	}
 }
+
+func TestProtobufLookup(t *testing.T) {
+	c := NewMock(t)
+	bf := &FlowMessage{}
+	if _, ok := c.ProtobufLookup(bf, ColumnBytes); ok {
+		t.Error("ProtobufLookup(Bytes) found something in an empty flow")
+	}
+	c.ProtobufAppendVarint(bf, ColumnDstAS, 65000)
+	c.ProtobufAppendBytes(bf, ColumnInIfName, []byte("Gi0/0/1"))
+	c.ProtobufAppendVarint(bf, ColumnBytes, 200)
+	c.ProtobufAppendVarint(bf, ColumnBytes, 300) // duplicate!
+
+	cases := []struct {
+		Key      ColumnKey
+		Expected interface{}
+	}{
+		{ColumnDstAS, uint64(65000)},
+		{ColumnInIfName, []byte("Gi0/0/1")},
+		{ColumnBytes, uint64(200)},
+		{ColumnPackets, nil},
+	}
+	for _, tc := range cases {
+		got, _ := c.ProtobufLookup(bf, tc.Key)
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("ProtobufLookup(%s) (-got, +want):\n%s", tc.Key, diff)
+		}
+	}
+}
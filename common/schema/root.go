@@ -41,6 +41,20 @@ func New(config Configuration) (*Component, error) {
 			}
 		}
 	}
+	if config.NetworkAttributesFromRoutes {
+		for _, k := range []ColumnKey{
+			ColumnSrcNetRole, ColumnDstNetRole,
+			ColumnSrcNetSite, ColumnDstNetSite,
+			ColumnSrcNetTenant, ColumnDstNetTenant,
+		} {
+			if column, ok := schema.LookupColumnByKey(k); ok {
+				column.ClickHouseGenerateFrom = fmt.Sprintf("if(%s = '', %s, %s)",
+					column.Name, column.ClickHouseGenerateFrom, column.Name)
+				column.ClickHouseSelfGenerated = true
+				column.ProtobufIndex = 0
+			}
+		}
+	}
 	for _, k := range config.Enabled {
 		if column, ok := schema.LookupColumnByKey(k); ok {
 			column.Disabled = false
@@ -63,6 +63,34 @@ func TestDisableForbiddenColumns(t *testing.T) {
 	}
 }
 
+func TestNetworkAttributesFromRoutes(t *testing.T) {
+	config := schema.DefaultConfiguration()
+	c, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if column, _ := c.LookupColumnByKey(schema.ColumnDstNetTenant); column.ClickHouseSelfGenerated {
+		t.Fatal("DstNetTenant is self-generated by default")
+	}
+
+	config.NetworkAttributesFromRoutes = true
+	c, err = schema.New(config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	column, _ := c.LookupColumnByKey(schema.ColumnDstNetTenant)
+	if !column.ClickHouseSelfGenerated {
+		t.Fatal("DstNetTenant is not self-generated")
+	}
+	if column.ProtobufIndex <= 0 {
+		t.Fatal("DstNetTenant is not part of the protobuf schema")
+	}
+	expected := "if(DstNetTenant = '', c_DstNetworks[tenant], DstNetTenant)"
+	if diff := helpers.Diff(column.ClickHouseGenerateFrom, expected); diff != "" {
+		t.Fatalf("ClickHouseGenerateFrom (-got, +want):\n%s", diff)
+	}
+}
+
 func TestCustomDictionaries(t *testing.T) {
 	config := schema.DefaultConfiguration()
 	config.CustomDictionaries = make(map[string]schema.CustomDict)
@@ -106,10 +106,30 @@ type FlowMessage struct {
 	SrcNetMask uint8
 	DstNetMask uint8
 
+	// SampledHeader is the raw sampled packet header when requested by the
+	// decoder. It is not serialized. It may reference the received packet and
+	// is only valid while decoding: it should be copied to be kept.
+	SampledHeader     []byte            `json:"-"`
+	SampledHeaderType SampledHeaderType `json:"-"`
+
 	// protobuf is the protobuf representation for the information not contained above.
 	protobuf      []byte
 	protobufSet   bitset.BitSet
 	ProtobufDebug map[ColumnKey]interface{} `json:"-"` // for testing purpose
 }
 
+// SampledHeaderType is the type of a raw sampled packet header.
+type SampledHeaderType uint8
+
+const (
+	// SampledHeaderNone means there is no sampled header.
+	SampledHeaderNone SampledHeaderType = iota
+	// SampledHeaderEthernet is a header starting with an Ethernet frame.
+	SampledHeaderEthernet
+	// SampledHeaderIPv4 is a header starting with an IPv4 packet.
+	SampledHeaderIPv4
+	// SampledHeaderIPv6 is a header starting with an IPv6 packet.
+	SampledHeaderIPv6
+)
+
 const maxSizeVarint = 10 // protowire.SizeVarint(^uint64(0))
@@ -3,6 +3,13 @@
 
 package authentication
 
+import (
+	"net/netip"
+	"time"
+
+	"akvorado/common/helpers"
+)
+
 // Configuration describes the configuration for the authentication component.
 type Configuration struct {
 	// Headers define authentication headers
@@ -11,6 +18,9 @@ type Configuration struct {
 	// headers are present. Leave `User' empty to not allow access
 	// without authentication.
 	DefaultUser UserInformation
+	// LDAP defines how to authenticate users against an LDAP directory. It
+	// coexists with authentication headers.
+	LDAP ConfigurationLDAP
 }
 
 // ConfigurationHeaders define headers used for authentication
@@ -21,6 +31,59 @@ type ConfigurationHeaders struct {
 	LogoutURL string
 }
 
+// ConfigurationLDAP defines the LDAP directory to authenticate users. Sessions
+// are kept in memory only: they are lost on restart and they are not shared
+// between several console instances.
+type ConfigurationLDAP struct {
+	// URL is the URL of the LDAP server (ldap:// or ldaps://). When empty,
+	// LDAP authentication is disabled.
+	URL string `validate:"omitempty,url"`
+	// TLS defines the TLS configuration. With an ldap:// URL, enabling TLS
+	// means using StartTLS.
+	TLS helpers.TLSConfiguration
+	// Timeout is the timeout to connect and query the LDAP server.
+	Timeout time.Duration `validate:"min=100ms"`
+	// BindDN is the DN used to search for users and groups. When empty, an
+	// anonymous bind is used.
+	BindDN string
+	// BindPassword is the password for BindDN.
+	BindPassword string
+	// BaseDN is where to search for users.
+	BaseDN string `validate:"required_with=URL"`
+	// UserFilter is the filter to find a user. %s is replaced by the login.
+	UserFilter string `validate:"required_with=URL"`
+	// Attributes maps user information to LDAP attributes.
+	Attributes ConfigurationLDAPAttributes
+	// GroupBaseDN is where to search for groups. When empty, groups are not
+	// looked up.
+	GroupBaseDN string
+	// GroupFilter is the filter to find the groups of a user. %s is replaced
+	// by the DN of the user.
+	GroupFilter string `validate:"required_with=GroupBaseDN"`
+	// GroupAttribute is the attribute containing the name of a group.
+	GroupAttribute string `validate:"required_with=GroupBaseDN"`
+	// Groups maps LDAP group names to console group names. When not empty,
+	// unmapped groups are ignored.
+	Groups map[string]string
+	// RequiredGroups restricts access to the users belonging to at least one
+	// of these groups (after mapping).
+	RequiredGroups []string
+	// SessionDuration is how long a session stays valid. Sessions are kept in
+	// memory only.
+	SessionDuration time.Duration `validate:"min=1m"`
+	// TrustedProxies are the networks of the reverse proxies allowed to set
+	// the X-Forwarded-Proto and X-Forwarded-For headers.
+	TrustedProxies []netip.Prefix
+}
+
+// ConfigurationLDAPAttributes maps user information to LDAP attributes.
+type ConfigurationLDAPAttributes struct {
+	Login  string `validate:"required"`
+	Name   string
+	Email  string
+	Avatar string
+}
+
 // DefaultConfiguration represents the default configuration for the console component.
 func DefaultConfiguration() Configuration {
 	return Configuration{
@@ -34,5 +97,17 @@ func DefaultConfiguration() Configuration {
 			Login: "__default",
 			Name:  "Default User",
 		},
+		LDAP: ConfigurationLDAP{
+			Timeout:    5 * time.Second,
+			UserFilter: "(uid=%s)",
+			Attributes: ConfigurationLDAPAttributes{
+				Login: "uid",
+				Name:  "cn",
+				Email: "mail",
+			},
+			GroupFilter:     "(member=%s)",
+			GroupAttribute:  "cn",
+			SessionDuration: 12 * time.Hour,
+		},
 	}
 }
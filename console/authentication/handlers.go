@@ -14,6 +14,7 @@ import (
 	"io/fs"
 	"math/rand"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -29,6 +30,26 @@ func (c *Component) UserInfoHandlerFunc(gc *gin.Context) {
 
 // UserAvatarHandlerFunc returns an avatar for the currently logger user.
 func (c *Component) UserAvatarHandlerFunc(gc *gin.Context) {
+	gc.Header("X-Content-Type-Options", "nosniff")
+
+	// Use the avatar from the LDAP directory if available. Only serve it if
+	// it is an image, otherwise, use a generated one.
+	if s, ok := c.currentSession(gc); ok && len(s.avatar) > 0 {
+		contentType := http.DetectContentType(s.avatar)
+		if strings.HasPrefix(contentType, "image/") {
+			hash := fnv.New64()
+			hash.Write(s.avatar)
+			etag := fmt.Sprintf(`"%x"`, hash.Sum64())
+			gc.Header("ETag", etag)
+			if header := gc.GetHeader("If-None-Match"); header == etag {
+				gc.Status(http.StatusNotModified)
+				return
+			}
+			gc.Data(http.StatusOK, contentType, s.avatar)
+			return
+		}
+	}
+
 	// Hash user login as a source
 	info := gc.MustGet("user").(UserInformation)
 	hash := fnv.New64()
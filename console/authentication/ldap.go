@@ -0,0 +1,354 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authentication
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-ldap/ldap/v3"
+
+	"akvorado/common/helpers"
+)
+
+const (
+	// sessionCookie is the name of the cookie containing the session token.
+	sessionCookie = "akvorado-session"
+	// logoutURL is the URL to log out a user authenticated with LDAP.
+	logoutURL = "/api/v0/console/user/logout"
+)
+
+var (
+	// errInvalidCredentials is returned when the login or the password is
+	// incorrect.
+	errInvalidCredentials = errors.New("invalid credentials")
+	// errNotAllowed is returned when the user does not belong to one of the
+	// required groups.
+	errNotAllowed = errors.New("user not allowed")
+)
+
+// session is a user session established after a successful LDAP login.
+type session struct {
+	user    UserInformation
+	avatar  []byte
+	expires time.Time
+}
+
+// userLoginInput is the input for the login endpoint.
+type userLoginInput struct {
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// UserLoginMethodsHandlerFunc tells which login methods are available.
+func (c *Component) UserLoginMethodsHandlerFunc(gc *gin.Context) {
+	gc.JSON(http.StatusOK, gin.H{"ldap": c.config.LDAP.URL != ""})
+}
+
+// UserLoginHandlerFunc authenticates a user against the LDAP directory and
+// establishes a session.
+func (c *Component) UserLoginHandlerFunc(gc *gin.Context) {
+	if c.config.LDAP.URL == "" {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "LDAP authentication is not enabled."})
+		return
+	}
+	var input userLoginInput
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	loginKey, clientKey := loginFailuresKeys(input.Login, c.clientAddr(gc))
+	if wait := c.loginRetryAfter(loginKey, clientKey); wait > 0 {
+		c.metrics.ldapLogins.WithLabelValues("throttled").Inc()
+		gc.Header("Retry-After", strconv.Itoa(int(wait.Round(time.Second).Seconds())))
+		gc.JSON(http.StatusTooManyRequests, gin.H{"message": "Too many failed login attempts. Try again later."})
+		return
+	}
+	s, err := c.ldapAuthenticate(input.Login, input.Password)
+	switch {
+	case errors.Is(err, errInvalidCredentials):
+		c.metrics.ldapLogins.WithLabelValues("invalid").Inc()
+		c.recordLoginFailure(loginKey, clientKey)
+		gc.JSON(http.StatusUnauthorized, gin.H{"message": "Invalid login or password."})
+		return
+	case errors.Is(err, errNotAllowed):
+		c.metrics.ldapLogins.WithLabelValues("denied").Inc()
+		gc.JSON(http.StatusForbidden, gin.H{"message": "User is not allowed to log in."})
+		return
+	case err != nil:
+		c.metrics.ldapLogins.WithLabelValues("error").Inc()
+		c.r.Err(err).Str("login", input.Login).Msg("unable to authenticate user with LDAP")
+		gc.JSON(http.StatusServiceUnavailable, gin.H{"message": "Unable to query LDAP server."})
+		return
+	}
+	c.resetLoginFailures(loginKey)
+	c.metrics.ldapLogins.WithLabelValues("success").Inc()
+
+	token, err := c.newSession(s)
+	if err != nil {
+		c.r.Err(err).Msg("unable to create session")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to create session."})
+		return
+	}
+	gc.SetSameSite(http.SameSiteLaxMode)
+	gc.SetCookie(sessionCookie, token, int(c.config.LDAP.SessionDuration.Seconds()),
+		"/", "", c.isSecure(gc), true)
+	gc.JSON(http.StatusOK, s.user)
+}
+
+// UserLogoutHandlerFunc terminates the current session, if any, and
+// redirects the user to the home page. It only accepts POST requests: as the
+// session cookie is not sent for cross-site POST requests, another site
+// cannot log out a user.
+func (c *Component) UserLogoutHandlerFunc(gc *gin.Context) {
+	if token, err := gc.Cookie(sessionCookie); err == nil {
+		c.sessionsLock.Lock()
+		delete(c.sessions, token)
+		c.sessionsLock.Unlock()
+	}
+	gc.SetSameSite(http.SameSiteLaxMode)
+	gc.SetCookie(sessionCookie, "", -1, "/", "", c.isSecure(gc), true)
+	gc.Redirect(http.StatusSeeOther, "/")
+}
+
+// remoteAddr returns the address of the peer, which may be a proxy.
+func remoteAddr(gc *gin.Context) netip.Addr {
+	addrPort, err := netip.ParseAddrPort(gc.Request.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addrPort.Addr().Unmap()
+}
+
+// trustedProxy tells if the provided address is a trusted proxy.
+func (c *Component) trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range c.config.LDAP.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecure tells if the request was received over HTTPS. The
+// X-Forwarded-Proto header is only used when set by a trusted proxy.
+func (c *Component) isSecure(gc *gin.Context) bool {
+	if gc.Request.TLS != nil {
+		return true
+	}
+	return c.trustedProxy(remoteAddr(gc)) && gc.GetHeader("X-Forwarded-Proto") == "https"
+}
+
+// clientAddr returns the address of the client. When the request comes from a
+// trusted proxy, the address is extracted from the X-Forwarded-For header,
+// skipping the other trusted proxies from the right.
+func (c *Component) clientAddr(gc *gin.Context) netip.Addr {
+	addr := remoteAddr(gc)
+	if !c.trustedProxy(addr) {
+		return addr
+	}
+	forwarded := strings.Split(strings.Join(gc.Request.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		candidate, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+		addr = candidate.Unmap()
+		if !c.trustedProxy(addr) {
+			break
+		}
+	}
+	return addr
+}
+
+// newSession registers a new session and returns its token. Expired sessions
+// are removed at the same time.
+func (c *Component) newSession(s session) (string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(random)
+	now := c.clock.Now()
+	s.expires = now.Add(c.config.LDAP.SessionDuration)
+
+	c.sessionsLock.Lock()
+	defer c.sessionsLock.Unlock()
+	for t, other := range c.sessions {
+		if now.After(other.expires) {
+			delete(c.sessions, t)
+		}
+	}
+	c.sessions[token] = s
+	return token, nil
+}
+
+// currentSession returns the session attached to the request, if any.
+func (c *Component) currentSession(gc *gin.Context) (session, bool) {
+	if c.config.LDAP.URL == "" {
+		return session{}, false
+	}
+	token, err := gc.Cookie(sessionCookie)
+	if err != nil || token == "" {
+		return session{}, false
+	}
+	c.sessionsLock.Lock()
+	defer c.sessionsLock.Unlock()
+	s, ok := c.sessions[token]
+	if !ok {
+		return session{}, false
+	}
+	if c.clock.Now().After(s.expires) {
+		delete(c.sessions, token)
+		return session{}, false
+	}
+	return s, true
+}
+
+// ldapConnect connects to the LDAP server and binds with the configured
+// credentials.
+func (c *Component) ldapConnect() (*ldap.Conn, error) {
+	config := c.config.LDAP
+	tlsConfig, err := config.TLS.MakeTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LDAP URL: %w", err)
+	}
+	options := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: config.Timeout})}
+	if u.Scheme == "ldaps" && tlsConfig != nil {
+		options = append(options, ldap.DialWithTLSConfig(tlsConfig))
+	}
+	conn, err := ldap.DialURL(config.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to LDAP server: %w", err)
+	}
+	conn.SetTimeout(config.Timeout)
+	if u.Scheme == "ldap" && tlsConfig != nil {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot negotiate TLS with LDAP server: %w", err)
+		}
+	}
+	if err := c.ldapServiceBind(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ldapServiceBind binds with the configured credentials.
+func (c *Component) ldapServiceBind(conn *ldap.Conn) error {
+	config := c.config.LDAP
+	if config.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+		return fmt.Errorf("cannot bind to LDAP server: %w", err)
+	}
+	return nil
+}
+
+// ldapAuthenticate checks the provided credentials against the LDAP
+// directory and returns a new session for the user.
+func (c *Component) ldapAuthenticate(login, password string) (session, error) {
+	config := c.config.LDAP
+	conn, err := c.ldapConnect()
+	if err != nil {
+		return session{}, err
+	}
+	defer conn.Close()
+
+	// Search the user
+	attributes := []string{}
+	for _, attribute := range []string{
+		config.Attributes.Login,
+		config.Attributes.Name,
+		config.Attributes.Email,
+		config.Attributes.Avatar,
+	} {
+		if attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	result, err := conn.Search(ldap.NewSearchRequest(
+		config.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(config.UserFilter, ldap.EscapeFilter(login)),
+		attributes, nil))
+	if err != nil {
+		return session{}, fmt.Errorf("cannot search user: %w", err)
+	}
+	if len(result.Entries) != 1 {
+		return session{}, errInvalidCredentials
+	}
+	entry := result.Entries[0]
+
+	// Check the password
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return session{}, errInvalidCredentials
+		}
+		return session{}, fmt.Errorf("cannot bind as user: %w", err)
+	}
+	s := session{
+		user: UserInformation{
+			Login:     entry.GetAttributeValue(config.Attributes.Login),
+			LogoutURL: logoutURL,
+		},
+	}
+	if s.user.Login == "" {
+		s.user.Login = login
+	}
+	if config.Attributes.Name != "" {
+		s.user.Name = entry.GetAttributeValue(config.Attributes.Name)
+	}
+	if config.Attributes.Email != "" {
+		s.user.Email = entry.GetAttributeValue(config.Attributes.Email)
+	}
+	if config.Attributes.Avatar != "" {
+		s.avatar = entry.GetRawAttributeValue(config.Attributes.Avatar)
+	}
+
+	// Search groups
+	if config.GroupBaseDN != "" {
+		if err := c.ldapServiceBind(conn); err != nil {
+			return session{}, err
+		}
+		result, err := conn.Search(ldap.NewSearchRequest(
+			config.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf(config.GroupFilter, ldap.EscapeFilter(entry.DN)),
+			[]string{config.GroupAttribute}, nil))
+		if err != nil {
+			return session{}, fmt.Errorf("cannot search groups: %w", err)
+		}
+		for _, group := range result.Entries {
+			name := group.GetAttributeValue(config.GroupAttribute)
+			if len(config.Groups) > 0 {
+				name = config.Groups[name]
+			}
+			if name != "" && !slices.Contains(s.user.Groups, name) {
+				s.user.Groups = append(s.user.Groups, name)
+			}
+		}
+	}
+	if len(config.RequiredGroups) > 0 && !slices.ContainsFunc(s.user.Groups, func(group string) bool {
+		return slices.Contains(config.RequiredGroups, group)
+	}) {
+		return session{}, errNotAllowed
+	}
+	return s, nil
+}
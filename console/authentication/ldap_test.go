@@ -0,0 +1,438 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authentication
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+)
+
+// fakeLDAPServer is a minimal in-process LDAP server. It accepts simple binds
+// for the provided DNs and answers searches from a fixed set of results
+// indexed by base DN and filter.
+type fakeLDAPServer struct {
+	listener  net.Listener
+	passwords map[string]string
+	searches  map[string][]*ldap.Entry
+}
+
+func newFakeLDAPServer(t *testing.T, passwords map[string]string, searches map[string][]*ldap.Entry) *fakeLDAPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	s := &fakeLDAPServer{
+		listener:  listener,
+		passwords: passwords,
+		searches:  searches,
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+// URL returns the URL to connect to the server.
+func (s *fakeLDAPServer) URL() string {
+	return fmt.Sprintf("ldap://%s", s.listener.Addr())
+}
+
+func (s *fakeLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		request, err := ber.ReadPacket(conn)
+		if err != nil || len(request.Children) < 2 {
+			return
+		}
+		id := request.Children[0].Value.(int64)
+		op := request.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := ldap.LDAPResultInvalidCredentials
+			if expected, ok := s.passwords[dn]; ok && expected == password {
+				code = ldap.LDAPResultSuccess
+			}
+			conn.Write(fakeLDAPResponse(id, fakeLDAPResult(ldap.ApplicationBindResponse, code)))
+		case ldap.ApplicationSearchRequest:
+			baseDN := op.Children[0].Value.(string)
+			filter, _ := ldap.DecompileFilter(op.Children[6])
+			for _, entry := range s.searches[fmt.Sprintf("%s %s", baseDN, filter)] {
+				conn.Write(fakeLDAPResponse(id, fakeLDAPEntry(entry)))
+			}
+			conn.Write(fakeLDAPResponse(id, fakeLDAPResult(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess)))
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func fakeLDAPResponse(id int64, op *ber.Packet) []byte {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	packet.AppendChild(op)
+	return packet.Bytes()
+}
+
+func fakeLDAPResult(application ber.Tag, code int) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return op
+}
+
+func fakeLDAPEntry(entry *ldap.Entry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "DN"))
+	attributes := ber.NewSequence("Attributes")
+	for _, attribute := range entry.Attributes {
+		packet := ber.NewSequence("Attribute")
+		packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, attribute.Name, "Type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range attribute.Values {
+			values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+		packet.AppendChild(values)
+		attributes.AppendChild(packet)
+	}
+	op.AppendChild(attributes)
+	return op
+}
+
+func TestLDAPAuthentication(t *testing.T) {
+	avatar := "\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"
+	server := newFakeLDAPServer(t, map[string]string{
+		"cn=akvorado,dc=example,dc=com":         "secret",
+		"uid=alfred,ou=users,dc=example,dc=com": "batcave",
+		"uid=bruce,ou=users,dc=example,dc=com":  "gotham",
+		"uid=dick,ou=users,dc=example,dc=com":   "circus",
+	}, map[string][]*ldap.Entry{
+		"ou=users,dc=example,dc=com (uid=alfred)": {
+			ldap.NewEntry("uid=alfred,ou=users,dc=example,dc=com", map[string][]string{
+				"uid":       {"alfred"},
+				"cn":        {"Alfred Pennyworth"},
+				"mail":      {"alfred@example.com"},
+				"jpegPhoto": {avatar},
+			}),
+		},
+		"ou=users,dc=example,dc=com (uid=bruce)": {
+			ldap.NewEntry("uid=bruce,ou=users,dc=example,dc=com", map[string][]string{
+				"uid": {"bruce"},
+			}),
+		},
+		"ou=users,dc=example,dc=com (uid=dick)": {
+			ldap.NewEntry("uid=dick,ou=users,dc=example,dc=com", map[string][]string{
+				"uid":       {"dick"},
+				"jpegPhoto": {"<html><script>alert(1)</script></html>"},
+			}),
+		},
+		"ou=groups,dc=example,dc=com (member=uid=alfred,ou=users,dc=example,dc=com)": {
+			ldap.NewEntry("cn=network,ou=groups,dc=example,dc=com", map[string][]string{
+				"cn": {"network"},
+			}),
+			ldap.NewEntry("cn=staff,ou=groups,dc=example,dc=com", map[string][]string{
+				"cn": {"staff"},
+			}),
+		},
+		"ou=groups,dc=example,dc=com (member=uid=dick,ou=users,dc=example,dc=com)": {
+			ldap.NewEntry("cn=network,ou=groups,dc=example,dc=com", map[string][]string{
+				"cn": {"network"},
+			}),
+		},
+	})
+
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	config := DefaultConfiguration()
+	config.DefaultUser = UserInformation{}
+	config.LDAP.URL = server.URL()
+	config.LDAP.BindDN = "cn=akvorado,dc=example,dc=com"
+	config.LDAP.BindPassword = "secret"
+	config.LDAP.BaseDN = "ou=users,dc=example,dc=com"
+	config.LDAP.Attributes.Avatar = "jpegPhoto"
+	config.LDAP.GroupBaseDN = "ou=groups,dc=example,dc=com"
+	config.LDAP.Groups = map[string]string{"network": "admins"}
+	config.LDAP.RequiredGroups = []string{"admins"}
+	c, err := New(r, config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	mockClock := clock.NewMock()
+	c.clock = mockClock
+	endpoint := h.GinRouter.Group("/api/v0/console/user", c.UserAuthentication())
+	endpoint.GET("/info", c.UserInfoHandlerFunc)
+	endpoint.GET("/avatar", c.UserAvatarHandlerFunc)
+	h.GinRouter.GET("/api/v0/console/user/login", c.UserLoginMethodsHandlerFunc)
+	h.GinRouter.POST("/api/v0/console/user/login", c.UserLoginHandlerFunc)
+	h.GinRouter.POST("/api/v0/console/user/logout", c.UserLogoutHandlerFunc)
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Jar: jar,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	request := func(method, path string, input any) (int, http.Header, string) {
+		t.Helper()
+		var body io.Reader
+		if input != nil {
+			encoded, _ := json.Marshal(input)
+			body = bytes.NewReader(encoded)
+		}
+		req, _ := http.NewRequest(method, fmt.Sprintf("http://%s%s", h.LocalAddr(), path), body)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s error:\n%+v", method, path, err)
+		}
+		defer resp.Body.Close()
+		output, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, resp.Header, strings.TrimSpace(string(output))
+	}
+	expect := func(method, path string, input any, expectedStatus int, expectedBody string) {
+		t.Helper()
+		status, _, body := request(method, path, input)
+		if diff := helpers.Diff([]any{status, body}, []any{expectedStatus, expectedBody}); diff != "" {
+			t.Errorf("%s %s (-got, +want):\n%s", method, path, diff)
+		}
+	}
+
+	expect("GET", "/api/v0/console/user/login", nil, 200, `{"ldap":true}`)
+	expect("GET", "/api/v0/console/user/info", nil,
+		401, `{"message":"No user logged in."}`)
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "alfred", "password": "robin"},
+		401, `{"message":"Invalid login or password."}`)
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "joker", "password": "robin"},
+		401, `{"message":"Invalid login or password."}`)
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "bruce", "password": "gotham"},
+		403, `{"message":"User is not allowed to log in."}`)
+	expect("GET", "/api/v0/console/user/info", nil,
+		401, `{"message":"No user logged in."}`)
+
+	alfred := `{"login":"alfred","name":"Alfred Pennyworth","email":"alfred@example.com","logout-url":"/api/v0/console/user/logout","groups":["admins"]}`
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "alfred", "password": "batcave"},
+		200, alfred)
+	expect("GET", "/api/v0/console/user/info", nil, 200, alfred)
+	expectAvatar := func(expectedBody bool) {
+		t.Helper()
+		status, headers, body := request("GET", "/api/v0/console/user/avatar", nil)
+		got := []any{status, headers.Get("Content-Type"), headers.Get("X-Content-Type-Options"), body == avatar}
+		expected := []any{200, "image/png", "nosniff", expectedBody}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("GET /api/v0/console/user/avatar (-got, +want):\n%s", diff)
+		}
+	}
+	expectAvatar(true)
+
+	expect("POST", "/api/v0/console/user/logout", nil, 303, "")
+	expect("GET", "/api/v0/console/user/info", nil,
+		401, `{"message":"No user logged in."}`)
+
+	// A session expires after the configured duration
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "alfred", "password": "batcave"},
+		200, alfred)
+	mockClock.Add(config.LDAP.SessionDuration - time.Minute)
+	expect("GET", "/api/v0/console/user/info", nil, 200, alfred)
+	mockClock.Add(2 * time.Minute)
+	expect("GET", "/api/v0/console/user/info", nil,
+		401, `{"message":"No user logged in."}`)
+
+	// An avatar which is not an image is replaced by a generated one
+	expect("POST", "/api/v0/console/user/login", map[string]string{"login": "dick", "password": "circus"},
+		200, `{"login":"dick","logout-url":"/api/v0/console/user/logout","groups":["admins"]}`)
+	expectAvatar(false)
+
+	gotMetrics := r.GetMetrics("akvorado_console_authentication_")
+	expectedMetrics := map[string]string{
+		`ldap_logins_total{result="denied"}`:  "1",
+		`ldap_logins_total{result="invalid"}`: "2",
+		`ldap_logins_total{result="success"}`: "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestLDAPAuthenticationErrors(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c := NewMock(t, r)
+	h.GinRouter.GET("/api/v0/console/user/login", c.UserLoginMethodsHandlerFunc)
+	h.GinRouter.POST("/api/v0/console/user/login", c.UserLoginHandlerFunc)
+
+	// Find an unused port
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	unreachable := fmt.Sprintf("ldap://%s", listener.Addr())
+	listener.Close()
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "login methods",
+			URL:         "/api/v0/console/user/login",
+			JSONOutput:  map[string]bool{"ldap": false},
+		}, {
+			Description: "LDAP not enabled",
+			URL:         "/api/v0/console/user/login",
+			JSONInput:   map[string]string{"login": "alfred", "password": "batcave"},
+			StatusCode:  404,
+			JSONOutput:  map[string]string{"message": "LDAP authentication is not enabled."},
+		},
+	})
+
+	c.config.LDAP.URL = unreachable
+	c.config.LDAP.BaseDN = "ou=users,dc=example,dc=com"
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "missing password",
+			URL:         "/api/v0/console/user/login",
+			JSONInput:   map[string]string{"login": "alfred"},
+			StatusCode:  400,
+			JSONOutput: map[string]string{
+				"message": "Key: 'userLoginInput.Password' Error:Field validation for 'Password' failed on the 'required' tag",
+			},
+		}, {
+			Description: "LDAP server unreachable",
+			URL:         "/api/v0/console/user/login",
+			JSONInput:   map[string]string{"login": "alfred", "password": "batcave"},
+			StatusCode:  503,
+			JSONOutput:  map[string]string{"message": "Unable to query LDAP server."},
+		},
+	})
+}
+
+func TestLDAPLoginThrottling(t *testing.T) {
+	server := newFakeLDAPServer(t, map[string]string{
+		"uid=alfred,ou=users,dc=example,dc=com": "batcave",
+	}, map[string][]*ldap.Entry{
+		"ou=users,dc=example,dc=com (uid=alfred)": {
+			ldap.NewEntry("uid=alfred,ou=users,dc=example,dc=com", map[string][]string{
+				"uid": {"alfred"},
+			}),
+		},
+	})
+
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	config := DefaultConfiguration()
+	config.LDAP.URL = server.URL()
+	config.LDAP.BaseDN = "ou=users,dc=example,dc=com"
+	c, err := New(r, config)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	mockClock := clock.NewMock()
+	c.clock = mockClock
+	h.GinRouter.POST("/api/v0/console/user/login", c.UserLoginHandlerFunc)
+
+	login := func(login, password string, headers map[string]string) *http.Response {
+		t.Helper()
+		encoded, _ := json.Marshal(map[string]string{"login": login, "password": password})
+		req, _ := http.NewRequest("POST",
+			fmt.Sprintf("http://%s/api/v0/console/user/login", h.LocalAddr()),
+			bytes.NewReader(encoded))
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/v0/console/user/login error:\n%+v", err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	expect := func(resp *http.Response, expectedStatus int, expectedRetryAfter string) {
+		t.Helper()
+		got := []any{resp.StatusCode, resp.Header.Get("Retry-After")}
+		if diff := helpers.Diff(got, []any{expectedStatus, expectedRetryAfter}); diff != "" {
+			t.Errorf("POST /api/v0/console/user/login (-got, +want):\n%s", diff)
+		}
+	}
+
+	// After 3 failures, the next attempt is delayed, even with the right
+	// password.
+	for range loginFreeFailures {
+		expect(login("alfred", "robin", nil), 401, "")
+	}
+	expect(login("alfred", "batcave", nil), 429, "1")
+	mockClock.Add(time.Second)
+	expect(login("Alfred", "robin", nil), 401, "")
+	expect(login("alfred", "batcave", nil), 429, "2")
+	mockClock.Add(2 * time.Second)
+	resp := login("alfred", "batcave", nil)
+	expect(resp, 200, "")
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == sessionCookie && cookie.Secure {
+			t.Error("session cookie is secure without a trusted proxy")
+		}
+	}
+
+	// The client is still throttled for another user.
+	expect(login("bruce", "gotham", nil), 401, "")
+	expect(login("bruce", "gotham", nil), 429, "4")
+
+	// X-Forwarded-For and X-Forwarded-Proto are only used from a trusted
+	// proxy.
+	headers := map[string]string{
+		"X-Forwarded-For":   "198.51.100.1",
+		"X-Forwarded-Proto": "https",
+	}
+	expect(login("bruce", "gotham", headers), 429, "4")
+	c.config.LDAP.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	resp = login("alfred", "batcave", headers)
+	expect(resp, 200, "")
+	secure := false
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == sessionCookie {
+			secure = cookie.Secure
+		}
+	}
+	if !secure {
+		t.Error("session cookie is not secure behind a trusted proxy")
+	}
+
+	// Failures are forgotten after a while.
+	mockClock.Add(loginMaxBackoff + time.Second)
+	expect(login("bruce", "gotham", nil), 401, "")
+	expect(login("alfred", "batcave", nil), 200, "")
+
+	gotMetrics := r.GetMetrics("akvorado_console_authentication_")
+	expectedMetrics := map[string]string{
+		`ldap_logins_total{result="invalid"}`:   "6",
+		`ldap_logins_total{result="success"}`:   "3",
+		`ldap_logins_total{result="throttled"}`: "4",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
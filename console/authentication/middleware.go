@@ -13,17 +13,23 @@ import (
 
 // UserInformation contains information about the current user.
 type UserInformation struct {
-	Login     string `json:"login" header:"LOGIN" binding:"required"`
-	Name      string `json:"name,omitempty" header:"NAME"`
-	Email     string `json:"email,omitempty" header:"EMAIL" binding:"omitempty,email"`
-	LogoutURL string `json:"logout-url,omitempty" header:"LOGOUT" binding:"omitempty,uri"`
+	Login     string   `json:"login" header:"LOGIN" binding:"required"`
+	Name      string   `json:"name,omitempty" header:"NAME"`
+	Email     string   `json:"email,omitempty" header:"EMAIL" binding:"omitempty,email"`
+	LogoutURL string   `json:"logout-url,omitempty" header:"LOGOUT" binding:"omitempty,uri"`
+	Groups    []string `json:"groups,omitempty"`
 }
 
 // UserAuthentication is a middleware to fill information about the
-// current user. It does not really perform authentication but relies
-// on HTTP headers.
+// current user. It uses the session established after an LDAP login. Otherwise,
+// it does not really perform authentication but relies on HTTP headers.
 func (c *Component) UserAuthentication() gin.HandlerFunc {
 	return func(gc *gin.Context) {
+		if s, ok := c.currentSession(gc); ok {
+			gc.Set("user", s.user)
+			gc.Next()
+			return
+		}
 		var info UserInformation
 		if err := gc.ShouldBindWith(&info, customHeaderBinding{c}); err != nil {
 			if c.config.DefaultUser.Login == "" {
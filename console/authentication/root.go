@@ -4,20 +4,49 @@
 // Package authentication handles user authentication for the console.
 package authentication
 
-import "akvorado/common/reporter"
+import (
+	"sync"
+
+	"github.com/benbjohnson/clock"
+
+	"akvorado/common/reporter"
+)
 
 // Component represents the authentication compomenent.
 type Component struct {
 	r      *reporter.Reporter
 	config Configuration
+
+	clock clock.Clock
+
+	sessionsLock sync.Mutex
+	sessions     map[string]session
+
+	loginFailuresLock sync.Mutex
+	loginFailures     map[string]loginFailures
+
+	metrics struct {
+		ldapLogins *reporter.CounterVec
+	}
 }
 
 // New creates a new authentication component.
 func New(r *reporter.Reporter, configuration Configuration) (*Component, error) {
 	c := Component{
-		r:      r,
-		config: configuration,
+		r:        r,
+		config:   configuration,
+		clock:    clock.New(),
+		sessions: map[string]session{},
+
+		loginFailures: map[string]loginFailures{},
 	}
 
+	c.metrics.ldapLogins = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "ldap_logins_total",
+			Help: "Number of login attempts using LDAP.",
+		}, []string{"result"},
+	)
+
 	return &c, nil
 }
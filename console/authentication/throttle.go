@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package authentication
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+)
+
+const (
+	// loginFreeFailures is the number of failed logins allowed before
+	// delaying the next attempts.
+	loginFreeFailures = 3
+	// loginMaxBackoff is the maximum delay between two login attempts after
+	// repeated failures. Failures older than this delay are forgotten.
+	loginMaxBackoff = 5 * time.Minute
+)
+
+// loginFailures tracks the failed logins for a user or a client.
+type loginFailures struct {
+	count int
+	last  time.Time
+}
+
+// delay returns how long to wait after the last failure before accepting a
+// new attempt. The delay doubles with each failure.
+func (f loginFailures) delay() time.Duration {
+	if f.count < loginFreeFailures {
+		return 0
+	}
+	return min(time.Second<<min(f.count-loginFreeFailures, 16), loginMaxBackoff)
+}
+
+// loginFailuresKeys returns the keys used to track failed logins for the
+// provided login and for the provided client.
+func loginFailuresKeys(login string, client netip.Addr) (string, string) {
+	return "login:" + strings.ToLower(login), "client:" + client.String()
+}
+
+// loginRetryAfter returns how long to wait before accepting a login attempt
+// for the provided keys.
+func (c *Component) loginRetryAfter(keys ...string) time.Duration {
+	now := c.clock.Now()
+	c.loginFailuresLock.Lock()
+	defer c.loginFailuresLock.Unlock()
+	wait := time.Duration(0)
+	for _, key := range keys {
+		if f, ok := c.loginFailures[key]; ok {
+			wait = max(wait, f.last.Add(f.delay()).Sub(now))
+		}
+	}
+	return wait
+}
+
+// recordLoginFailure records a failed login for the provided keys. Old
+// failures are forgotten at the same time.
+func (c *Component) recordLoginFailure(keys ...string) {
+	now := c.clock.Now()
+	c.loginFailuresLock.Lock()
+	defer c.loginFailuresLock.Unlock()
+	for key, f := range c.loginFailures {
+		if now.Sub(f.last) > loginMaxBackoff {
+			delete(c.loginFailures, key)
+		}
+	}
+	for _, key := range keys {
+		f := c.loginFailures[key]
+		f.count++
+		f.last = now
+		c.loginFailures[key] = f
+	}
+}
+
+// resetLoginFailures forgets the failed logins for the provided keys.
+func (c *Component) resetLoginFailures(keys ...string) {
+	c.loginFailuresLock.Lock()
+	defer c.loginFailuresLock.Unlock()
+	for _, key := range keys {
+		delete(c.loginFailures, key)
+	}
+}
@@ -8,11 +8,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
 	"time"
 
+	"akvorado/common/schema"
 	"akvorado/console/query"
 )
 
@@ -76,28 +78,111 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 		return errors.New("no flows table present (yet?)")
 	}
 
+	// Get columns for each table
+	var columns []struct {
+		Table   string   `ch:"table"`
+		Columns []string `ch:"columns"`
+	}
+	err = c.d.ClickHouseDB.Select(ctx, &columns, `
+SELECT table, groupArray(name) AS columns
+FROM system.columns
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+GROUP BY table
+`)
+	if err != nil {
+		return fmt.Errorf("cannot query flows table columns: %w", err)
+	}
+	newFlowsTablesColumns := map[string][]string{}
+	for _, table := range columns {
+		newFlowsTablesColumns[table.Table] = table.Columns
+	}
+
+	// Get tables with a sampling key. For distributed tables, the sampling
+	// key is on the local table.
+	var sampled []struct {
+		Name string `ch:"name"`
+	}
+	err = c.d.ClickHouseDB.Select(ctx, &sampled, `
+SELECT name
+FROM system.tables
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+AND sampling_key != ''
+`)
+	if err != nil {
+		// Approximate queries are only an optimization: without this
+		// information, we fall back to exact queries.
+		c.r.Err(err).Msg("cannot query flows table sampling keys")
+		sampled = nil
+	}
+	newFlowsTablesSampled := map[string]bool{}
+	for _, table := range sampled {
+		newFlowsTablesSampled[strings.TrimSuffix(table.Name, "_local")] = true
+	}
+
 	c.flowsTablesLock.Lock()
 	c.flowsTables = newFlowsTables
+	c.flowsTablesColumns = newFlowsTablesColumns
+	c.flowsTablesSampled = newFlowsTablesSampled
 	c.flowsTablesLock.Unlock()
 	return nil
 }
 
+// queryInfo contains information collected while finalizing a query.
+type queryInfo struct {
+	// Approximate is true when at least one table is sampled.
+	Approximate bool
+	// Ranges tells which tables served which time ranges.
+	Ranges []tableRange
+}
+
 // finalizeQuery builds the finalized query. A single "context"
 // function is provided to return a `Context` struct with all the
 // information needed.
 func (c *Component) finalizeQuery(query string) string {
-	t := template.Must(template.New("query").
+	result, _ := c.finalizeQueryWithInfo(query)
+	return result
+}
+
+// finalizeQueryWithInfo is like finalizeQuery but also returns
+// information about the finalized query.
+func (c *Component) finalizeQueryWithInfo(query string) (string, queryInfo) {
+	result, info, err := c.executeQueryTemplate(query)
+	if err != nil {
+		c.r.Err(err).Str("query", query).Msg("invalid query")
+		panic(err)
+	}
+	return result, info
+}
+
+// executeQueryTemplate executes the query template and returns the
+// finalized query or an error if the template is invalid.
+func (c *Component) executeQueryTemplate(query string) (string, queryInfo, error) {
+	info := queryInfo{}
+	t, err := template.New("query").
 		Funcs(template.FuncMap{
-			"context": c.contextFunc,
+			"context": func(input string) context {
+				result := c.contextFunc(input)
+				info.Approximate = info.Approximate || result.Approximate
+				for _, r := range result.Ranges {
+					if !slices.Contains(info.Ranges, r) {
+						info.Ranges = append(info.Ranges, r)
+					}
+				}
+				return result
+			},
 		}).
 		Option("missingkey=error").
-		Parse(strings.TrimSpace(query)))
+		Parse(strings.TrimSpace(query))
+	if err != nil {
+		return "", info, err
+	}
 	buf := bytes.NewBufferString("")
 	if err := t.Execute(buf, nil); err != nil {
-		c.r.Err(err).Str("query", query).Msg("invalid query")
-		panic(err)
+		return "", info, err
 	}
-	return buf.String()
+	return buf.String(), info, nil
 }
 
 type inputContext struct {
@@ -105,8 +190,11 @@ type inputContext struct {
 	End               time.Time  `json:"end"`
 	StartForInterval  *time.Time `json:"start-for-interval,omitempty"`
 	MainTableRequired bool       `json:"main-table-required,omitempty"`
+	Columns           []string   `json:"columns,omitempty"`
 	Points            uint       `json:"points"`
 	Units             string     `json:"units,omitempty"`
+	Approximate       bool       `json:"approximate,omitempty"`
+	Stitch            bool       `json:"stitch,omitempty"`
 }
 
 type context struct {
@@ -116,7 +204,23 @@ type context struct {
 	TimefilterEnd     string
 	Units             string
 	Interval          uint64
+	Approximate       bool
 	ToStartOfInterval func(string) string
+	// Parts contains one context for each table serving the request. When
+	// stitching several tables, each part has its own time filter and
+	// interval and the results should be merged with UNION ALL. Otherwise,
+	// there is only one part.
+	Parts []context
+	// Ranges tells which tables serve which time ranges.
+	Ranges []tableRange
+}
+
+// tableRange is a time range served by a flows table.
+type tableRange struct {
+	Table    string    `json:"table"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Interval uint64    `json:"interval"`
 }
 
 // templateEscape escapes `{{` and `}}` from a string. In fact, only
@@ -149,6 +253,11 @@ func (c *Component) contextFunc(inputStr string) context {
 	}
 
 	table, computedInterval, targetInterval := c.computeTableAndInterval(input)
+	if input.Stitch && input.StartForInterval == nil && !input.MainTableRequired {
+		if ranges := c.planFlowsTables(input, targetInterval); len(ranges) > 1 {
+			return c.stitchedContext(input, ranges)
+		}
+	}
 
 	// Make start/end match the computed interval (currently equal to the table resolution)
 	start := input.Start.Truncate(computedInterval)
@@ -159,21 +268,58 @@ func (c *Component) contextFunc(inputStr string) context {
 	}
 	// Adapt end to ensure we get a full interval
 	end = start.Add(end.Sub(start).Truncate(computedInterval))
-	// Now, toStartOfInterval will provide an incorrect value. We
-	// compute a correction offset. Go's truncate seems to
-	// be different from what we expect.
-	computedIntervalOffset := start.UTC().Sub(
-		time.Unix(start.UTC().Unix()/
-			int64(computedInterval.Seconds())*
-			int64(computedInterval.Seconds()), 0))
-	diffOffset := uint64(computedInterval.Seconds()) - uint64(computedIntervalOffset.Seconds())
 
 	// Compute all strings
-	timefilterStart := fmt.Sprintf(`toDateTime('%s', 'UTC')`, start.UTC().Format("2006-01-02 15:04:05"))
-	timefilterEnd := fmt.Sprintf(`toDateTime('%s', 'UTC')`, end.UTC().Format("2006-01-02 15:04:05"))
+	timefilterStart := sqlDateTime(start)
+	timefilterEnd := sqlDateTime(end)
 	timefilter := fmt.Sprintf(`TimeReceived BETWEEN %s AND %s`, timefilterStart, timefilterEnd)
+	if c.excludeDuplicates(input.Columns) {
+		timefilter = fmt.Sprintf("%s AND Duplicate = 0", timefilter)
+	}
+
+	// When sampling the table, scale the sampling rate accordingly. Tables
+	// without a sampling key (for example, when created before enabling
+	// sampling) are queried exactly.
+	metricsTable := table
+	samplingRate := "SamplingRate"
+	approximate := input.Approximate && c.flowsTableSampled(table)
+	if approximate {
+		table = fmt.Sprintf("%s SAMPLE 1/%d", table, c.config.SampleFactor)
+		samplingRate = "SamplingRate*_sample_factor"
+	}
+
+	units := strings.ReplaceAll(unitsExpression(input.Units), "SamplingRate", samplingRate)
+
+	c.metrics.clickhouseQueries.WithLabelValues(metricsTable).Inc()
+	result := context{
+		Table:             table,
+		Timefilter:        timefilter,
+		TimefilterStart:   timefilterStart,
+		TimefilterEnd:     timefilterEnd,
+		Units:             units,
+		Interval:          uint64(computedInterval.Seconds()),
+		Approximate:       approximate,
+		ToStartOfInterval: toStartOfInterval(start, computedInterval),
+		Ranges: []tableRange{{
+			Table:    metricsTable,
+			Start:    start,
+			End:      end,
+			Interval: uint64(computedInterval.Seconds()),
+		}},
+	}
+	result.Parts = []context{result}
+	return result
+}
+
+// sqlDateTime formats the provided time for ClickHouse.
+func sqlDateTime(t time.Time) string {
+	return fmt.Sprintf(`toDateTime('%s', 'UTC')`, t.UTC().Format("2006-01-02 15:04:05"))
+}
+
+// unitsExpression returns the aggregate expression for the provided units.
+func unitsExpression(input string) string {
 	var units string
-	switch input.Units {
+	switch input {
 	case "pps":
 		units = `SUM(Packets*SamplingRate)`
 	case "l3bps":
@@ -193,24 +339,198 @@ func (c *Component) contextFunc(inputStr string) context {
 		// Same but using output interface as reference
 		units = `ifNotFinite(SUM((Bytes+38*Packets)*SamplingRate*8*100/(OutIfSpeed*1000000))/COUNT(DISTINCT ExporterAddress, OutIfName),0)`
 	}
+	return units
+}
+
+// toStartOfInterval returns a function rounding down a field to the provided
+// interval, with intervals starting at the provided time.
+func toStartOfInterval(start time.Time, interval time.Duration) func(string) string {
+	// toStartOfInterval will provide an incorrect value. We compute a
+	// correction offset. Go's truncate seems to be different from what we
+	// expect.
+	intervalOffset := start.UTC().Sub(
+		time.Unix(start.UTC().Unix()/
+			int64(interval.Seconds())*
+			int64(interval.Seconds()), 0))
+	diffOffset := uint64(interval.Seconds()) - uint64(intervalOffset.Seconds())
+	return func(field string) string {
+		return fmt.Sprintf(
+			`toStartOfInterval(%s + INTERVAL %d second, INTERVAL %d second) - INTERVAL %d second`,
+			field,
+			diffOffset,
+			uint64(interval.Seconds()),
+			diffOffset)
+	}
+}
+
+// stitchedContext builds a context for a request served by several tables.
+// The table is a UNION ALL of the provided ranges, each range selecting only
+// the columns needed by the request. Sampling is not used.
+func (c *Component) stitchedContext(input inputContext, ranges []tableRange) context {
+	columns := []string{"TimeReceived", "Bytes", "Packets", "SamplingRate"}
+	for _, column := range c.contextColumns(input) {
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	excludeDuplicates := c.excludeDuplicates(input.Columns)
+	units := unitsExpression(input.Units)
 
-	c.metrics.clickhouseQueries.WithLabelValues(table).Inc()
+	selects := make([]string, 0, len(ranges))
+	parts := make([]context, 0, len(ranges))
+	for idx, r := range ranges {
+		interval := time.Duration(r.Interval) * time.Second
+		timefilterStart := sqlDateTime(r.Start)
+		timefilterEnd := sqlDateTime(r.End)
+		timefilter := fmt.Sprintf(`TimeReceived >= %s AND TimeReceived < %s`, timefilterStart, timefilterEnd)
+		if idx == len(ranges)-1 {
+			// The last range includes its end, like for a single table.
+			timefilter = fmt.Sprintf(`TimeReceived BETWEEN %s AND %s`, timefilterStart, timefilterEnd)
+		} else {
+			// Templates fill values up to TimefilterEnd included.
+			timefilterEnd = sqlDateTime(r.End.Add(-time.Second))
+		}
+		selects = append(selects, fmt.Sprintf("SELECT %s FROM %s WHERE %s",
+			strings.Join(columns, ", "), r.Table, timefilter))
+		if excludeDuplicates {
+			timefilter = fmt.Sprintf("%s AND Duplicate = 0", timefilter)
+		}
+		c.metrics.clickhouseQueries.WithLabelValues(r.Table).Inc()
+		parts = append(parts, context{
+			Timefilter:        timefilter,
+			TimefilterStart:   timefilterStart,
+			TimefilterEnd:     timefilterEnd,
+			Units:             units,
+			Interval:          r.Interval,
+			ToStartOfInterval: toStartOfInterval(r.Start, interval),
+		})
+	}
+	table := fmt.Sprintf("(%s)", strings.Join(selects, " UNION ALL "))
+	for idx := range parts {
+		parts[idx].Table = table
+	}
+
+	first, last := ranges[0], ranges[len(ranges)-1]
+	timefilter := fmt.Sprintf(`TimeReceived BETWEEN %s AND %s`, sqlDateTime(first.Start), sqlDateTime(last.End))
+	if excludeDuplicates {
+		timefilter = fmt.Sprintf("%s AND Duplicate = 0", timefilter)
+	}
 	return context{
-		Table:           table,
-		Timefilter:      timefilter,
-		TimefilterStart: timefilterStart,
-		TimefilterEnd:   timefilterEnd,
-		Units:           units,
-		Interval:        uint64(computedInterval.Seconds()),
-		ToStartOfInterval: func(field string) string {
-			return fmt.Sprintf(
-				`toStartOfInterval(%s + INTERVAL %d second, INTERVAL %d second) - INTERVAL %d second`,
-				field,
-				diffOffset,
-				uint64(computedInterval.Seconds()),
-				diffOffset)
-		},
+		Table:             table,
+		Timefilter:        timefilter,
+		TimefilterStart:   sqlDateTime(first.Start),
+		TimefilterEnd:     sqlDateTime(last.End),
+		Units:             units,
+		Interval:          first.Interval,
+		ToStartOfInterval: parts[0].ToStartOfInterval,
+		Parts:             parts,
+		Ranges:            ranges,
+	}
+}
+
+// planFlowsTables splits the requested time range across several flows
+// tables: the finest appropriate resolution is used for recent data and
+// coarser resolutions for older data. Each range has its own interval and
+// its length is a multiple of it. Ranges are sorted from the oldest one.
+// When the request can be served by a single table, nil is returned.
+func (c *Component) planFlowsTables(input inputContext, targetInterval time.Duration) []tableRange {
+	columns := c.contextColumns(input)
+	c.flowsTablesLock.RLock()
+	flowsTables := []flowsTable{}
+	for _, table := range c.flowsTables {
+		if c.flowsTableHasColumns(table, columns) {
+			flowsTables = append(flowsTables, table)
+		}
+	}
+	c.flowsTablesLock.RUnlock()
+	sort.Slice(flowsTables, func(i, j int) bool {
+		return flowsTables[i].Resolution < flowsTables[j].Resolution
+	})
+
+	// bestTable returns the best table for data received from the provided
+	// time. Like for getBestTable, this is the coarsest table with a
+	// resolution below the target interval.
+	bestTable := func(t time.Time) (flowsTable, bool) {
+		candidates := []flowsTable{}
+		for _, table := range flowsTables {
+			if !t.Before(table.Oldest.Add(table.Resolution)) {
+				candidates = append(candidates, table)
+			}
+		}
+		if len(candidates) == 0 {
+			return flowsTable{}, false
+		}
+		for len(candidates) > 1 && candidates[1].Resolution < targetInterval {
+			candidates = candidates[1:]
+		}
+		return candidates[0], true
+	}
+
+	// Split the range at each point a table starts having data.
+	type segment struct {
+		table flowsTable
+		start time.Time
+	}
+	starts := []time.Time{input.Start}
+	for _, table := range flowsTables {
+		if t := table.Oldest.Add(table.Resolution); t.After(input.Start) && t.Before(input.End) {
+			starts = append(starts, t)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+	segments := []segment{}
+	for _, start := range starts {
+		table, ok := bestTable(start)
+		if !ok {
+			return nil
+		}
+		if len(segments) > 0 && segments[len(segments)-1].table.Name == table.Name {
+			continue
+		}
+		segments = append(segments, segment{table, start})
+	}
+	if len(segments) < 2 {
+		return nil
+	}
+
+	// Align ranges on their intervals. A range is extended to cover a full
+	// interval, which is possible as older tables also contain recent data.
+	ranges := []tableRange{}
+	for idx, segment := range segments {
+		resolution := max(segment.table.Resolution, time.Second)
+		interval := resolution
+		if targetInterval > interval {
+			interval = targetInterval.Truncate(resolution)
+		}
+		start := input.Start.Truncate(resolution)
+		if len(ranges) > 0 {
+			start = ranges[len(ranges)-1].End
+		}
+		var end time.Time
+		if idx == len(segments)-1 {
+			end = input.End.Truncate(resolution)
+			end = start.Add(end.Sub(start).Truncate(interval))
+		} else {
+			count := (segments[idx+1].start.Sub(start) + interval - 1) / interval
+			end = start.Add(count * interval)
+		}
+		if !end.After(start) {
+			continue
+		}
+		ranges = append(ranges, tableRange{
+			Table:    segment.table.Name,
+			Start:    start,
+			End:      end,
+			Interval: uint64(interval.Seconds()),
+		})
+		if !end.Before(input.End) {
+			break
+		}
 	}
+	if len(ranges) < 2 {
+		return nil
+	}
+	return ranges
 }
 
 func (c *Component) computeTableAndInterval(input inputContext) (string, time.Duration, time.Duration) {
@@ -224,26 +544,59 @@ func (c *Component) computeTableAndInterval(input inputContext) (string, time.Du
 	if input.MainTableRequired {
 		targetIntervalForTableSelection = time.Second
 	}
-	table, computedInterval := c.getBestTable(input.Start, targetIntervalForTableSelection)
+	columns := c.contextColumns(input)
+	table, computedInterval := c.getBestTable(input.Start, targetIntervalForTableSelection, columns)
 	if input.StartForInterval != nil {
-		_, computedInterval = c.getBestTable(*input.StartForInterval, targetIntervalForTableSelection)
+		_, computedInterval = c.getBestTable(*input.StartForInterval, targetIntervalForTableSelection, columns)
 	}
 	return table, computedInterval, targetInterval
 }
 
-// Get the best table starting at the specified time.
-func (c *Component) getBestTable(start time.Time, targetInterval time.Duration) (string, time.Duration) {
+// contextColumns returns the columns needed by a request, including the ones
+// needed for the requested units.
+func (c *Component) contextColumns(input inputContext) []string {
+	columns := input.Columns
+	switch input.Units {
+	case "inl2%":
+		columns = append(slices.Clone(columns), "InIfSpeed", "InIfName", "ExporterAddress")
+	case "outl2%":
+		columns = append(slices.Clone(columns), "OutIfSpeed", "OutIfName", "ExporterAddress")
+	}
+	if c.excludeDuplicates(input.Columns) {
+		columns = append(slices.Clone(columns), "Duplicate")
+	}
+	return columns
+}
+
+// excludeDuplicates tells if flows from non-authoritative observation points
+// should be excluded from a query using the provided columns. This is the
+// case when the Duplicate column is enabled, unless the query explicitly
+// uses it.
+func (c *Component) excludeDuplicates(columns []string) bool {
+	column, _ := c.d.Schema.LookupColumnByKey(schema.ColumnDuplicate)
+	return !column.Disabled && !slices.Contains(columns, column.Name)
+}
+
+// Get the best table starting at the specified time and containing the
+// provided columns.
+func (c *Component) getBestTable(start time.Time, targetInterval time.Duration, columns []string) (string, time.Duration) {
 	c.flowsTablesLock.RLock()
 	defer c.flowsTablesLock.RUnlock()
 
 	table := "flows"
 	computedInterval := time.Second
-	if len(c.flowsTables) > 0 {
+	flowsTables := []flowsTable{}
+	for _, table := range c.flowsTables {
+		if c.flowsTableHasColumns(table, columns) {
+			flowsTables = append(flowsTables, table)
+		}
+	}
+	if len(flowsTables) > 0 {
 		// We can use the consolidated data. The first
 		// criteria is to find the tables matching the time
 		// criteria.
 		candidates := []int{}
-		for idx, table := range c.flowsTables {
+		for idx, table := range flowsTables {
 			if start.After(table.Oldest.Add(table.Resolution)) {
 				candidates = append(candidates, idx)
 			}
@@ -251,38 +604,68 @@ func (c *Component) getBestTable(start time.Time, targetInterval time.Duration)
 		if len(candidates) == 0 {
 			// No candidate, fallback to the one with oldest data
 			best := 0
-			for idx, table := range c.flowsTables {
-				if c.flowsTables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
+			for idx, table := range flowsTables {
+				if flowsTables[best].Oldest.After(table.Oldest.Add(table.Resolution)) {
 					best = idx
 				}
 			}
 			candidates = []int{best}
 			// Add other candidates that are not far off in term of oldest data
-			for idx, table := range c.flowsTables {
+			for idx, table := range flowsTables {
 				if idx == best {
 					continue
 				}
-				if c.flowsTables[best].Oldest.After(table.Oldest) {
+				if flowsTables[best].Oldest.After(table.Oldest) {
 					candidates = append(candidates, idx)
 				}
 			}
 		}
 		sort.Slice(candidates, func(i, j int) bool {
-			return c.flowsTables[candidates[i]].Resolution < c.flowsTables[candidates[j]].Resolution
+			return flowsTables[candidates[i]].Resolution < flowsTables[candidates[j]].Resolution
 		})
 		// If possible, use the first resolution before the target interval
 		for len(candidates) > 1 {
-			if c.flowsTables[candidates[1]].Resolution < targetInterval {
+			if flowsTables[candidates[1]].Resolution < targetInterval {
 				candidates = candidates[1:]
 			} else {
 				break
 			}
 		}
-		table = c.flowsTables[candidates[0]].Name
-		computedInterval = c.flowsTables[candidates[0]].Resolution
+		table = flowsTables[candidates[0]].Name
+		computedInterval = flowsTables[candidates[0]].Resolution
 	}
 	if computedInterval < time.Second {
 		computedInterval = time.Second
 	}
 	return table, computedInterval
 }
+
+// flowsTableHasColumns tells if the provided flows table contains all the
+// provided columns. When we don't know the columns of a consolidated table,
+// we assume it contains all the columns not restricted to the main table. The
+// lock should be held.
+func (c *Component) flowsTableHasColumns(table flowsTable, columns []string) bool {
+	if table.Resolution == 0 {
+		return true
+	}
+	known, ok := c.flowsTablesColumns[table.Name]
+	for _, name := range columns {
+		if ok {
+			if !slices.Contains(known, name) {
+				return false
+			}
+			continue
+		}
+		if column, ok := c.d.Schema.LookupColumnByName(name); ok && column.ClickHouseMainOnly {
+			return false
+		}
+	}
+	return true
+}
+
+// flowsTableSampled tells if the provided flows table has a sampling key.
+func (c *Component) flowsTableSampled(table string) bool {
+	c.flowsTablesLock.RLock()
+	defer c.flowsTablesLock.RUnlock()
+	return c.flowsTablesSampled[table]
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/schema"
 
 	"go.uber.org/mock/gomock"
 )
@@ -58,6 +59,37 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 		SetArg(1, []struct {
 			T time.Time `ch:"t"`
 		}{{time.Date(2022, 2, 10, 15, 45, 10, 0, time.UTC)}})
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT table, groupArray(name) AS columns
+FROM system.columns
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+GROUP BY table
+`).
+		Return(nil).
+		SetArg(1, []struct {
+			Table   string   `ch:"table"`
+			Columns []string `ch:"columns"`
+		}{
+			{"flows", []string{"TimeReceived", "SrcAS", "DstPort"}},
+			{"flows_1m0s", []string{"TimeReceived", "SrcAS", "DstPort"}},
+			{"flows_5m0s", []string{"TimeReceived", "SrcAS"}},
+		})
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT name
+FROM system.tables
+WHERE database=currentDatabase()
+AND table LIKE 'flows%'
+AND sampling_key != ''
+`).
+		Return(nil).
+		SetArg(1, []struct {
+			Name string `ch:"name"`
+		}{
+			{"flows_local"},
+		})
 	if err := c.refreshFlowsTables(); err != nil {
 		t.Fatalf("refreshFlowsTables() error:\n%+v", err)
 	}
@@ -71,6 +103,18 @@ AND (engine LIKE '%MergeTree' OR engine = 'Distributed')
 	if diff := helpers.Diff(c.flowsTables, expected); diff != "" {
 		t.Fatalf("refreshFlowsTables() diff:\n%s", diff)
 	}
+	expectedColumns := map[string][]string{
+		"flows":      {"TimeReceived", "SrcAS", "DstPort"},
+		"flows_1m0s": {"TimeReceived", "SrcAS", "DstPort"},
+		"flows_5m0s": {"TimeReceived", "SrcAS"},
+	}
+	if diff := helpers.Diff(c.flowsTablesColumns, expectedColumns); diff != "" {
+		t.Fatalf("refreshFlowsTables() columns diff:\n%s", diff)
+	}
+	expectedSampled := map[string]bool{"flows": true}
+	if diff := helpers.Diff(c.flowsTablesSampled, expectedSampled); diff != "" {
+		t.Fatalf("refreshFlowsTables() sampled diff:\n%s", diff)
+	}
 }
 
 func TestFinalizeQuery(t *testing.T) {
@@ -303,12 +347,138 @@ func TestFinalizeQuery(t *testing.T) {
 	}
 }
 
-func TestComputeBestTableAndInterval(t *testing.T) {
+func TestFinalizeQueryApproximate(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)},
+		{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)},
+	}
+	c.flowsTablesSampled = map[string]bool{"flows": true}
+	query := "SELECT {{ .Units }} FROM {{ .Table }}"
+
 	cases := []struct {
 		Description string
-		Tables      []flowsTable
 		Context     inputContext
-		Expected    tableIntervalOutput
+		Expected    string
+		Approximate bool
+	}{
+		{
+			Description: "sampled table",
+			Context: inputContext{
+				Start:       time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:         time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points:      86400,
+				Units:       "l3bps",
+				Approximate: true,
+			},
+			Expected:    "SELECT SUM(Bytes*SamplingRate*_sample_factor*8) FROM flows SAMPLE 1/10",
+			Approximate: true,
+		}, {
+			Description: "sampled table without approximation",
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 86400,
+				Units:  "l3bps",
+			},
+			Expected: "SELECT SUM(Bytes*SamplingRate*8) FROM flows",
+		}, {
+			Description: "table without sampling key",
+			Context: inputContext{
+				Start:       time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:         time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points:      200,
+				Units:       "pps",
+				Approximate: true,
+			},
+			Expected: "SELECT SUM(Packets*SamplingRate) FROM flows_1m0s",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			got, info := c.finalizeQueryWithInfo(
+				fmt.Sprintf(`{{ with %s }}%s{{ end }}`, templateContext(tc.Context), query))
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("finalizeQueryWithInfo(): (-got, +want):\n%s", diff)
+			}
+			if info.Approximate != tc.Approximate {
+				t.Errorf("finalizeQueryWithInfo() approximate = %v, expected %v", info.Approximate, tc.Approximate)
+			}
+		})
+	}
+
+	// Main table created before enabling sampling
+	c.flowsTablesSampled = map[string]bool{}
+	got, info := c.finalizeQueryWithInfo(
+		fmt.Sprintf(`{{ with %s }}%s{{ end }}`, templateContext(cases[0].Context), query))
+	if diff := helpers.Diff(got, "SELECT SUM(Bytes*SamplingRate*8) FROM flows"); diff != "" {
+		t.Errorf("finalizeQueryWithInfo(): (-got, +want):\n%s", diff)
+	}
+	if info.Approximate {
+		t.Error("finalizeQueryWithInfo() approximate = true, expected false")
+	}
+}
+
+func TestFinalizeQueryExcludeDuplicates(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	config := schema.DefaultConfiguration()
+	config.Enabled = []schema.ColumnKey{schema.ColumnDuplicate}
+	sch, err := schema.New(config)
+	if err != nil {
+		t.Fatalf("schema.New() error:\n%+v", err)
+	}
+	c.d.Schema = sch
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)},
+		{"flows_1m0s", time.Minute, time.Date(2022, 3, 10, 15, 45, 10, 0, time.UTC)},
+	}
+	c.flowsTablesColumns = map[string][]string{
+		"flows_1m0s": {"SrcAS", "DstAS"},
+	}
+	query := "SELECT 1 FROM {{ .Table }} WHERE {{ .Timefilter }}"
+
+	cases := []struct {
+		Description string
+		Context     inputContext
+		Expected    string
+	}{
+		{
+			Description: "duplicates excluded",
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 200,
+			},
+			Expected: "SELECT 1 FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC') AND Duplicate = 0",
+		}, {
+			Description: "duplicate column used by the query",
+			Context: inputContext{
+				Start:   time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:     time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points:  200,
+				Columns: []string{"Duplicate"},
+			},
+			Expected: "SELECT 1 FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC')",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			got := c.finalizeQuery(
+				fmt.Sprintf(`{{ with %s }}%s{{ end }}`, templateContext(tc.Context), query))
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("finalizeQuery(): (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestComputeBestTableAndInterval(t *testing.T) {
+	cases := []struct {
+		Description   string
+		Tables        []flowsTable
+		TablesColumns map[string][]string
+		Context       inputContext
+		Expected      tableIntervalOutput
 	}{
 		{
 			Description: "simple query without additional tables",
@@ -361,6 +531,58 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 				Points: 720, // 2-minute resolution,
 			},
 			Expected: tableIntervalOutput{Table: "flows_1m0s", Interval: 60},
+		}, {
+			Description: "consolidated tables without a main-only column",
+			Tables: []flowsTable{
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+			},
+			Context: inputContext{
+				Start:   time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:     time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Columns: []string{"SrcAS", "DstPort"},
+				Points:  72, // 20-minute resolution,
+			},
+			Expected: tableIntervalOutput{Table: "flows", Interval: 1},
+		}, {
+			Description: "coarsest consolidated table with the required columns",
+			Tables: []flowsTable{
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+			},
+			TablesColumns: map[string][]string{
+				"flows":      {"TimeReceived", "SrcAS", "DstPort"},
+				"flows_1m0s": {"TimeReceived", "SrcAS", "DstPort"},
+				"flows_5m0s": {"TimeReceived", "SrcAS"},
+			},
+			Context: inputContext{
+				Start:   time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:     time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Columns: []string{"SrcAS", "DstPort"},
+				Points:  72, // 20-minute resolution,
+			},
+			Expected: tableIntervalOutput{Table: "flows_1m0s", Interval: 60},
+		}, {
+			Description: "coarsest consolidated table without specific columns",
+			Tables: []flowsTable{
+				{"flows", 0, time.Date(2022, 3, 10, 22, 45, 10, 0, time.UTC)},
+				{"flows_5m0s", 5 * time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+				{"flows_1m0s", time.Minute, time.Date(2022, 4, 2, 22, 45, 10, 0, time.UTC)},
+			},
+			TablesColumns: map[string][]string{
+				"flows":      {"TimeReceived", "SrcAS", "DstPort"},
+				"flows_1m0s": {"TimeReceived", "SrcAS", "DstPort"},
+				"flows_5m0s": {"TimeReceived", "SrcAS"},
+			},
+			Context: inputContext{
+				Start:   time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:     time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Columns: []string{"SrcAS"},
+				Points:  72, // 20-minute resolution,
+			},
+			Expected: tableIntervalOutput{Table: "flows_5m0s", Interval: 300},
 		},
 	}
 
@@ -368,6 +590,7 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.Description, func(t *testing.T) {
 			c.flowsTables = tc.Tables
+			c.flowsTablesColumns = tc.TablesColumns
 			table, interval, _ := c.computeTableAndInterval(
 				tc.Context)
 			got := tableIntervalOutput{
@@ -380,3 +603,75 @@ func TestComputeBestTableAndInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestFinalizeQueryStitched(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Date(2022, 4, 10, 0, 0, 0, 0, time.UTC)},
+		{"flows_1m0s", time.Minute, time.Date(2022, 4, 4, 0, 0, 0, 0, time.UTC)},
+		{"flows_1h0m0s", time.Hour, time.Date(2021, 4, 11, 0, 0, 0, 0, time.UTC)},
+	}
+	query := `{{ range .Parts }}SELECT {{ .Units }}, {{ .Interval }} FROM {{ .Table }} WHERE {{ .Timefilter }} // {{ .TimefilterEnd }}
+{{ end }}`
+
+	cases := []struct {
+		Description string
+		Context     inputContext
+		Expected    string
+		Ranges      []tableRange
+	}{
+		{
+			Description: "stitching not requested",
+			Context: inputContext{
+				Start:  time.Date(2022, 2, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 2000,
+				Units:  "pps",
+			},
+			Expected: "SELECT SUM(Packets*SamplingRate), 3600 FROM flows_1h0m0s WHERE TimeReceived BETWEEN toDateTime('2022-02-10 15:00:00', 'UTC') AND toDateTime('2022-04-11 15:00:00', 'UTC') // toDateTime('2022-04-11 15:00:00', 'UTC')\n",
+			Ranges: []tableRange{
+				{"flows_1h0m0s", time.Date(2022, 2, 10, 15, 0, 0, 0, time.UTC), time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC), 3600},
+			},
+		}, {
+			Description: "stitched tables",
+			Context: inputContext{
+				Start:  time.Date(2022, 2, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 2000,
+				Units:  "pps",
+				Stitch: true,
+			},
+			Expected: "SELECT SUM(Packets*SamplingRate), 3600 FROM (SELECT TimeReceived, Bytes, Packets, SamplingRate FROM flows_1h0m0s WHERE TimeReceived >= toDateTime('2022-02-10 15:00:00', 'UTC') AND TimeReceived < toDateTime('2022-04-04 01:00:00', 'UTC') UNION ALL SELECT TimeReceived, Bytes, Packets, SamplingRate FROM flows_1m0s WHERE TimeReceived BETWEEN toDateTime('2022-04-04 01:00:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC')) WHERE TimeReceived >= toDateTime('2022-02-10 15:00:00', 'UTC') AND TimeReceived < toDateTime('2022-04-04 01:00:00', 'UTC') // toDateTime('2022-04-04 00:59:59', 'UTC')\n" +
+				"SELECT SUM(Packets*SamplingRate), 2580 FROM (SELECT TimeReceived, Bytes, Packets, SamplingRate FROM flows_1h0m0s WHERE TimeReceived >= toDateTime('2022-02-10 15:00:00', 'UTC') AND TimeReceived < toDateTime('2022-04-04 01:00:00', 'UTC') UNION ALL SELECT TimeReceived, Bytes, Packets, SamplingRate FROM flows_1m0s WHERE TimeReceived BETWEEN toDateTime('2022-04-04 01:00:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC')) WHERE TimeReceived BETWEEN toDateTime('2022-04-04 01:00:00', 'UTC') AND toDateTime('2022-04-11 15:45:00', 'UTC') // toDateTime('2022-04-11 15:45:00', 'UTC')\n",
+			Ranges: []tableRange{
+				{"flows_1h0m0s", time.Date(2022, 2, 10, 15, 0, 0, 0, time.UTC), time.Date(2022, 4, 4, 1, 0, 0, 0, time.UTC), 3600},
+				{"flows_1m0s", time.Date(2022, 4, 4, 1, 0, 0, 0, time.UTC), time.Date(2022, 4, 11, 15, 45, 0, 0, time.UTC), 2580},
+			},
+		}, {
+			Description: "stitching not needed",
+			Context: inputContext{
+				Start:  time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				End:    time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				Points: 200,
+				Units:  "pps",
+				Stitch: true,
+			},
+			Expected: "SELECT SUM(Packets*SamplingRate), 420 FROM flows_1m0s WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:00', 'UTC') AND toDateTime('2022-04-11 15:40:00', 'UTC') // toDateTime('2022-04-11 15:40:00', 'UTC')\n",
+			Ranges: []tableRange{
+				{"flows_1m0s", time.Date(2022, 4, 10, 15, 45, 0, 0, time.UTC), time.Date(2022, 4, 11, 15, 40, 0, 0, time.UTC), 420},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			got, info := c.finalizeQueryWithInfo(
+				fmt.Sprintf(`{{ with %s }}%s{{ end }}`, templateContext(tc.Context), query))
+			if diff := helpers.Diff(got, tc.Expected); diff != "" {
+				t.Errorf("finalizeQueryWithInfo(): (-got, +want):\n%s", diff)
+			}
+			if diff := helpers.Diff(info.Ranges, tc.Ranges); diff != "" {
+				t.Errorf("finalizeQueryWithInfo() ranges (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
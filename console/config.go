@@ -29,6 +29,75 @@ type Configuration struct {
 	DimensionsLimit int `validate:"min=10"`
 	// CacheTTL tells how long to keep the most costly requests in cache.
 	CacheTTL time.Duration `validate:"min=5s"`
+	// SampleFactor is the sampling factor used for approximate queries: only
+	// one flow out of SampleFactor is read from tables with a sampling key.
+	SampleFactor uint `validate:"min=2"`
+	// SQL configures the ad-hoc SQL endpoint.
+	SQL SQLConfiguration
+	// Jobs configures asynchronous query jobs.
+	Jobs JobsConfiguration
+	// RecordingQueries defines named queries periodically evaluated and
+	// exposed as Prometheus metrics.
+	RecordingQueries map[string]RecordingQueryConfiguration `validate:"dive"`
+}
+
+// RecordingQueryConfiguration defines a query periodically evaluated to
+// export traffic figures as Prometheus metrics.
+type RecordingQueryConfiguration struct {
+	// Filter is the filter to apply to flows.
+	Filter query.Filter
+	// Dimensions is the list of dimensions. Their values are used as labels.
+	Dimensions []query.Column
+	// Units is the unit for the returned values.
+	Units string `validate:"oneof=pps l3bps l2bps"`
+	// Window is the period over which the traffic rate is averaged.
+	Window time.Duration `validate:"min=1m"`
+	// Interval is the time between two evaluations of the query.
+	Interval time.Duration `validate:"min=10s"`
+	// Limit is the maximum number of series to export.
+	Limit int `validate:"min=1,max=1000"`
+}
+
+// DefaultRecordingQueryConfiguration represents the default configuration
+// for a recording query.
+func DefaultRecordingQueryConfiguration() RecordingQueryConfiguration {
+	return RecordingQueryConfiguration{
+		Units:    "l3bps",
+		Window:   5 * time.Minute,
+		Interval: time.Minute,
+		Limit:    10,
+	}
+}
+
+// SQLConfiguration defines the configuration for the ad-hoc SQL endpoint.
+type SQLConfiguration struct {
+	// AllowedUsers is the list of users allowed to run ad-hoc SQL
+	// queries. When empty, the endpoint is disabled.
+	AllowedUsers []string
+	// Username is the ClickHouse user used to run ad-hoc SQL queries. It is
+	// mandatory when the endpoint is enabled. This user should only be
+	// allowed to read the flows tables and the dictionaries.
+	Username string
+	// Password is the password for the ClickHouse user.
+	Password string
+	// MaxRows is the maximum number of rows returned by a query.
+	MaxRows uint64 `validate:"min=1"`
+	// MaxExecutionTime is the maximum execution time for a query.
+	MaxExecutionTime time.Duration `validate:"min=1s"`
+	// MaxMemoryUsage is the maximum amount of memory (in bytes) a query
+	// can use.
+	MaxMemoryUsage uint64 `validate:"min=1048576"`
+}
+
+// JobsConfiguration defines the configuration for asynchronous query jobs.
+type JobsConfiguration struct {
+	// MaxPerUser is the maximum number of running jobs for a user.
+	MaxPerUser int `validate:"min=1"`
+	// ResultTTL tells how long to keep the result of a finished job.
+	ResultTTL time.Duration `validate:"min=10s"`
+	// PollTimeout is the time after which a running job not polled by the
+	// client is cancelled.
+	PollTimeout time.Duration `validate:"min=10s"`
 }
 
 // HomepageTopWidget represents a top widget on the homepage.
@@ -98,11 +167,27 @@ func DefaultConfiguration() Configuration {
 		},
 		DimensionsLimit:        50,
 		CacheTTL:               3 * time.Hour,
+		SampleFactor:           10,
 		HomepageGraphFilter:    "InIfBoundary = 'external'",
 		HomepageGraphTimeRange: 24 * time.Hour,
+		SQL: SQLConfiguration{
+			MaxRows:          10000,
+			MaxExecutionTime: 30 * time.Second,
+			MaxMemoryUsage:   1 << 30,
+		},
+		Jobs: JobsConfiguration{
+			MaxPerUser:  4,
+			ResultTTL:   10 * time.Minute,
+			PollTimeout: time.Minute,
+		},
 	}
 }
 
+func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.DefaultValuesUnmarshallerHook(DefaultRecordingQueryConfiguration()))
+}
+
 func (c *Component) configHandlerFunc(gc *gin.Context) {
 	dimensions := []string{}
 	truncatable := []string{}
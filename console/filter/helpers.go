@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/netip"
+	"slices"
 	"strings"
 
 	"akvorado/common/schema"
@@ -19,8 +20,8 @@ type Meta struct {
 	Schema *schema.Component
 	// ReverseDirection tells if we require the reverse direction for the provided filter (used as input)
 	ReverseDirection bool
-	// MainTableRequired tells if the main table is required to execute the expression (used as output)
-	MainTableRequired bool
+	// Columns is the list of columns used by the expression (used as output)
+	Columns []string
 }
 
 // flattenExpr takes an expression and flattens it to a slice of strings. It
@@ -52,8 +53,8 @@ func (c *current) flattenExpr(expr []any, meta *Meta) []string {
 	}
 	metaColumn := func(col schema.Column) schema.Column {
 		col = reverseColumn(col)
-		if col.ClickHouseMainOnly {
-			meta.MainTableRequired = true
+		if !slices.Contains(meta.Columns, col.Name) {
+			meta.Columns = append(meta.Columns, col.Name)
 		}
 		return col
 	}
@@ -152,18 +153,21 @@ func (c *current) parsePrefix(direction string) ([]any, error) {
 	// If the prefix was materialized, we can directly access it
 	col := c.getColumn(fmt.Sprintf("%sNetPrefix", direction))
 	if col.ClickHouseMaterialized {
+		meta := c.globalStore["meta"].(*Meta)
+		if !slices.Contains(meta.Columns, col.Name) {
+			meta.Columns = append(meta.Columns, col.Name)
+		}
 		return []any{
-			fmt.Sprintf("%sNetPrefix", direction), "=",
+			col.Name, "=",
 			fmt.Sprintf("'%s'", net.String())}, nil
 	}
 	// If the prefix is not materialized, we use the "between" operator
-	c.globalStore["meta"].(*Meta).MainTableRequired = true
 	prefix := "::ffff:"
 	if net.Addr().Is6() {
 		prefix = ""
 	}
 	return []any{
-		fmt.Sprintf("%sAddr", direction),
+		c.getColumn(fmt.Sprintf("%sAddr", direction)),
 		fmt.Sprintf("BETWEEN toIPv6('%s%s') AND toIPv6('%s%s') AND",
 			prefix, net.Masked().Addr().String(), prefix, lastIP(net).String()),
 		c.getColumn(fmt.Sprintf("%sNetMask", direction)), "=", net.Bits(),
@@ -17,249 +17,433 @@ func TestValidFilter(t *testing.T) {
 		MetaIn  Meta
 		MetaOut Meta
 	}{
-		{Input: `ExporterName = 'something'`, Output: `ExporterName = 'something'`},
 		{
 			Input: `ExporterName = 'something'`, Output: `ExporterName = 'something'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
 		},
-		{Input: `exportername = 'something'`, Output: `ExporterName = 'something'`},
-		{Input: `ExporterName='something'`, Output: `ExporterName = 'something'`},
-		{Input: `ExporterName="something"`, Output: `ExporterName = 'something'`},
-		{Input: `ExporterName="something'"`, Output: `ExporterName = 'something\''`},
-		{Input: `ExporterName="something\"`, Output: `ExporterName = 'something\\'`},
-		{Input: `ExporterName!="something"`, Output: `ExporterName != 'something'`},
-		{Input: `ExporterName IN ("something")`, Output: `ExporterName IN ('something')`},
-		{Input: `ExporterName IN ("something","something else")`, Output: `ExporterName IN ('something', 'something else')`},
-		{Input: `ExporterName LIKE "something%"`, Output: `ExporterName LIKE 'something%'`},
-		{Input: `ExporterName UNLIKE "something%"`, Output: `ExporterName NOT LIKE 'something%'`},
-		{Input: `ExporterName IUNLIKE "something%"`, Output: `ExporterName NOT ILIKE 'something%'`},
-		{Input: `ExporterName="something with spaces"`, Output: `ExporterName = 'something with spaces'`},
-		{Input: `ExporterName="something with 'quotes'"`, Output: `ExporterName = 'something with \'quotes\''`},
-		{Input: `ExporterAddress=203.0.113.1`, Output: `ExporterAddress = toIPv6('203.0.113.1')`},
-		{Input: `ExporterAddress=2001:db8::1`, Output: `ExporterAddress = toIPv6('2001:db8::1')`},
-		{Input: `ExporterAddress=2001:db8:0::1`, Output: `ExporterAddress = toIPv6('2001:db8::1')`},
 		{
-			Input:  `ExporterAddress << 2001:db8:0::/64`,
-			Output: `ExporterAddress BETWEEN toIPv6('2001:db8::') AND toIPv6('2001:db8::ffff:ffff:ffff:ffff')`,
+			Input: `ExporterName = 'something'`, Output: `ExporterName = 'something'`,
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `exportername = 'something'`, Output: `ExporterName = 'something'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
 		},
 		{
-			Input:  `ExporterAddress << 2001:db8::c000/115`,
-			Output: `ExporterAddress BETWEEN toIPv6('2001:db8::c000') AND toIPv6('2001:db8::dfff')`,
+			Input: `ExporterName='something'`, Output: `ExporterName = 'something'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
 		},
 		{
-			Input:  `ExporterAddress << 192.168.0.0/24`,
-			Output: `ExporterAddress BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
+			Input: `ExporterName="something"`, Output: `ExporterName = 'something'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName="something'"`, Output: `ExporterName = 'something\''`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName="something\"`, Output: `ExporterName = 'something\\'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName!="something"`, Output: `ExporterName != 'something'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName IN ("something")`, Output: `ExporterName IN ('something')`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName IN ("something","something else")`, Output: `ExporterName IN ('something', 'something else')`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName LIKE "something%"`, Output: `ExporterName LIKE 'something%'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName UNLIKE "something%"`, Output: `ExporterName NOT LIKE 'something%'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName IUNLIKE "something%"`, Output: `ExporterName NOT ILIKE 'something%'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName="something with spaces"`, Output: `ExporterName = 'something with spaces'`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterName="something with 'quotes'"`, Output: `ExporterName = 'something with \'quotes\''`,
+			MetaOut: Meta{Columns: []string{"ExporterName"}},
+		},
+		{
+			Input: `ExporterAddress=203.0.113.1`, Output: `ExporterAddress = toIPv6('203.0.113.1')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input: `ExporterAddress=2001:db8::1`, Output: `ExporterAddress = toIPv6('2001:db8::1')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input: `ExporterAddress=2001:db8:0::1`, Output: `ExporterAddress = toIPv6('2001:db8::1')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input:   `ExporterAddress << 2001:db8:0::/64`,
+			Output:  `ExporterAddress BETWEEN toIPv6('2001:db8::') AND toIPv6('2001:db8::ffff:ffff:ffff:ffff')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input:   `ExporterAddress << 2001:db8::c000/115`,
+			Output:  `ExporterAddress BETWEEN toIPv6('2001:db8::c000') AND toIPv6('2001:db8::dfff')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input:   `ExporterAddress << 192.168.0.0/24`,
+			Output:  `ExporterAddress BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
 		},
 		{
 			Input:   `DstAddr << 192.168.0.0/24`,
 			Output:  `DstAddr BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddr"}},
 		},
 		{
 			Input:   `DstAddr << 192.168.0.0/24`,
 			Output:  `SrcAddr BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
 			MetaIn:  Meta{ReverseDirection: true},
-			MetaOut: Meta{ReverseDirection: true, MainTableRequired: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"SrcAddr"}},
 		},
 		{
 			Input:   `SrcAddr << 192.168.0.1/24`,
 			Output:  `SrcAddr BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr"}},
 		},
 		{
 			Input:   `DstAddr !<< 192.168.0.0/24`,
 			Output:  `DstAddr NOT BETWEEN toIPv6('::ffff:192.168.0.0') AND toIPv6('::ffff:192.168.0.255')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddr"}},
 		},
 		{
 			Input:   `DstAddr !<< 192.168.0.128/27`,
 			Output:  `DstAddr NOT BETWEEN toIPv6('::ffff:192.168.0.128') AND toIPv6('::ffff:192.168.0.159')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddr"}},
 		},
 		{
 			Input:   `DstNetPrefix = 192.168.0.128/27`,
 			Output:  `DstAddr BETWEEN toIPv6('::ffff:192.168.0.128') AND toIPv6('::ffff:192.168.0.159') AND DstNetMask = 27`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddr", "DstNetMask"}},
 		},
 		{
 			Input:   `SrcNetPrefix = 192.168.0.128/27`,
 			Output:  `SrcAddr BETWEEN toIPv6('::ffff:192.168.0.128') AND toIPv6('::ffff:192.168.0.159') AND SrcNetMask = 27`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr", "SrcNetMask"}},
 		},
 		{
 			Input:   `SrcNetPrefix = 2001:db8::/48`,
 			Output:  `SrcAddr BETWEEN toIPv6('2001:db8::') AND toIPv6('2001:db8:0:ffff:ffff:ffff:ffff:ffff') AND SrcNetMask = 48`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr", "SrcNetMask"}},
+		},
+		{
+			Input: `ExporterGroup= "group"`, Output: `ExporterGroup = 'group'`,
+			MetaOut: Meta{Columns: []string{"ExporterGroup"}},
 		},
-		{Input: `ExporterGroup= "group"`, Output: `ExporterGroup = 'group'`},
 		{
 			Input: `SrcAddr=203.0.113.1`, Output: `SrcAddr = toIPv6('203.0.113.1')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr"}},
 		},
 		{
 			Input: `DstAddr=203.0.113.2`, Output: `DstAddr = toIPv6('203.0.113.2')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddr"}},
 		},
 		{
 			Input: `SrcAddr IN (203.0.113.1)`, Output: `SrcAddr IN (toIPv6('203.0.113.1'))`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr"}},
 		},
 		{
 			Input: `SrcAddr IN (203.0.113.1, 2001:db8::1)`, Output: `SrcAddr IN (toIPv6('203.0.113.1'), toIPv6('2001:db8::1'))`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddr"}},
+		},
+		{
+			Input: `SrcNetName="alpha"`, Output: `SrcNetName = 'alpha'`,
+			MetaOut: Meta{Columns: []string{"SrcNetName"}},
+		},
+		{
+			Input: `DstNetName="alpha"`, Output: `DstNetName = 'alpha'`,
+			MetaOut: Meta{Columns: []string{"DstNetName"}},
+		},
+		{
+			Input: `DstNetRole="stuff"`, Output: `DstNetRole = 'stuff'`,
+			MetaOut: Meta{Columns: []string{"DstNetRole"}},
+		},
+		{
+			Input: `SrcNetTenant="mobile"`, Output: `SrcNetTenant = 'mobile'`,
+			MetaOut: Meta{Columns: []string{"SrcNetTenant"}},
+		},
+		{
+			Input: `SrcAS=12322`, Output: `SrcAS = 12322`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `SrcAS=AS12322`, Output: `SrcAS = 12322`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
 		},
-		{Input: `SrcNetName="alpha"`, Output: `SrcNetName = 'alpha'`},
-		{Input: `DstNetName="alpha"`, Output: `DstNetName = 'alpha'`},
-		{Input: `DstNetRole="stuff"`, Output: `DstNetRole = 'stuff'`},
-		{Input: `SrcNetTenant="mobile"`, Output: `SrcNetTenant = 'mobile'`},
-		{Input: `SrcAS=12322`, Output: `SrcAS = 12322`},
-		{Input: `SrcAS=AS12322`, Output: `SrcAS = 12322`},
 		{
 			Input: `SrcAS=AS12322`, Output: `DstAS = 12322`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"DstAS"}},
+		},
+		{
+			Input: `SrcAS=as12322`, Output: `SrcAS = 12322`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `SrcAS IN(12322, 29447)`, Output: `SrcAS IN (12322, 29447)`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `SrcAS IN( 12322  , 29447  )`, Output: `SrcAS IN (12322, 29447)`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `SrcAS NOTIN(12322, 29447)`, Output: `SrcAS NOT IN (12322, 29447)`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `SrcAS NOTIN (AS12322, 29447)`, Output: `SrcAS NOT IN (12322, 29447)`,
+			MetaOut: Meta{Columns: []string{"SrcAS"}},
+		},
+		{
+			Input: `DstAS=12322`, Output: `DstAS = 12322`,
+			MetaOut: Meta{Columns: []string{"DstAS"}},
+		},
+		{
+			Input: `SrcCountry='FR'`, Output: `SrcCountry = 'FR'`,
+			MetaOut: Meta{Columns: []string{"SrcCountry"}},
 		},
-		{Input: `SrcAS=as12322`, Output: `SrcAS = 12322`},
-		{Input: `SrcAS IN(12322, 29447)`, Output: `SrcAS IN (12322, 29447)`},
-		{Input: `SrcAS IN( 12322  , 29447  )`, Output: `SrcAS IN (12322, 29447)`},
-		{Input: `SrcAS NOTIN(12322, 29447)`, Output: `SrcAS NOT IN (12322, 29447)`},
-		{Input: `SrcAS NOTIN (AS12322, 29447)`, Output: `SrcAS NOT IN (12322, 29447)`},
-		{Input: `DstAS=12322`, Output: `DstAS = 12322`},
-		{Input: `SrcCountry='FR'`, Output: `SrcCountry = 'FR'`},
 		{
 			Input: `SrcCountry='FR'`, Output: `DstCountry = 'FR'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"DstCountry"}},
+		},
+		{
+			Input: `DstCountry='FR'`, Output: `DstCountry = 'FR'`,
+			MetaOut: Meta{Columns: []string{"DstCountry"}},
 		},
-		{Input: `DstCountry='FR'`, Output: `DstCountry = 'FR'`},
 		{
 			Input: `DstCountry='FR'`, Output: `SrcCountry = 'FR'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"SrcCountry"}},
+		},
+		{
+			Input: `InIfName='Gi0/0/0/1'`, Output: `InIfName = 'Gi0/0/0/1'`,
+			MetaOut: Meta{Columns: []string{"InIfName"}},
 		},
-		{Input: `InIfName='Gi0/0/0/1'`, Output: `InIfName = 'Gi0/0/0/1'`},
 		{
 			Input: `InIfName='Gi0/0/0/1'`, Output: `OutIfName = 'Gi0/0/0/1'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfName"}},
+		},
+		{
+			Input: `OutIfName = 'Gi0/0/0/1'`, Output: `OutIfName = 'Gi0/0/0/1'`,
+			MetaOut: Meta{Columns: []string{"OutIfName"}},
 		},
-		{Input: `OutIfName = 'Gi0/0/0/1'`, Output: `OutIfName = 'Gi0/0/0/1'`},
 		{
 			Input: `OutIfName = 'Gi0/0/0/1'`, Output: `InIfName = 'Gi0/0/0/1'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"InIfName"}},
+		},
+		{
+			Input: `InIfDescription='Some description'`, Output: `InIfDescription = 'Some description'`,
+			MetaOut: Meta{Columns: []string{"InIfDescription"}},
 		},
-		{Input: `InIfDescription='Some description'`, Output: `InIfDescription = 'Some description'`},
 		{
 			Input: `InIfDescription='Some description'`, Output: `OutIfDescription = 'Some description'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfDescription"}},
+		},
+		{
+			Input: `OutIfDescription='Some other description'`, Output: `OutIfDescription = 'Some other description'`,
+			MetaOut: Meta{Columns: []string{"OutIfDescription"}},
 		},
-		{Input: `OutIfDescription='Some other description'`, Output: `OutIfDescription = 'Some other description'`},
 		{
 			Input: `OutIfDescription='Some other description'`, Output: `InIfDescription = 'Some other description'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"InIfDescription"}},
+		},
+		{
+			Input: `InIfSpeed>=1000`, Output: `InIfSpeed >= 1000`,
+			MetaOut: Meta{Columns: []string{"InIfSpeed"}},
 		},
-		{Input: `InIfSpeed>=1000`, Output: `InIfSpeed >= 1000`},
 		{
 			Input: `InIfSpeed>=1000`, Output: `OutIfSpeed >= 1000`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfSpeed"}},
+		},
+		{
+			Input: `InIfSpeed!=1000`, Output: `InIfSpeed != 1000`,
+			MetaOut: Meta{Columns: []string{"InIfSpeed"}},
+		},
+		{
+			Input: `InIfSpeed<1000`, Output: `InIfSpeed < 1000`,
+			MetaOut: Meta{Columns: []string{"InIfSpeed"}},
+		},
+		{
+			Input: `OutIfSpeed!=1000`, Output: `OutIfSpeed != 1000`,
+			MetaOut: Meta{Columns: []string{"OutIfSpeed"}},
 		},
-		{Input: `InIfSpeed!=1000`, Output: `InIfSpeed != 1000`},
-		{Input: `InIfSpeed<1000`, Output: `InIfSpeed < 1000`},
-		{Input: `OutIfSpeed!=1000`, Output: `OutIfSpeed != 1000`},
 		{
 			Input: `OutIfSpeed!=1000`, Output: `InIfSpeed != 1000`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"InIfSpeed"}},
+		},
+		{
+			Input: `InIfConnectivity = 'pni'`, Output: `InIfConnectivity = 'pni'`,
+			MetaOut: Meta{Columns: []string{"InIfConnectivity"}},
 		},
-		{Input: `InIfConnectivity = 'pni'`, Output: `InIfConnectivity = 'pni'`},
 		{
 			Input: `InIfConnectivity = 'pni'`, Output: `OutIfConnectivity = 'pni'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfConnectivity"}},
+		},
+		{
+			Input: `OutIfConnectivity = 'ix'`, Output: `OutIfConnectivity = 'ix'`,
+			MetaOut: Meta{Columns: []string{"OutIfConnectivity"}},
 		},
-		{Input: `OutIfConnectivity = 'ix'`, Output: `OutIfConnectivity = 'ix'`},
 		{
 			Input: `OutIfConnectivity = 'ix'`, Output: `InIfConnectivity = 'ix'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"InIfConnectivity"}},
+		},
+		{
+			Input: `InIfProvider = 'cogent'`, Output: `InIfProvider = 'cogent'`,
+			MetaOut: Meta{Columns: []string{"InIfProvider"}},
 		},
-		{Input: `InIfProvider = 'cogent'`, Output: `InIfProvider = 'cogent'`},
 		{
 			Input: `InIfProvider = 'cogent'`, Output: `OutIfProvider = 'cogent'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfProvider"}},
+		},
+		{
+			Input: `OutIfProvider = 'telia'`, Output: `OutIfProvider = 'telia'`,
+			MetaOut: Meta{Columns: []string{"OutIfProvider"}},
 		},
-		{Input: `OutIfProvider = 'telia'`, Output: `OutIfProvider = 'telia'`},
 		{
 			Input: `OutIfProvider = 'telia'`, Output: `InIfProvider = 'telia'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"InIfProvider"}},
+		},
+		{
+			Input: `InIfBoundary = external`, Output: `InIfBoundary = 'external'`,
+			MetaOut: Meta{Columns: []string{"InIfBoundary"}},
 		},
-		{Input: `InIfBoundary = external`, Output: `InIfBoundary = 'external'`},
 		{
 			Input: `InIfBoundary = external`, Output: `OutIfBoundary = 'external'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfBoundary"}},
+		},
+		{
+			Input: `InIfBoundary = EXTERNAL`, Output: `InIfBoundary = 'external'`,
+			MetaOut: Meta{Columns: []string{"InIfBoundary"}},
 		},
-		{Input: `InIfBoundary = EXTERNAL`, Output: `InIfBoundary = 'external'`},
 		{
 			Input: `InIfBoundary = EXTERNAL`, Output: `OutIfBoundary = 'external'`,
-			MetaIn: Meta{ReverseDirection: true}, MetaOut: Meta{ReverseDirection: true},
+			MetaIn:  Meta{ReverseDirection: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"OutIfBoundary"}},
+		},
+		{
+			Input: `OutIfBoundary != internal`, Output: `OutIfBoundary != 'internal'`,
+			MetaOut: Meta{Columns: []string{"OutIfBoundary"}},
+		},
+		{
+			Input: `EType = ipv4`, Output: `EType = 2048`,
+			MetaOut: Meta{Columns: []string{"EType"}},
+		},
+		{
+			Input: `EType != ipv6`, Output: `EType != 34525`,
+			MetaOut: Meta{Columns: []string{"EType"}},
+		},
+		{
+			Input: `Proto = 1`, Output: `Proto = 1`,
+			MetaOut: Meta{Columns: []string{"Proto"}},
+		},
+		{
+			Input: `Proto = 'gre'`, Output: `dictGetOrDefault('protocols', 'name', Proto, '???') = 'gre'`,
+			MetaOut: Meta{Columns: []string{"Proto"}},
 		},
-		{Input: `OutIfBoundary != internal`, Output: `OutIfBoundary != 'internal'`},
-		{Input: `EType = ipv4`, Output: `EType = 2048`},
-		{Input: `EType != ipv6`, Output: `EType != 34525`},
-		{Input: `Proto = 1`, Output: `Proto = 1`},
-		{Input: `Proto = 'gre'`, Output: `dictGetOrDefault('protocols', 'name', Proto, '???') = 'gre'`},
 		{
 			Input: `SrcPort = 80`, Output: `SrcPort = 80`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcPort"}},
 		},
 		{
 			Input: `SrcPort = 80`, Output: `DstPort = 80`,
 			MetaIn:  Meta{ReverseDirection: true},
-			MetaOut: Meta{ReverseDirection: true, MainTableRequired: true},
+			MetaOut: Meta{ReverseDirection: true, Columns: []string{"DstPort"}},
 		},
 		{
 			Input: `DstPort > 1024`, Output: `DstPort > 1024`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort"}},
+		},
+		{
+			Input: `ForwardingStatus >= 128`, Output: `ForwardingStatus >= 128`,
+			MetaOut: Meta{Columns: []string{"ForwardingStatus"}},
+		},
+		{
+			Input: `PacketSize > 1500`, Output: `PacketSize > 1500`,
+			MetaOut: Meta{Columns: []string{"PacketSize"}},
 		},
-		{Input: `ForwardingStatus >= 128`, Output: `ForwardingStatus >= 128`},
-		{Input: `PacketSize > 1500`, Output: `PacketSize > 1500`},
 		{
 			Input: `DstPort > 1024 AND SrcPort < 1024`, Output: `DstPort > 1024 AND SrcPort < 1024`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort"}},
 		},
 		{
 			Input: `DstPort > 1024 OR SrcPort < 1024`, Output: `DstPort > 1024 OR SrcPort < 1024`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort"}},
 		},
 		{
 			Input: `NOT DstPort > 1024 AND SrcPort < 1024`, Output: `NOT DstPort > 1024 AND SrcPort < 1024`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort"}},
 		},
 		{
 			Input: `not DstPort > 1024 and SrcPort < 1024`, Output: `NOT DstPort > 1024 AND SrcPort < 1024`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort"}},
 		},
 		{
 			Input:   `DstPort > 1024 AND SrcPort < 1024 OR InIfSpeed >= 1000`,
 			Output:  `DstPort > 1024 AND SrcPort < 1024 OR InIfSpeed >= 1000`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort", "InIfSpeed"}},
 		},
 		{
 			Input:   `DstPort > 1024 AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
 			Output:  `DstPort > 1024 AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort", "InIfSpeed"}},
 		},
 		{
 			Input:   `  DstPort >   1024   AND   (  SrcPort   <   1024   OR   InIfSpeed   >=   1000   )  `,
 			Output:  `DstPort > 1024 AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort", "InIfSpeed"}},
 		},
 		{
 			Input:   `DstPort > 1024 AND(SrcPort < 1024 OR InIfSpeed >= 1000)`,
 			Output:  `DstPort > 1024 AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort", "InIfSpeed"}},
 		},
 		{
 			Input: `DstPort > 1024
                   AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
 			Output:  `DstPort > 1024 AND (SrcPort < 1024 OR InIfSpeed >= 1000)`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcPort", "InIfSpeed"}},
+		},
+		{
+			Input: `(ExporterAddress=203.0.113.1)`, Output: `(ExporterAddress = toIPv6('203.0.113.1'))`,
+			MetaOut: Meta{Columns: []string{"ExporterAddress"}},
+		},
+		{
+			Input: `ForwardingStatus >= 128 -- Nothing`, Output: `ForwardingStatus >= 128`,
+			MetaOut: Meta{Columns: []string{"ForwardingStatus"}},
 		},
-		{Input: `(ExporterAddress=203.0.113.1)`, Output: `(ExporterAddress = toIPv6('203.0.113.1'))`},
-		{Input: `ForwardingStatus >= 128 -- Nothing`, Output: `ForwardingStatus >= 128`},
 		{
 			Input: `
 -- Example of commented request
@@ -267,70 +451,172 @@ func TestValidFilter(t *testing.T) {
 DstPort > 1024 -- Non-privileged port
 AND SrcAS = AS12322 -- Proxad ASN`,
 			Output:  `DstPort > 1024 AND SrcAS = 12322`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstPort", "SrcAS"}},
+		},
+		{
+			Input:   `InIfDescription = "This contains a -- comment" -- nope`,
+			Output:  `InIfDescription = 'This contains a -- comment'`,
+			MetaOut: Meta{Columns: []string{"InIfDescription"}},
 		},
 		{
-			Input:  `InIfDescription = "This contains a -- comment" -- nope`,
-			Output: `InIfDescription = 'This contains a -- comment'`,
+			Input:   `InIfDescription = "This contains a /* comment"`,
+			Output:  `InIfDescription = 'This contains a /* comment'`,
+			MetaOut: Meta{Columns: []string{"InIfDescription"}},
 		},
 		{
-			Input:  `InIfDescription = "This contains a /* comment"`,
-			Output: `InIfDescription = 'This contains a /* comment'`,
+			Input: `OutIfProvider /* That's the output provider */ = 'telia'`, Output: `OutIfProvider = 'telia'`,
+			MetaOut: Meta{Columns: []string{"OutIfProvider"}},
 		},
-		{Input: `OutIfProvider /* That's the output provider */ = 'telia'`, Output: `OutIfProvider = 'telia'`},
 		{
 			Input: `OutIfProvider /* That's the
 output provider */ = 'telia'`,
-			Output: `OutIfProvider = 'telia'`,
+			Output:  `OutIfProvider = 'telia'`,
+			MetaOut: Meta{Columns: []string{"OutIfProvider"}},
+		},
+		{
+			Input: `DstASPath = 65000`, Output: `has(DstASPath, 65000)`,
+			MetaOut: Meta{Columns: []string{"DstASPath"}},
+		},
+		{
+			Input: `DstASPath != 65000`, Output: `NOT has(DstASPath, 65000)`,
+			MetaOut: Meta{Columns: []string{"DstASPath"}},
+		},
+		{
+			Input: `DstCommunities = 65000:100`, Output: `has(DstCommunities, 4259840100)`,
+			MetaOut: Meta{Columns: []string{"DstCommunities"}},
+		},
+		{
+			Input: `DstCommunities != 65000:100`, Output: `NOT has(DstCommunities, 4259840100)`,
+			MetaOut: Meta{Columns: []string{"DstCommunities"}},
+		},
+		{
+			Input: `DstCommunities = 65000:100:200`, Output: `has(DstLargeCommunities, bitShiftLeft(65000::UInt128, 64) + bitShiftLeft(100::UInt128, 32) + 200::UInt128)`,
+			MetaOut: Meta{Columns: []string{"DstLargeCommunities"}},
+		},
+		{
+			Input: `DstCommunities != 65000:100:200`, Output: `NOT has(DstLargeCommunities, bitShiftLeft(65000::UInt128, 64) + bitShiftLeft(100::UInt128, 32) + 200::UInt128)`,
+			MetaOut: Meta{Columns: []string{"DstLargeCommunities"}},
+		},
+		{
+			Input: `SrcVlan = 1000`, Output: `SrcVlan = 1000`,
+			MetaOut: Meta{Columns: []string{"SrcVlan"}},
+		},
+		{
+			Input: `DstVlan = 1000`, Output: `DstVlan = 1000`,
+			MetaOut: Meta{Columns: []string{"DstVlan"}},
 		},
-		{Input: `DstASPath = 65000`, Output: `has(DstASPath, 65000)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `DstASPath != 65000`, Output: `NOT has(DstASPath, 65000)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `DstCommunities = 65000:100`, Output: `has(DstCommunities, 4259840100)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `DstCommunities != 65000:100`, Output: `NOT has(DstCommunities, 4259840100)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `DstCommunities = 65000:100:200`, Output: `has(DstLargeCommunities, bitShiftLeft(65000::UInt128, 64) + bitShiftLeft(100::UInt128, 32) + 200::UInt128)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `DstCommunities != 65000:100:200`, Output: `NOT has(DstLargeCommunities, bitShiftLeft(65000::UInt128, 64) + bitShiftLeft(100::UInt128, 32) + 200::UInt128)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `SrcVlan = 1000`, Output: `SrcVlan = 1000`},
-		{Input: `DstVlan = 1000`, Output: `DstVlan = 1000`},
 		{
 			Input: `SrcAddrNAT = 203.0.113.4`, Output: `SrcAddrNAT = toIPv6('203.0.113.4')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcAddrNAT"}},
 		},
 		{
 			Input: `DstAddrNAT = 203.0.113.4`, Output: `DstAddrNAT = toIPv6('203.0.113.4')`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"DstAddrNAT"}},
 		},
 		{
 			Input: `SrcPortNAT = 22`, Output: `SrcPortNAT = 22`,
-			MetaOut: Meta{MainTableRequired: true},
+			MetaOut: Meta{Columns: []string{"SrcPortNAT"}},
 		},
 		{
 			Input: `DstPortNAT = 22`, Output: `DstPortNAT = 22`,
-			MetaOut: Meta{MainTableRequired: true},
-		},
-		{Input: `SrcMAC = 00:11:22:33:44:55`, Output: `SrcMAC = MACStringToNum('00:11:22:33:44:55')`},
-		{Input: `DstMAC = 00:11:22:33:44:55`, Output: `DstMAC = MACStringToNum('00:11:22:33:44:55')`},
-		{Input: `SrcMAC != 00:0c:fF:33:44:55`, Output: `SrcMAC != MACStringToNum('00:0c:ff:33:44:55')`},
-		{Input: `SrcMAC = 0000.5e00.5301`, Output: `SrcMAC = MACStringToNum('00:00:5e:00:53:01')`},
-		{Input: `ipttl > 50`, Output: `IPTTL > 50`},
-		{Input: `iptos = 0`, Output: `IPTos = 0`},
-		{Input: `ipfragmentid != 0`, Output: `IPFragmentID != 0`},
-		{Input: `ipfragmentoffset = 3`, Output: `IPFragmentOffset = 3`},
-		{Input: `ipv6flowlabel = 0`, Output: `IPv6FlowLabel = 0`},
-		{Input: `tcpflags = 2`, Output: `TCPFlags = 2`},
-		{Input: `icmpv4type = 8 AND icmpv4code = 0`, Output: `ICMPv4Type = 8 AND ICMPv4Code = 0`},
-		{Input: `icmpv6type = 8 or icmpv6code = 0`, Output: `ICMPv6Type = 8 OR ICMPv6Code = 0`},
-		{Input: `icmpv6 = "echo-reply"`, Output: `ICMPv6 = 'echo-reply'`},
-		{Input: `SrcAddrDimensionAttribute = "Test"`, Output: `SrcAddrDimensionAttribute = 'Test'`},
-		{Input: `DstAddrDimensionAttribute = "Test"`, Output: `DstAddrDimensionAttribute = 'Test'`},
-		{Input: `DstAddrRole = "Test"`, Output: `DstAddrRole = 'Test'`},
-		{Input: `DstAddrPriority = 200`, Output: `DstAddrPriority = 200`},
-		{Input: `DstAddrSibling = 2001:db8::1`, Output: `DstAddrSibling = toIPv6('2001:db8::1')`},
-		{Input: `SrcAddrDimensionAttribute IN ("Test", "None")`, Output: `SrcAddrDimensionAttribute IN ('Test', 'None')`},
-		{Input: `MPLSLabels = 76876`, Output: `has(MPLSLabels, 76876)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `MPLSLabels != 76876`, Output: `NOT has(MPLSLabels, 76876)`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `MPLS1stLabel = 76876`, Output: `MPLS1stLabel = 76876`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `MPLS2ndLabel > 76876`, Output: `MPLS2ndLabel > 76876`, MetaOut: Meta{MainTableRequired: true}},
-		{Input: `MPLS3rdLabel < 76876`, Output: `MPLS3rdLabel < 76876`, MetaOut: Meta{MainTableRequired: true}},
+			MetaOut: Meta{Columns: []string{"DstPortNAT"}},
+		},
+		{
+			Input: `SrcMAC = 00:11:22:33:44:55`, Output: `SrcMAC = MACStringToNum('00:11:22:33:44:55')`,
+			MetaOut: Meta{Columns: []string{"SrcMAC"}},
+		},
+		{
+			Input: `DstMAC = 00:11:22:33:44:55`, Output: `DstMAC = MACStringToNum('00:11:22:33:44:55')`,
+			MetaOut: Meta{Columns: []string{"DstMAC"}},
+		},
+		{
+			Input: `SrcMAC != 00:0c:fF:33:44:55`, Output: `SrcMAC != MACStringToNum('00:0c:ff:33:44:55')`,
+			MetaOut: Meta{Columns: []string{"SrcMAC"}},
+		},
+		{
+			Input: `SrcMAC = 0000.5e00.5301`, Output: `SrcMAC = MACStringToNum('00:00:5e:00:53:01')`,
+			MetaOut: Meta{Columns: []string{"SrcMAC"}},
+		},
+		{
+			Input: `ipttl > 50`, Output: `IPTTL > 50`,
+			MetaOut: Meta{Columns: []string{"IPTTL"}},
+		},
+		{
+			Input: `iptos = 0`, Output: `IPTos = 0`,
+			MetaOut: Meta{Columns: []string{"IPTos"}},
+		},
+		{
+			Input: `ipfragmentid != 0`, Output: `IPFragmentID != 0`,
+			MetaOut: Meta{Columns: []string{"IPFragmentID"}},
+		},
+		{
+			Input: `ipfragmentoffset = 3`, Output: `IPFragmentOffset = 3`,
+			MetaOut: Meta{Columns: []string{"IPFragmentOffset"}},
+		},
+		{
+			Input: `ipv6flowlabel = 0`, Output: `IPv6FlowLabel = 0`,
+			MetaOut: Meta{Columns: []string{"IPv6FlowLabel"}},
+		},
+		{
+			Input: `tcpflags = 2`, Output: `TCPFlags = 2`,
+			MetaOut: Meta{Columns: []string{"TCPFlags"}},
+		},
+		{
+			Input: `icmpv4type = 8 AND icmpv4code = 0`, Output: `ICMPv4Type = 8 AND ICMPv4Code = 0`,
+			MetaOut: Meta{Columns: []string{"ICMPv4Type", "ICMPv4Code"}},
+		},
+		{
+			Input: `icmpv6type = 8 or icmpv6code = 0`, Output: `ICMPv6Type = 8 OR ICMPv6Code = 0`,
+			MetaOut: Meta{Columns: []string{"ICMPv6Type", "ICMPv6Code"}},
+		},
+		{
+			Input: `icmpv6 = "echo-reply"`, Output: `ICMPv6 = 'echo-reply'`,
+			MetaOut: Meta{Columns: []string{"ICMPv6"}},
+		},
+		{
+			Input: `SrcAddrDimensionAttribute = "Test"`, Output: `SrcAddrDimensionAttribute = 'Test'`,
+			MetaOut: Meta{Columns: []string{"SrcAddrDimensionAttribute"}},
+		},
+		{
+			Input: `DstAddrDimensionAttribute = "Test"`, Output: `DstAddrDimensionAttribute = 'Test'`,
+			MetaOut: Meta{Columns: []string{"DstAddrDimensionAttribute"}},
+		},
+		{
+			Input: `DstAddrRole = "Test"`, Output: `DstAddrRole = 'Test'`,
+			MetaOut: Meta{Columns: []string{"DstAddrRole"}},
+		},
+		{
+			Input: `DstAddrPriority = 200`, Output: `DstAddrPriority = 200`,
+			MetaOut: Meta{Columns: []string{"DstAddrPriority"}},
+		},
+		{
+			Input: `DstAddrSibling = 2001:db8::1`, Output: `DstAddrSibling = toIPv6('2001:db8::1')`,
+			MetaOut: Meta{Columns: []string{"DstAddrSibling"}},
+		},
+		{
+			Input: `SrcAddrDimensionAttribute IN ("Test", "None")`, Output: `SrcAddrDimensionAttribute IN ('Test', 'None')`,
+			MetaOut: Meta{Columns: []string{"SrcAddrDimensionAttribute"}},
+		},
+		{
+			Input: `MPLSLabels = 76876`, Output: `has(MPLSLabels, 76876)`,
+			MetaOut: Meta{Columns: []string{"MPLSLabels"}},
+		},
+		{
+			Input: `MPLSLabels != 76876`, Output: `NOT has(MPLSLabels, 76876)`,
+			MetaOut: Meta{Columns: []string{"MPLSLabels"}},
+		},
+		{
+			Input: `MPLS1stLabel = 76876`, Output: `MPLS1stLabel = 76876`,
+			MetaOut: Meta{Columns: []string{"MPLS1stLabel"}},
+		},
+		{
+			Input: `MPLS2ndLabel > 76876`, Output: `MPLS2ndLabel > 76876`,
+			MetaOut: Meta{Columns: []string{"MPLS2ndLabel"}},
+		},
+		{
+			Input: `MPLS3rdLabel < 76876`, Output: `MPLS3rdLabel < 76876`,
+			MetaOut: Meta{Columns: []string{"MPLS3rdLabel"}},
+		},
 	}
 	config := schema.DefaultConfiguration()
 	config.CustomDictionaries = make(map[string]schema.CustomDict)
@@ -376,17 +662,17 @@ func TestValidMaterializedFilter(t *testing.T) {
 		{
 			Input:   `DstNetPrefix = 192.168.0.128/27`,
 			Output:  `DstNetPrefix = '192.168.0.128/27'`,
-			MetaOut: Meta{MainTableRequired: false},
+			MetaOut: Meta{Columns: []string{"DstNetPrefix"}},
 		},
 		{
 			Input:   `SrcNetPrefix = 192.168.0.128/27`,
 			Output:  `SrcNetPrefix = '192.168.0.128/27'`,
-			MetaOut: Meta{MainTableRequired: false},
+			MetaOut: Meta{Columns: []string{"SrcNetPrefix"}},
 		},
 		{
 			Input:   `SrcNetPrefix = 2001:db8::/48`,
 			Output:  `SrcNetPrefix = '2001:db8::/48'`,
-			MetaOut: Meta{MainTableRequired: false},
+			MetaOut: Meta{Columns: []string{"SrcNetPrefix"}},
 		},
 	}
 	for _, tc := range cases {
@@ -6,6 +6,7 @@ import HomePage from "@/views/HomePage.vue";
 import VisualizePage from "@/views/VisualizePage.vue";
 import DocumentationPage from "@/views/DocumentationPage.vue";
 import ErrorPage from "@/views/ErrorPage.vue";
+import LoginPage from "@/views/LoginPage.vue";
 
 declare module "vue-router" {
   interface RouteMeta {
@@ -57,9 +58,8 @@ const router = createRouter({
     {
       path: "/login",
       name: "401",
-      component: ErrorPage,
+      component: LoginPage,
       meta: { title: "Not authorized", notAuthenticated: true },
-      props: { error: "Not authorized!" },
     },
   ],
 });
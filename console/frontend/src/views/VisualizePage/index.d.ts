@@ -37,6 +37,12 @@ export type GraphLineHandlerOutput = {
   min: number[];
   max: number[];
   "95th": number[];
+  tables?: {
+    table: string;
+    start: string;
+    end: string;
+    interval: number;
+  }[];
 };
 export type GraphSankeyHandlerResult = GraphSankeyHandlerOutput & {
   graphType: Extract<GraphType, "sankey">;
@@ -25,6 +25,22 @@ type graphCommonHandlerInput struct {
 	TruncateAddrV4 int            `json:"truncate-v4" binding:"min=0,max=32"`  // 0 or 32 = no truncation
 	TruncateAddrV6 int            `json:"truncate-v6" binding:"min=0,max=128"` // 0 or 128 = no truncation
 	Units          string         `json:"units" binding:"required,oneof=pps l3bps l2bps inl2% outl2%"`
+	Approximate    bool           `json:"approximate"` // sample flows when possible
+}
+
+// validateGraphCommonInput validates the common part of the input for a
+// graph.
+func (c *Component) validateGraphCommonInput(input *graphCommonHandlerInput) error {
+	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+		return err
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		return err
+	}
+	if input.Limit > c.config.DimensionsLimit {
+		return fmt.Errorf("limit is set beyond maximum value (%d)", c.config.DimensionsLimit)
+	}
+	return nil
 }
 
 // sourceSelect builds a SELECT query to use as a source for data. Notably, it
@@ -0,0 +1,325 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	stdcontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+)
+
+// jobStatus is the status of an asynchronous query job.
+type jobStatus string
+
+const (
+	jobRunning   jobStatus = "running"
+	jobDone      jobStatus = "done"
+	jobFailed    jobStatus = "failed"
+	jobCancelled jobStatus = "cancelled"
+)
+
+// queryJob is an asynchronous query executed in the background.
+type queryJob struct {
+	id      string
+	user    string
+	queryID string
+	db      *clickhousedb.Component
+	cancel  stdcontext.CancelFunc
+
+	lock       sync.Mutex
+	status     jobStatus
+	started    time.Time
+	finished   time.Time
+	lastPolled time.Time
+	rowsRead   uint64
+	bytesRead  uint64
+	totalRows  uint64
+	result     interface{}
+}
+
+// jobHandlerOutput describes the output for the /jobs/:id endpoint.
+type jobHandlerOutput struct {
+	ID        string    `json:"id"`
+	Status    jobStatus `json:"status"`
+	RowsRead  uint64    `json:"rows-read"`
+	BytesRead uint64    `json:"bytes-read"`
+	TotalRows uint64    `json:"total-rows"`
+	Elapsed   float64   `json:"elapsed"`
+}
+
+var errTooManyJobs = errors.New("too many running jobs")
+
+// startJob starts a new asynchronous job for the provided user. The provided
+// function is executed with a context tracking progress and a query ID to be
+// used to cancel the query. The query should be executed on the provided
+// connection as it is also used to kill it.
+func (c *Component) startJob(user string, db *clickhousedb.Component, run func(ctx stdcontext.Context) (interface{}, error)) (*queryJob, error) {
+	now := c.d.Clock.Now()
+	rnd := make([]byte, 16)
+	if _, err := rand.Read(rnd); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(rnd)
+	ctx, cancel := stdcontext.WithCancel(c.t.Context(nil))
+	job := &queryJob{
+		id:         id,
+		user:       user,
+		queryID:    fmt.Sprintf("akvorado-job-%s", id),
+		db:         db,
+		cancel:     cancel,
+		status:     jobRunning,
+		started:    now,
+		lastPolled: now,
+	}
+
+	c.jobsLock.Lock()
+	running := 0
+	for _, other := range c.jobs {
+		other.lock.Lock()
+		if other.user == user && other.status == jobRunning {
+			running++
+		}
+		other.lock.Unlock()
+	}
+	if running >= c.config.Jobs.MaxPerUser {
+		c.jobsLock.Unlock()
+		cancel()
+		return nil, errTooManyJobs
+	}
+	c.jobs[id] = job
+	c.jobsLock.Unlock()
+
+	ctx = clickhouse.Context(ctx,
+		clickhouse.WithQueryID(job.queryID),
+		clickhouse.WithProgress(func(p *clickhouse.Progress) {
+			job.lock.Lock()
+			job.rowsRead += p.Rows
+			job.bytesRead += p.Bytes
+			job.totalRows += p.TotalRows
+			job.lock.Unlock()
+		}))
+	c.t.Go(func() error {
+		defer cancel()
+		result, err := run(ctx)
+		job.lock.Lock()
+		defer job.lock.Unlock()
+		job.finished = c.d.Clock.Now()
+		switch {
+		case job.status == jobCancelled:
+		case err != nil:
+			c.r.Err(err).Str("job", id).Msg("unable to execute job")
+			job.status = jobFailed
+		default:
+			job.status = jobDone
+			job.result = result
+		}
+		return nil
+	})
+	return job, nil
+}
+
+// cancelJob cancels a running job and kills the associated query.
+func (c *Component) cancelJob(job *queryJob) {
+	job.lock.Lock()
+	if job.status != jobRunning {
+		job.lock.Unlock()
+		return
+	}
+	job.status = jobCancelled
+	job.finished = c.d.Clock.Now()
+	job.lock.Unlock()
+	job.cancel()
+
+	ctx := c.t.Context(nil)
+	if err := job.db.Exec(ctx, "KILL QUERY WHERE query_id = $1 ASYNC", job.queryID); err != nil {
+		c.r.Err(err).Str("job", job.id).Msg("unable to kill query")
+	}
+}
+
+// expireJobs cancels abandoned jobs and removes expired results.
+func (c *Component) expireJobs() {
+	now := c.d.Clock.Now()
+	abandoned := []*queryJob{}
+	c.jobsLock.Lock()
+	for id, job := range c.jobs {
+		job.lock.Lock()
+		switch {
+		case job.status == jobRunning && now.Sub(job.lastPolled) > c.config.Jobs.PollTimeout:
+			abandoned = append(abandoned, job)
+		case job.status != jobRunning && now.Sub(job.finished) > c.config.Jobs.ResultTTL:
+			delete(c.jobs, id)
+		}
+		job.lock.Unlock()
+	}
+	c.jobsLock.Unlock()
+	for _, job := range abandoned {
+		c.r.Info().Str("job", job.id).Msg("cancel abandoned job")
+		c.cancelJob(job)
+	}
+}
+
+// runJobsExpiration periodically expires jobs.
+func (c *Component) runJobsExpiration() error {
+	ticker := c.d.Clock.Ticker(10 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.expireJobs()
+		case <-c.t.Dying():
+			return nil
+		}
+	}
+}
+
+// lookupJob returns the job matching the ID in the request, if it belongs to
+// the current user.
+func (c *Component) lookupJob(gc *gin.Context) *queryJob {
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	c.jobsLock.Lock()
+	job, ok := c.jobs[gc.Param("id")]
+	c.jobsLock.Unlock()
+	if !ok || job.user != user {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Job not found."})
+		return nil
+	}
+	return job
+}
+
+// submitJob starts a job executing a query on the provided connection and
+// returns its ID to the client.
+func (c *Component) submitJob(gc *gin.Context, db *clickhousedb.Component, run func(ctx stdcontext.Context) (interface{}, error)) {
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	job, err := c.startJob(user, db, run)
+	if errors.Is(err, errTooManyJobs) {
+		gc.JSON(http.StatusTooManyRequests, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("unable to start job")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to start job."})
+		return
+	}
+	gc.JSON(http.StatusAccepted, gin.H{"id": job.id})
+}
+
+func (c *Component) jobGraphLineHandlerFunc(gc *gin.Context) {
+	input := graphLineHandlerInput{graphCommonHandlerInput: graphCommonHandlerInput{schema: c.d.Schema}}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := c.validateGraphCommonInput(&input.graphCommonHandlerInput); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	sqlQuery, info := c.finalizeQueryWithInfo(input.toSQL())
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	c.submitJob(gc, c.d.ClickHouseDB, func(ctx stdcontext.Context) (interface{}, error) {
+		return c.graphLineExecute(ctx, input, sqlQuery, info)
+	})
+}
+
+func (c *Component) jobGraphSankeyHandlerFunc(gc *gin.Context) {
+	input := graphSankeyHandlerInput{graphCommonHandlerInput: graphCommonHandlerInput{schema: c.d.Schema}}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := c.validateGraphCommonInput(&input.graphCommonHandlerInput); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	sqlQuery, err := input.toSQL()
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	sqlQuery, info := c.finalizeQueryWithInfo(sqlQuery)
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	c.submitJob(gc, c.d.ClickHouseDB, func(ctx stdcontext.Context) (interface{}, error) {
+		return c.graphSankeyExecute(ctx, input, sqlQuery, info)
+	})
+}
+
+func (c *Component) jobSQLHandlerFunc(gc *gin.Context) {
+	input, query, ok := c.sqlPrepare(gc)
+	if !ok {
+		return
+	}
+	c.submitJob(gc, c.d.SQLClickHouseDB, func(ctx stdcontext.Context) (interface{}, error) {
+		output, err := c.sqlExecute(ctx, query)
+		if err != nil || input.Format != "csv" {
+			return output, err
+		}
+		return sqlCSVOutput(output), nil
+	})
+}
+
+func (c *Component) jobStatusHandlerFunc(gc *gin.Context) {
+	job := c.lookupJob(gc)
+	if job == nil {
+		return
+	}
+	now := c.d.Clock.Now()
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	job.lastPolled = now
+	end := now
+	if job.status != jobRunning {
+		end = job.finished
+	}
+	gc.JSON(http.StatusOK, jobHandlerOutput{
+		ID:        job.id,
+		Status:    job.status,
+		RowsRead:  job.rowsRead,
+		BytesRead: job.bytesRead,
+		TotalRows: job.totalRows,
+		Elapsed:   end.Sub(job.started).Seconds(),
+	})
+}
+
+func (c *Component) jobResultHandlerFunc(gc *gin.Context) {
+	job := c.lookupJob(gc)
+	if job == nil {
+		return
+	}
+	job.lock.Lock()
+	defer job.lock.Unlock()
+	job.lastPolled = c.d.Clock.Now()
+	switch job.status {
+	case jobDone:
+		if renderer, ok := job.result.(render.Render); ok {
+			gc.Render(http.StatusOK, renderer)
+			return
+		}
+		gc.JSON(http.StatusOK, job.result)
+	case jobFailed:
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+	default:
+		gc.JSON(http.StatusConflict, gin.H{"message": fmt.Sprintf("Job is %s.", job.status)})
+	}
+}
+
+func (c *Component) jobCancelHandlerFunc(gc *gin.Context) {
+	job := c.lookupJob(gc)
+	if job == nil {
+		return
+	}
+	c.cancelJob(job)
+	gc.Status(http.StatusNoContent)
+}
@@ -0,0 +1,332 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"bytes"
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+)
+
+func submitTestJob(t *testing.T, h *httpserver.Component, url string, input gin.H, expectedStatus int) string {
+	t.Helper()
+	payload := new(bytes.Buffer)
+	if err := json.NewEncoder(payload).Encode(input); err != nil {
+		t.Fatalf("Encode() error:\n%+v", err)
+	}
+	resp, err := http.Post(fmt.Sprintf("http://%s%s", h.LocalAddr(), url), "application/json", payload)
+	if err != nil {
+		t.Fatalf("POST %s:\n%+v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != expectedStatus {
+		t.Fatalf("POST %s: got status code %d, not %d", url, resp.StatusCode, expectedStatus)
+	}
+	var output struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&output); err != nil {
+		t.Fatalf("Decode() error:\n%+v", err)
+	}
+	return output.ID
+}
+
+func waitJobStatus(t *testing.T, c *Component, id string, status jobStatus) {
+	t.Helper()
+	for range 100 {
+		c.jobsLock.Lock()
+		job := c.jobs[id]
+		c.jobsLock.Unlock()
+		job.lock.Lock()
+		current := job.status
+		job.lock.Unlock()
+		if current == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach status %s", id, status)
+}
+
+var jobsTestSankeyInput = gin.H{
+	"start":      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+	"end":        time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+	"dimensions": []string{"SrcAS", "ExporterName"},
+	"limit":      10,
+	"filter":     "DstCountry = 'FR'",
+	"units":      "l3bps",
+}
+
+func TestJobsSankey(t *testing.T) {
+	c, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+	expectedSQL := []struct {
+		Xps        float64  `ch:"xps"`
+		Dimensions []string `ch:"dimensions"`
+	}{
+		{9677, []string{"AS100", "router1"}},
+		{621, []string{"Other", "Other"}},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		SetArg(1, expectedSQL).
+		Return(nil)
+
+	id := submitTestJob(t, h, "/api/v0/console/jobs/graph/sankey", jobsTestSankeyInput, http.StatusAccepted)
+	waitJobStatus(t, c, id, jobDone)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "status",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s", id),
+			JSONOutput: gin.H{
+				"id":         id,
+				"status":     "done",
+				"rows-read":  0,
+				"bytes-read": 0,
+				"total-rows": 0,
+				"elapsed":    0,
+			},
+		}, {
+			Description: "result",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s/result", id),
+			JSONOutput: gin.H{
+				"rows": [][]string{
+					{"AS100", "router1"},
+					{"Other", "Other"},
+				},
+				"xps": []int{9677, 621},
+				"nodes": []string{
+					"SrcAS: AS100",
+					"ExporterName: router1",
+					"SrcAS: Other",
+					"ExporterName: Other",
+				},
+				"links": []gin.H{
+					{"source": "SrcAS: AS100", "target": "ExporterName: router1", "xps": 9677},
+					{"source": "SrcAS: Other", "target": "ExporterName: Other", "xps": 621},
+				},
+			},
+		}, {
+			Description: "unknown job",
+			URL:         "/api/v0/console/jobs/unknown",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Job not found."},
+		}, {
+			Description: "invalid input",
+			URL:         "/api/v0/console/jobs/graph/sankey",
+			JSONInput: gin.H{
+				"start":      time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+				"end":        time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+				"dimensions": []string{"SrcAS"},
+				"limit":      1000,
+				"units":      "l3bps",
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "Limit is set beyond maximum value (50)"},
+		},
+	})
+}
+
+func TestJobsSQL(t *testing.T) {
+	config := DefaultConfiguration()
+	config.SQL.AllowedUsers = []string{"__default"}
+	c, h, mockConn, _ := NewMock(t, config)
+
+	ctrl := gomock.NewController(t)
+	expectQuery := func() {
+		mockRows := mocks.NewMockRows(ctrl)
+		mockConn.EXPECT().
+			Query(gomock.Any(), "SELECT SrcAS, COUNT(*) AS count FROM flows GROUP BY SrcAS").
+			Return(mockRows, nil)
+		colSrcAS := mocks.NewMockColumnType(ctrl)
+		colCount := mocks.NewMockColumnType(ctrl)
+		colSrcAS.EXPECT().ScanType().Return(reflect.TypeOf(uint32(0))).AnyTimes()
+		colCount.EXPECT().ScanType().Return(reflect.TypeOf(uint64(0))).AnyTimes()
+		mockRows.EXPECT().Columns().Return([]string{"SrcAS", "count"})
+		mockRows.EXPECT().ColumnTypes().Return([]driver.ColumnType{colSrcAS, colCount})
+		mockRows.EXPECT().Next().Return(true)
+		mockRows.EXPECT().Scan(gomock.Any()).
+			DoAndReturn(func(args ...interface{}) interface{} {
+				*args[0].(*uint32) = 64500
+				*args[1].(*uint64) = 12
+				return nil
+			})
+		mockRows.EXPECT().Next().Return(false)
+		mockRows.EXPECT().Err().Return(nil)
+		mockRows.EXPECT().Close()
+	}
+
+	input := gin.H{
+		"query": "SELECT SrcAS, COUNT(*) AS count FROM flows GROUP BY SrcAS",
+		"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+		"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+	}
+	expectQuery()
+	id := submitTestJob(t, h, "/api/v0/console/jobs/sql", input, http.StatusAccepted)
+	waitJobStatus(t, c, id, jobDone)
+	input["format"] = "csv"
+	expectQuery()
+	csvID := submitTestJob(t, h, "/api/v0/console/jobs/sql", input, http.StatusAccepted)
+	waitJobStatus(t, c, csvID, jobDone)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "result",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s/result", id),
+			JSONOutput: gin.H{
+				"columns":   []string{"SrcAS", "count"},
+				"rows":      [][]interface{}{{64500, 12}},
+				"truncated": false,
+			},
+		}, {
+			Description: "CSV result",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s/result", csvID),
+			ContentType: "text/csv; charset=utf-8",
+			FirstLines: []string{
+				"SrcAS,count",
+				"64500,12",
+			},
+		}, {
+			Description: "forbidden table",
+			URL:         "/api/v0/console/jobs/sql",
+			JSONInput: gin.H{
+				"query": "SELECT * FROM system.tables",
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "Table system.tables is not allowed"},
+		},
+	})
+}
+
+func TestJobsCancel(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Jobs.MaxPerUser = 1
+	c, h, mockConn, mockClock := NewMock(t, config)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx stdcontext.Context, _ interface{}, _ string, _ ...interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	id := submitTestJob(t, h, "/api/v0/console/jobs/graph/sankey", jobsTestSankeyInput, http.StatusAccepted)
+	mockConn.EXPECT().
+		Exec(gomock.Any(), "KILL QUERY WHERE query_id = $1 ASYNC", fmt.Sprintf("akvorado-job-%s", id)).
+		Return(nil)
+	mockClock.Add(2 * time.Second)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "too many jobs",
+			URL:         "/api/v0/console/jobs/graph/sankey",
+			JSONInput:   jobsTestSankeyInput,
+			StatusCode:  429,
+			JSONOutput:  gin.H{"message": "Too many running jobs"},
+		}, {
+			Description: "running",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s/result", id),
+			StatusCode:  409,
+			JSONOutput:  gin.H{"message": "Job is running."},
+		}, {
+			Description: "cancel",
+			Method:      "DELETE",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s", id),
+			StatusCode:  204,
+		}, {
+			Description: "cancelled",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s", id),
+			JSONOutput: gin.H{
+				"id":         id,
+				"status":     "cancelled",
+				"rows-read":  0,
+				"bytes-read": 0,
+				"total-rows": 0,
+				"elapsed":    2,
+			},
+		},
+	})
+
+	// Result expires after some time
+	mockClock.Add(config.Jobs.ResultTTL + time.Second)
+	c.expireJobs()
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "expired",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s", id),
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Job not found."},
+		},
+	})
+}
+
+func TestJobsCancelSQL(t *testing.T) {
+	config := DefaultConfiguration()
+	config.SQL.AllowedUsers = []string{"__default"}
+	c, h, _, _ := NewMock(t, config)
+	// Use a distinct connection for SQL queries to check the query is killed
+	// through it.
+	sqlCh, sqlMockConn := clickhousedb.NewMock(t, reporter.NewMock(t))
+	c.d.SQLClickHouseDB = sqlCh
+	sqlMockConn.EXPECT().
+		Query(gomock.Any(), "SELECT SrcAS FROM flows").
+		DoAndReturn(func(ctx stdcontext.Context, _ string, _ ...interface{}) (driver.Rows, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+
+	input := gin.H{
+		"query": "SELECT SrcAS FROM flows",
+		"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+		"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+	}
+	id := submitTestJob(t, h, "/api/v0/console/jobs/sql", input, http.StatusAccepted)
+	sqlMockConn.EXPECT().
+		Exec(gomock.Any(), "KILL QUERY WHERE query_id = $1 ASYNC", fmt.Sprintf("akvorado-job-%s", id)).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "cancel",
+			Method:      "DELETE",
+			URL:         fmt.Sprintf("/api/v0/console/jobs/%s", id),
+			StatusCode:  204,
+		},
+	})
+	waitJobStatus(t, c, id, jobCancelled)
+}
+
+func TestJobsAbandoned(t *testing.T) {
+	c, h, mockConn, mockClock := NewMock(t, DefaultConfiguration())
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx stdcontext.Context, _ interface{}, _ string, _ ...interface{}) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	id := submitTestJob(t, h, "/api/v0/console/jobs/graph/sankey", jobsTestSankeyInput, http.StatusAccepted)
+	mockConn.EXPECT().
+		Exec(gomock.Any(), "KILL QUERY WHERE query_id = $1 ASYNC", fmt.Sprintf("akvorado-job-%s", id)).
+		Return(nil)
+	mockClock.Add(DefaultConfiguration().Jobs.PollTimeout + time.Second)
+	c.expireJobs()
+	waitJobStatus(t, c, id, jobCancelled)
+}
@@ -4,6 +4,7 @@
 package console
 
 import (
+	stdcontext "context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -39,6 +40,8 @@ type graphLineHandlerOutput struct {
 	Min                  []int          `json:"min"`     // row → min xps
 	Max                  []int          `json:"max"`     // row → max xps
 	NinetyFivePercentile []int          `json:"95th"`    // row → 95th xps
+	Approximate          bool           `json:"approximate,omitempty"`
+	Tables               []tableRange   `json:"tables,omitempty"` // tables serving each time range
 }
 
 // reverseDirection reverts the direction of a provided input. It does not
@@ -157,9 +160,13 @@ func (input graphLineHandlerInput) toSQL1(axis int, options toSQL1Options) strin
 		}
 	}
 
+	// When several tables are stitched together, there is one SELECT for
+	// each of them, with their own interval.
 	sqlQuery := fmt.Sprintf(`
 {{ with %s }}%s
-SELECT %d AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT %d AS axis, * FROM (
 SELECT
  %s
 FROM source
@@ -170,14 +177,17 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second%s
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS %s))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		templateContext(inputContext{
-			Start:             input.Start,
-			End:               input.End,
-			StartForInterval:  startForInterval,
-			MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
-			Points:            input.Points,
-			Units:             units,
+			Start:            input.Start,
+			End:              input.End,
+			StartForInterval: startForInterval,
+			Columns:          requiredColumns(input.Dimensions, input.Filter),
+			Points:           input.Points,
+			Units:            units,
+			Approximate:      input.Approximate,
+			// Stitching would not align with the previous period.
+			Stitch: !input.PreviousPeriod,
 		}),
 		withStr, axis, strings.Join(fields, ",\n "), where, offsetShift, offsetShift,
 		dimensionsInterpolate,
@@ -219,25 +229,25 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+	if err := c.validateGraphCommonInput(&input.graphCommonHandlerInput); err != nil {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := input.Filter.Validate(input.schema); err != nil {
-		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
-		return
-	}
-	if input.Limit > c.config.DimensionsLimit {
-		gc.JSON(http.StatusBadRequest,
-			gin.H{"message": fmt.Sprintf("Limit is set beyond maximum value (%d)",
-				c.config.DimensionsLimit)})
-		return
-	}
 
-	sqlQuery := input.toSQL()
-	sqlQuery = c.finalizeQuery(sqlQuery)
+	sqlQuery, info := c.finalizeQueryWithInfo(input.toSQL())
 	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	output, err := c.graphLineExecute(ctx, input, sqlQuery, info)
+	if err != nil {
+		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		return
+	}
+	gc.JSON(http.StatusOK, output)
+}
 
+// graphLineExecute executes the finalized query for a line graph and builds
+// the output.
+func (c *Component) graphLineExecute(ctx stdcontext.Context, input graphLineHandlerInput, sqlQuery string, info queryInfo) (graphLineHandlerOutput, error) {
 	results := []struct {
 		Axis       uint8     `ch:"axis"`
 		Time       time.Time `ch:"time"`
@@ -245,10 +255,15 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 		Dimensions []string  `ch:"dimensions"`
 	}{}
 	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
-		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
-		return
+		return graphLineHandlerOutput{}, err
 	}
+	// Results for an axis may come from several SELECT when stitching tables.
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Axis != results[j].Axis {
+			return results[i].Axis < results[j].Axis
+		}
+		return results[i].Time.Before(results[j].Time)
+	})
 
 	// When filling 0 value, we may get an empty dimensions.
 	// From ClickHouse 22.4, it is possible to do interpolation database-side
@@ -267,7 +282,9 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 
 	// Set time axis. We assume the first returned axis has the complete view.
 	output := graphLineHandlerOutput{
-		Time: []time.Time{},
+		Time:        []time.Time{},
+		Approximate: info.Approximate,
+		Tables:      info.Ranges,
 	}
 	lastTime := time.Time{}
 	for _, result := range results {
@@ -425,7 +442,7 @@ func (c *Component) graphLineHandlerFunc(gc *gin.Context) {
 			output.AxisNames[axis] = fmt.Sprintf("Previous %s", name)
 		}
 	}
-	gc.JSON(http.StatusOK, output)
+	return output, nil
 }
 
 type tableIntervalInput struct {
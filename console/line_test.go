@@ -179,10 +179,12 @@ func TestGraphQuerySQL(t *testing.T) {
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -195,7 +197,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no dimensions, no filters, l2 bps",
 			Pos:         helpers.Mark(),
@@ -210,10 +212,12 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l2bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l2bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -226,7 +230,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}
+{{ end }}{{ end }}
 `,
 		}, {
 			Description: "no dimensions, no filters, pps",
@@ -242,10 +246,12 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"pps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"pps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -258,7 +264,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "truncated source address",
 			Pos:         helpers.Mark(),
@@ -275,11 +281,13 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","main-table-required":true,"points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["SrcAddr"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * REPLACE (tupleElement(IPv6CIDRToRange(SrcAddr, if(tupleElement(IPv6CIDRToRange(SrcAddr, 96), 1) = toIPv6('::ffff:0.0.0.0'), 120, 48)), 1) AS SrcAddr) FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
  rows AS (SELECT SrcAddr FROM source WHERE {{ .Timefilter }} AND (SrcAddr BETWEEN toIPv6('::ffff:1.0.0.0') AND toIPv6('::ffff:1.255.255.255')) GROUP BY SrcAddr ORDER BY {{ .Units }} DESC LIMIT 0)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -292,7 +300,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other']))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no dimensions",
 			Pos:         helpers.Mark(),
@@ -307,10 +315,12 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["DstCountry","SrcCountry"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -323,7 +333,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no dimensions, escaped filter",
 			Pos:         helpers.Mark(),
@@ -338,10 +348,12 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["InIfDescription","SrcCountry"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -354,7 +366,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no dimensions, reverse direction",
 			Pos:         helpers.Mark(),
@@ -370,10 +382,12 @@ ORDER BY time WITH FILL
 				Bidirectional: true,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["DstCountry","SrcCountry"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -386,10 +400,12 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}
+{{ end }}{{ end }}
+UNION ALL
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["SrcCountry","DstCountry"],"points":100,"units":"l3bps","stitch":true}@@ }}
+{{ range $i, $_ := .Parts }}{{ if $i }}
 UNION ALL
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
-SELECT 2 AS axis, * FROM (
+{{ end }}SELECT 2 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -402,7 +418,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no dimensions, reverse direction, inl2%",
 			Pos:         helpers.Mark(),
@@ -418,10 +434,12 @@ ORDER BY time WITH FILL
 				Bidirectional: true,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"inl2%"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["DstCountry","SrcCountry"],"points":100,"units":"inl2%","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -434,10 +452,12 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}
+{{ end }}{{ end }}
 UNION ALL
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"outl2%"}@@ }}
-SELECT 2 AS axis, * FROM (
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["SrcCountry","DstCountry"],"points":100,"units":"outl2%","stitch":true}@@ }}
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 2 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -450,7 +470,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no filters",
 			Pos:         helpers.Mark(),
@@ -469,11 +489,13 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["ExporterName","InIfProvider"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
  rows AS (SELECT ExporterName, InIfProvider FROM source WHERE {{ .Timefilter }} GROUP BY ExporterName, InIfProvider ORDER BY {{ .Units }} DESC LIMIT 20)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -486,7 +508,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other', 'Other']))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no filters, limitType by max",
 			Pos:         helpers.Mark(),
@@ -506,11 +528,13 @@ ORDER BY time WITH FILL
 				Points: 100,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["ExporterName","InIfProvider"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
  rows AS (SELECT ExporterName, InIfProvider FROM ( SELECT ExporterName, InIfProvider, {{ .Units }} AS sum_at_time FROM source WHERE {{ .Timefilter }} GROUP BY ExporterName, InIfProvider ) GROUP BY ExporterName, InIfProvider ORDER BY MAX(sum_at_time) DESC LIMIT 20)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -523,7 +547,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other', 'Other']))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no filters, reverse",
 			Pos:         helpers.Mark(),
@@ -543,11 +567,13 @@ ORDER BY time WITH FILL
 				Bidirectional: true,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["ExporterName","InIfProvider"],"points":100,"units":"l3bps","stitch":true}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
  rows AS (SELECT ExporterName, InIfProvider FROM source WHERE {{ .Timefilter }} GROUP BY ExporterName, InIfProvider ORDER BY {{ .Units }} DESC LIMIT 20)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -560,10 +586,12 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other', 'Other']))
-{{ end }}
+{{ end }}{{ end }}
+UNION ALL
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["ExporterName","OutIfProvider"],"points":100,"units":"l3bps","stitch":true}@@ }}
+{{ range $i, $_ := .Parts }}{{ if $i }}
 UNION ALL
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
-SELECT 2 AS axis, * FROM (
+{{ end }}SELECT 2 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -576,7 +604,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other', 'Other']))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		}, {
 			Description: "no filters, previous period",
 			Pos:         helpers.Mark(),
@@ -596,11 +624,13 @@ ORDER BY time WITH FILL
 				PreviousPeriod: true,
 			},
 			Expected: `
-{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","points":100,"units":"l3bps"}@@ }}
+{{ with context @@{"start":"2022-04-10T15:45:10Z","end":"2022-04-11T15:45:10Z","columns":["ExporterName","InIfProvider"],"points":100,"units":"l3bps"}@@ }}
 WITH
  source AS (SELECT * FROM {{ .Table }} SETTINGS asterisk_include_alias_columns = 1),
  rows AS (SELECT ExporterName, InIfProvider FROM source WHERE {{ .Timefilter }} GROUP BY ExporterName, InIfProvider ORDER BY {{ .Units }} DESC LIMIT 20)
-SELECT 1 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 1 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -613,10 +643,12 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS ['Other', 'Other']))
-{{ end }}
+{{ end }}{{ end }}
 UNION ALL
 {{ with context @@{"start":"2022-04-09T15:45:10Z","end":"2022-04-10T15:45:10Z","start-for-interval":"2022-04-10T15:45:10Z","points":100,"units":"l3bps"}@@ }}
-SELECT 3 AS axis, * FROM (
+{{ range $i, $_ := .Parts }}{{ if $i }}
+UNION ALL
+{{ end }}SELECT 3 AS axis, * FROM (
 SELECT
  {{ call .ToStartOfInterval "TimeReceived" }} + INTERVAL 86400 second AS time,
  {{ .Units }}/{{ .Interval }} AS xps,
@@ -629,7 +661,7 @@ ORDER BY time WITH FILL
  TO {{ .TimefilterEnd }} + INTERVAL 1 second + INTERVAL 86400 second
  STEP {{ .Interval }}
  INTERPOLATE (dimensions AS emptyArrayString()))
-{{ end }}`,
+{{ end }}{{ end }}`,
 		},
 	}
 	for _, tc := range cases {
@@ -784,6 +816,9 @@ func TestGraphLineHandler(t *testing.T) {
 						{"router2", "provider4"}, // 1000
 						{"Other", "Other"},       // 2100
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
@@ -867,6 +902,9 @@ func TestGraphLineHandler(t *testing.T) {
 						{"router2", "provider4"}, // 100
 						{"Other", "Other"},       // 210
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
@@ -982,6 +1020,10 @@ func TestGraphLineHandler(t *testing.T) {
 						{"Other", "Other"},       // 2100
 						{"Other", "Other"},       // Previous day
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+						{"table": "flows", "start": "2022-04-09T15:45:10Z", "end": "2022-04-10T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
@@ -1174,6 +1216,9 @@ func TestGraphLineHandler(t *testing.T) {
 						{"router2", "provider4"},
 						{"Other", "Other"},
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
@@ -1257,6 +1302,9 @@ func TestGraphLineHandler(t *testing.T) {
 						{"router2", "provider4"}, // 100
 						{"Other", "Other"},       // 210
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
@@ -1372,6 +1420,10 @@ func TestGraphLineHandler(t *testing.T) {
 						{"Other", "Other"},       // 2100
 						{"Other", "Other"},       // Previous day
 					},
+					"tables": []gin.H{
+						{"table": "flows", "start": "2022-04-10T15:45:10Z", "end": "2022-04-11T15:45:10Z", "interval": 864},
+						{"table": "flows", "start": "2022-04-09T15:45:10Z", "end": "2022-04-10T15:45:10Z", "interval": 864},
+					},
 					"t": []string{
 						"2009-11-10T23:00:00Z",
 						"2009-11-10T23:01:00Z",
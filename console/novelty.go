@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/console/query"
+)
+
+// noveltyHandlerInput describes the input for the /novelty endpoint. Values
+// of the dimension carrying traffic between start and end are compared with
+// the traffic they carried between baseline-start and baseline-end.
+type noveltyHandlerInput struct {
+	schema        *schema.Component
+	Start         time.Time    `json:"start" binding:"required"`
+	End           time.Time    `json:"end" binding:"required,gtfield=Start"`
+	BaselineStart time.Time    `json:"baseline-start" binding:"required"`
+	BaselineEnd   time.Time    `json:"baseline-end" binding:"required,gtfield=BaselineStart,ltefield=Start"`
+	Dimension     query.Column `json:"dimension"`
+	Filter        query.Filter `json:"filter"`
+	Units         string       `json:"units" binding:"required,oneof=pps l3bps l2bps"`
+	Limit         int          `json:"limit" binding:"min=1"`
+	// MinXps is the minimum rate over the current period for a value to be
+	// considered.
+	MinXps float64 `json:"min-xps" binding:"min=0"`
+	// BaselineRatio is the maximum ratio between the rate during the
+	// baseline and the rate during the current period for a value to be
+	// considered new. With 0, the value should be absent from the baseline.
+	BaselineRatio float64 `json:"baseline-ratio" binding:"min=0,max=1"`
+}
+
+// noveltyEntry is a value returned by the /novelty endpoint.
+type noveltyEntry struct {
+	Dimension   string    `json:"dimension"`
+	Xps         int       `json:"xps"`
+	BaselineXps int       `json:"baseline-xps"`
+	FirstSeen   time.Time `json:"first-seen"`
+}
+
+// noveltyHandlerOutput describes the output for the /novelty endpoint. Entries
+// are sorted by traffic.
+type noveltyHandlerOutput struct {
+	Rows []noveltyEntry `json:"rows"`
+}
+
+// toSQL converts a novelty query to an SQL request.
+func (input noveltyHandlerInput) toSQL() string {
+	where := templateWhere(input.Filter)
+	dimension := input.Dimension.ToSQLSelect(input.schema)
+	columns := requiredColumns([]query.Column{input.Dimension}, input.Filter)
+	sqlQuery := fmt.Sprintf(`
+WITH
+ current AS ({{ with %s }}
+  SELECT
+   %s AS dimension,
+   {{ .Units }}/%d AS xps,
+   MIN(TimeReceived) AS firstSeen
+  FROM {{ .Table }}
+  WHERE %s
+  GROUP BY dimension
+  HAVING xps >= %g{{ end }}
+ ),
+ baseline AS ({{ with %s }}
+  SELECT
+   %s AS dimension,
+   {{ .Units }}/%d AS xps
+  FROM {{ .Table }}
+  WHERE %s
+  AND dimension IN (SELECT dimension FROM current)
+  GROUP BY dimension{{ end }}
+ )
+SELECT
+ current.dimension AS dimension,
+ current.xps AS xps,
+ baseline.xps AS baselineXps,
+ current.firstSeen AS firstSeen
+FROM current
+LEFT JOIN baseline USING (dimension)
+WHERE baselineXps <= %g * xps
+ORDER BY xps DESC
+LIMIT %d`,
+		templateContext(inputContext{
+			Start:   input.Start,
+			End:     input.End,
+			Columns: columns,
+			Points:  20,
+			Units:   input.Units,
+		}),
+		dimension, uint64(input.End.Sub(input.Start).Seconds()), where, input.MinXps,
+		templateContext(inputContext{
+			Start:   input.BaselineStart,
+			End:     input.BaselineEnd,
+			Columns: columns,
+			Points:  20,
+			Units:   input.Units,
+		}),
+		dimension, uint64(input.BaselineEnd.Sub(input.BaselineStart).Seconds()), where,
+		input.BaselineRatio, input.Limit)
+	return strings.TrimSpace(sqlQuery)
+}
+
+func (c *Component) noveltyHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input := noveltyHandlerInput{schema: c.d.Schema, Limit: 20}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := input.Dimension.Validate(input.schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if err := input.Filter.Validate(input.schema); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	if input.Limit > c.config.DimensionsLimit {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf(
+			"Limit is set beyond maximum value (%d)", c.config.DimensionsLimit)})
+		return
+	}
+
+	sqlQuery := c.finalizeQuery(input.toSQL())
+	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	results := []struct {
+		Dimension   string    `ch:"dimension"`
+		Xps         float64   `ch:"xps"`
+		BaselineXps float64   `ch:"baselineXps"`
+		FirstSeen   time.Time `ch:"firstSeen"`
+	}{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
+		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		return
+	}
+
+	output := noveltyHandlerOutput{Rows: make([]noveltyEntry, 0, len(results))}
+	for _, result := range results {
+		output.Rows = append(output.Rows, noveltyEntry{
+			Dimension:   result.Dimension,
+			Xps:         int(result.Xps),
+			BaselineXps: int(result.BaselineXps),
+			FirstSeen:   result.FirstSeen.UTC(),
+		})
+	}
+	gc.JSON(http.StatusOK, output)
+}
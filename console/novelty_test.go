@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+)
+
+func TestNoveltyHandler(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+	base := time.Date(2022, 4, 11, 3, 0, 0, 0, time.UTC)
+
+	expectedSQL := []struct {
+		Dimension   string    `ch:"dimension"`
+		Xps         float64   `ch:"xps"`
+		BaselineXps float64   `ch:"baselineXps"`
+		FirstSeen   time.Time `ch:"firstSeen"`
+	}{
+		{"64496: Example 1", 15000.4, 0, base},
+		{"64497: Example 2", 2000, 10, base.Add(time.Hour)},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `WITH
+ current AS (
+  SELECT
+   concat(toString(SrcAS), ': ', dictGetOrDefault('asns', 'name', SrcAS, '???')) AS dimension,
+   SUM(Bytes*SamplingRate*8)/86400 AS xps,
+   MIN(TimeReceived) AS firstSeen
+  FROM flows
+  WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:45:10', 'UTC') AND toDateTime('2022-04-11 15:45:10', 'UTC') AND (InIfBoundary = 'external')
+  GROUP BY dimension
+  HAVING xps >= 1000
+ ),
+ baseline AS (
+  SELECT
+   concat(toString(SrcAS), ': ', dictGetOrDefault('asns', 'name', SrcAS, '???')) AS dimension,
+   SUM(Bytes*SamplingRate*8)/2592000 AS xps
+  FROM flows
+  WHERE TimeReceived BETWEEN toDateTime('2022-03-11 15:45:10', 'UTC') AND toDateTime('2022-04-10 15:45:10', 'UTC') AND (InIfBoundary = 'external')
+  AND dimension IN (SELECT dimension FROM current)
+  GROUP BY dimension
+ )
+SELECT
+ current.dimension AS dimension,
+ current.xps AS xps,
+ baseline.xps AS baselineXps,
+ current.firstSeen AS firstSeen
+FROM current
+LEFT JOIN baseline USING (dimension)
+WHERE baselineXps <= 0.01 * xps
+ORDER BY xps DESC
+LIMIT 10`).
+		SetArg(1, expectedSQL).
+		Return(nil)
+
+	input := gin.H{
+		"start":          time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+		"end":            time.Date(2022, 4, 11, 15, 45, 10, 0, time.UTC),
+		"baseline-start": time.Date(2022, 3, 11, 15, 45, 10, 0, time.UTC),
+		"baseline-end":   time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC),
+		"dimension":      "SrcAS",
+		"filter":         "InIfBoundary = external",
+		"units":          "l3bps",
+		"limit":          10,
+		"min-xps":        1000,
+		"baseline-ratio": 0.01,
+	}
+	withInput := func(key string, value any) gin.H {
+		modified := gin.H{}
+		for k, v := range input {
+			modified[k] = v
+		}
+		modified[key] = value
+		return modified
+	}
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL:       "/api/v0/console/novelty",
+			JSONInput: input,
+			JSONOutput: gin.H{
+				"rows": []gin.H{
+					{
+						"dimension":    "64496: Example 1",
+						"xps":          15000,
+						"baseline-xps": 0,
+						"first-seen":   "2022-04-11T03:00:00Z",
+					}, {
+						"dimension":    "64497: Example 2",
+						"xps":          2000,
+						"baseline-xps": 10,
+						"first-seen":   "2022-04-11T04:00:00Z",
+					},
+				},
+			},
+		}, {
+			Description: "unknown dimension",
+			URL:         "/api/v0/console/novelty",
+			JSONInput:   withInput("dimension", "Nothing"),
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": "Unknown column name Nothing"},
+		}, {
+			Description: "baseline overlapping current period",
+			URL:         "/api/v0/console/novelty",
+			JSONInput:   withInput("baseline-end", time.Date(2022, 4, 11, 0, 0, 0, 0, time.UTC)),
+			StatusCode:  400,
+			JSONOutput: gin.H{
+				"message": "Key: 'noveltyHandlerInput.BaselineEnd' Error:Field validation for 'BaselineEnd' failed on the 'ltefield' tag",
+			},
+		}, {
+			Description: "limit too high",
+			URL:         "/api/v0/console/novelty",
+			JSONInput:   withInput("limit", 100),
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": "Limit is set beyond maximum value (50)"},
+		},
+	})
+}
@@ -5,22 +5,22 @@ package console
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
-	"akvorado/common/schema"
 	"akvorado/console/query"
 )
 
-func requireMainTable(sch *schema.Component, qcs []query.Column, qf query.Filter) bool {
-	if qf.MainTableRequired() {
-		return true
-	}
+// requiredColumns returns the columns required to run a query with the
+// provided dimensions and filter.
+func requiredColumns(qcs []query.Column, qf query.Filter) []string {
+	columns := slices.Clone(qf.Columns())
 	for _, qc := range qcs {
-		if column, ok := sch.LookupColumnByKey(qc.Key()); ok && column.ClickHouseMainOnly {
-			return true
+		if name := qc.String(); !slices.Contains(columns, name) {
+			columns = append(columns, name)
 		}
 	}
-	return false
+	return columns
 }
 
 // fixQueryColumnName fix capitalization of the provided column name
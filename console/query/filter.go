@@ -13,10 +13,11 @@ import (
 
 // Filter represents a query filter. It should be instantiated with NewFilter() and validated with Validate().
 type Filter struct {
-	validated         bool
-	filter            string
-	reverseFilter     string
-	mainTableRequired bool
+	validated      bool
+	filter         string
+	reverseFilter  string
+	columns        []string
+	reverseColumns []string
 }
 
 // NewFilter creates a new filter. It should be validated with Validate() before use.
@@ -59,22 +60,23 @@ func (qf *Filter) Validate(sch *schema.Component) error {
 	if err != nil {
 		return fmt.Errorf("cannot parse filter: %s", filter.HumanError(err))
 	}
-	meta = &filter.Meta{Schema: sch, ReverseDirection: true}
-	reverse, err := filter.Parse("", input, filter.GlobalStore("meta", meta))
+	reverseMeta := &filter.Meta{Schema: sch, ReverseDirection: true}
+	reverse, err := filter.Parse("", input, filter.GlobalStore("meta", reverseMeta))
 	if err != nil {
 		return fmt.Errorf("cannot parse reverse filter: %s", filter.HumanError(err))
 	}
 	qf.filter = direct.(string)
 	qf.reverseFilter = reverse.(string)
-	qf.mainTableRequired = meta.MainTableRequired
+	qf.columns = meta.Columns
+	qf.reverseColumns = reverseMeta.Columns
 	qf.validated = true
 	return nil
 }
 
-// MainTableRequired tells if the main table is required for this filter.
-func (qf Filter) MainTableRequired() bool {
+// Columns returns the columns used by the filter.
+func (qf Filter) Columns() []string {
 	qf.check()
-	return qf.mainTableRequired
+	return qf.columns
 }
 
 // Reverse provides the reverse filter.
@@ -92,4 +94,5 @@ func (qf Filter) Direct() string {
 // Swap swap direct and reverse filter.
 func (qf *Filter) Swap() {
 	qf.filter, qf.reverseFilter = qf.reverseFilter, qf.filter
+	qf.columns, qf.reverseColumns = qf.reverseColumns, qf.columns
 }
@@ -11,36 +11,34 @@ import (
 	"akvorado/console/query"
 )
 
-func TestRequireMainTable(t *testing.T) {
+func TestRequiredColumns(t *testing.T) {
 	cases := []struct {
 		Pos      helpers.Pos
 		Columns  []query.Column
 		Filter   query.Filter
-		Expected bool
+		Expected []string
 	}{
-		{helpers.Mark(), []query.Column{}, query.NewFilter(""), false},
-		{helpers.Mark(), []query.Column{query.NewColumn("SrcAS")}, query.NewFilter(""), false},
-		{helpers.Mark(), []query.Column{query.NewColumn("ExporterAddress")}, query.NewFilter(""), false},
-		{helpers.Mark(), []query.Column{query.NewColumn("SrcPort")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("SrcAddr")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("DstPort")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("DstAddr")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("SrcAS"), query.NewColumn("DstAddr")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("DstAddr"), query.NewColumn("SrcAS")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{query.NewColumn("DstNetPrefix")}, query.NewFilter(""), true},
-		{helpers.Mark(), []query.Column{}, query.NewFilter("SrcAddr = 203.0.113.15"), true},
+		{helpers.Mark(), []query.Column{}, query.NewFilter(""), []string{}},
+		{helpers.Mark(), []query.Column{query.NewColumn("SrcAS")}, query.NewFilter(""), []string{"SrcAS"}},
+		{helpers.Mark(), []query.Column{query.NewColumn("SrcAS"), query.NewColumn("DstAddr")}, query.NewFilter(""),
+			[]string{"SrcAS", "DstAddr"}},
+		{helpers.Mark(), []query.Column{}, query.NewFilter("SrcAddr = 203.0.113.15"), []string{"SrcAddr"}},
+		{helpers.Mark(), []query.Column{query.NewColumn("SrcAddr")}, query.NewFilter("SrcAddr = 203.0.113.15"),
+			[]string{"SrcAddr"}},
+		{helpers.Mark(), []query.Column{query.NewColumn("DstPort")}, query.NewFilter("InIfBoundary = external AND ExporterName = 'SrcAS'"),
+			[]string{"InIfBoundary", "ExporterName", "DstPort"}},
 	}
 	sch := schema.NewMock(t)
-	for idx, tc := range cases {
+	for _, tc := range cases {
 		if err := query.Columns(tc.Columns).Validate(sch); err != nil {
 			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
 		}
 		if err := tc.Filter.Validate(sch); err != nil {
 			t.Fatalf("%sValidate() error:\n%+v", tc.Pos, err)
 		}
-		got := requireMainTable(sch, tc.Columns, tc.Filter)
-		if got != tc.Expected {
-			t.Errorf("%srequireMainTable(%d) == %v but expected %v", tc.Pos, idx, got, tc.Expected)
+		got := requiredColumns(tc.Columns, tc.Filter)
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("%srequiredColumns() (-got, +want):\n%s", tc.Pos, diff)
 		}
 	}
 }
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"akvorado/common/reporter"
+	"akvorado/console/query"
+)
+
+// recordingQuery is a recording query with the associated metric.
+type recordingQuery struct {
+	name   string
+	config RecordingQueryConfiguration
+	gauge  *reporter.GaugeVec
+}
+
+var recordingQueryNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// newRecordingQueries validates recording queries and registers the
+// associated metrics.
+func (c *Component) newRecordingQueries() error {
+	names := make([]string, 0, len(c.config.RecordingQueries))
+	for name := range c.config.RecordingQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		config := c.config.RecordingQueries[name]
+		if !recordingQueryNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid name for recording query %q", name)
+		}
+		if err := config.Filter.Validate(c.d.Schema); err != nil {
+			return fmt.Errorf("invalid filter for recording query %q: %w", name, err)
+		}
+		if err := query.Columns(config.Dimensions).Validate(c.d.Schema); err != nil {
+			return fmt.Errorf("invalid dimensions for recording query %q: %w", name, err)
+		}
+		labels := make([]string, len(config.Dimensions))
+		for idx, column := range config.Dimensions {
+			labels[idx] = column.String()
+		}
+		c.recordingQueries = append(c.recordingQueries, recordingQuery{
+			name:   name,
+			config: config,
+			gauge: c.r.GaugeVec(
+				reporter.GaugeOpts{
+					Name: fmt.Sprintf("recording_%s", name),
+					Help: fmt.Sprintf("Traffic for recording query %s (%s).", name, config.Units),
+				}, labels),
+		})
+	}
+	return nil
+}
+
+// runRecordingQuery periodically evaluates the provided recording query.
+func (c *Component) runRecordingQuery(rq recordingQuery) error {
+	ticker := c.d.Clock.Ticker(rq.config.Interval)
+	defer ticker.Stop()
+	for {
+		if err := c.evaluateRecordingQuery(rq); err != nil {
+			c.r.Err(err).Str("name", rq.name).Msg("cannot evaluate recording query")
+		}
+		select {
+		case <-ticker.C:
+		case <-c.t.Dying():
+			return nil
+		}
+	}
+}
+
+// recordingQuerySQL builds the SQL query for a recording query.
+func (c *Component) recordingQuerySQL(rq recordingQuery) string {
+	now := c.d.Clock.Now()
+	selectFields := []string{}
+	dimensions := []string{}
+	for _, column := range rq.config.Dimensions {
+		selectFields = append(selectFields, column.ToSQLSelect(c.d.Schema))
+		dimensions = append(dimensions, column.String())
+	}
+	fields := []string{`{{ .Units }}/{{ .Interval }} AS xps`}
+	groupBy := ""
+	if len(dimensions) > 0 {
+		fields = append(fields, fmt.Sprintf("[%s] AS dimensions", strings.Join(selectFields, ", ")))
+		groupBy = fmt.Sprintf("\nGROUP BY %s", strings.Join(dimensions, ", "))
+	} else {
+		fields = append(fields, "emptyArrayString() AS dimensions")
+	}
+	return c.finalizeQuery(fmt.Sprintf(`
+{{ with %s }}
+SELECT
+ %s
+FROM {{ .Table }}
+WHERE %s%s
+ORDER BY xps DESC
+LIMIT %d
+{{ end }}`,
+		templateContext(inputContext{
+			Start:   now.Add(-rq.config.Window),
+			End:     now,
+			Columns: requiredColumns(rq.config.Dimensions, rq.config.Filter),
+			Points:  1,
+			Units:   rq.config.Units,
+		}),
+		strings.Join(fields, ",\n "),
+		templateWhere(rq.config.Filter),
+		groupBy,
+		rq.config.Limit))
+}
+
+// evaluateRecordingQuery evaluates a recording query and updates the
+// associated metric.
+func (c *Component) evaluateRecordingQuery(rq recordingQuery) error {
+	ctx := c.t.Context(nil)
+	sqlQuery := c.recordingQuerySQL(rq)
+	results := []struct {
+		Xps        float64  `ch:"xps"`
+		Dimensions []string `ch:"dimensions"`
+	}{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, strings.TrimSpace(sqlQuery)); err != nil {
+		return fmt.Errorf("unable to query database: %w", err)
+	}
+	rq.gauge.Reset()
+	for _, result := range results {
+		rq.gauge.WithLabelValues(result.Dimensions...).Set(result.Xps)
+	}
+	return nil
+}
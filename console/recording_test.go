@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/console/authentication"
+	"akvorado/console/database"
+	"akvorado/console/query"
+)
+
+func TestRecordingQueryInvalid(t *testing.T) {
+	r := reporter.NewMock(t)
+	for _, tc := range []struct {
+		Name          string
+		Configuration RecordingQueryConfiguration
+	}{
+		{"Transit", DefaultRecordingQueryConfiguration()},
+		{"transit", RecordingQueryConfiguration{
+			Filter: query.NewFilter("InIfBoundary ="),
+		}},
+		{"transit", RecordingQueryConfiguration{
+			Dimensions: []query.Column{query.NewColumn("Unknown")},
+		}},
+	} {
+		config := DefaultConfiguration()
+		config.RecordingQueries = map[string]RecordingQueryConfiguration{
+			tc.Name: tc.Configuration,
+		}
+		_, err := New(r, config, Dependencies{
+			Daemon: daemon.NewMock(t),
+			Schema: schema.NewMock(t),
+		})
+		if err == nil {
+			t.Errorf("New(%q) did not error", tc.Name)
+		}
+	}
+}
+
+func TestRecordingQuery(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	ch, mockConn := clickhousedb.NewMock(t, r)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2022, 4, 10, 15, 45, 10, 0, time.UTC))
+	config := DefaultConfiguration()
+	rqConfig := DefaultRecordingQueryConfiguration()
+	rqConfig.Filter = query.NewFilter("InIfBoundary = external")
+	rqConfig.Dimensions = []query.Column{query.NewColumn("InIfProvider"), query.NewColumn("SrcCountry")}
+	rqConfig.Limit = 3
+	config.RecordingQueries = map[string]RecordingQueryConfiguration{
+		"transit": rqConfig,
+	}
+	c, err := New(r, config, Dependencies{
+		Daemon:       daemon.NewMock(t),
+		HTTP:         h,
+		ClickHouseDB: ch,
+		Clock:        mockClock,
+		Auth:         authentication.NewMock(t, r),
+		Database:     database.NewMock(t, r, database.DefaultConfiguration()),
+		Schema:       schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	expected := []struct {
+		Xps        float64  `ch:"xps"`
+		Dimensions []string `ch:"dimensions"`
+	}{
+		{1000, []string{"cogent", "FR"}},
+		{500, []string{"telia", "FR"}},
+		{200, []string{"cogent", "US"}},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `SELECT
+ SUM(Bytes*SamplingRate*8)/300 AS xps,
+ [InIfProvider, SrcCountry] AS dimensions
+FROM flows
+WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:40:10', 'UTC') AND toDateTime('2022-04-10 15:45:10', 'UTC') AND (InIfBoundary = 'external')
+GROUP BY InIfProvider, SrcCountry
+ORDER BY xps DESC
+LIMIT 3`).
+		SetArg(1, expected).
+		Return(nil)
+	helpers.StartStop(t, c)
+
+	var got map[string]string
+	for range 100 {
+		got = r.GetMetrics("akvorado_console_recording_")
+		if len(got) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	expectedMetrics := map[string]string{
+		`transit{InIfProvider="cogent",SrcCountry="FR"}`: "1000",
+		`transit{InIfProvider="telia",SrcCountry="FR"}`:  "500",
+		`transit{InIfProvider="cogent",SrcCountry="US"}`: "200",
+	}
+	if diff := helpers.Diff(got, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -5,6 +5,7 @@
 package console
 
 import (
+	"errors"
 	"io/fs"
 	"net/http"
 	"os"
@@ -34,8 +35,15 @@ type Component struct {
 	t      tomb.Tomb
 	config Configuration
 
-	flowsTables     []flowsTable
-	flowsTablesLock sync.RWMutex
+	flowsTables        []flowsTable
+	flowsTablesColumns map[string][]string
+	flowsTablesSampled map[string]bool
+	flowsTablesLock    sync.RWMutex
+
+	recordingQueries []recordingQuery
+
+	jobs     map[string]*queryJob
+	jobsLock sync.Mutex
 
 	metrics struct {
 		clickhouseQueries *reporter.CounterVec
@@ -47,10 +55,13 @@ type Dependencies struct {
 	Daemon       daemon.Component
 	HTTP         *httpserver.Component
 	ClickHouseDB *clickhousedb.Component
-	Clock        clock.Clock
-	Auth         *authentication.Component
-	Database     *database.Component
-	Schema       *schema.Component
+	// SQLClickHouseDB is the restricted connection used for ad-hoc SQL
+	// queries. It is only needed when the SQL endpoint is enabled.
+	SQLClickHouseDB *clickhousedb.Component
+	Clock           clock.Clock
+	Auth            *authentication.Component
+	Database        *database.Component
+	Schema          *schema.Component
 }
 
 // New creates a new console component.
@@ -61,11 +72,15 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 	if err := query.Columns(config.DefaultVisualizeOptions.Dimensions).Validate(dependencies.Schema); err != nil {
 		return nil, err
 	}
+	if len(config.SQL.AllowedUsers) > 0 && dependencies.SQLClickHouseDB == nil {
+		return nil, errors.New("SQL endpoint requires a dedicated ClickHouse user")
+	}
 	c := Component{
 		r:           r,
 		d:           &dependencies,
 		config:      config,
 		flowsTables: []flowsTable{{"flows", 0, time.Time{}}},
+		jobs:        map[string]*queryJob{},
 	}
 
 	c.d.Daemon.Track(&c.t, "console")
@@ -76,6 +91,9 @@ func New(r *reporter.Reporter, config Configuration, dependencies Dependencies)
 			Help: "Number of requests to ClickHouse.",
 		}, []string{"table"},
 	)
+	if err := c.newRecordingQueries(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
@@ -94,7 +112,16 @@ func (c *Component) Start() error {
 	endpoint.GET("/widget/graph", c.d.HTTP.CacheByRequestPath(5*time.Minute), c.widgetGraphHandlerFunc)
 	endpoint.POST("/graph/line", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.graphLineHandlerFunc)
 	endpoint.POST("/graph/sankey", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.graphSankeyHandlerFunc)
+	endpoint.POST("/jobs/graph/line", c.jobGraphLineHandlerFunc)
+	endpoint.POST("/jobs/graph/sankey", c.jobGraphSankeyHandlerFunc)
+	endpoint.POST("/jobs/sql", c.jobSQLHandlerFunc)
+	endpoint.GET("/jobs/:id", c.jobStatusHandlerFunc)
+	endpoint.GET("/jobs/:id/result", c.jobResultHandlerFunc)
+	endpoint.DELETE("/jobs/:id", c.jobCancelHandlerFunc)
 	endpoint.POST("/graph/table-interval", c.getTableAndIntervalHandlerFunc)
+	endpoint.GET("/routes/history", c.routeHistoryHandlerFunc)
+	endpoint.POST("/novelty", c.d.HTTP.CacheByRequestBody(c.config.CacheTTL), c.noveltyHandlerFunc)
+	endpoint.POST("/sql", c.sqlHandlerFunc)
 	endpoint.POST("/filter/validate", c.filterValidateHandlerFunc)
 	endpoint.POST("/filter/complete", c.d.HTTP.CacheByRequestBody(time.Minute), c.filterCompleteHandlerFunc)
 	endpoint.GET("/filter/saved", c.filterSavedListHandlerFunc)
@@ -102,6 +129,10 @@ func (c *Component) Start() error {
 	endpoint.POST("/filter/saved", c.filterSavedAddHandlerFunc)
 	endpoint.GET("/user/info", c.d.Auth.UserInfoHandlerFunc)
 	endpoint.GET("/user/avatar", c.d.Auth.UserAvatarHandlerFunc)
+	// Login and logout do not require an authenticated user
+	c.d.HTTP.GinRouter.GET("/api/v0/console/user/login", c.d.Auth.UserLoginMethodsHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/console/user/login", c.d.Auth.UserLoginHandlerFunc)
+	c.d.HTTP.GinRouter.POST("/api/v0/console/user/logout", c.d.Auth.UserLogoutHandlerFunc)
 
 	c.t.Go(func() error {
 		ticker := time.NewTicker(10 * time.Second)
@@ -120,6 +151,12 @@ func (c *Component) Start() error {
 			}
 		}
 	})
+	c.t.Go(c.runJobsExpiration)
+	for _, rq := range c.recordingQueries {
+		c.t.Go(func() error {
+			return c.runRecordingQuery(rq)
+		})
+	}
 	return nil
 }
 
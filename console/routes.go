@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+)
+
+// routeHistoryInput is the input for the route history endpoint.
+type routeHistoryInput struct {
+	Prefix string    `form:"prefix" binding:"required"`
+	Start  time.Time `form:"start" binding:"required"`
+	End    time.Time `form:"end" binding:"required,gtfield=Start"`
+	Limit  int       `form:"limit" binding:"isdefault|min=1,max=10000"`
+}
+
+// routeHistoryRow is a row from the routes table.
+type routeHistoryRow struct {
+	TimeReceived     time.Time
+	Prefix           net.IP
+	PrefixLen        uint8
+	ExporterAddress  net.IP
+	PeerAddress      net.IP
+	PeerAS           uint32
+	RD               string
+	Withdrawn        bool
+	NextHop          net.IP
+	ASPath           []uint32
+	Communities      []uint32
+	LargeCommunities []string
+}
+
+// routeHistoryEntry is a route announcement or withdrawal returned by the
+// route history endpoint.
+type routeHistoryEntry struct {
+	Time             time.Time `json:"time"`
+	Prefix           string    `json:"prefix"`
+	Exporter         string    `json:"exporter"`
+	Peer             string    `json:"peer"`
+	PeerAS           uint32    `json:"peer-as"`
+	RD               string    `json:"rd"`
+	Withdrawn        bool      `json:"withdrawn"`
+	NextHop          string    `json:"next-hop,omitempty"`
+	ASPath           []uint32  `json:"as-path"`
+	Communities      []string  `json:"communities"`
+	LargeCommunities []string  `json:"large-communities"`
+}
+
+func (c *Component) routeHistoryHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input := routeHistoryInput{Limit: 1000}
+	if err := gc.ShouldBindQuery(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	prefix, err := netip.ParsePrefix(input.Prefix)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": "Invalid prefix."})
+		return
+	}
+	prefix = prefix.Masked()
+	prefixLen := prefix.Bits()
+	if prefix.Addr().Is4() {
+		prefixLen += 96
+	}
+	prefixAddr := netip.AddrFrom16(prefix.Addr().As16())
+
+	// Look for the routes covering the requested prefix: the requested
+	// prefix truncated to the length of the route should be the route.
+	query := fmt.Sprintf(`
+SELECT
+ TimeReceived, Prefix, PrefixLen, ExporterAddress, PeerAddress, PeerAS, RD,
+ Withdrawn, NextHop, ASPath, Communities, LargeCommunities
+FROM routes
+WHERE PrefixLen <= $2 AND Prefix <= toIPv6($1)
+AND tupleElement(IPv6CIDRToRange(toIPv6($1), PrefixLen), 1) = Prefix
+AND TimeReceived BETWEEN toDateTime($3, 'UTC') AND toDateTime($4, 'UTC')
+ORDER BY TimeReceived DESC
+LIMIT %d`, input.Limit)
+	gc.Header("X-SQL-Query", query)
+	c.metrics.clickhouseQueries.WithLabelValues("routes").Inc()
+
+	rows := []routeHistoryRow{}
+	if err := c.d.ClickHouseDB.Conn.Select(ctx, &rows, query,
+		prefixAddr.String(), prefixLen,
+		input.Start.UTC().Unix(), input.End.UTC().Unix()); err != nil {
+		c.r.Err(err).Msg("unable to query database")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		return
+	}
+
+	routes := make([]routeHistoryEntry, len(rows))
+	for idx, row := range rows {
+		entry := routeHistoryEntry{
+			Time:             row.TimeReceived.UTC(),
+			Prefix:           unmapPrefix(row.Prefix, row.PrefixLen),
+			Exporter:         unmapIP(row.ExporterAddress),
+			Peer:             unmapIP(row.PeerAddress),
+			PeerAS:           row.PeerAS,
+			RD:               row.RD,
+			Withdrawn:        row.Withdrawn,
+			ASPath:           row.ASPath,
+			Communities:      make([]string, len(row.Communities)),
+			LargeCommunities: row.LargeCommunities,
+		}
+		if !row.Withdrawn {
+			entry.NextHop = unmapIP(row.NextHop)
+		}
+		for cidx, community := range row.Communities {
+			entry.Communities[cidx] = fmt.Sprintf("%d:%d", community>>16, community&0xffff)
+		}
+		routes[idx] = entry
+	}
+	gc.JSON(http.StatusOK, gin.H{
+		"prefix": prefix.String(),
+		"routes": routes,
+	})
+}
+
+// unmapPrefix turns a prefix into a string, unmapping IPv4 prefixes.
+func unmapPrefix(ip net.IP, prefixLen uint8) string {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ""
+	}
+	bits := int(prefixLen)
+	if addr.Is4In6() {
+		addr = addr.Unmap()
+		bits -= 96
+	}
+	return netip.PrefixFrom(addr, bits).String()
+}
+
+// unmapIP turns an IP address into a string, unmapping IPv4 addresses.
+func unmapIP(ip net.IP) string {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return ""
+	}
+	return addr.Unmap().String()
+}
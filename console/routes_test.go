@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/helpers"
+)
+
+func TestRouteHistory(t *testing.T) {
+	_, h, mockConn, _ := NewMock(t, DefaultConfiguration())
+
+	expected := []routeHistoryRow{
+		{
+			TimeReceived:     time.Date(2025, time.March, 10, 10, 5, 0, 0, time.UTC),
+			Prefix:           net.ParseIP("::ffff:192.0.2.0"),
+			PrefixLen:        120,
+			ExporterAddress:  net.ParseIP("::ffff:192.0.2.1"),
+			PeerAddress:      net.ParseIP("::ffff:203.0.113.4"),
+			PeerAS:           64500,
+			RD:               "0:0",
+			Withdrawn:        true,
+			NextHop:          net.ParseIP("::"),
+			ASPath:           []uint32{},
+			Communities:      []uint32{},
+			LargeCommunities: []string{},
+		}, {
+			TimeReceived:     time.Date(2025, time.March, 10, 10, 0, 0, 0, time.UTC),
+			Prefix:           net.ParseIP("::ffff:192.0.0.0"),
+			PrefixLen:        112,
+			ExporterAddress:  net.ParseIP("::ffff:192.0.2.1"),
+			PeerAddress:      net.ParseIP("::ffff:203.0.113.4"),
+			PeerAS:           64500,
+			RD:               "0:0",
+			NextHop:          net.ParseIP("::ffff:203.0.113.4"),
+			ASPath:           []uint32{64500, 174},
+			Communities:      []uint32{64500<<16 + 100},
+			LargeCommunities: []string{"64500:1:2"},
+		},
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), `
+SELECT
+ TimeReceived, Prefix, PrefixLen, ExporterAddress, PeerAddress, PeerAS, RD,
+ Withdrawn, NextHop, ASPath, Communities, LargeCommunities
+FROM routes
+WHERE PrefixLen <= $2 AND Prefix <= toIPv6($1)
+AND tupleElement(IPv6CIDRToRange(toIPv6($1), PrefixLen), 1) = Prefix
+AND TimeReceived BETWEEN toDateTime($3, 'UTC') AND toDateTime($4, 'UTC')
+ORDER BY TimeReceived DESC
+LIMIT 1000`, "::ffff:192.0.2.0", 120, int64(1741600000), int64(1741604400)).
+		SetArg(1, expected).
+		Return(nil)
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/routes/history?prefix=192.0.2.0/24&start=2025-03-10T09:46:40Z&end=2025-03-10T11:00:00Z",
+			JSONOutput: gin.H{
+				"prefix": "192.0.2.0/24",
+				"routes": []gin.H{
+					{
+						"time":              "2025-03-10T10:05:00Z",
+						"prefix":            "192.0.2.0/24",
+						"exporter":          "192.0.2.1",
+						"peer":              "203.0.113.4",
+						"peer-as":           64500,
+						"rd":                "0:0",
+						"withdrawn":         true,
+						"as-path":           []uint32{},
+						"communities":       []string{},
+						"large-communities": []string{},
+					}, {
+						"time":              "2025-03-10T10:00:00Z",
+						"prefix":            "192.0.0.0/16",
+						"exporter":          "192.0.2.1",
+						"peer":              "203.0.113.4",
+						"peer-as":           64500,
+						"rd":                "0:0",
+						"withdrawn":         false,
+						"next-hop":          "203.0.113.4",
+						"as-path":           []uint32{64500, 174},
+						"communities":       []string{"64500:100"},
+						"large-communities": []string{"64500:1:2"},
+					},
+				},
+			},
+		}, {
+			Description: "invalid prefix",
+			URL:         "/api/v0/console/routes/history?prefix=192.0.2.0/33&start=2025-03-10T09:46:40Z&end=2025-03-10T11:00:00Z",
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": "Invalid prefix."},
+		},
+	})
+}
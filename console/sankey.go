@@ -4,6 +4,7 @@
 package console
 
 import (
+	stdcontext "context"
 	"fmt"
 	"net/http"
 	"sort"
@@ -12,7 +13,6 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"akvorado/common/helpers"
-	"akvorado/console/query"
 )
 
 // graphSankeyHandlerInput describes the input for the /graph/sankey endpoint.
@@ -28,6 +28,8 @@ type graphSankeyHandlerOutput struct {
 	// Processed data for sankey graph
 	Nodes []string     `json:"nodes"`
 	Links []sankeyLink `json:"links"`
+	// Approximate is true when flows were sampled
+	Approximate bool `json:"approximate,omitempty"`
 }
 type sankeyLink struct {
 	Source string `json:"source"`
@@ -72,11 +74,12 @@ GROUP BY dimensions
 ORDER BY xps DESC
 {{ end }}`,
 		templateContext(inputContext{
-			Start:             input.Start,
-			End:               input.End,
-			MainTableRequired: requireMainTable(input.schema, input.Dimensions, input.Filter),
-			Points:            20,
-			Units:             input.Units,
+			Start:       input.Start,
+			End:         input.End,
+			Columns:     requiredColumns(input.Dimensions, input.Filter),
+			Points:      20,
+			Units:       input.Units,
+			Approximate: input.Approximate,
 		}),
 		strings.Join(with, ",\n "), strings.Join(fields, ",\n "), where)
 	return strings.TrimSpace(sqlQuery), nil
@@ -89,21 +92,10 @@ func (c *Component) graphSankeyHandlerFunc(gc *gin.Context) {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := query.Columns(input.Dimensions).Validate(input.schema); err != nil {
+	if err := c.validateGraphCommonInput(&input.graphCommonHandlerInput); err != nil {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
 		return
 	}
-	if err := input.Filter.Validate(input.schema); err != nil {
-		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
-		return
-	}
-	if input.Limit > c.config.DimensionsLimit {
-		gc.JSON(http.StatusBadRequest,
-			gin.H{"message": fmt.Sprintf("Limit is set beyond maximum value (%d)",
-				c.config.DimensionsLimit)})
-		return
-	}
-
 	sqlQuery, err := input.toSQL()
 	if err != nil {
 		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
@@ -111,24 +103,35 @@ func (c *Component) graphSankeyHandlerFunc(gc *gin.Context) {
 	}
 
 	// Prepare and execute query
-	sqlQuery = c.finalizeQuery(sqlQuery)
+	sqlQuery, info := c.finalizeQueryWithInfo(sqlQuery)
 	gc.Header("X-SQL-Query", strings.ReplaceAll(sqlQuery, "\n", "  "))
+	output, err := c.graphSankeyExecute(ctx, input, sqlQuery, info)
+	if err != nil {
+		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
+		return
+	}
+	gc.JSON(http.StatusOK, output)
+}
+
+// graphSankeyExecute executes the finalized query for a sankey graph and
+// builds the output.
+func (c *Component) graphSankeyExecute(ctx stdcontext.Context, input graphSankeyHandlerInput, sqlQuery string, info queryInfo) (graphSankeyHandlerOutput, error) {
 	results := []struct {
 		Xps        float64  `ch:"xps"`
 		Dimensions []string `ch:"dimensions"`
 	}{}
 	if err := c.d.ClickHouseDB.Conn.Select(ctx, &results, sqlQuery); err != nil {
-		c.r.Err(err).Str("query", sqlQuery).Msg("unable to query database")
-		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to query database."})
-		return
+		return graphSankeyHandlerOutput{}, err
 	}
 
 	// Prepare output
 	output := graphSankeyHandlerOutput{
-		Rows:  make([][]string, 0, len(results)),
-		Xps:   make([]int, 0, len(results)),
-		Nodes: make([]string, 0),
-		Links: make([]sankeyLink, 0),
+		Rows:        make([][]string, 0, len(results)),
+		Xps:         make([]int, 0, len(results)),
+		Nodes:       make([]string, 0),
+		Links:       make([]sankeyLink, 0),
+		Approximate: info.Approximate,
 	}
 	completeName := func(name string, index int) string {
 		return fmt.Sprintf("%s: %s", input.Dimensions[index].String(), name)
@@ -167,6 +170,5 @@ func (c *Component) graphSankeyHandlerFunc(gc *gin.Context) {
 		}
 		return output.Links[i].Xps > output.Links[j].Xps
 	})
-
-	gc.JSON(http.StatusOK, output)
+	return output, nil
 }
@@ -0,0 +1,385 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	stdcontext "context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/console/authentication"
+)
+
+// sqlHandlerInput describes the input for the /sql endpoint.
+type sqlHandlerInput struct {
+	Query             string    `json:"query" binding:"required"`
+	Start             time.Time `json:"start" binding:"required"`
+	End               time.Time `json:"end" binding:"required,gtfield=Start"`
+	Points            uint      `json:"points" binding:"isdefault|min=1,max=2000"`
+	MainTableRequired bool      `json:"main-table-required"`
+	Format            string    `json:"format" binding:"isdefault|oneof=json csv"`
+}
+
+// sqlHandlerOutput describes the JSON output for the /sql endpoint.
+type sqlHandlerOutput struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"`
+}
+
+func (c *Component) sqlHandlerFunc(gc *gin.Context) {
+	ctx := c.t.Context(gc.Request.Context())
+	input, query, ok := c.sqlPrepare(gc)
+	if !ok {
+		return
+	}
+	output, err := c.sqlExecute(ctx, query)
+	if errors.Is(err, errSQLParse) {
+		c.r.Err(err).Msg("unable to parse result")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Unable to parse result."})
+		return
+	} else if err != nil {
+		c.r.Err(err).Msg("unable to query database")
+		gc.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Unable to query database: %s", err)})
+		return
+	}
+
+	if input.Format == "csv" {
+		gc.Render(http.StatusOK, sqlCSVOutput(output))
+		return
+	}
+	gc.JSON(http.StatusOK, output)
+}
+
+// sqlCSVOutput renders the output of an ad-hoc SQL query as CSV.
+type sqlCSVOutput sqlHandlerOutput
+
+// Render writes the output as CSV.
+func (output sqlCSVOutput) Render(w http.ResponseWriter) error {
+	output.WriteContentType(w)
+	cw := csv.NewWriter(w)
+	cw.Write(output.Columns)
+	for _, row := range output.Rows {
+		record := make([]string, len(row))
+		for idx, value := range row {
+			record[idx] = fmt.Sprint(reflect.ValueOf(value).Elem().Interface())
+		}
+		cw.Write(record)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteContentType writes the content type for CSV.
+func (sqlCSVOutput) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+}
+
+// sqlPrepare checks the user is allowed to run ad-hoc SQL queries and
+// returns the parsed input and the finalized query. On error, the response is
+// already sent and false is returned.
+func (c *Component) sqlPrepare(gc *gin.Context) (sqlHandlerInput, string, bool) {
+	user := gc.MustGet("user").(authentication.UserInformation).Login
+	input := sqlHandlerInput{Points: 200, Format: "json"}
+	if !slices.Contains(c.config.SQL.AllowedUsers, user) {
+		gc.JSON(http.StatusForbidden, gin.H{"message": "Not allowed to run SQL queries."})
+		return input, "", false
+	}
+	if err := gc.ShouldBindJSON(&input); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return input, "", false
+	}
+
+	tmpl := fmt.Sprintf("{{ with %s }}%s{{ end }}",
+		templateContext(inputContext{
+			Start:             input.Start,
+			End:               input.End,
+			MainTableRequired: input.MainTableRequired,
+			Points:            input.Points,
+		}),
+		strings.TrimSpace(input.Query))
+	query, _, err := c.executeQueryTemplate(tmpl)
+	if err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return input, "", false
+	}
+	query = strings.TrimSpace(query)
+	if err := c.checkSQLQuery(query); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return input, "", false
+	}
+	query = strings.TrimSuffix(query, ";")
+	gc.Header("X-SQL-Query", query)
+	c.r.Info().Str("user", user).Str("query", query).Msg("execute ad-hoc SQL query")
+	return input, query, true
+}
+
+var errSQLParse = errors.New("cannot parse result")
+
+// sqlExecute executes a finalized ad-hoc SQL query and builds the output.
+func (c *Component) sqlExecute(ctx stdcontext.Context, query string) (sqlHandlerOutput, error) {
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"readonly":             2,
+		"max_result_rows":      c.config.SQL.MaxRows,
+		"result_overflow_mode": "break",
+		"max_execution_time":   uint64(c.config.SQL.MaxExecutionTime.Seconds()),
+		"max_memory_usage":     c.config.SQL.MaxMemoryUsage,
+	}))
+	rows, err := c.d.SQLClickHouseDB.Conn.Query(ctx, query)
+	if err != nil {
+		return sqlHandlerOutput{}, err
+	}
+	defer rows.Close()
+
+	output := sqlHandlerOutput{
+		Columns: rows.Columns(),
+		Rows:    [][]interface{}{},
+	}
+	columnTypes := rows.ColumnTypes()
+	for rows.Next() {
+		if uint64(len(output.Rows)) >= c.config.SQL.MaxRows {
+			output.Truncated = true
+			break
+		}
+		vars := make([]interface{}, len(columnTypes))
+		for i := range columnTypes {
+			vars[i] = reflect.New(columnTypes[i].ScanType()).Interface()
+		}
+		if err := rows.Scan(vars...); err != nil {
+			return sqlHandlerOutput{}, fmt.Errorf("%w: %w", errSQLParse, err)
+		}
+		output.Rows = append(output.Rows, vars)
+	}
+	if err := rows.Err(); err != nil {
+		return sqlHandlerOutput{}, err
+	}
+	return output, nil
+}
+
+// checkSQLQuery checks if an ad-hoc SQL query is acceptable: it should be a
+// single SELECT statement only reading from flows tables, without SETTINGS or
+// FORMAT clauses. Dictionaries can be used through the dictGet() family of
+// functions. This check is
+// conservative and may reject some valid queries. It only provides friendly
+// error messages: the query is executed by a ClickHouse user only allowed to
+// read the flows tables and the dictionaries.
+func (c *Component) checkSQLQuery(query string) error {
+	tokens, err := tokenizeSQL(query)
+	if err != nil {
+		return err
+	}
+	if len(tokens) > 0 && tokens[len(tokens)-1] == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) == 0 {
+		return errors.New("empty query")
+	}
+	if first := strings.ToLower(tokens[0]); first != "select" && first != "with" {
+		return errors.New("only SELECT queries are allowed")
+	}
+
+	// Collect allowed tables: flows tables and common table expressions
+	// defined at the top of the query.
+	allowed := map[string]bool{"flows": true}
+	c.flowsTablesLock.RLock()
+	for _, table := range c.flowsTables {
+		allowed[table.Name] = true
+	}
+	c.flowsTablesLock.RUnlock()
+	if strings.EqualFold(tokens[0], "with") {
+		depth := 0
+	cte:
+		for idx := 1; idx+2 < len(tokens); idx++ {
+			switch {
+			case tokens[idx] == "(":
+				depth++
+			case tokens[idx] == ")":
+				depth--
+			case depth == 0 && strings.EqualFold(tokens[idx], "select"):
+				break cte
+			case depth == 0 && strings.EqualFold(tokens[idx+1], "as") && tokens[idx+2] == "(":
+				allowed[unquoteSQLIdentifier(tokens[idx])] = true
+			}
+		}
+	}
+
+	checkTable := func(idx int, isIn bool) error {
+		if idx >= len(tokens) || tokens[idx] == "(" || !isSQLIdentifier(tokens[idx]) {
+			return nil
+		}
+		next := idx + 1
+		if next < len(tokens) && tokens[next] == "(" {
+			if isIn && sqlInFunctions[strings.ToLower(tokens[idx])] {
+				// Function call, like tuple().
+				return nil
+			}
+			return fmt.Errorf("table function %s is not allowed", tokens[idx])
+		}
+		if next < len(tokens) && tokens[next] == "." {
+			return fmt.Errorf("table %s.%s is not allowed",
+				tokens[idx], tokens[min(next+1, len(tokens)-1)])
+		}
+		if table := unquoteSQLIdentifier(tokens[idx]); !allowed[table] {
+			return fmt.Errorf("table %s is not allowed", table)
+		}
+		return nil
+	}
+	for idx, token := range tokens {
+		switch strings.ToLower(token) {
+		case ";":
+			return errors.New("only a single statement is allowed")
+		case "into":
+			return errors.New("INTO clause is not allowed")
+		case "settings":
+			// Settings would allow a query to lift the limits.
+			return errors.New("SETTINGS clause is not allowed")
+		case "format":
+			if idx+1 < len(tokens) && tokens[idx+1] == "(" {
+				// format() function
+				continue
+			}
+			return errors.New("FORMAT clause is not allowed")
+		case "join":
+			if idx > 0 && strings.EqualFold(tokens[idx-1], "array") {
+				continue
+			}
+			if err := checkTable(idx+1, false); err != nil {
+				return err
+			}
+		case "in":
+			if err := checkTable(idx+1, true); err != nil {
+				return err
+			}
+		case "from":
+			if err := checkTable(idx+1, false); err != nil {
+				return err
+			}
+			// Check the other tables when using the comma syntax.
+			depth := 0
+		outer:
+			for next := idx + 1; next+1 < len(tokens); next++ {
+				switch {
+				case tokens[next] == "(":
+					depth++
+				case tokens[next] == ")":
+					if depth == 0 {
+						break outer
+					}
+					depth--
+				case depth > 0:
+				case tokens[next] == ",":
+					if err := checkTable(next+1, false); err != nil {
+						return err
+					}
+				case sqlFromTerminators[strings.ToLower(tokens[next])]:
+					break outer
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sqlInFunctions is the list of functions accepted after IN.
+var sqlInFunctions = map[string]bool{
+	"tuple": true,
+	"array": true,
+}
+
+// sqlFromTerminators is the list of keywords ending the list of tables in a
+// FROM clause.
+var sqlFromTerminators = map[string]bool{
+	"array":    true,
+	"final":    true,
+	"format":   true,
+	"group":    true,
+	"having":   true,
+	"join":     true,
+	"limit":    true,
+	"order":    true,
+	"prewhere": true,
+	"qualify":  true,
+	"sample":   true,
+	"settings": true,
+	"union":    true,
+	"where":    true,
+	"window":   true,
+}
+
+// tokenizeSQL splits a SQL query into tokens. Comments are discarded and
+// string literals are kept as a single token. Quoted identifiers are kept
+// with their quotes.
+func tokenizeSQL(query string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-',
+			r == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			for i += 2; i+1 < len(runes) && (runes[i] != '*' || runes[i+1] != '/'); i++ {
+			}
+			if i+1 >= len(runes) {
+				return nil, errors.New("unterminated comment")
+			}
+			i += 2
+		case r == '\'' || r == '"' || r == '`':
+			start := i
+			i++
+			for ; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			i++
+			tokens = append(tokens, string(runes[start:i]))
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// isSQLIdentifier tells if a token is an identifier (quoted or not).
+func isSQLIdentifier(token string) bool {
+	if token[0] == '"' || token[0] == '`' {
+		return true
+	}
+	return token[0] == '_' || unicode.IsLetter(rune(token[0]))
+}
+
+// unquoteSQLIdentifier removes quotes around an identifier.
+func unquoteSQLIdentifier(token string) string {
+	if len(token) >= 2 && (token[0] == '"' || token[0] == '`') {
+		return token[1 : len(token)-1]
+	}
+	return token
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package console
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb/mocks"
+	"akvorado/common/helpers"
+)
+
+func TestCheckSQLQuery(t *testing.T) {
+	c, _, _, _ := NewMock(t, DefaultConfiguration())
+	c.flowsTables = []flowsTable{
+		{"flows", 0, time.Time{}},
+		{"flows_1m0s", time.Minute, time.Time{}},
+	}
+	cases := []struct {
+		Query string
+		Error bool
+	}{
+		{"SELECT 1", false},
+		{"SELECT count() FROM flows", false},
+		{"SELECT count() FROM flows_1m0s;", false},
+		{"select SrcAS, SUM(Bytes) FROM `flows` GROUP BY SrcAS", false},
+		{"SELECT dictGet('protocols', 'name', Proto) FROM flows", false},
+		{"SELECT * FROM (SELECT * FROM flows) AS f", false},
+		{"WITH top AS (SELECT SrcAS FROM flows LIMIT 10) SELECT * FROM flows WHERE SrcAS IN top", false},
+		{"SELECT * FROM flows WHERE SrcAS IN (64500, 64501)", false},
+		{"SELECT * FROM flows WHERE SrcAS IN tuple(64500, 64501)", false},
+		{"SELECT 'FROM system.tables' FROM flows", false},
+		{"SELECT 1 -- FROM system.tables", false},
+		{"", true},
+		{"INSERT INTO flows VALUES (1)", true},
+		{"DROP TABLE flows", true},
+		{"SELECT * FROM system.tables", true},
+		{"SELECT * FROM flows_raw_errors", true},
+		{"SELECT * FROM exporters", true},
+		{"SELECT * FROM flows JOIN exporters USING ExporterAddress", true},
+		{"SELECT * FROM flows WHERE SrcAS IN (SELECT asn FROM asns)", true},
+		{"SELECT * FROM url('http://example.com', CSV)", true},
+		{"SELECT * FROM /* comment */ `system`.tables", true},
+		{"SELECT 1; SELECT 2", true},
+		{"SELECT 1 INTO OUTFILE 'test'", true},
+		{"SELECT 'unterminated", true},
+		{"SELECT * FROM flows, system.users", true},
+		{"SELECT * FROM flows AS f, users", true},
+		{"SELECT * FROM (SELECT * FROM flows), `system`.`users`", true},
+		{"SELECT * FROM flows WHERE SrcAS IN url('http://example.com', CSV)", true},
+		{"SELECT * FROM flows WHERE SrcAS IN remote('127.0.0.1', system.users)", true},
+		{"SELECT * FROM flows WHERE SrcAS IN file('users.csv')", true},
+		{"SELECT (WITH users AS (SELECT 1) SELECT 1), * FROM users", true},
+		{"SELECT * FROM flows ARRAY JOIN DstASPath AS asn", false},
+		{"SELECT SrcAS, DstAS FROM flows ORDER BY SrcAS, DstAS", false},
+		{"SELECT format('{} {}', SrcAS, DstAS) FROM flows", false},
+		{"SELECT `settings` FROM flows", false},
+		{"SELECT count() FROM flows SETTINGS max_execution_time = 0", true},
+		{"SELECT count() FROM flows settings max_result_rows=0, max_memory_usage=0", true},
+		{"SELECT * FROM (SELECT * FROM flows SETTINGS max_execution_time = 0)", true},
+		{"SELECT count() FROM flows FORMAT JSON", true},
+	}
+	for _, tc := range cases {
+		err := c.checkSQLQuery(tc.Query)
+		if err == nil && tc.Error {
+			t.Errorf("checkSQLQuery(%q) did not error", tc.Query)
+		} else if err != nil && !tc.Error {
+			t.Errorf("checkSQLQuery(%q) error:\n%+v", tc.Query, err)
+		}
+	}
+}
+
+func TestSQLHandler(t *testing.T) {
+	config := DefaultConfiguration()
+	config.SQL.AllowedUsers = []string{"__default"}
+	config.SQL.MaxRows = 2
+	_, h, mockConn, _ := NewMock(t, config)
+
+	ctrl := gomock.NewController(t)
+	expectQuery := func(rows [][]interface{}) {
+		mockRows := mocks.NewMockRows(ctrl)
+		mockConn.EXPECT().Query(gomock.Any(), `SELECT SrcAS, SUM(Bytes) AS Bytes FROM flows WHERE TimeReceived BETWEEN toDateTime('2022-04-10 15:00:00', 'UTC') AND toDateTime('2022-04-11 15:00:00', 'UTC') GROUP BY SrcAS ORDER BY Bytes DESC`).
+			Return(mockRows, nil)
+		colSrcAS := mocks.NewMockColumnType(ctrl)
+		colBytes := mocks.NewMockColumnType(ctrl)
+		colSrcAS.EXPECT().ScanType().Return(reflect.TypeOf(uint32(0))).AnyTimes()
+		colBytes.EXPECT().ScanType().Return(reflect.TypeOf(uint64(0))).AnyTimes()
+		mockRows.EXPECT().Columns().Return([]string{"SrcAS", "Bytes"})
+		mockRows.EXPECT().ColumnTypes().Return([]driver.ColumnType{colSrcAS, colBytes})
+		for _, row := range rows {
+			mockRows.EXPECT().Next().Return(true)
+			if row == nil {
+				continue
+			}
+			mockRows.EXPECT().Scan(gomock.Any()).
+				DoAndReturn(func(args ...interface{}) interface{} {
+					*args[0].(*uint32) = row[0].(uint32)
+					*args[1].(*uint64) = row[1].(uint64)
+					return nil
+				})
+		}
+		if len(rows) == 0 || rows[len(rows)-1] != nil {
+			mockRows.EXPECT().Next().Return(false)
+		}
+		mockRows.EXPECT().Err().Return(nil)
+		mockRows.EXPECT().Close()
+	}
+	expectQuery([][]interface{}{
+		{uint32(64500), uint64(1000)},
+		{uint32(64501), uint64(500)},
+	})
+	expectQuery([][]interface{}{
+		{uint32(64500), uint64(1000)},
+		{uint32(64501), uint64(500)},
+		nil,
+	})
+
+	query := "SELECT SrcAS, SUM(Bytes) AS Bytes FROM {{ .Table }} WHERE {{ .Timefilter }} GROUP BY SrcAS ORDER BY Bytes DESC"
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "JSON output",
+			URL:         "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query": query,
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			JSONOutput: gin.H{
+				"columns": []string{"SrcAS", "Bytes"},
+				"rows": [][]interface{}{
+					{64500, 1000},
+					{64501, 500},
+				},
+				"truncated": false,
+			},
+		}, {
+			Description: "CSV output, truncated",
+			URL:         "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query":  query,
+				"start":  time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":    time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+				"format": "csv",
+			},
+			ContentType: "text/csv; charset=utf-8",
+			FirstLines: []string{
+				"SrcAS,Bytes",
+				"64500,1000",
+				"64501,500",
+			},
+		}, {
+			Description: "invalid template",
+			URL:         "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query": "SELECT * FROM {{ .Unknown }}",
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{
+				"message": `Template: query:1:112: executing "query" at <.Unknown>: can't evaluate field Unknown in type console.context`,
+			},
+		}, {
+			Description: "forbidden table",
+			URL:         "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query": "SELECT * FROM system.tables",
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "Table system.tables is not allowed"},
+		}, {
+			Description: "settings override",
+			URL:         "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query": "SELECT count() FROM flows SETTINGS max_execution_time=0, max_result_rows=0, max_memory_usage=0",
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			StatusCode: 400,
+			JSONOutput: gin.H{"message": "SETTINGS clause is not allowed"},
+		},
+	})
+}
+
+func TestSQLHandlerForbidden(t *testing.T) {
+	_, h, _, _ := NewMock(t, DefaultConfiguration())
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL: "/api/v0/console/sql",
+			JSONInput: gin.H{
+				"query": "SELECT 1",
+				"start": time.Date(2022, 4, 10, 15, 0, 0, 0, time.UTC),
+				"end":   time.Date(2022, 4, 11, 15, 0, 0, 0, time.UTC),
+			},
+			StatusCode: 403,
+			JSONOutput: gin.H{"message": "Not allowed to run SQL queries."},
+		},
+	})
+}
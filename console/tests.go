@@ -29,13 +29,14 @@ func NewMock(t *testing.T, config Configuration) (*Component, *httpserver.Compon
 	ch, mockConn := clickhousedb.NewMock(t, r)
 	mockClock := clock.NewMock()
 	c, err := New(r, config, Dependencies{
-		Daemon:       daemon.NewMock(t),
-		HTTP:         h,
-		ClickHouseDB: ch,
-		Clock:        mockClock,
-		Auth:         authentication.NewMock(t, r),
-		Database:     database.NewMock(t, r, database.DefaultConfiguration()),
-		Schema:       schema.NewMock(t),
+		Daemon:          daemon.NewMock(t),
+		HTTP:            h,
+		ClickHouseDB:    ch,
+		SQLClickHouseDB: ch,
+		Clock:           mockClock,
+		Auth:            authentication.NewMock(t, r),
+		Database:        database.NewMock(t, r, database.DefaultConfiguration()),
+		Schema:          schema.NewMock(t),
 	})
 	if err != nil {
 		t.Fatalf("New() error:\n%+v", err)
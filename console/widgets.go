@@ -136,10 +136,10 @@ type topResult struct {
 func (c *Component) widgetTopHandlerFunc(gc *gin.Context) {
 	ctx := c.t.Context(gc.Request.Context())
 	var (
-		selector          string
-		groupby           string
-		filter            string
-		mainTableRequired bool
+		selector string
+		groupby  string
+		filter   string
+		columns  []string
 	)
 
 	type URIParams struct {
@@ -176,11 +176,11 @@ func (c *Component) widgetTopHandlerFunc(gc *gin.Context) {
 	case HomepageTopWidgetSrcPort:
 		selector = fmt.Sprintf(`concat(dictGetOrDefault('%s', 'name', Proto, '???'), '/', toString(SrcPort))`, schema.DictionaryProtocols)
 		groupby = `Proto, SrcPort`
-		mainTableRequired = true
+		columns = []string{"Proto", "SrcPort"}
 	case HomepageTopWidgetDstPort:
 		selector = fmt.Sprintf(`concat(dictGetOrDefault('%s', 'name', Proto, '???'), '/', toString(DstPort))`, schema.DictionaryProtocols)
 		groupby = `Proto, DstPort`
-		mainTableRequired = true
+		columns = []string{"Proto", "DstPort"}
 	}
 	if strings.HasPrefix(gc.Param("name"), "src-") {
 		filter = "AND InIfBoundary = 'external'"
@@ -207,10 +207,10 @@ ORDER BY Percent DESC
 LIMIT 5
 {{ end }}`,
 		templateContext(inputContext{
-			Start:             now.Add(-5 * time.Minute),
-			End:               now,
-			MainTableRequired: mainTableRequired,
-			Points:            5,
+			Start:   now.Add(-5 * time.Minute),
+			End:     now,
+			Columns: columns,
+			Points:  5,
 		}),
 		filter, selector, selector, filter, groupby))
 	gc.Header("X-SQL-Query", query)
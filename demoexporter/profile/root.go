@@ -0,0 +1,368 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package profile builds a demo exporter flow configuration reproducing the
+// traffic observed in ClickHouse.
+package profile
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/netip"
+	"slices"
+	"strings"
+	"time"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/demoexporter/flows"
+)
+
+// Options describes how to build a profile.
+type Options struct {
+	// Period is how far back flows should be analyzed.
+	Period time.Duration
+	// Top is the number of flow configurations to generate. Each of them
+	// matches a combination of remote AS, remote country, protocol and
+	// service port.
+	Top int
+	// Scale is applied to the observed packet rate.
+	Scale float64
+	// Target is the target for the generated flows.
+	Target string
+	// ExternalIfIndex is the interface index for traffic coming from or going
+	// to the outside.
+	ExternalIfIndex int
+	// InternalIfIndex is the interface index for traffic coming from or going
+	// to the inside.
+	InternalIfIndex int
+}
+
+// DefaultOptions returns the default options to build a profile.
+func DefaultOptions() Options {
+	return Options{
+		Period:          7 * 24 * time.Hour,
+		Top:             20,
+		Scale:           1,
+		Target:          "127.0.0.1:2055",
+		ExternalIfIndex: 10,
+		InternalIfIndex: 20,
+	}
+}
+
+// Our own network is replaced by the same networks as in the shipped demo
+// configuration. When a remote network cannot be found for a given AS and
+// country, we use documentation prefixes.
+var (
+	internalAS       uint32 = 64501
+	internalNetworks        = [2]netip.Prefix{
+		netip.MustParsePrefix("192.0.2.0/24"),
+		netip.MustParsePrefix("2a01:db8:cafe:1::/64"),
+	}
+	fallbackNetworks = [2]netip.Prefix{
+		netip.MustParsePrefix("198.51.100.0/24"),
+		netip.MustParsePrefix("2001:db8::/64"),
+	}
+)
+
+// ErrNoFlows is returned when there are no flows to build a profile from.
+var ErrNoFlows = errors.New("no flows found for the requested period")
+
+// trafficShape describes how traffic evolves during the day.
+type trafficShape struct {
+	// PeakHour is the hour with the most traffic.
+	PeakHour time.Duration
+	// Multiplier is the ratio between the peak and the trough.
+	Multiplier float64
+	// TroughRatio is the ratio between the trough and the average.
+	TroughRatio float64
+	// SamplingRate is the average sampling rate.
+	SamplingRate int
+}
+
+// hourlyTraffic is the traffic for one hour of the day.
+type hourlyTraffic struct {
+	Hour           uint8  `ch:"Hour"`
+	Packets        uint64 `ch:"Packets"`
+	SampledPackets uint64 `ch:"SampledPackets"`
+}
+
+// flowGroup is the traffic for a combination of direction, address family,
+// protocol, remote AS, remote country, and service ports. Ports above 1023
+// are set to 0 as the demo exporter picks a random port for them.
+type flowGroup struct {
+	Inbound       uint8   `ch:"Inbound"`
+	IPv6          uint8   `ch:"IPv6"`
+	Proto         uint8   `ch:"Proto"`
+	RemoteAS      uint32  `ch:"RemoteAS"`
+	RemoteCountry string  `ch:"RemoteCountry"`
+	SrcPort       uint16  `ch:"ServiceSrcPort"`
+	DstPort       uint16  `ch:"ServiceDstPort"`
+	PacketRate    float64 `ch:"PacketRate"`
+	PacketSize    float64 `ch:"PacketSize"`
+}
+
+// flowsTable is a consolidated flows table. Like in the console, its
+// resolution is extracted from its name.
+type flowsTable struct {
+	Name       string
+	Resolution time.Duration
+	Oldest     time.Time
+	Columns    []string
+}
+
+// tableColumns is the list of columns of a flows table.
+type tableColumns struct {
+	Table   string   `ch:"table"`
+	Columns []string `ch:"columns"`
+}
+
+// oldestFlow is the timestamp of the oldest flow in a table.
+type oldestFlow struct {
+	T time.Time `ch:"t"`
+}
+
+// maxResolution is the coarsest resolution of the consolidated tables we can
+// use as the traffic shape is computed hour by hour.
+const maxResolution = time.Hour
+
+// dictionaryNetwork is a network from the networks dictionary.
+type dictionaryNetwork struct {
+	Network string `ch:"network"`
+}
+
+// Generate queries ClickHouse to build a flow configuration for the demo
+// exporter. Our own addresses and AS numbers are replaced by documentation
+// ones, while remote addresses are replaced by a network from the same AS and
+// country, as found in the networks dictionary.
+func Generate(ctx context.Context, db *clickhousedb.Component, options Options) (flows.Configuration, error) {
+	config := flows.DefaultConfiguration()
+	config.Target = options.Target
+	if options.Period < time.Hour {
+		return config, errors.New("period should be at least one hour")
+	}
+	if options.Top < 1 {
+		return config, errors.New("at least one flow configuration should be generated")
+	}
+	seconds := int64(options.Period.Seconds())
+	start := time.Now().Add(-options.Period)
+
+	tables, err := queryFlowsTables(ctx, db)
+	if err != nil {
+		return config, err
+	}
+	shape, err := queryTrafficShape(ctx, db,
+		selectTable(tables, start, "TimeReceived", "Packets", "SamplingRate"), seconds)
+	if err != nil {
+		return config, err
+	}
+	config.SamplingRate = shape.SamplingRate
+
+	table := selectTable(tables, start,
+		"TimeReceived", "InIfBoundary", "EType", "Proto", "SrcAS", "DstAS",
+		"SrcCountry", "DstCountry", "SrcPort", "DstPort", "Bytes", "Packets")
+	var groups []flowGroup
+	if err := db.Select(ctx, &groups, fmt.Sprintf(`
+SELECT
+ toUInt8(InIfBoundary = 'external') AS Inbound,
+ toUInt8(EType = 0x86dd) AS IPv6,
+ Proto,
+ if(Inbound = 1, SrcAS, DstAS) AS RemoteAS,
+ toString(if(Inbound = 1, SrcCountry, DstCountry)) AS RemoteCountry,
+ toUInt16(if(SrcPort < 1024, SrcPort, 0)) AS ServiceSrcPort,
+ toUInt16(if(DstPort < 1024, DstPort, 0)) AS ServiceDstPort,
+ SUM(Packets) / %d AS PacketRate,
+ SUM(Bytes) / SUM(Packets) AS PacketSize
+FROM %s
+WHERE TimeReceived > date_sub(second, %d, now())
+AND Proto IN (1, 6, 17, 58)
+GROUP BY Inbound, IPv6, Proto, RemoteAS, RemoteCountry, ServiceSrcPort, ServiceDstPort
+ORDER BY PacketRate DESC
+LIMIT %d`, seconds, table, seconds, options.Top)); err != nil {
+		return config, fmt.Errorf("cannot query flow distribution: %w", err)
+	}
+
+	remoteNetworks := map[string]netip.Prefix{}
+	for _, group := range groups {
+		perSecond := math.Round(group.PacketRate*shape.TroughRatio*options.Scale*1000) / 1000
+		if perSecond <= 0 {
+			continue
+		}
+		family := int(group.IPv6)
+		country := strings.TrimRight(group.RemoteCountry, "\x00")
+		key := fmt.Sprintf("%d-%s-%d", group.RemoteAS, country, family)
+		remoteNetwork, ok := remoteNetworks[key]
+		if !ok {
+			remoteNetwork, err = lookupNetwork(ctx, db, group.RemoteAS, country, family)
+			if err != nil {
+				return config, err
+			}
+			remoteNetworks[key] = remoteNetwork
+		}
+		flow := flows.FlowConfiguration{
+			PerSecond:  perSecond,
+			InIfIndex:  []int{options.InternalIfIndex},
+			OutIfIndex: []int{options.ExternalIfIndex},
+			PeakHour:   shape.PeakHour,
+			Multiplier: shape.Multiplier,
+			SrcNet:     internalNetworks[family],
+			DstNet:     remoteNetwork,
+			SrcAS:      []uint32{internalAS},
+			DstAS:      []uint32{group.RemoteAS},
+			SrcPort:    []uint16{group.SrcPort},
+			DstPort:    []uint16{group.DstPort},
+			Protocol:   []string{protocolName(group.Proto)},
+			Size:       uint(min(max(math.Round(group.PacketSize), 64), 9000)),
+		}
+		if group.Inbound == 1 {
+			flow.InIfIndex, flow.OutIfIndex = flow.OutIfIndex, flow.InIfIndex
+			flow.SrcNet, flow.DstNet = flow.DstNet, flow.SrcNet
+			flow.SrcAS, flow.DstAS = flow.DstAS, flow.SrcAS
+		}
+		config.Flows = append(config.Flows, flow)
+	}
+	if len(config.Flows) == 0 {
+		return config, ErrNoFlows
+	}
+	return config, nil
+}
+
+// queryFlowsTables returns the consolidated flows tables with a resolution of
+// at most one hour, the coarsest first.
+func queryFlowsTables(ctx context.Context, db *clickhousedb.Component) ([]flowsTable, error) {
+	var columns []tableColumns
+	if err := db.Select(ctx, &columns, `
+SELECT table, groupArray(name) AS columns
+FROM system.columns
+WHERE database=currentDatabase()
+AND table LIKE 'flows_%'
+AND table NOT LIKE '%_local'
+GROUP BY table`); err != nil {
+		return nil, fmt.Errorf("cannot query flows tables: %w", err)
+	}
+	tables := []flowsTable{}
+	for _, table := range columns {
+		// This also excludes tables like flows_raw_errors.
+		resolution, err := time.ParseDuration(strings.TrimPrefix(table.Table, "flows_"))
+		if err != nil || resolution <= 0 || resolution > maxResolution {
+			continue
+		}
+		var oldest []oldestFlow
+		if err := db.Select(ctx, &oldest,
+			fmt.Sprintf(`SELECT MIN(TimeReceived) AS t FROM %s`, table.Table)); err != nil {
+			return nil, fmt.Errorf("cannot query table %s for oldest timestamp: %w", table.Table, err)
+		}
+		if len(oldest) == 0 {
+			continue
+		}
+		tables = append(tables, flowsTable{
+			Name:       table.Table,
+			Resolution: resolution,
+			Oldest:     oldest[0].T,
+			Columns:    table.Columns,
+		})
+	}
+	slices.SortFunc(tables, func(a, b flowsTable) int {
+		return cmp.Compare(b.Resolution, a.Resolution)
+	})
+	return tables, nil
+}
+
+// selectTable returns the consolidated table with the coarsest resolution
+// covering the period starting at the provided time and containing the
+// provided columns. It falls back to the main table. Notably, ports are only
+// present in the main table by default.
+func selectTable(tables []flowsTable, start time.Time, columns ...string) string {
+outer:
+	for _, table := range tables {
+		if !start.After(table.Oldest.Add(table.Resolution)) {
+			continue
+		}
+		for _, column := range columns {
+			if !slices.Contains(table.Columns, column) {
+				continue outer
+			}
+		}
+		return table.Name
+	}
+	return "flows"
+}
+
+// queryTrafficShape computes the traffic shape from the hourly traffic in the
+// provided table. Hours are in UTC, like for the demo exporter.
+func queryTrafficShape(ctx context.Context, db *clickhousedb.Component, table string, seconds int64) (trafficShape, error) {
+	var hours []hourlyTraffic
+	if err := db.Select(ctx, &hours, fmt.Sprintf(`
+SELECT
+ toHour(TimeReceived, 'UTC') AS Hour,
+ SUM(Packets) AS Packets,
+ SUM(Packets * SamplingRate) AS SampledPackets
+FROM %s
+WHERE TimeReceived > date_sub(second, %d, now())
+GROUP BY Hour
+ORDER BY Hour`, table, seconds)); err != nil {
+		return trafficShape{}, fmt.Errorf("cannot query traffic shape: %w", err)
+	}
+	var total, sampled, peak uint64
+	count := 0
+	trough := uint64(math.MaxUint64)
+	shape := trafficShape{}
+	for _, hour := range hours {
+		if hour.Packets == 0 {
+			continue
+		}
+		count++
+		total += hour.Packets
+		sampled += hour.SampledPackets
+		if hour.Packets > peak {
+			peak = hour.Packets
+			shape.PeakHour = time.Duration(hour.Hour) * time.Hour
+		}
+		trough = min(trough, hour.Packets)
+	}
+	if total == 0 {
+		return trafficShape{}, ErrNoFlows
+	}
+	shape.Multiplier = math.Round(float64(peak)/float64(trough)*100) / 100
+	shape.TroughRatio = float64(trough) * float64(count) / float64(total)
+	shape.SamplingRate = max(1, int(math.Round(float64(sampled)/float64(total))))
+	return shape, nil
+}
+
+// lookupNetwork returns a network from the networks dictionary matching the
+// provided AS number, country, and address family (0 for IPv4, 1 for IPv6).
+func lookupNetwork(ctx context.Context, db *clickhousedb.Component, asn uint32, country string, family int) (netip.Prefix, error) {
+	if asn == 0 && country == "" {
+		return fallbackNetworks[family], nil
+	}
+	var networks []dictionaryNetwork
+	if err := db.Select(ctx, &networks, `
+SELECT network
+FROM dictionary('networks')
+WHERE asn = ? AND country = ? AND toUInt8(position(network, ':') > 0) = ?
+ORDER BY network
+LIMIT 1`, asn, country, uint8(family)); err != nil {
+		return netip.Prefix{}, fmt.Errorf("cannot query networks dictionary: %w", err)
+	}
+	if len(networks) == 0 {
+		return fallbackNetworks[family], nil
+	}
+	network, err := netip.ParsePrefix(networks[0].Network)
+	if err != nil {
+		return fallbackNetworks[family], nil
+	}
+	return network, nil
+}
+
+// protocolName returns the protocol name understood by the demo exporter.
+func protocolName(proto uint8) string {
+	switch proto {
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	}
+	return "icmp"
+}
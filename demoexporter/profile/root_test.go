@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package profile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/mock/gomock"
+
+	"akvorado/common/clickhousedb"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/demoexporter/flows"
+)
+
+func TestGenerate(t *testing.T) {
+	r := reporter.NewMock(t)
+	db, mockConn := clickhousedb.NewMock(t, r)
+
+	// Traffic peaks at 20:00 with three times more traffic than at 04:00.
+	hours := []hourlyTraffic{}
+	for hour := range 24 {
+		distance := hour - 20
+		if distance < 0 {
+			distance = -distance
+		}
+		if distance > 12 {
+			distance = 24 - distance
+		}
+		packets := uint64(3000 - 2000*distance/8)
+		if distance > 8 {
+			packets = 1000
+		}
+		hours = append(hours, hourlyTraffic{uint8(hour), packets, packets * 1000})
+	}
+	// Ports are only present in the 1-minute consolidated table. The 1-day
+	// one is too coarse. The 5-minute one does not cover the whole period.
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Cond(func(query string) bool {
+			return strings.Contains(query, "FROM system.columns")
+		})).
+		SetArg(1, []tableColumns{
+			{"flows_raw_errors", []string{"TimeReceived"}},
+			{"flows_1h0m0s", []string{"TimeReceived", "SamplingRate", "InIfBoundary", "EType", "Proto",
+				"SrcAS", "DstAS", "SrcCountry", "DstCountry", "Bytes", "Packets"}},
+			{"flows_1m0s", []string{"TimeReceived", "SamplingRate", "InIfBoundary", "EType", "Proto",
+				"SrcAS", "DstAS", "SrcCountry", "DstCountry", "SrcPort", "DstPort", "Bytes", "Packets"}},
+			{"flows_5m0s", []string{"TimeReceived", "SamplingRate", "InIfBoundary", "EType", "Proto",
+				"SrcAS", "DstAS", "SrcCountry", "DstCountry", "SrcPort", "DstPort", "Bytes", "Packets"}},
+			{"flows_24h0m0s", []string{"TimeReceived", "SamplingRate", "Packets"}},
+		}).
+		Return(nil)
+	for table, oldest := range map[string]time.Duration{
+		"flows_1h0m0s": 30 * 24 * time.Hour,
+		"flows_1m0s":   7 * 24 * time.Hour,
+		"flows_5m0s":   12 * time.Hour,
+	} {
+		mockConn.EXPECT().
+			Select(gomock.Any(), gomock.Any(), fmt.Sprintf("SELECT MIN(TimeReceived) AS t FROM %s", table)).
+			SetArg(1, []oldestFlow{{time.Now().Add(-oldest)}}).
+			Return(nil)
+	}
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Cond(func(query string) bool {
+			return strings.Contains(query, "toHour(TimeReceived, 'UTC') AS Hour") &&
+				strings.Contains(query, "FROM flows_1h0m0s\n") &&
+				strings.Contains(query, "date_sub(second, 86400, now())")
+		})).
+		SetArg(1, hours).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Cond(func(query string) bool {
+			return strings.Contains(query, "GROUP BY Inbound, IPv6") &&
+				strings.Contains(query, "FROM flows_1m0s\n") &&
+				strings.Contains(query, "LIMIT 4")
+		})).
+		SetArg(1, []flowGroup{
+			{1, 0, 6, 15169, "US", 443, 0, 100, 1300},
+			{1, 1, 17, 15169, "US", 443, 0, 50, 1200.4},
+			{0, 0, 6, 2906, "NL", 0, 22, 10, 20},
+			{1, 0, 6, 0, "\x00\x00", 0, 0, 0.00001, 500},
+		}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(), uint32(15169), "US", uint8(0)).
+		SetArg(1, []dictionaryNetwork{{"216.58.206.0/24"}}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(), uint32(15169), "US", uint8(1)).
+		SetArg(1, []dictionaryNetwork{{"2a00:1450:4007:807::/64"}}).
+		Return(nil)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any(), uint32(2906), "NL", uint8(0)).
+		Return(nil)
+
+	options := DefaultOptions()
+	options.Period = 24 * time.Hour
+	options.Top = 4
+	options.Scale = 0.5
+	got, err := Generate(context.Background(), db, options)
+	if err != nil {
+		t.Fatalf("Generate() error:\n%+v", err)
+	}
+	// The trough is 1000 packets while the average is about 1667 packets.
+	expected := flows.Configuration{
+		SamplingRate: 1000,
+		Target:       "127.0.0.1:2055",
+		Flows: []flows.FlowConfiguration{
+			{
+				PerSecond:  30,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				PeakHour:   20 * time.Hour,
+				Multiplier: 3,
+				SrcNet:     netip.MustParsePrefix("216.58.206.0/24"),
+				DstNet:     netip.MustParsePrefix("192.0.2.0/24"),
+				SrcAS:      []uint32{15169},
+				DstAS:      []uint32{64501},
+				SrcPort:    []uint16{443},
+				DstPort:    []uint16{0},
+				Protocol:   []string{"tcp"},
+				Size:       1300,
+			}, {
+				PerSecond:  15,
+				InIfIndex:  []int{10},
+				OutIfIndex: []int{20},
+				PeakHour:   20 * time.Hour,
+				Multiplier: 3,
+				SrcNet:     netip.MustParsePrefix("2a00:1450:4007:807::/64"),
+				DstNet:     netip.MustParsePrefix("2a01:db8:cafe:1::/64"),
+				SrcAS:      []uint32{15169},
+				DstAS:      []uint32{64501},
+				SrcPort:    []uint16{443},
+				DstPort:    []uint16{0},
+				Protocol:   []string{"udp"},
+				Size:       1200,
+			}, {
+				PerSecond:  3,
+				InIfIndex:  []int{20},
+				OutIfIndex: []int{10},
+				PeakHour:   20 * time.Hour,
+				Multiplier: 3,
+				SrcNet:     netip.MustParsePrefix("192.0.2.0/24"),
+				DstNet:     netip.MustParsePrefix("198.51.100.0/24"),
+				SrcAS:      []uint32{64501},
+				DstAS:      []uint32{2906},
+				SrcPort:    []uint16{0},
+				DstPort:    []uint16{22},
+				Protocol:   []string{"tcp"},
+				Size:       64,
+			},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Generate() (-got, +want):\n%s", diff)
+	}
+	if err := helpers.Validate.Struct(got); err != nil {
+		t.Fatalf("Validate() error:\n%+v", err)
+	}
+}
+
+func TestGenerateWithoutFlows(t *testing.T) {
+	r := reporter.NewMock(t)
+	db, mockConn := clickhousedb.NewMock(t, r)
+	mockConn.EXPECT().
+		Select(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		Times(2)
+
+	_, err := Generate(context.Background(), db, DefaultOptions())
+	if !errors.Is(err, ErrNoFlows) {
+		t.Fatalf("Generate() error:\n%+v", err)
+	}
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package archive
+
+import "time"
+
+// Configuration describes the configuration for the archive component.
+type Configuration struct {
+	// Enable turns on writing enriched flows to local files.
+	Enable bool
+	// Exclusive tells to only write flows to files and to not send them to
+	// Kafka.
+	Exclusive bool
+	// Directory is the directory where files are written.
+	Directory string `validate:"required_if=Enable true"`
+	// Format is the format of the files (ndjson or parquet).
+	Format string `validate:"oneof=ndjson parquet"`
+	// Compression is the compression algorithm to use (none, gzip, or zstd).
+	Compression string `validate:"oneof=none gzip zstd"`
+	// RotateSize is the amount of flow data (in its uncompressed protobuf
+	// representation) after which a file is rotated.
+	RotateSize int64 `validate:"min=1000"`
+	// RotateInterval is the maximum time a file is kept open.
+	RotateInterval time.Duration `validate:"min=1s"`
+	// MaxFiles is the maximum number of files to keep (0 for unlimited).
+	MaxFiles int `validate:"min=0"`
+	// MaxTotalSize is the maximum size of all the files to keep (0 for
+	// unlimited).
+	MaxTotalSize int64 `validate:"min=0"`
+	// MaxAge is the maximum age of a file to keep (0 for unlimited).
+	MaxAge time.Duration `validate:"min=0"`
+	// Fsync tells when to sync data to disk: never (let the OS decide),
+	// rotate (when a file is closed), or interval (periodically and when a
+	// file is closed).
+	Fsync string `validate:"oneof=never rotate interval"`
+	// FsyncInterval is the interval between two syncs when Fsync is interval.
+	FsyncInterval time.Duration `validate:"min=100ms"`
+	// QueueSize defines the number of flows to buffer before dropping them.
+	QueueSize int `validate:"min=1"`
+}
+
+// DefaultConfiguration represents the default configuration for the archive component.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Enable:         false,
+		Format:         "ndjson",
+		Compression:    "zstd",
+		RotateSize:     256 * 1024 * 1024,
+		RotateInterval: time.Hour,
+		Fsync:          "rotate",
+		FsyncInterval:  10 * time.Second,
+		QueueSize:      10000,
+	}
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package archive
+
+import "akvorado/common/reporter"
+
+type metrics struct {
+	records      *reporter.CounterVec
+	dropped      *reporter.CounterVec
+	files        reporter.Counter
+	removedFiles reporter.Counter
+	errors       *reporter.CounterVec
+	queueLength  reporter.GaugeFunc
+}
+
+func (c *Component) initMetrics() {
+	c.metrics.records = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "records_total",
+			Help: "Number of flows written from a given exporter.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.dropped = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "dropped_records_total",
+			Help: "Number of flows from a given exporter dropped because the queue was full.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.files = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "files_total",
+			Help: "Number of archive files completed.",
+		},
+	)
+	c.metrics.removedFiles = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "removed_files_total",
+			Help: "Number of archive files removed by the retention policy.",
+		},
+	)
+	c.metrics.errors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors when archiving flows.",
+		},
+		[]string{"error"},
+	)
+	c.metrics.queueLength = c.r.GaugeFunc(
+		reporter.GaugeOpts{
+			Name: "queue_length",
+			Help: "Number of flows waiting to be written.",
+		},
+		func() float64 {
+			return float64(len(c.queue))
+		},
+	)
+}
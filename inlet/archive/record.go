@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package archive
+
+import (
+	"errors"
+	"net/netip"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"akvorado/common/schema"
+)
+
+// recordField tells how to decode a field of the protobuf representation of
+// a flow.
+type recordField struct {
+	name     string
+	kind     protoreflect.Kind
+	enum     map[int]string
+	repeated bool
+}
+
+// recordDecoder turns the protobuf representation of a flow into a record
+// mapping column names to values. Integers are decoded as uint64, IP addresses
+// and enums as strings, and repeated fields as slices of uint64.
+type recordDecoder struct {
+	fields map[protowire.Number]recordField
+	names  []string // in schema order
+}
+
+var errInvalidRecord = errors.New("invalid protobuf record")
+
+func newRecordDecoder(sch *schema.Component) *recordDecoder {
+	d := recordDecoder{
+		fields: map[protowire.Number]recordField{},
+	}
+	for _, column := range sch.Columns() {
+		for _, column := range append([]schema.Column{column}, column.ClickHouseTransformFrom...) {
+			if column.ProtobufIndex <= 0 || column.Disabled {
+				continue
+			}
+			d.fields[column.ProtobufIndex] = recordField{
+				name:     column.Name,
+				kind:     column.ProtobufType,
+				enum:     column.ProtobufEnum,
+				repeated: column.ProtobufRepeated,
+			}
+			d.names = append(d.names, column.Name)
+		}
+	}
+	return &d
+}
+
+// decode decodes a length-prefixed protobuf message, as produced by
+// ProtobufMarshal().
+func (d *recordDecoder) decode(buf []byte) (map[string]any, error) {
+	size, n := protowire.ConsumeVarint(buf)
+	if n < 0 || uint64(len(buf)-n) != size {
+		return nil, errInvalidRecord
+	}
+	buf = buf[n:]
+	record := map[string]any{}
+	for len(buf) > 0 {
+		num, typ, n := protowire.ConsumeTag(buf)
+		if n < 0 {
+			return nil, errInvalidRecord
+		}
+		buf = buf[n:]
+		field, ok := d.fields[num]
+		if !ok {
+			n = protowire.ConsumeFieldValue(num, typ, buf)
+			if n < 0 {
+				return nil, errInvalidRecord
+			}
+			buf = buf[n:]
+			continue
+		}
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(buf)
+			if n < 0 {
+				return nil, errInvalidRecord
+			}
+			buf = buf[n:]
+			switch {
+			case field.repeated:
+				current, _ := record[field.name].([]uint64)
+				record[field.name] = append(current, v)
+			case field.kind == protoreflect.EnumKind:
+				record[field.name] = strings.ToLower(field.enum[int(v)])
+			default:
+				record[field.name] = v
+			}
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(buf)
+			if n < 0 {
+				return nil, errInvalidRecord
+			}
+			buf = buf[n:]
+			switch {
+			case field.repeated:
+				// Packed repeated integers
+				current, _ := record[field.name].([]uint64)
+				for len(v) > 0 {
+					i, n := protowire.ConsumeVarint(v)
+					if n < 0 {
+						return nil, errInvalidRecord
+					}
+					current = append(current, i)
+					v = v[n:]
+				}
+				record[field.name] = current
+			case field.kind == protoreflect.BytesKind:
+				ip, ok := netip.AddrFromSlice(v)
+				if !ok {
+					return nil, errInvalidRecord
+				}
+				record[field.name] = ip.Unmap().String()
+			default:
+				record[field.name] = string(v)
+			}
+		default:
+			return nil, errInvalidRecord
+		}
+	}
+	return record, nil
+}
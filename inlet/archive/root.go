@@ -0,0 +1,352 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package archive writes enriched flows to rotating local files.
+package archive
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers/rotatingfile"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+// Component represents the archive component.
+type Component struct {
+	r      *reporter.Reporter
+	d      *Dependencies
+	t      tomb.Tomb
+	config Configuration
+
+	decoder   *recordDecoder
+	queue     chan queuedFlow
+	directory *rotatingfile.Directory
+	errLogger reporter.Logger
+	metrics   metrics
+
+	// Only accessed from the main goroutine
+	current *archiveFile
+}
+
+// Dependencies define the dependencies of the archive component.
+type Dependencies struct {
+	Daemon daemon.Component
+	Schema *schema.Component
+	Clock  clock.Clock
+}
+
+type queuedFlow struct {
+	exporter string
+	buf      []byte
+}
+
+// manifest describes a closed archive file. It is written next to the file.
+type manifest struct {
+	File        string    `json:"file"`
+	Format      string    `json:"format"`
+	Compression string    `json:"compression"`
+	Records     uint64    `json:"records"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Created     time.Time `json:"created"`
+	Closed      time.Time `json:"closed"`
+	FirstFlow   time.Time `json:"first-flow"`
+	LastFlow    time.Time `json:"last-flow"`
+	Schema      string    `json:"schema"`
+	Columns     []string  `json:"columns"`
+}
+
+// archiveFile is the file currently written.
+type archiveFile struct {
+	file     *rotatingfile.File
+	output   *countingWriter
+	writer   recordWriter
+	size     int64 // uncompressed size
+	lastSync time.Time
+	manifest manifest
+}
+
+// countingWriter counts and hashes bytes written to the underlying writer.
+type countingWriter struct {
+	w     io.Writer
+	hash  hash.Hash
+	count int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.hash.Write(p[:n])
+	cw.count += int64(n)
+	return n, err
+}
+
+const manifestSuffix = ".manifest.json"
+
+// New creates a new archive component.
+func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	if dependencies.Clock == nil {
+		dependencies.Clock = clock.New()
+	}
+	c := Component{
+		r:      r,
+		d:      &dependencies,
+		config: configuration,
+
+		decoder:   newRecordDecoder(dependencies.Schema),
+		queue:     make(chan queuedFlow, configuration.QueueSize),
+		errLogger: r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+	}
+	c.directory = rotatingfile.New(configuration.Directory, "flows-", "."+c.extension())
+	c.initMetrics()
+	c.d.Daemon.Track(&c.t, "inlet/archive")
+	return &c, nil
+}
+
+// Start starts the archive component.
+func (c *Component) Start() error {
+	c.r.Info().Str("directory", c.config.Directory).Msg("starting archive component")
+	if err := os.MkdirAll(c.config.Directory, 0o755); err != nil {
+		return fmt.Errorf("cannot create archive directory: %w", err)
+	}
+	c.applyRetention()
+
+	c.t.Go(func() error {
+		rotatingfile.Run(&c.t, c.d.Clock, c.queue, c.write, c.tick, c.rotate)
+		return nil
+	})
+	return nil
+}
+
+// Stop stops the archive component.
+func (c *Component) Stop() error {
+	defer c.r.Info().Msg("archive component stopped")
+	c.r.Info().Msg("stopping archive component")
+	c.t.Kill(nil)
+	return c.t.Wait()
+}
+
+// Send queues a flow to be written. It never blocks: when the queue is full,
+// the flow is dropped. The provided buffer is not modified.
+func (c *Component) Send(exporter string, buf []byte) {
+	select {
+	case c.queue <- queuedFlow{exporter, buf}:
+	default:
+		c.metrics.dropped.WithLabelValues(exporter).Inc()
+	}
+}
+
+// write writes a flow to the current file, opening a new one if needed.
+func (c *Component) write(flow queuedFlow) {
+	record, err := c.decoder.decode(flow.buf)
+	if err != nil {
+		c.metrics.errors.WithLabelValues("decode").Inc()
+		c.errLogger.Err(err).Str("exporter", flow.exporter).Msg("cannot decode flow")
+		return
+	}
+	if c.current == nil {
+		if err := c.open(); err != nil {
+			c.metrics.errors.WithLabelValues("open").Inc()
+			c.errLogger.Err(err).Msg("cannot open archive file")
+			return
+		}
+	}
+	if err := c.current.writer.Write(record); err != nil {
+		c.metrics.errors.WithLabelValues("write").Inc()
+		c.errLogger.Err(err).Str("file", c.current.file.Name).Msg("cannot write flow")
+		c.rotate()
+		return
+	}
+	c.metrics.records.WithLabelValues(flow.exporter).Inc()
+	c.current.size += int64(len(flow.buf))
+	c.current.manifest.Records++
+	if received, ok := record[schema.ColumnTimeReceived.String()].(uint64); ok {
+		received := time.Unix(int64(received), 0).UTC()
+		if c.current.manifest.FirstFlow.IsZero() || received.Before(c.current.manifest.FirstFlow) {
+			c.current.manifest.FirstFlow = received
+		}
+		if received.After(c.current.manifest.LastFlow) {
+			c.current.manifest.LastFlow = received
+		}
+	}
+	if c.current.size >= c.config.RotateSize {
+		c.rotate()
+	}
+}
+
+// tick handles time-based rotation and syncing.
+func (c *Component) tick() {
+	if c.current == nil {
+		return
+	}
+	now := c.d.Clock.Now()
+	if now.Sub(c.current.manifest.Created) >= c.config.RotateInterval {
+		c.rotate()
+		return
+	}
+	if c.config.Fsync == "interval" && now.Sub(c.current.lastSync) >= c.config.FsyncInterval {
+		c.current.lastSync = now
+		if err := c.current.writer.Flush(); err != nil {
+			c.metrics.errors.WithLabelValues("flush").Inc()
+			c.errLogger.Err(err).Str("file", c.current.file.Name).Msg("cannot flush archive file")
+			return
+		}
+		if err := c.current.file.Sync(); err != nil {
+			c.metrics.errors.WithLabelValues("sync").Inc()
+			c.errLogger.Err(err).Str("file", c.current.file.Name).Msg("cannot sync archive file")
+		}
+	}
+}
+
+// open opens a new archive file.
+func (c *Component) open() error {
+	file, err := c.directory.Create(c.d.Clock.Now())
+	if err != nil {
+		return err
+	}
+	output := &countingWriter{w: file, hash: sha256.New()}
+	writer, err := c.newRecordWriter(output)
+	if err != nil {
+		file.Abort()
+		return err
+	}
+	c.current = &archiveFile{
+		file:     file,
+		output:   output,
+		writer:   writer,
+		lastSync: file.Created,
+		manifest: manifest{
+			File:        file.Name,
+			Format:      c.config.Format,
+			Compression: c.config.Compression,
+			Created:     file.Created,
+			Schema:      c.d.Schema.ProtobufMessageHash(),
+			Columns:     c.decoder.names,
+		},
+	}
+	return nil
+}
+
+// rotate closes the current file (if any) and writes its manifest. A new file
+// is opened on the next write.
+func (c *Component) rotate() {
+	current := c.current
+	if current == nil {
+		return
+	}
+	c.current = nil
+	if err := c.finalize(current); err != nil {
+		c.metrics.errors.WithLabelValues("rotate").Inc()
+		c.errLogger.Err(err).Str("file", current.file.Name).Msg("cannot close archive file")
+		return
+	}
+	c.metrics.files.Inc()
+	c.applyRetention()
+}
+
+// finalize closes the provided file and writes its manifest. The manifest is
+// written before the file gets its final name, so a file without a
+// ".part" suffix always has a manifest.
+func (c *Component) finalize(current *archiveFile) error {
+	if err := current.writer.Close(); err != nil {
+		current.file.Close()
+		return err
+	}
+	manifestPath := current.file.Path + manifestSuffix
+	err := current.file.Commit(c.config.Fsync != "never", func() error {
+		current.manifest.Size = current.output.count
+		current.manifest.SHA256 = hex.EncodeToString(current.output.hash.Sum(nil))
+		current.manifest.Closed = c.d.Clock.Now().UTC()
+		content, err := json.MarshalIndent(current.manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		return rotatingfile.WriteFileAtomically(manifestPath, append(content, '\n'), c.config.Fsync != "never")
+	})
+	if err != nil {
+		os.Remove(manifestPath)
+		return err
+	}
+	return nil
+}
+
+// applyRetention removes the oldest archived files when over the configured
+// limits. Only files with a manifest are considered.
+func (c *Component) applyRetention() {
+	if c.config.MaxFiles == 0 && c.config.MaxTotalSize == 0 && c.config.MaxAge == 0 {
+		return
+	}
+	entries, err := os.ReadDir(c.config.Directory)
+	if err != nil {
+		c.metrics.errors.WithLabelValues("retention").Inc()
+		c.errLogger.Err(err).Msg("cannot list archive directory")
+		return
+	}
+	manifests := []manifest{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), manifestSuffix) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(c.config.Directory, entry.Name()))
+		if err != nil {
+			c.metrics.errors.WithLabelValues("retention").Inc()
+			c.errLogger.Err(err).Str("file", entry.Name()).Msg("cannot read manifest")
+			continue
+		}
+		var m manifest
+		if err := json.Unmarshal(content, &m); err != nil || m.File == "" {
+			c.metrics.errors.WithLabelValues("retention").Inc()
+			c.errLogger.Err(err).Str("file", entry.Name()).Msg("cannot parse manifest")
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+	slices.SortFunc(manifests, func(a, b manifest) int {
+		return cmp.Or(a.Created.Compare(b.Created), strings.Compare(a.File, b.File))
+	})
+
+	var totalSize int64
+	for _, m := range manifests {
+		totalSize += m.Size
+	}
+	now := c.d.Clock.Now()
+	for len(manifests) > 0 {
+		oldest := manifests[0]
+		switch {
+		case c.config.MaxFiles > 0 && len(manifests) > c.config.MaxFiles:
+		case c.config.MaxTotalSize > 0 && totalSize > c.config.MaxTotalSize:
+		case c.config.MaxAge > 0 && now.Sub(oldest.Closed) > c.config.MaxAge:
+		default:
+			return
+		}
+		path := filepath.Join(c.config.Directory, filepath.Base(oldest.File))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			c.metrics.errors.WithLabelValues("retention").Inc()
+			c.errLogger.Err(err).Str("file", oldest.File).Msg("cannot remove archive file")
+			return
+		}
+		if err := os.Remove(path + manifestSuffix); err != nil && !os.IsNotExist(err) {
+			c.metrics.errors.WithLabelValues("retention").Inc()
+			c.errLogger.Err(err).Str("file", oldest.File).Msg("cannot remove manifest")
+			return
+		}
+		c.metrics.removedFiles.Inc()
+		totalSize -= oldest.Size
+		manifests = manifests[1:]
+	}
+}
@@ -0,0 +1,338 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package archive
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/helpers/rotatingfile"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func newFlow(sch *schema.Component, received uint64, bytes uint64) []byte {
+	flow := &schema.FlowMessage{
+		TimeReceived:    received,
+		SamplingRate:    1000,
+		ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+		SrcAddr:         netip.MustParseAddr("::ffff:198.51.100.10"),
+		DstAddr:         netip.MustParseAddr("2001:db8::1"),
+	}
+	sch.ProtobufAppendVarint(flow, schema.ColumnBytes, bytes)
+	sch.ProtobufAppendBytes(flow, schema.ColumnInIfName, []byte("Gi0/0/1"))
+	sch.ProtobufAppendVarint(flow, schema.ColumnInIfBoundary, uint64(schema.InterfaceBoundaryExternal))
+	sch.ProtobufAppendVarint(flow, schema.ColumnDstASPath, 64500)
+	sch.ProtobufAppendVarint(flow, schema.ColumnDstASPath, 64501)
+	return sch.ProtobufMarshal(flow)
+}
+
+func newArchive(t *testing.T, config Configuration) (*Component, *reporter.Reporter, *clock.Mock) {
+	t.Helper()
+	r := reporter.NewMock(t)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, time.March, 4, 10, 0, 0, 0, time.UTC))
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		Schema: schema.NewMock(t),
+		Clock:  mockClock,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	return c, r, mockClock
+}
+
+func readManifest(t *testing.T, path string) manifest {
+	t.Helper()
+	content, err := os.ReadFile(path + manifestSuffix)
+	if err != nil {
+		t.Fatalf("ReadFile() error:\n%+v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(content, &m); err != nil {
+		t.Fatalf("Unmarshal() error:\n%+v", err)
+	}
+	return m
+}
+
+func TestRecordDecoder(t *testing.T) {
+	sch := schema.NewMock(t)
+	decoder := newRecordDecoder(sch)
+	got, err := decoder.decode(newFlow(sch, 1741082400, 1500))
+	if err != nil {
+		t.Fatalf("decode() error:\n%+v", err)
+	}
+	expected := map[string]any{
+		"TimeReceived":    uint64(1741082400),
+		"SamplingRate":    uint64(1000),
+		"ExporterAddress": "192.0.2.1",
+		"SrcAddr":         "198.51.100.10",
+		"DstAddr":         "2001:db8::1",
+		"Bytes":           uint64(1500),
+		"InIfName":        "Gi0/0/1",
+		"InIfBoundary":    "external",
+		"DstASPath":       []uint64{64500, 64501},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("decode() (-got, +want):\n%s", diff)
+	}
+
+	if _, err := decoder.decode([]byte{10, 1, 2}); err == nil {
+		t.Fatal("decode() did not error on truncated input")
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.RotateSize = 1000
+	c, r, _ := newArchive(t, config)
+
+	// Send enough flows for 2 full files and a partial one
+	size := len(newFlow(c.d.Schema, 1741082400, 1500))
+	perFile := (int(config.RotateSize) + size - 1) / size
+	count := 2*perFile + 3
+	for i := range count {
+		c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082400+uint64(i), 1500))
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	files := rotatingfile.ListFiles(t, config.Directory)
+	if len(files) != 6 {
+		t.Fatalf("Stop() produced %d files, expected 6:\n%s", len(files), strings.Join(files, "\n"))
+	}
+	var total uint64
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".ndjson.zst") {
+			continue
+		}
+		path := filepath.Join(config.Directory, file)
+		m := readManifest(t, path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("ReadFile() error:\n%+v", err)
+		}
+		checksum := sha256.Sum256(content)
+		if diff := helpers.Diff(m.SHA256, hex.EncodeToString(checksum[:])); diff != "" {
+			t.Errorf("manifest SHA256 (-got, +want):\n%s", diff)
+		}
+		if m.Size != int64(len(content)) || m.File != file || m.Format != "ndjson" || m.Compression != "zstd" {
+			t.Errorf("manifest for %s: %+v", file, m)
+		}
+
+		// Read it back
+		zr, err := zstd.NewReader(strings.NewReader(string(content)))
+		if err != nil {
+			t.Fatalf("zstd.NewReader() error:\n%+v", err)
+		}
+		scanner := bufio.NewScanner(zr)
+		var records uint64
+		for scanner.Scan() {
+			var record map[string]any
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatalf("Unmarshal() error:\n%+v", err)
+			}
+			if record["InIfBoundary"] != "external" || record["SrcAddr"] != "198.51.100.10" {
+				t.Fatalf("Unmarshal() got unexpected record %+v", record)
+			}
+			received := time.Unix(int64(record["TimeReceived"].(float64)), 0).UTC()
+			if received.Before(m.FirstFlow) || received.After(m.LastFlow) {
+				t.Fatalf("record received at %s outside of [%s, %s]", received, m.FirstFlow, m.LastFlow)
+			}
+			records++
+		}
+		zr.Close()
+		if records != m.Records {
+			t.Errorf("%s: got %d records, manifest says %d", file, records, m.Records)
+		}
+		total += records
+	}
+	if total != uint64(count) {
+		t.Errorf("got %d records, expected %d", total, count)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_archive_", "records_", "files_", "errors_")
+	expectedMetrics := map[string]string{
+		`records_total{exporter="192.0.2.1"}`: strconv.Itoa(count),
+		`files_total`:                         "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestRotateInterval(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.Compression = "none"
+	config.RotateInterval = time.Minute
+	config.Fsync = "interval"
+	config.FsyncInterval = time.Second
+	c, _, mockClock := newArchive(t, config)
+	defer c.Stop()
+
+	c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082400, 1500))
+	time.Sleep(20 * time.Millisecond)
+	mockClock.Add(2 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+
+	// Data should be flushed, but the file is not rotated yet.
+	files := rotatingfile.ListFiles(t, config.Directory)
+	expected := []string{"flows-20250304T100000.000000Z.ndjson.part"}
+	if diff := helpers.Diff(files, expected); diff != "" {
+		t.Fatalf("ListFiles() (-got, +want):\n%s", diff)
+	}
+	content, _ := os.ReadFile(filepath.Join(config.Directory, files[0]))
+	if !strings.Contains(string(content), `"InIfName":"Gi0/0/1"`) {
+		t.Fatalf("ReadFile() got %q", content)
+	}
+
+	mockClock.Add(time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	files = rotatingfile.ListFiles(t, config.Directory)
+	expected = []string{
+		"flows-20250304T100000.000000Z.ndjson",
+		"flows-20250304T100000.000000Z.ndjson.manifest.json",
+	}
+	if diff := helpers.Diff(files, expected); diff != "" {
+		t.Fatalf("ListFiles() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.Compression = "none"
+	config.RotateSize = 1000
+	config.MaxFiles = 2
+	c, r, mockClock := newArchive(t, config)
+	size := len(newFlow(c.d.Schema, 1741082400, 1500))
+	perFile := (int(config.RotateSize) + size - 1) / size
+	for i := range 5*perFile + 5 {
+		c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082400+uint64(i), 1500))
+		if i%perFile == perFile-1 {
+			time.Sleep(10 * time.Millisecond)
+			mockClock.Add(time.Second)
+		}
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+	files := rotatingfile.ListFiles(t, config.Directory)
+	if len(files) != 4 {
+		t.Fatalf("Stop() kept %d files, expected 4:\n%s", len(files), strings.Join(files, "\n"))
+	}
+	m := readManifest(t, filepath.Join(config.Directory, files[len(files)-2]))
+	if m.Records != 5 {
+		t.Errorf("last file has %d records, expected 5", m.Records)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_archive_", "files_", "removed_")
+	expectedMetrics := map[string]string{
+		`files_total`:         "6",
+		`removed_files_total`: "4",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.Format = "parquet"
+	c, _, _ := newArchive(t, config)
+
+	for i := range 10 {
+		c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082400+uint64(i), 1500))
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+	files := rotatingfile.ListFiles(t, config.Directory)
+	expected := []string{
+		"flows-20250304T100000.000000Z.parquet",
+		"flows-20250304T100000.000000Z.parquet.manifest.json",
+	}
+	if diff := helpers.Diff(files, expected); diff != "" {
+		t.Fatalf("ListFiles() (-got, +want):\n%s", diff)
+	}
+
+	f, err := os.Open(filepath.Join(config.Directory, files[0]))
+	if err != nil {
+		t.Fatalf("Open() error:\n%+v", err)
+	}
+	defer f.Close()
+	reader := parquet.NewReader(f)
+	if reader.NumRows() != 10 {
+		t.Fatalf("NumRows() == %d, expected 10", reader.NumRows())
+	}
+	record := map[string]any{}
+	if err := reader.Read(&record); err != nil {
+		t.Fatalf("Read() error:\n%+v", err)
+	}
+	for key, value := range map[string]any{
+		"TimeReceived": uint64(1741082400),
+		"SrcAddr":      "198.51.100.10",
+		"InIfBoundary": "external",
+		"InIfName":     "Gi0/0/1",
+	} {
+		if diff := helpers.Diff(record[key], value); diff != "" {
+			t.Errorf("Read() %s (-got, +want):\n%s", key, diff)
+		}
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.QueueSize = 1
+	r := reporter.NewMock(t)
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		Schema: schema.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Not started, the second flow does not fit in the queue
+	c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082400, 1500))
+	c.Send("192.0.2.1", newFlow(c.d.Schema, 1741082401, 1500))
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_archive_", "dropped_", "queue_")
+	expectedMetrics := map[string]string{
+		`dropped_records_total{exporter="192.0.2.1"}`: "1",
+		`queue_length`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
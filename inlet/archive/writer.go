@@ -0,0 +1,148 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package archive
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/parquet-go/parquet-go"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// recordWriter writes records to a file.
+type recordWriter interface {
+	// Write writes a record.
+	Write(record map[string]any) error
+	// Flush flushes buffered data to the underlying file.
+	Flush() error
+	// Close flushes buffered data and writes trailers. It does not close the
+	// underlying file.
+	Close() error
+}
+
+// extension returns the file extension for the configured format and
+// compression.
+func (c *Component) extension() string {
+	switch c.config.Format {
+	case "parquet":
+		return "parquet"
+	}
+	switch c.config.Compression {
+	case "gzip":
+		return "ndjson.gz"
+	case "zstd":
+		return "ndjson.zst"
+	}
+	return "ndjson"
+}
+
+// newRecordWriter creates a new record writer writing to the provided file.
+func (c *Component) newRecordWriter(w io.Writer) (recordWriter, error) {
+	switch c.config.Format {
+	case "parquet":
+		return newParquetWriter(w, c.decoder, c.config.Compression)
+	default:
+		return newNDJSONWriter(w, c.config.Compression)
+	}
+}
+
+// ndjsonWriter writes records as newline-delimited JSON.
+type ndjsonWriter struct {
+	compressor io.WriteCloser // may be nil
+	buffered   *bufio.Writer
+	encoder    *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer, compression string) (*ndjsonWriter, error) {
+	nw := ndjsonWriter{}
+	switch compression {
+	case "gzip":
+		nw.compressor = gzip.NewWriter(w)
+		w = nw.compressor
+	case "zstd":
+		compressor, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create zstd writer: %w", err)
+		}
+		nw.compressor = compressor
+		w = compressor
+	}
+	nw.buffered = bufio.NewWriterSize(w, 64*1024)
+	nw.encoder = json.NewEncoder(nw.buffered)
+	return &nw, nil
+}
+
+func (nw *ndjsonWriter) Write(record map[string]any) error {
+	return nw.encoder.Encode(record)
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	if err := nw.buffered.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := nw.compressor.(interface{ Flush() error }); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	if err := nw.buffered.Flush(); err != nil {
+		return err
+	}
+	if nw.compressor != nil {
+		return nw.compressor.Close()
+	}
+	return nil
+}
+
+// parquetWriter writes records to a Parquet file.
+type parquetWriter struct {
+	writer *parquet.Writer
+}
+
+func newParquetWriter(w io.Writer, decoder *recordDecoder, compression string) (*parquetWriter, error) {
+	group := parquet.Group{}
+	for _, field := range decoder.fields {
+		switch {
+		case field.repeated:
+			group[field.name] = parquet.Repeated(parquet.Uint(64))
+		case field.kind == protoreflect.StringKind,
+			field.kind == protoreflect.BytesKind,
+			field.kind == protoreflect.EnumKind:
+			group[field.name] = parquet.Optional(parquet.String())
+		default:
+			group[field.name] = parquet.Optional(parquet.Uint(64))
+		}
+	}
+	var codec parquet.WriterOption
+	switch compression {
+	case "gzip":
+		codec = parquet.Compression(&parquet.Gzip)
+	case "zstd":
+		codec = parquet.Compression(&parquet.Zstd)
+	default:
+		codec = parquet.Compression(&parquet.Uncompressed)
+	}
+	return &parquetWriter{
+		writer: parquet.NewWriter(w, parquet.NewSchema("flow", group), codec),
+	}, nil
+}
+
+func (pw *parquetWriter) Write(record map[string]any) error {
+	return pw.writer.Write(record)
+}
+
+func (pw *parquetWriter) Flush() error {
+	return pw.writer.Flush()
+}
+
+func (pw *parquetWriter) Close() error {
+	return pw.writer.Close()
+}
@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import "time"
+
+// Configuration describes the configuration for the capture component.
+type Configuration struct {
+	// Enable turns on capturing raw sampled headers to local files.
+	Enable bool
+	// Directory is the directory where capture files are written.
+	Directory string `validate:"required_if=Enable true"`
+	// Filter is an expression selecting the flows to capture. When empty,
+	// all flows with a sampled header are captured.
+	Filter FilterRule
+	// RotateSize is the size after which a capture file is rotated.
+	RotateSize int64 `validate:"min=1000"`
+	// RotateInterval is the maximum time a capture file is kept open.
+	RotateInterval time.Duration `validate:"min=1s"`
+	// MaxFiles is the maximum number of capture files to keep.
+	MaxFiles int `validate:"min=1"`
+	// HTTPAccess enables listing and downloading capture files through the
+	// HTTP API of the inlet. As this API is not authenticated and raw headers
+	// may contain sensitive data, this is disabled by default.
+	HTTPAccess bool
+	// QueueSize defines the number of packets to buffer before dropping
+	// them.
+	QueueSize int `validate:"min=1"`
+}
+
+// DefaultConfiguration represents the default configuration for the capture component.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Enable:         false,
+		RotateSize:     16 * 1024 * 1024,
+		RotateInterval: 10 * time.Minute,
+		MaxFiles:       10,
+		QueueSize:      1000,
+	}
+}
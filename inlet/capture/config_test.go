@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestDefaultConfiguration(t *testing.T) {
+	if err := helpers.Validate.Struct(DefaultConfiguration()); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+}
+
+func TestConfigurationWithoutDirectory(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	if err := helpers.Validate.Struct(config); err == nil {
+		t.Fatal("validate.Struct() did not error")
+	}
+	config.Directory = "/var/lib/akvorado/captures"
+	if err := helpers.Validate.Struct(config); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+}
+
+func TestFilterRule(t *testing.T) {
+	cases := []struct {
+		Description string
+		Rule        string
+		Env         filterEnvironment
+		Expected    bool
+		Error       bool
+	}{
+		{
+			Description: "empty filter",
+			Rule:        "",
+			Expected:    true,
+		}, {
+			Description: "matching filter",
+			Rule:        `Exporter == "192.0.2.1" && Proto == 6 && DstPort in [80, 443]`,
+			Env:         filterEnvironment{Exporter: "192.0.2.1", Proto: 6, DstPort: 443},
+			Expected:    true,
+		}, {
+			Description: "non-matching filter",
+			Rule:        `InIf == 10 || SrcAddr startsWith "2001:db8:"`,
+			Env:         filterEnvironment{InIf: 11, SrcAddr: "192.0.2.10"},
+			Expected:    false,
+		}, {
+			Description: "not a boolean",
+			Rule:        `InIf`,
+			Error:       true,
+		}, {
+			Description: "unknown variable",
+			Rule:        `Interface == 10`,
+			Error:       true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			var rule FilterRule
+			err := rule.UnmarshalText([]byte(tc.Rule))
+			if err != nil && !tc.Error {
+				t.Fatalf("UnmarshalText() error:\n%+v", err)
+			} else if err == nil && tc.Error {
+				t.Fatal("UnmarshalText() did not error")
+			}
+			if tc.Error {
+				return
+			}
+			if got := rule.String(); got != tc.Rule {
+				t.Errorf("String() == %q, expected %q", got, tc.Rule)
+			}
+			got, err := rule.match(tc.Env)
+			if err != nil {
+				t.Fatalf("match() error:\n%+v", err)
+			}
+			if got != tc.Expected {
+				t.Errorf("match() == %v, expected %v", got, tc.Expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+
+	"akvorado/common/schema"
+)
+
+// FilterRule selects the flows to capture.
+type FilterRule struct {
+	program *vm.Program
+}
+
+// filterEnvironment defines the environment used by the filter.
+type filterEnvironment struct {
+	Exporter     string
+	InIf         uint32
+	OutIf        uint32
+	SamplingRate uint32
+	SrcAddr      string
+	DstAddr      string
+	EType        uint64
+	Proto        uint64
+	SrcPort      uint64
+	DstPort      uint64
+}
+
+// newFilterEnvironment extracts the information exposed to the filter from a
+// flow.
+func newFilterEnvironment(sch *schema.Component, exporter string, flow *schema.FlowMessage) filterEnvironment {
+	env := filterEnvironment{
+		Exporter:     exporter,
+		InIf:         flow.InIf,
+		OutIf:        flow.OutIf,
+		SamplingRate: flow.SamplingRate,
+	}
+	if flow.SrcAddr.IsValid() {
+		env.SrcAddr = flow.SrcAddr.Unmap().String()
+	}
+	if flow.DstAddr.IsValid() {
+		env.DstAddr = flow.DstAddr.Unmap().String()
+	}
+	lookup := func(key schema.ColumnKey) uint64 {
+		value, _ := sch.ProtobufLookup(flow, key)
+		v, _ := value.(uint64)
+		return v
+	}
+	env.EType = lookup(schema.ColumnEType)
+	env.Proto = lookup(schema.ColumnProto)
+	env.SrcPort = lookup(schema.ColumnSrcPort)
+	env.DstPort = lookup(schema.ColumnDstPort)
+	return env
+}
+
+// match tells if the provided environment matches the filter. An empty filter
+// matches everything.
+func (fr *FilterRule) match(env filterEnvironment) (bool, error) {
+	if fr.program == nil {
+		return true, nil
+	}
+	result, err := expr.Run(fr.program, env)
+	if err != nil {
+		return false, fmt.Errorf("unable to execute capture filter %q: %w", fr, err)
+	}
+	return result.(bool), nil
+}
+
+// UnmarshalText compiles a capture filter.
+func (fr *FilterRule) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		fr.program = nil
+		return nil
+	}
+	program, err := expr.Compile(string(text),
+		expr.Env(filterEnvironment{}),
+		expr.AsBool())
+	if err != nil {
+		return fmt.Errorf("cannot compile capture filter %q: %w", string(text), err)
+	}
+	fr.program = program
+	return nil
+}
+
+// String turns a capture filter into a string
+func (fr FilterRule) String() string {
+	if fr.program == nil {
+		return ""
+	}
+	return fr.program.Source().String()
+}
+
+// MarshalText turns a capture filter into a string
+func (fr FilterRule) MarshalText() ([]byte, error) {
+	return []byte(fr.String()), nil
+}
@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type captureInfo struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// listHTTPHandler lists the completed capture files, most recent first.
+func (c *Component) listHTTPHandler(gc *gin.Context) {
+	names, err := c.directory.List()
+	if err != nil {
+		c.r.Err(err).Msg("cannot list capture directory")
+		gc.JSON(http.StatusInternalServerError, gin.H{"message": "Cannot list captures."})
+		return
+	}
+	captures := []captureInfo{}
+	for i := len(names) - 1; i >= 0; i-- {
+		info, err := os.Stat(filepath.Join(c.config.Directory, names[i]))
+		if err != nil {
+			// The file may have been removed by the retention policy
+			continue
+		}
+		captures = append(captures, captureInfo{
+			Name:     info.Name(),
+			Size:     info.Size(),
+			Modified: info.ModTime().UTC(),
+		})
+	}
+	gc.JSON(http.StatusOK, gin.H{"captures": captures})
+}
+
+// downloadHTTPHandler sends a completed capture file.
+func (c *Component) downloadHTTPHandler(gc *gin.Context) {
+	name := gc.Param("name")
+	if !c.directory.Match(name) {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Capture not found."})
+		return
+	}
+	path := filepath.Join(c.config.Directory, name)
+	if _, err := os.Stat(path); err != nil {
+		gc.JSON(http.StatusNotFound, gin.H{"message": "Capture not found."})
+		return
+	}
+	gc.Header("Content-Type", "application/x-pcapng")
+	gc.FileAttachment(path, name)
+}
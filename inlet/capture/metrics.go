@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import "akvorado/common/reporter"
+
+type metrics struct {
+	packets      *reporter.CounterVec
+	dropped      reporter.Counter
+	files        reporter.Counter
+	removedFiles reporter.Counter
+	errors       *reporter.CounterVec
+	queueLength  reporter.GaugeFunc
+}
+
+func (c *Component) initMetrics() {
+	c.metrics.packets = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "packets_total",
+			Help: "Number of sampled headers written from a given exporter.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.dropped = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "dropped_packets_total",
+			Help: "Number of sampled headers dropped because the queue was full.",
+		},
+	)
+	c.metrics.files = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "files_total",
+			Help: "Number of capture files completed.",
+		},
+	)
+	c.metrics.removedFiles = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "removed_files_total",
+			Help: "Number of capture files removed by the retention policy.",
+		},
+	)
+	c.metrics.errors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors when capturing sampled headers.",
+		},
+		[]string{"error"},
+	)
+	c.metrics.queueLength = c.r.GaugeFunc(
+		reporter.GaugeOpts{
+			Name: "queue_length",
+			Help: "Number of sampled headers waiting to be written.",
+		},
+		func() float64 {
+			return float64(len(c.queue))
+		},
+	)
+}
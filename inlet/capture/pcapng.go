@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"akvorado/common/schema"
+)
+
+// pcapngWriter writes packets using the pcapng format. Only the few blocks we
+// need are implemented: a section header, one interface description per
+// supported link type, and enhanced packets with a comment.
+type pcapngWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+const (
+	pcapngSectionHeaderBlock        = 0x0a0d0d0a
+	pcapngInterfaceDescriptionBlock = 0x00000001
+	pcapngEnhancedPacketBlock       = 0x00000006
+	pcapngByteOrderMagic            = 0x1a2b3c4d
+	pcapngOptionEnd                 = 0
+	pcapngOptionComment             = 1
+	pcapngSnapLength                = 65535
+)
+
+// pcapngInterfaces maps a sampled header type to an interface identifier and
+// its link type. Interfaces are declared in this order in each file.
+var pcapngInterfaces = []struct {
+	headerType schema.SampledHeaderType
+	linkType   uint16
+}{
+	{schema.SampledHeaderEthernet, 1}, // LINKTYPE_ETHERNET
+	{schema.SampledHeaderIPv4, 228},   // LINKTYPE_IPV4
+	{schema.SampledHeaderIPv6, 229},   // LINKTYPE_IPV6
+}
+
+// newPcapngWriter writes the section header and the interface descriptions to
+// the provided writer.
+func newPcapngWriter(w io.Writer) (*pcapngWriter, error) {
+	pw := &pcapngWriter{w: w}
+	body := binary.LittleEndian.AppendUint32(nil, pcapngByteOrderMagic)
+	body = binary.LittleEndian.AppendUint16(body, 1) // major version
+	body = binary.LittleEndian.AppendUint16(body, 0) // minor version
+	body = binary.LittleEndian.AppendUint64(body, ^uint64(0))
+	if err := pw.writeBlock(pcapngSectionHeaderBlock, body); err != nil {
+		return nil, err
+	}
+	for _, iface := range pcapngInterfaces {
+		body := binary.LittleEndian.AppendUint16(nil, iface.linkType)
+		body = binary.LittleEndian.AppendUint16(body, 0) // reserved
+		body = binary.LittleEndian.AppendUint32(body, pcapngSnapLength)
+		if err := pw.writeBlock(pcapngInterfaceDescriptionBlock, body); err != nil {
+			return nil, err
+		}
+	}
+	return pw, nil
+}
+
+// writePacket writes a packet with the provided comment. It returns the
+// number of bytes written.
+func (pw *pcapngWriter) writePacket(ts time.Time, headerType schema.SampledHeaderType, data []byte, comment string) (int, error) {
+	var iface uint32
+	for idx, candidate := range pcapngInterfaces {
+		if candidate.headerType == headerType {
+			iface = uint32(idx)
+		}
+	}
+	micro := uint64(ts.UnixMicro())
+	body := binary.LittleEndian.AppendUint32(pw.buf[:0], iface)
+	body = binary.LittleEndian.AppendUint32(body, uint32(micro>>32))
+	body = binary.LittleEndian.AppendUint32(body, uint32(micro))
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data))) // captured length
+	body = binary.LittleEndian.AppendUint32(body, uint32(len(data))) // original length
+	body = appendPadded(body, data)
+	if comment != "" {
+		body = binary.LittleEndian.AppendUint16(body, pcapngOptionComment)
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(comment)))
+		body = appendPadded(body, []byte(comment))
+		body = binary.LittleEndian.AppendUint32(body, pcapngOptionEnd)
+	}
+	pw.buf = body
+	return len(body) + 12, pw.writeBlock(pcapngEnhancedPacketBlock, body)
+}
+
+// writeBlock writes a block with the provided type and body. The body should
+// already be padded to 32 bits.
+func (pw *pcapngWriter) writeBlock(blockType uint32, body []byte) error {
+	length := uint32(len(body) + 12)
+	header := binary.LittleEndian.AppendUint32(nil, blockType)
+	header = binary.LittleEndian.AppendUint32(header, length)
+	if _, err := pw.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := pw.w.Write(body); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(binary.LittleEndian.AppendUint32(nil, length))
+	return err
+}
+
+// appendPadded appends data to buf and pads it to 32 bits.
+func appendPadded(buf []byte, data []byte) []byte {
+	buf = append(buf, data...)
+	for len(buf)%4 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package capture writes raw sampled headers to rotating pcapng files.
+package capture
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers/rotatingfile"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+// Component represents the capture component.
+type Component struct {
+	r      *reporter.Reporter
+	d      *Dependencies
+	t      tomb.Tomb
+	config Configuration
+
+	queue     chan capturedPacket
+	directory *rotatingfile.Directory
+	errLogger reporter.Logger
+	metrics   metrics
+
+	// Only accessed from the main goroutine
+	current *captureFile
+}
+
+// Dependencies define the dependencies of the capture component.
+type Dependencies struct {
+	Daemon daemon.Component
+	HTTP   *httpserver.Component
+	Schema *schema.Component
+	Clock  clock.Clock
+}
+
+// capturedPacket is a sampled header waiting to be written.
+type capturedPacket struct {
+	received   time.Time
+	exporter   string
+	headerType schema.SampledHeaderType
+	data       []byte
+	comment    string
+}
+
+// captureFile is the file currently written.
+type captureFile struct {
+	file   *rotatingfile.File
+	output *bufio.Writer
+	writer *pcapngWriter
+	size   int64
+}
+
+// New creates a new capture component.
+func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	if dependencies.Clock == nil {
+		dependencies.Clock = clock.New()
+	}
+	c := Component{
+		r:      r,
+		d:      &dependencies,
+		config: configuration,
+
+		queue:     make(chan capturedPacket, configuration.QueueSize),
+		directory: rotatingfile.New(configuration.Directory, "capture-", ".pcapng"),
+		errLogger: r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+	}
+	c.initMetrics()
+	c.d.Daemon.Track(&c.t, "inlet/capture")
+	return &c, nil
+}
+
+// Start starts the capture component.
+func (c *Component) Start() error {
+	c.r.Info().Str("directory", c.config.Directory).Msg("starting capture component")
+	if err := os.MkdirAll(c.config.Directory, 0o755); err != nil {
+		return fmt.Errorf("cannot create capture directory: %w", err)
+	}
+	c.applyRetention()
+
+	c.t.Go(func() error {
+		rotatingfile.Run(&c.t, c.d.Clock, c.queue, c.write, c.tick, c.rotate)
+		return nil
+	})
+	if c.config.HTTPAccess {
+		c.d.HTTP.GinRouter.GET("/api/v0/inlet/captures", c.listHTTPHandler)
+		c.d.HTTP.GinRouter.GET("/api/v0/inlet/captures/:name", c.downloadHTTPHandler)
+	}
+	return nil
+}
+
+// Stop stops the capture component.
+func (c *Component) Stop() error {
+	defer c.r.Info().Msg("capture component stopped")
+	c.r.Info().Msg("stopping capture component")
+	c.t.Kill(nil)
+	return c.t.Wait()
+}
+
+// Capture queues a copy of the sampled header of the provided flow if it
+// matches the filter. It never blocks: when the queue is full, the header is
+// dropped.
+func (c *Component) Capture(flow *schema.FlowMessage) {
+	if len(flow.SampledHeader) == 0 || flow.SampledHeaderType == schema.SampledHeaderNone {
+		return
+	}
+	exporter := flow.ExporterAddress.Unmap().String()
+	ok, err := c.config.Filter.match(newFilterEnvironment(c.d.Schema, exporter, flow))
+	if err != nil {
+		c.metrics.errors.WithLabelValues("filter").Inc()
+		c.errLogger.Err(err).Str("exporter", exporter).Msg("cannot apply capture filter")
+		return
+	}
+	if !ok {
+		return
+	}
+	packet := capturedPacket{
+		received:   c.d.Clock.Now(),
+		exporter:   exporter,
+		headerType: flow.SampledHeaderType,
+		data:       slices.Clone(flow.SampledHeader),
+		comment: fmt.Sprintf("exporter=%s in-if=%d out-if=%d sampling-rate=%d",
+			exporter, flow.InIf, flow.OutIf, flow.SamplingRate),
+	}
+	select {
+	case c.queue <- packet:
+	default:
+		c.metrics.dropped.Inc()
+	}
+}
+
+// write writes a packet to the current file, opening a new one if needed.
+func (c *Component) write(packet capturedPacket) {
+	if c.current == nil {
+		if err := c.open(); err != nil {
+			c.metrics.errors.WithLabelValues("open").Inc()
+			c.errLogger.Err(err).Msg("cannot open capture file")
+			return
+		}
+	}
+	n, err := c.current.writer.writePacket(packet.received, packet.headerType, packet.data, packet.comment)
+	if err != nil {
+		c.metrics.errors.WithLabelValues("write").Inc()
+		c.errLogger.Err(err).Str("file", c.current.file.Name).Msg("cannot write packet")
+		c.rotate()
+		return
+	}
+	c.metrics.packets.WithLabelValues(packet.exporter).Inc()
+	c.current.size += int64(n)
+	if c.current.size >= c.config.RotateSize {
+		c.rotate()
+	}
+}
+
+// tick handles time-based rotation.
+func (c *Component) tick() {
+	if c.current == nil {
+		return
+	}
+	if c.d.Clock.Now().Sub(c.current.file.Created) >= c.config.RotateInterval {
+		c.rotate()
+	}
+}
+
+// open opens a new capture file.
+func (c *Component) open() error {
+	file, err := c.directory.Create(c.d.Clock.Now())
+	if err != nil {
+		return err
+	}
+	output := bufio.NewWriter(file)
+	writer, err := newPcapngWriter(output)
+	if err != nil {
+		file.Abort()
+		return err
+	}
+	c.current = &captureFile{
+		file:   file,
+		output: output,
+		writer: writer,
+	}
+	return nil
+}
+
+// rotate closes the current file (if any). A new file is opened on the next
+// write.
+func (c *Component) rotate() {
+	current := c.current
+	if current == nil {
+		return
+	}
+	c.current = nil
+	if err := c.finalize(current); err != nil {
+		c.metrics.errors.WithLabelValues("rotate").Inc()
+		c.errLogger.Err(err).Str("file", current.file.Name).Msg("cannot close capture file")
+		return
+	}
+	c.metrics.files.Inc()
+	c.applyRetention()
+}
+
+// finalize closes the provided file and makes it available.
+func (c *Component) finalize(current *captureFile) error {
+	if err := current.output.Flush(); err != nil {
+		current.file.Close()
+		return err
+	}
+	return current.file.Commit(false, nil)
+}
+
+// applyRetention removes the oldest capture files when over the configured
+// limit.
+func (c *Component) applyRetention() {
+	names, err := c.directory.List()
+	if err != nil {
+		c.metrics.errors.WithLabelValues("retention").Inc()
+		c.errLogger.Err(err).Msg("cannot list capture directory")
+		return
+	}
+	for len(names) > c.config.MaxFiles {
+		oldest := names[0]
+		if err := os.Remove(filepath.Join(c.config.Directory, oldest)); err != nil && !os.IsNotExist(err) {
+			c.metrics.errors.WithLabelValues("retention").Inc()
+			c.errLogger.Err(err).Str("file", oldest).Msg("cannot remove capture file")
+			return
+		}
+		c.metrics.removedFiles.Inc()
+		names = names[1:]
+	}
+}
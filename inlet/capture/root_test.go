@@ -0,0 +1,290 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/gin-gonic/gin"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/helpers/rotatingfile"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+)
+
+func newCapture(t *testing.T, config Configuration) (*Component, *reporter.Reporter, *schema.Component, *httpserver.Component) {
+	t.Helper()
+	r := reporter.NewMock(t)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, time.March, 4, 10, 0, 0, 0, time.UTC))
+	sch := schema.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   h,
+		Schema: sch,
+		Clock:  mockClock,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	return c, r, sch, h
+}
+
+func newFlow(sch *schema.Component, headerType schema.SampledHeaderType, header []byte, proto uint64) *schema.FlowMessage {
+	flow := &schema.FlowMessage{
+		SamplingRate:      1000,
+		ExporterAddress:   netip.MustParseAddr("::ffff:192.0.2.1"),
+		InIf:              10,
+		OutIf:             20,
+		SampledHeader:     header,
+		SampledHeaderType: headerType,
+	}
+	sch.ProtobufAppendVarint(flow, schema.ColumnProto, proto)
+	return flow
+}
+
+var (
+	ethernetHeader = []byte{
+		0x00, 0x1b, 0x21, 0x3c, 0x9d, 0xf8, 0x00, 0x1b, 0x21, 0x3c, 0x9d, 0xf9, 0x08, 0x00,
+		0x45, 0x00, 0x00, 0x28, 0x00, 0x01, 0x00, 0x00, 0x40, 0x06, 0x00, 0x00,
+		0xc6, 0x33, 0x64, 0x0a, 0xcb, 0x00, 0x71, 0x0a,
+	}
+	ipv4Header = []byte{
+		0x45, 0x00, 0x00, 0x1c, 0x00, 0x01, 0x00, 0x00, 0x40, 0x11, 0x00, 0x00,
+		0xc6, 0x33, 0x64, 0x0a, 0xcb, 0x00, 0x71, 0x0a,
+	}
+	ipv6Header = []byte{
+		0x60, 0x00, 0x00, 0x00, 0x00, 0x14, 0x06, 0x40,
+		0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+		0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2,
+	}
+)
+
+func TestCapture(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.HTTPAccess = true
+	if err := config.Filter.UnmarshalText([]byte("Proto == 6")); err != nil {
+		t.Fatalf("UnmarshalText() error:\n%+v", err)
+	}
+	c, r, sch, h := newCapture(t, config)
+
+	c.Capture(newFlow(sch, schema.SampledHeaderEthernet, ethernetHeader, 6))
+	c.Capture(newFlow(sch, schema.SampledHeaderIPv4, ipv4Header, 17))
+	c.Capture(newFlow(sch, schema.SampledHeaderIPv6, ipv6Header, 6))
+	c.Capture(newFlow(sch, schema.SampledHeaderNone, nil, 6))
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	files := rotatingfile.ListFiles(t, config.Directory)
+	expectedFiles := []string{"capture-20250304T100000.000000Z.pcapng"}
+	if diff := helpers.Diff(files, expectedFiles); diff != "" {
+		t.Fatalf("Capture() files (-got, +want):\n%s", diff)
+	}
+	content, err := os.ReadFile(filepath.Join(config.Directory, files[0]))
+	if err != nil {
+		t.Fatalf("ReadFile() error:\n%+v", err)
+	}
+	reader, err := pcapgo.NewNgReader(bytes.NewReader(content), pcapgo.NgReaderOptions{WantMixedLinkType: true})
+	if err != nil {
+		t.Fatalf("NewNgReader() error:\n%+v", err)
+	}
+	type packet struct {
+		LinkType layers.LinkType
+		Data     []byte
+	}
+	got := []packet{}
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("ReadPacketData() error:\n%+v", err)
+		}
+		got = append(got, packet{ci.AncillaryData[0].(layers.LinkType), data})
+	}
+	expected := []packet{
+		{layers.LinkTypeEthernet, ethernetHeader},
+		{layers.LinkTypeIPv6, ipv6Header},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("ReadPacketData() (-got, +want):\n%s", diff)
+	}
+	comment := []byte("exporter=192.0.2.1 in-if=10 out-if=20 sampling-rate=1000")
+	if count := bytes.Count(content, comment); count != 2 {
+		t.Errorf("Capture() comments: got %d, expected 2", count)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_capture_", "-queue_length")
+	expectedMetrics := map[string]string{
+		`dropped_packets_total`:               "0",
+		`files_total`:                         "1",
+		`packets_total{exporter="192.0.2.1"}`: "2",
+		`removed_files_total`:                 "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	t.Run("list", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/api/v0/inlet/captures", h.LocalAddr()))
+		if err != nil {
+			t.Fatalf("GET /api/v0/inlet/captures:\n%+v", err)
+		}
+		defer resp.Body.Close()
+		var got struct {
+			Captures []captureInfo `json:"captures"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatalf("Decode() error:\n%+v", err)
+		}
+		if len(got.Captures) != 1 || got.Captures[0].Name != files[0] || got.Captures[0].Size != int64(len(content)) {
+			t.Fatalf("GET /api/v0/inlet/captures: got %+v", got.Captures)
+		}
+	})
+	t.Run("download", func(t *testing.T) {
+		resp, err := http.Get(fmt.Sprintf("http://%s/api/v0/inlet/captures/%s", h.LocalAddr(), files[0]))
+		if err != nil {
+			t.Fatalf("GET /api/v0/inlet/captures/%s:\n%+v", files[0], err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET /api/v0/inlet/captures/%s: got status code %d", files[0], resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if !bytes.Equal(body, content) {
+			t.Fatalf("GET /api/v0/inlet/captures/%s: content mismatch", files[0])
+		}
+	})
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "missing capture",
+			URL:         "/api/v0/inlet/captures/capture-20250304T110000.000000Z.pcapng",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Capture not found."},
+		}, {
+			Description: "invalid name",
+			URL:         "/api/v0/inlet/captures/root_test.go",
+			StatusCode:  404,
+			JSONOutput:  gin.H{"message": "Capture not found."},
+		},
+	})
+}
+
+func TestHTTPAccessDisabled(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	c, _, sch, h := newCapture(t, config)
+	c.Capture(newFlow(sch, schema.SampledHeaderEthernet, ethernetHeader, 6))
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	for _, url := range []string{
+		"/api/v0/inlet/captures",
+		"/api/v0/inlet/captures/capture-20250304T100000.000000Z.pcapng",
+	} {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", h.LocalAddr(), url))
+		if err != nil {
+			t.Fatalf("GET %s:\n%+v", url, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s: got status code %d, expected 404", url, resp.StatusCode)
+		}
+	}
+}
+
+func TestRotationAndRetention(t *testing.T) {
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.RotateSize = 1000
+	config.MaxFiles = 2
+	c, r, sch, _ := newCapture(t, config)
+
+	// Each packet uses about 140 bytes, so we get a new file every 8 packets.
+	for range 30 {
+		c.Capture(newFlow(sch, schema.SampledHeaderEthernet, ethernetHeader, 6))
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+
+	files := rotatingfile.ListFiles(t, config.Directory)
+	expectedFiles := []string{
+		"capture-20250304T100000.000002Z.pcapng",
+		"capture-20250304T100000.000003Z.pcapng",
+	}
+	if diff := helpers.Diff(files, expectedFiles); diff != "" {
+		t.Fatalf("Capture() files (-got, +want):\n%s", diff)
+	}
+	gotMetrics := r.GetMetrics("akvorado_inlet_capture_", "files_total", "removed_files_total")
+	expectedMetrics := map[string]string{
+		`files_total`:         "4",
+		`removed_files_total`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	r := reporter.NewMock(t)
+	sch := schema.NewMock(t)
+	config := DefaultConfiguration()
+	config.Enable = true
+	config.Directory = t.TempDir()
+	config.QueueSize = 1
+	c, err := New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: sch,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	// Not started, so the queue is not consumed
+	header := slices.Clone(ethernetHeader)
+	c.Capture(newFlow(sch, schema.SampledHeaderEthernet, header, 6))
+	c.Capture(newFlow(sch, schema.SampledHeaderEthernet, header, 6))
+	header[0] = 0xff
+	gotMetrics := r.GetMetrics("akvorado_inlet_capture_", "dropped_packets_total", "queue_length")
+	expectedMetrics := map[string]string{
+		`dropped_packets_total`: "1",
+		`queue_length`:          "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+
+	// The header was copied
+	packet := <-c.queue
+	if diff := helpers.Diff(packet.data, ethernetHeader); diff != "" {
+		t.Fatalf("Capture() header (-got, +want):\n%s", diff)
+	}
+}
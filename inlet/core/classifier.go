@@ -143,6 +143,17 @@ type interfaceInfo struct {
 	Description string
 	Speed       uint32
 	VLAN        uint16
+	Parent      parentInterfaceInfo
+}
+
+// parentInterfaceInfo contains the information we want to expose about the
+// parent of an interface (aggregated interface for a LAG member, underlying
+// interface for a subinterface).
+type parentInterfaceInfo struct {
+	Index       uint32
+	Name        string
+	Description string
+	Speed       uint32
 }
 
 // interfaceClassification contains the information about an interface classification
@@ -151,8 +162,10 @@ type interfaceClassification struct {
 	Provider     string
 	Boundary     schema.InterfaceBoundary
 	Reject       bool
+	UseParent    bool
 	Name         string
 	Description  string
+	Speed        uint32
 }
 
 // interfaceClassifierEnvironment defines the environment used by the interface classifier
@@ -168,6 +181,7 @@ type interfaceClassifierEnvironment struct {
 	ClassifyInternal          func() bool
 	SetName                   func(string) bool
 	SetDescription            func(string) bool
+	UseParent                 func() bool
 	Reject                    func() bool
 }
 
@@ -211,6 +225,12 @@ func (scr *InterfaceClassifierRule) exec(si exporterInfo, ii interfaceInfo, ic *
 		ClassifyProviderRegex:     withRegex(classifyProvider),
 		SetName:                   setName,
 		SetDescription:            setDescription,
+		UseParent: func() bool {
+			if ii.Parent.Name != "" {
+				ic.UseParent = true
+			}
+			return true
+		},
 		Reject: func() bool {
 			ic.Reject = true
 			return false
@@ -188,6 +188,28 @@ func TestInterfaceClassifier(t *testing.T) {
 			},
 			Program:                `Interface.Index == 200 && Reject()`,
 			ExpectedClassification: interfaceClassification{Reject: true},
+		}, {
+			Description: "use parent",
+			InterfaceInfo: interfaceInfo{
+				Index: 100,
+				Name:  "Gi0/0/0",
+				Parent: parentInterfaceInfo{
+					Index:       200,
+					Name:        "Po200",
+					Description: "Transit: Telia",
+					Speed:       2000,
+				},
+			},
+			Program:                `Interface.Parent.Description startsWith "Transit:" && UseParent()`,
+			ExpectedClassification: interfaceClassification{UseParent: true},
+		}, {
+			Description: "use parent without parent",
+			InterfaceInfo: interfaceInfo{
+				Index: 100,
+				Name:  "Gi0/0/0",
+			},
+			Program:                `UseParent()`,
+			ExpectedClassification: interfaceClassification{},
 		}, {
 			Description: "complex example",
 			Program: `
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"akvorado/common/helpers"
+	"akvorado/common/schema"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -32,6 +33,14 @@ type Configuration struct {
 	ASNProviders []ASNProvider `validate:"dive"`
 	// NetProviders defines the source used to get Prefix/Network Information
 	NetProviders []NetProvider `validate:"dive"`
+	// ExitPoints maps BGP next hops (loopbacks or peer addresses) to exit points
+	ExitPoints helpers.SubnetMap[ExitPoint]
+	// NetworkRules derives network attributes from routes
+	NetworkRules []NetworkRule `validate:"dive"`
+	// Deduplication defines how to handle traffic observed by several exporters
+	Deduplication DeduplicationConfiguration
+	// Sidecar defines an external process to enrich flows
+	Sidecar SidecarConfiguration
 	// Old configuration settings
 	classifierCacheSize uint
 }
@@ -45,9 +54,56 @@ func DefaultConfiguration() Configuration {
 		ClassifierCacheDuration: 5 * time.Minute,
 		ASNProviders:            []ASNProvider{ASNProviderFlow, ASNProviderRouting},
 		NetProviders:            []NetProvider{NetProviderFlow, NetProviderRouting},
+		Deduplication: DeduplicationConfiguration{
+			Action: "tag",
+		},
+		Sidecar: SidecarConfiguration{
+			Timeout:    20 * time.Millisecond,
+			BatchSize:  100,
+			BatchDelay: 5 * time.Millisecond,
+		},
 	}
 }
 
+// SidecarConfiguration defines an external process enriching flows with gRPC.
+type SidecarConfiguration struct {
+	// Target is the address of the sidecar (host:port or unix:///path). When
+	// empty, no sidecar is used.
+	Target string
+	// TLS defines how to secure the connection to the sidecar.
+	TLS helpers.TLSConfiguration
+	// Timeout is the latency budget for a batch. On timeout or error, flows
+	// are forwarded without the additional values.
+	Timeout time.Duration `validate:"min=1ms"`
+	// BatchSize is the maximum number of flows in a batch.
+	BatchSize int `validate:"min=1"`
+	// BatchDelay is the maximum time to wait for a batch to be full.
+	BatchDelay time.Duration `validate:"min=1ms"`
+	// InputColumns are the columns sent to the sidecar.
+	InputColumns []schema.ColumnKey `validate:"required_with=Target"`
+	// OutputColumns are the columns the sidecar may set.
+	OutputColumns []schema.ColumnKey `validate:"required_with=Target"`
+}
+
+// DeduplicationConfiguration defines how to handle the same traffic observed
+// by several exporters.
+type DeduplicationConfiguration struct {
+	// Authoritative is a rule telling if a flow comes from an authoritative
+	// observation point. When empty, all flows are authoritative.
+	Authoritative AuthoritativeRule
+	// Action is the action to apply to flows from non-authoritative
+	// observation points: tag them with the Duplicate column or drop them.
+	Action string `validate:"oneof=tag drop"`
+}
+
+// ExitPoint describes where the traffic leaves the network.
+type ExitPoint struct {
+	// Router is the name of the exit router
+	Router string
+	// Site is the site of the exit router
+	Site string
+}
+
 type (
 	// ASNProvider describes one AS number provider.
 	ASNProvider int
@@ -147,4 +203,5 @@ func init() {
 	helpers.RegisterMapstructureUnmarshallerHook(ASNProviderUnmarshallerHook())
 	helpers.RegisterMapstructureUnmarshallerHook(NetProviderUnmarshallerHook())
 	helpers.RegisterMapstructureUnmarshallerHook(helpers.SubnetMapUnmarshallerHook[uint]())
+	helpers.RegisterMapstructureUnmarshallerHook(helpers.SubnetMapUnmarshallerHook[ExitPoint]())
 }
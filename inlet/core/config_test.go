@@ -112,6 +112,73 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 				NetProviders: []NetProvider{NetProviderFlow, NetProviderRouting},
 			},
 			SkipValidation: true,
+		}, {
+			Description: "network-rules",
+			Initial:     func() interface{} { return Configuration{} },
+			Configuration: func() interface{} {
+				return gin.H{
+					"network-rules": []gin.H{
+						{
+							"communities":       []string{"65000:1??"},
+							"large-communities": []string{"65000:*:100"},
+							"tenant":            "customer-a",
+						}, {
+							"origin-asns": []uint32{64500},
+							"role":        "internal",
+						},
+					},
+				}
+			},
+			Expected: Configuration{
+				NetworkRules: []NetworkRule{
+					{
+						Communities:      []CommunityPattern{"65000:1??"},
+						LargeCommunities: []CommunityPattern{"65000:*:100"},
+						Tenant:           "customer-a",
+					}, {
+						OriginASNs: []uint32{64500},
+						Role:       "internal",
+					},
+				},
+			},
+			SkipValidation: true,
+		}, {
+			Description: "network-rules with invalid community pattern",
+			Initial:     func() interface{} { return Configuration{} },
+			Configuration: func() interface{} {
+				return gin.H{
+					"network-rules": []gin.H{
+						{
+							"communities": []string{"65000"},
+							"tenant":      "customer-a",
+						},
+					},
+				}
+			},
+			Error:          true,
+			SkipValidation: true,
+		}, {
+			Description: "network-rules without criteria",
+			Initial:     func() interface{} { return DefaultConfiguration() },
+			Configuration: func() interface{} {
+				return gin.H{
+					"network-rules": []gin.H{
+						{"tenant": "customer-a"},
+					},
+				}
+			},
+			Error: true,
+		}, {
+			Description: "network-rules without attributes",
+			Initial:     func() interface{} { return DefaultConfiguration() },
+			Configuration: func() interface{} {
+				return gin.H{
+					"network-rules": []gin.H{
+						{"origin-asns": []uint32{64500}},
+					},
+				}
+			},
+			Error: true,
 		},
 	})
 }
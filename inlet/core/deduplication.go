@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// AuthoritativeRule defines a rule telling if a flow comes from an
+// authoritative observation point.
+type AuthoritativeRule struct {
+	program *vm.Program
+}
+
+// observationExporter contains the information we want to expose about the
+// exporter of a flow.
+type observationExporter struct {
+	IP     string
+	Name   string
+	Group  string
+	Role   string
+	Site   string
+	Region string
+	Tenant string
+}
+
+// observationInterface contains the information we want to expose about an
+// interface of a flow.
+type observationInterface struct {
+	Index        uint32
+	Name         string
+	Description  string
+	Speed        uint32
+	VLAN         uint16
+	Connectivity string
+	Provider     string
+	Boundary     string
+}
+
+// authoritativeEnvironment defines the environment used by the authoritative
+// rule.
+type authoritativeEnvironment struct {
+	Exporter observationExporter
+	InIf     observationInterface
+	OutIf    observationInterface
+}
+
+// exec executes the authoritative rule with the provided environment.
+func (ar *AuthoritativeRule) exec(env authoritativeEnvironment) (bool, error) {
+	result, err := expr.Run(ar.program, env)
+	if err != nil {
+		return false, fmt.Errorf("unable to execute authoritative rule %q: %w", ar, err)
+	}
+	return result.(bool), nil
+}
+
+// UnmarshalText compiles an authoritative rule.
+func (ar *AuthoritativeRule) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		ar.program = nil
+		return nil
+	}
+	program, err := expr.Compile(string(text),
+		expr.Env(authoritativeEnvironment{}),
+		expr.AsBool())
+	if err != nil {
+		return fmt.Errorf("cannot compile authoritative rule %q: %w", string(text), err)
+	}
+	ar.program = program
+	return nil
+}
+
+// String turns an authoritative rule into a string
+func (ar AuthoritativeRule) String() string {
+	if ar.program == nil {
+		return ""
+	}
+	return ar.program.Source().String()
+}
+
+// MarshalText turns an authoritative rule into a string
+func (ar AuthoritativeRule) MarshalText() ([]byte, error) {
+	return []byte(ar.String()), nil
+}
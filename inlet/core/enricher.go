@@ -24,6 +24,7 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 	var flowInIfName, flowInIfDescription, flowOutIfName, flowOutIfDescription string
 	var flowInIfSpeed, flowOutIfSpeed, flowInIfIndex, flowOutIfIndex uint32
 	var flowInIfVlan, flowOutIfVlan uint16
+	var flowInIfParent, flowOutIfParent parentInterfaceInfo
 
 	t := time.Now() // only call it once
 	expClassification := exporterClassification{}
@@ -46,6 +47,12 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 			flowInIfName = answer.Interface.Name
 			flowInIfDescription = answer.Interface.Description
 			flowInIfSpeed = uint32(answer.Interface.Speed)
+			flowInIfParent = parentInterfaceInfo{
+				Index:       uint32(answer.Parent.IfIndex),
+				Name:        answer.Parent.Name,
+				Description: answer.Parent.Description,
+				Speed:       uint32(answer.Parent.Speed),
+			}
 			inIfClassification.Provider = answer.Interface.Provider
 			inIfClassification.Connectivity = answer.Interface.Connectivity
 			inIfClassification.Boundary = answer.Interface.Boundary
@@ -73,6 +80,12 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 			flowOutIfName = answer.Interface.Name
 			flowOutIfDescription = answer.Interface.Description
 			flowOutIfSpeed = uint32(answer.Interface.Speed)
+			flowOutIfParent = parentInterfaceInfo{
+				Index:       uint32(answer.Parent.IfIndex),
+				Name:        answer.Parent.Name,
+				Description: answer.Parent.Description,
+				Speed:       uint32(answer.Parent.Speed),
+			}
 			outIfClassification.Provider = answer.Interface.Provider
 			outIfClassification.Connectivity = answer.Interface.Connectivity
 			outIfClassification.Boundary = answer.Interface.Boundary
@@ -103,16 +116,70 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 	}
 
 	// Classification
-	if !c.classifyExporter(t, exporterStr, flowExporterName, flow, expClassification) ||
-		!c.classifyInterface(t, exporterStr, flowExporterName, flow,
-			flowOutIfIndex, flowOutIfName, flowOutIfDescription, flowOutIfSpeed, flowOutIfVlan, outIfClassification,
-			false) ||
-		!c.classifyInterface(t, exporterStr, flowExporterName, flow,
-			flowInIfIndex, flowInIfName, flowInIfDescription, flowInIfSpeed, flowInIfVlan, inIfClassification,
-			true) {
+	var ok bool
+	if expClassification, ok = c.classifyExporter(t, exporterStr, flowExporterName, flow, expClassification); !ok {
 		// Flow is rejected
 		return true
 	}
+	if outIfClassification, ok = c.classifyInterface(t, exporterStr, flowExporterName, flow,
+		flowOutIfIndex, flowOutIfName, flowOutIfDescription, flowOutIfSpeed, flowOutIfVlan, flowOutIfParent, outIfClassification,
+		false); !ok {
+		return true
+	}
+	if inIfClassification, ok = c.classifyInterface(t, exporterStr, flowExporterName, flow,
+		flowInIfIndex, flowInIfName, flowInIfDescription, flowInIfSpeed, flowInIfVlan, flowInIfParent, inIfClassification,
+		true); !ok {
+		return true
+	}
+
+	// Deduplication
+	if c.config.Deduplication.Authoritative.program != nil {
+		env := authoritativeEnvironment{
+			Exporter: observationExporter{
+				IP:     exporterStr,
+				Name:   flowExporterName,
+				Group:  expClassification.Group,
+				Role:   expClassification.Role,
+				Site:   expClassification.Site,
+				Region: expClassification.Region,
+				Tenant: expClassification.Tenant,
+			},
+			InIf: observationInterface{
+				Index:        flowInIfIndex,
+				Name:         inIfClassification.Name,
+				Description:  inIfClassification.Description,
+				Speed:        inIfClassification.Speed,
+				VLAN:         flowInIfVlan,
+				Connectivity: inIfClassification.Connectivity,
+				Provider:     inIfClassification.Provider,
+				Boundary:     inIfClassification.Boundary.String(),
+			},
+			OutIf: observationInterface{
+				Index:        flowOutIfIndex,
+				Name:         outIfClassification.Name,
+				Description:  outIfClassification.Description,
+				Speed:        outIfClassification.Speed,
+				VLAN:         flowOutIfVlan,
+				Connectivity: outIfClassification.Connectivity,
+				Provider:     outIfClassification.Provider,
+				Boundary:     outIfClassification.Boundary.String(),
+			},
+		}
+		authoritative, err := c.config.Deduplication.Authoritative.exec(env)
+		if err != nil {
+			c.classifierErrLogger.Err(err).
+				Str("type", "authoritative").
+				Str("exporter", flowExporterName).
+				Msg("error executing authoritative rule")
+			c.metrics.classifierErrors.WithLabelValues("authoritative", "0").Inc()
+		} else if !authoritative {
+			c.metrics.flowsDuplicates.WithLabelValues(exporterStr).Inc()
+			if c.config.Deduplication.Action == "drop" {
+				return true
+			}
+			c.d.Schema.ProtobufAppendVarint(flow, schema.ColumnDuplicate, 1)
+		}
+	}
 
 	ctx := c.t.Context(context.Background())
 	sourceRouting := c.d.Routing.Lookup(ctx, flow.SrcAddr, netip.Addr{}, flow.ExporterAddress)
@@ -124,6 +191,13 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 
 	// set next hop according to user config
 	flow.NextHop = c.getNextHop(flow.NextHop, destRouting.NextHop)
+	if exitPoint, ok := c.getExitPoint(flow.NextHop, destRouting.ExitRouter); ok {
+		c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnDstExitRouter, []byte(exitPoint.Router))
+		c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnDstExitSite, []byte(exitPoint.Site))
+	}
+	if destRouting.ExitPeer.IsValid() {
+		c.d.Schema.ProtobufAppendIP(flow, schema.ColumnDstExitPeer, destRouting.ExitPeer)
+	}
 
 	// set asns according to user config
 	flow.SrcAS = c.getASNumber(flow.SrcAS, sourceRouting.ASN)
@@ -146,14 +220,36 @@ func (c *Component) enrichFlow(exporterIP netip.Addr, exporterStr string, flow *
 		c.d.Schema.ProtobufAppendVarintForce(flow,
 			schema.ColumnDstLargeCommunitiesLocalData2, uint64(comm.LocalData2))
 	}
+	if len(c.config.NetworkRules) > 0 {
+		c.writeNetworkAttributes(flow, sourceRouting, destRouting)
+	}
 
 	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnExporterName, []byte(flowExporterName))
-	c.d.Schema.ProtobufAppendVarint(flow, schema.ColumnInIfSpeed, uint64(flowInIfSpeed))
-	c.d.Schema.ProtobufAppendVarint(flow, schema.ColumnOutIfSpeed, uint64(flowOutIfSpeed))
+	c.d.Schema.ProtobufAppendVarint(flow, schema.ColumnInIfSpeed, uint64(inIfClassification.Speed))
+	c.d.Schema.ProtobufAppendVarint(flow, schema.ColumnOutIfSpeed, uint64(outIfClassification.Speed))
 
 	return
 }
 
+// getExitPoint retrieves the exit point for a flow. The next hop is looked up
+// in the configured exit points first. Otherwise, the router which learned the
+// route (as revealed by BMP) is used, with the configured name and site if
+// any.
+func (c *Component) getExitPoint(nextHop, exitRouter netip.Addr) (ExitPoint, bool) {
+	if nextHop.IsValid() && !nextHop.IsUnspecified() {
+		if exitPoint, ok := c.config.ExitPoints.Lookup(nextHop); ok {
+			return exitPoint, true
+		}
+	}
+	if !exitRouter.IsValid() || exitRouter.IsUnspecified() {
+		return ExitPoint{}, false
+	}
+	if exitPoint, ok := c.config.ExitPoints.Lookup(exitRouter); ok {
+		return exitPoint, true
+	}
+	return ExitPoint{Router: exitRouter.Unmap().String()}, true
+}
+
 // getASNumber retrieves the AS number for a flow, depending on user preferences.
 func (c *Component) getASNumber(flowAS, bmpAS uint32) (asn uint32) {
 	for _, provider := range c.config.ASNProviders {
@@ -224,17 +320,17 @@ func (c *Component) writeExporter(flow *schema.FlowMessage, classification expor
 	return true
 }
 
-func (c *Component) classifyExporter(t time.Time, ip string, name string, flow *schema.FlowMessage, classification exporterClassification) bool {
+func (c *Component) classifyExporter(t time.Time, ip string, name string, flow *schema.FlowMessage, classification exporterClassification) (exporterClassification, bool) {
 	// we already have the info provided by the metadata component
 	if (classification != exporterClassification{}) {
-		return c.writeExporter(flow, classification)
+		return classification, c.writeExporter(flow, classification)
 	}
 	if len(c.config.ExporterClassifiers) == 0 {
-		return true
+		return classification, true
 	}
 	si := exporterInfo{IP: ip, Name: name}
 	if classification, ok := c.classifierExporterCache.Get(t, si); ok {
-		return c.writeExporter(flow, classification)
+		return classification, c.writeExporter(flow, classification)
 	}
 
 	for idx, rule := range c.config.ExporterClassifiers {
@@ -253,7 +349,7 @@ func (c *Component) classifyExporter(t time.Time, ip string, name string, flow *
 		break
 	}
 	c.classifierExporterCache.Put(t, si, classification)
-	return c.writeExporter(flow, classification)
+	return classification, c.writeExporter(flow, classification)
 }
 
 func (c *Component) writeInterface(flow *schema.FlowMessage, classification interfaceClassification, directionIn bool) bool {
@@ -286,20 +382,23 @@ func (c *Component) classifyInterface(
 	ifDescription string,
 	ifSpeed uint32,
 	ifVlan uint16,
+	ifParent parentInterfaceInfo,
 	classification interfaceClassification,
 	directionIn bool,
-) bool {
+) (interfaceClassification, bool) {
 	// we already have the info provided by the metadata component
 	if (classification != interfaceClassification{}) {
 		classification.Name = ifName
 		classification.Description = ifDescription
-		return c.writeInterface(fl, classification, directionIn)
+		classification.Speed = ifSpeed
+		return classification, c.writeInterface(fl, classification, directionIn)
 	}
 	if len(c.config.InterfaceClassifiers) == 0 {
 		classification.Name = ifName
 		classification.Description = ifDescription
+		classification.Speed = ifSpeed
 		c.writeInterface(fl, classification, directionIn)
-		return true
+		return classification, true
 	}
 	si := exporterInfo{IP: ip, Name: exporterName}
 	ii := interfaceInfo{
@@ -308,13 +407,14 @@ func (c *Component) classifyInterface(
 		Description: ifDescription,
 		Speed:       ifSpeed,
 		VLAN:        ifVlan,
+		Parent:      ifParent,
 	}
 	key := exporterAndInterfaceInfo{
 		Exporter:  si,
 		Interface: ii,
 	}
 	if classification, ok := c.classifierInterfaceCache.Get(t, key); ok {
-		return c.writeInterface(fl, classification, directionIn)
+		return classification, c.writeInterface(fl, classification, directionIn)
 	}
 
 	for idx, rule := range c.config.InterfaceClassifiers {
@@ -329,6 +429,16 @@ func (c *Component) classifyInterface(
 			c.metrics.classifierErrors.WithLabelValues("interface", strconv.Itoa(idx)).Inc()
 			break
 		}
+		if classification.UseParent && ii.Parent.Name != "" {
+			// Next rules and the result use the parent interface.
+			ii = interfaceInfo{
+				Index:       ii.Parent.Index,
+				Name:        ii.Parent.Name,
+				Description: ii.Parent.Description,
+				Speed:       ii.Parent.Speed,
+				VLAN:        ii.VLAN,
+			}
+		}
 		if classification.Connectivity == "" || classification.Provider == "" {
 			continue
 		}
@@ -338,13 +448,14 @@ func (c *Component) classifyInterface(
 		break
 	}
 	if classification.Name == "" {
-		classification.Name = ifName
+		classification.Name = ii.Name
 	}
 	if classification.Description == "" {
-		classification.Description = ifDescription
+		classification.Description = ii.Description
 	}
+	classification.Speed = ii.Speed
 	c.classifierInterfaceCache.Put(t, key, classification)
-	return c.writeInterface(fl, classification, directionIn)
+	return classification, c.writeInterface(fl, classification, directionIn)
 }
 
 func isPrivateAS(as uint32) bool {
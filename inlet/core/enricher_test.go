@@ -26,10 +26,11 @@ import (
 
 func TestEnrich(t *testing.T) {
 	cases := []struct {
-		Name          string
-		Configuration gin.H
-		InputFlow     func() *schema.FlowMessage
-		OutputFlow    *schema.FlowMessage
+		Name           string
+		Configuration  gin.H
+		EnabledColumns []schema.ColumnKey
+		InputFlow      func() *schema.FlowMessage
+		OutputFlow     *schema.FlowMessage
 	}{
 		{
 			Name:          "no rule",
@@ -353,6 +354,44 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 					schema.ColumnOutIfBoundary:    schema.InterfaceBoundaryInternal,
 				},
 			},
+		}, {
+			Name: "interface rule with parent",
+			Configuration: gin.H{
+				"interfaceclassifiers": []string{
+					`Interface.Parent.Description startsWith "Transit:" && UseParent()`,
+					`
+Interface.Description startsWith "Transit:" &&
+ClassifyConnectivity("transit") &&
+ClassifyExternal() &&
+ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
+					`ClassifyInternal()`,
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            1100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfProvider:     "cogent",
+					schema.ColumnInIfConnectivity: "transit",
+					schema.ColumnInIfName:         "Po1000",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Transit: Cogent",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        2000,
+					schema.ColumnOutIfSpeed:       1000,
+					schema.ColumnInIfBoundary:     schema.InterfaceBoundaryExternal,
+					schema.ColumnOutIfBoundary:    schema.InterfaceBoundaryInternal,
+				},
+			},
 		}, {
 			Name: "configure twice boundary",
 			Configuration: gin.H{
@@ -452,6 +491,91 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				},
 			},
 		},
+		{
+			Name: "tag flows from non-authoritative observation points",
+			Configuration: gin.H{
+				"interfaceclassifiers": []string{`ClassifyInternal()`},
+				"deduplication": gin.H{
+					"authoritative": `InIf.Boundary == "external" || Exporter.Name == "edge1"`,
+				},
+			},
+			EnabledColumns: []schema.ColumnKey{schema.ColumnDuplicate},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        1000,
+					schema.ColumnOutIfSpeed:       1000,
+					schema.ColumnInIfBoundary:     2, // internal
+					schema.ColumnOutIfBoundary:    2, // internal
+					schema.ColumnDuplicate:        1,
+				},
+			},
+		},
+		{
+			Name: "do not tag flows from authoritative observation points",
+			Configuration: gin.H{
+				"interfaceclassifiers": []string{`Interface.Index == 100 && ClassifyExternal()`, `ClassifyInternal()`},
+				"deduplication": gin.H{
+					"authoritative": `InIf.Boundary == "external"`,
+				},
+			},
+			EnabledColumns: []schema.ColumnKey{schema.ColumnDuplicate},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:     "192_0_2_142",
+					schema.ColumnInIfName:         "Gi0/0/100",
+					schema.ColumnOutIfName:        "Gi0/0/200",
+					schema.ColumnInIfDescription:  "Interface 100",
+					schema.ColumnOutIfDescription: "Interface 200",
+					schema.ColumnInIfSpeed:        1000,
+					schema.ColumnOutIfSpeed:       1000,
+					schema.ColumnInIfBoundary:     1, // external
+					schema.ColumnOutIfBoundary:    2, // internal
+				},
+			},
+		},
+		{
+			Name: "drop flows from non-authoritative observation points",
+			Configuration: gin.H{
+				"interfaceclassifiers": []string{`ClassifyInternal()`},
+				"deduplication": gin.H{
+					"authoritative": `InIf.Boundary == "external"`,
+					"action":        "drop",
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+				}
+			},
+		},
 		{
 			Name: "use metatada instead of classifier",
 			Configuration: gin.H{
@@ -531,6 +655,197 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 					schema.ColumnDstNetMask:                    27,
 				},
 			},
+		}, {
+			Name: "exit point from routing next hop",
+			Configuration: gin.H{
+				"exitpoints": gin.H{
+					"198.51.100.0/29": gin.H{"router": "edge1", "site": "paris"},
+					"198.51.100.8/29": gin.H{"router": "edge2", "site": "lyon"},
+				},
+			},
+			EnabledColumns: []schema.ColumnKey{schema.ColumnDstExitRouter, schema.ColumnDstExitSite},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+					DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+					NextHop:         netip.IPv6Unspecified(),
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+				DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+				SrcAS:           1299,
+				DstAS:           174,
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:                  "192_0_2_142",
+					schema.ColumnInIfName:                      "Gi0/0/100",
+					schema.ColumnOutIfName:                     "Gi0/0/200",
+					schema.ColumnInIfDescription:               "Interface 100",
+					schema.ColumnOutIfDescription:              "Interface 200",
+					schema.ColumnInIfSpeed:                     1000,
+					schema.ColumnOutIfSpeed:                    1000,
+					schema.ColumnDstASPath:                     []uint32{64200, 1299, 174},
+					schema.ColumnDstCommunities:                []uint32{100, 200, 400},
+					schema.ColumnDstLargeCommunitiesASN:        []int32{64200},
+					schema.ColumnDstLargeCommunitiesLocalData1: []int32{2},
+					schema.ColumnDstLargeCommunitiesLocalData2: []int32{3},
+					schema.ColumnSrcNetMask:                    27,
+					schema.ColumnDstNetMask:                    27,
+					schema.ColumnDstExitRouter:                 "edge1",
+					schema.ColumnDstExitSite:                   "paris",
+				},
+			},
+		}, {
+			Name: "exit point from BMP peer",
+			Configuration: gin.H{
+				"exitpoints": gin.H{
+					"127.0.0.1/32": gin.H{"router": "edge3", "site": "marseille"},
+				},
+			},
+			EnabledColumns: []schema.ColumnKey{
+				schema.ColumnDstExitRouter,
+				schema.ColumnDstExitSite,
+				schema.ColumnDstExitPeer,
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+					DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+					NextHop:         netip.IPv6Unspecified(),
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+				DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+				SrcAS:           1299,
+				DstAS:           174,
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:                  "192_0_2_142",
+					schema.ColumnInIfName:                      "Gi0/0/100",
+					schema.ColumnOutIfName:                     "Gi0/0/200",
+					schema.ColumnInIfDescription:               "Interface 100",
+					schema.ColumnOutIfDescription:              "Interface 200",
+					schema.ColumnInIfSpeed:                     1000,
+					schema.ColumnOutIfSpeed:                    1000,
+					schema.ColumnDstASPath:                     []uint32{64200, 1299, 174},
+					schema.ColumnDstCommunities:                []uint32{100, 200, 400},
+					schema.ColumnDstLargeCommunitiesASN:        []int32{64200},
+					schema.ColumnDstLargeCommunitiesLocalData1: []int32{2},
+					schema.ColumnDstLargeCommunitiesLocalData2: []int32{3},
+					schema.ColumnSrcNetMask:                    27,
+					schema.ColumnDstNetMask:                    27,
+					schema.ColumnDstExitRouter:                 "edge3",
+					schema.ColumnDstExitSite:                   "marseille",
+					schema.ColumnDstExitPeer: []byte{
+						0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 203, 0, 113, 4,
+					},
+				},
+			},
+		}, {
+			Name:           "exit router from unknown BMP peer",
+			Configuration:  gin.H{},
+			EnabledColumns: []schema.ColumnKey{schema.ColumnDstExitRouter, schema.ColumnDstExitSite},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+					DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+					NextHop:         netip.IPv6Unspecified(),
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+				DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+				SrcAS:           1299,
+				DstAS:           174,
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:                  "192_0_2_142",
+					schema.ColumnInIfName:                      "Gi0/0/100",
+					schema.ColumnOutIfName:                     "Gi0/0/200",
+					schema.ColumnInIfDescription:               "Interface 100",
+					schema.ColumnOutIfDescription:              "Interface 200",
+					schema.ColumnInIfSpeed:                     1000,
+					schema.ColumnOutIfSpeed:                    1000,
+					schema.ColumnDstASPath:                     []uint32{64200, 1299, 174},
+					schema.ColumnDstCommunities:                []uint32{100, 200, 400},
+					schema.ColumnDstLargeCommunitiesASN:        []int32{64200},
+					schema.ColumnDstLargeCommunitiesLocalData1: []int32{2},
+					schema.ColumnDstLargeCommunitiesLocalData2: []int32{3},
+					schema.ColumnSrcNetMask:                    27,
+					schema.ColumnDstNetMask:                    27,
+					schema.ColumnDstExitRouter:                 "127.0.0.1",
+				},
+			},
+		},
+		{
+			Name: "network attributes from routes",
+			Configuration: gin.H{
+				"networkrules": []gin.H{
+					{"communities": []string{"0:1*"}, "tenant": "customer-a"},
+					{
+						"largecommunities": []string{"64200:2:*"},
+						"originasns":       []uint32{174},
+						"tenant":           "ignored",
+						"role":             "customer",
+					},
+					{"originasns": []uint32{1299}, "role": "transit", "site": "paris"},
+				},
+			},
+			InputFlow: func() *schema.FlowMessage {
+				return &schema.FlowMessage{
+					SamplingRate:    1000,
+					ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+					InIf:            100,
+					OutIf:           200,
+					SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+					DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+				}
+			},
+			OutputFlow: &schema.FlowMessage{
+				SamplingRate:    1000,
+				ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.142"),
+				SrcAddr:         netip.MustParseAddr("::ffff:192.0.2.142"),
+				DstAddr:         netip.MustParseAddr("::ffff:192.0.2.10"),
+				SrcAS:           1299,
+				DstAS:           174,
+				ProtobufDebug: map[schema.ColumnKey]interface{}{
+					schema.ColumnExporterName:                  "192_0_2_142",
+					schema.ColumnInIfName:                      "Gi0/0/100",
+					schema.ColumnOutIfName:                     "Gi0/0/200",
+					schema.ColumnInIfDescription:               "Interface 100",
+					schema.ColumnOutIfDescription:              "Interface 200",
+					schema.ColumnInIfSpeed:                     1000,
+					schema.ColumnOutIfSpeed:                    1000,
+					schema.ColumnDstASPath:                     []uint32{64200, 1299, 174},
+					schema.ColumnDstCommunities:                []uint32{100, 200, 400},
+					schema.ColumnDstLargeCommunitiesASN:        []int32{64200},
+					schema.ColumnDstLargeCommunitiesLocalData1: []int32{2},
+					schema.ColumnDstLargeCommunitiesLocalData2: []int32{3},
+					schema.ColumnSrcNetMask:                    27,
+					schema.ColumnDstNetMask:                    27,
+					schema.ColumnSrcNetRole:                    "transit",
+					schema.ColumnSrcNetSite:                    "paris",
+					schema.ColumnDstNetTenant:                  "customer-a",
+					schema.ColumnDstNetRole:                    "customer",
+				},
+			},
 		},
 	}
 	for _, tc := range cases {
@@ -557,6 +872,18 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				t.Fatalf("Decode() error:\n%+v", err)
 			}
 
+			// Prepare the schema
+			schemaComponent := schema.NewMock(t)
+			if tc.EnabledColumns != nil || len(configuration.NetworkRules) > 0 {
+				schemaConfiguration := schema.DefaultConfiguration()
+				schemaConfiguration.Enabled = tc.EnabledColumns
+				schemaConfiguration.NetworkAttributesFromRoutes = len(configuration.NetworkRules) > 0
+				schemaComponent, err = schema.New(schemaConfiguration)
+				if err != nil {
+					t.Fatalf("schema.New() error:\n%+v", err)
+				}
+			}
+
 			// Instantiate and start core
 			c, err := New(r, configuration, Dependencies{
 				Daemon:   daemonComponent,
@@ -565,7 +892,7 @@ ClassifyProviderRegex(Interface.Description, "^Transit: ([^ ]+)", "$1")`,
 				Kafka:    kafkaComponent,
 				HTTP:     httpComponent,
 				Routing:  routingComponent,
-				Schema:   schema.NewMock(t),
+				Schema:   schemaComponent,
 			})
 			if err != nil {
 				t.Fatalf("New() error:\n%+v", err)
@@ -13,11 +13,18 @@ type metrics struct {
 	flowsReceived    *reporter.CounterVec
 	flowsForwarded   *reporter.CounterVec
 	flowsErrors      *reporter.CounterVec
+	flowsDuplicates  *reporter.CounterVec
 	flowsHTTPClients reporter.GaugeFunc
 
 	classifierExporterCacheSize  reporter.CounterFunc
 	classifierInterfaceCacheSize reporter.CounterFunc
 	classifierErrors             *reporter.CounterVec
+
+	networkAttributes *reporter.CounterVec
+
+	sidecarLatency reporter.Summary
+	sidecarErrors  *reporter.CounterVec
+	sidecarFlows   *reporter.CounterVec
 }
 
 func (c *Component) initMetrics() {
@@ -42,6 +49,13 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"exporter", "error"},
 	)
+	c.metrics.flowsDuplicates = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "duplicate_flows_total",
+			Help: "Number of flows from non-authoritative observation points.",
+		},
+		[]string{"exporter"},
+	)
 	c.metrics.flowsHTTPClients = c.r.GaugeFunc(
 		reporter.GaugeOpts{
 			Name: "flows_http_clients",
@@ -76,4 +90,35 @@ func (c *Component) initMetrics() {
 			Help: "Number of errors when evaluating a classifer",
 		},
 		[]string{"type", "index"})
+	c.metrics.networkAttributes = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "network_attributes_total",
+			Help: "Number of network attributes derived from routes.",
+		},
+		[]string{"attribute", "rule"})
+
+	if c.sidecar == nil {
+		return
+	}
+	c.metrics.sidecarLatency = c.r.Summary(
+		reporter.SummaryOpts{
+			Name:       "sidecar_request_seconds",
+			Help:       "Latency added by the enrichment sidecar for a batch.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		},
+	)
+	c.metrics.sidecarErrors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sidecar_errors_total",
+			Help: "Number of errors when using the enrichment sidecar.",
+		},
+		[]string{"error"},
+	)
+	c.metrics.sidecarFlows = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sidecar_flows_total",
+			Help: "Number of flows sent to the enrichment sidecar.",
+		},
+		[]string{"outcome"},
+	)
 }
@@ -0,0 +1,203 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/osrg/gobgp/v3/pkg/packet/bgp"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+	"akvorado/inlet/routing/provider"
+)
+
+// NetworkRule maps routes to network attributes. A route matches a rule when
+// it matches all the provided criteria.
+type NetworkRule struct {
+	// Communities is a list of patterns for standard communities. At least
+	// one of them should match.
+	Communities []CommunityPattern `validate:"required_without_all=LargeCommunities OriginASNs"`
+	// LargeCommunities is a list of patterns for large communities. At least
+	// one of them should match.
+	LargeCommunities []CommunityPattern
+	// OriginASNs is a list of origin AS numbers. One of them should match.
+	OriginASNs []uint32
+	// Tenant is the tenant to set when the rule matches.
+	Tenant string `validate:"required_without_all=Role Site"`
+	// Role is the role to set when the rule matches.
+	Role string
+	// Site is the site to set when the rule matches.
+	Site string
+}
+
+// CommunityPattern is a pattern matching a community, like 65000:100 or
+// 65000:1*. The syntax is the one for shell patterns.
+type CommunityPattern string
+
+// UnmarshalText validates a community pattern.
+func (cp *CommunityPattern) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return fmt.Errorf("invalid community pattern %q", string(text))
+	}
+	for _, part := range parts {
+		if _, err := path.Match(part, ""); part == "" || err != nil {
+			return fmt.Errorf("invalid community pattern %q", string(text))
+		}
+	}
+	*cp = CommunityPattern(text)
+	return nil
+}
+
+// matchCommunities tells if one of the patterns matches one of the provided
+// communities.
+func matchCommunities(patterns []CommunityPattern, communities []string) bool {
+	for _, pattern := range patterns {
+		for _, community := range communities {
+			if ok, _ := path.Match(string(pattern), community); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// networkAttribute is an attribute derived from a route with the index of the
+// rule providing it (-1 when no rule matched).
+type networkAttribute struct {
+	Value string `json:"value"`
+	Rule  int    `json:"rule"`
+}
+
+// networkAttributes contains the attributes derived from a route.
+type networkAttributes struct {
+	Tenant networkAttribute `json:"tenant"`
+	Role   networkAttribute `json:"role"`
+	Site   networkAttribute `json:"site"`
+}
+
+// matchNetworkRules applies network rules to the provided route. For each
+// attribute, the first matching rule providing it wins.
+func (c *Component) matchNetworkRules(route provider.LookupResult) networkAttributes {
+	attrs := networkAttributes{
+		Tenant: networkAttribute{Rule: -1},
+		Role:   networkAttribute{Rule: -1},
+		Site:   networkAttribute{Rule: -1},
+	}
+	if route.ASN == 0 && len(route.Communities) == 0 && len(route.LargeCommunities) == 0 {
+		return attrs
+	}
+	var communities, largeCommunities []string
+	for idx, rule := range c.config.NetworkRules {
+		if attrs.Tenant.Value != "" && attrs.Role.Value != "" && attrs.Site.Value != "" {
+			break
+		}
+		if len(rule.OriginASNs) > 0 && !slices.Contains(rule.OriginASNs, route.ASN) {
+			continue
+		}
+		if len(rule.Communities) > 0 {
+			if communities == nil {
+				communities = make([]string, len(route.Communities))
+				for i, comm := range route.Communities {
+					communities[i] = fmt.Sprintf("%d:%d", comm>>16, comm&0xffff)
+				}
+			}
+			if !matchCommunities(rule.Communities, communities) {
+				continue
+			}
+		}
+		if len(rule.LargeCommunities) > 0 {
+			if largeCommunities == nil {
+				largeCommunities = make([]string, len(route.LargeCommunities))
+				for i, comm := range route.LargeCommunities {
+					largeCommunities[i] = fmt.Sprintf("%d:%d:%d", comm.ASN, comm.LocalData1, comm.LocalData2)
+				}
+			}
+			if !matchCommunities(rule.LargeCommunities, largeCommunities) {
+				continue
+			}
+		}
+		if attrs.Tenant.Value == "" && rule.Tenant != "" {
+			attrs.Tenant = networkAttribute{rule.Tenant, idx}
+		}
+		if attrs.Role.Value == "" && rule.Role != "" {
+			attrs.Role = networkAttribute{rule.Role, idx}
+		}
+		if attrs.Site.Value == "" && rule.Site != "" {
+			attrs.Site = networkAttribute{rule.Site, idx}
+		}
+	}
+	return attrs
+}
+
+// deriveNetworkAttributes applies network rules to the provided route and
+// records which rule provided each attribute.
+func (c *Component) deriveNetworkAttributes(route provider.LookupResult) networkAttributes {
+	attrs := c.matchNetworkRules(route)
+	if attrs.Tenant.Rule >= 0 {
+		c.metrics.networkAttributes.WithLabelValues("tenant", strconv.Itoa(attrs.Tenant.Rule)).Inc()
+	}
+	if attrs.Role.Rule >= 0 {
+		c.metrics.networkAttributes.WithLabelValues("role", strconv.Itoa(attrs.Role.Rule)).Inc()
+	}
+	if attrs.Site.Rule >= 0 {
+		c.metrics.networkAttributes.WithLabelValues("site", strconv.Itoa(attrs.Site.Rule)).Inc()
+	}
+	return attrs
+}
+
+// writeNetworkAttributes derives network attributes from the source and
+// destination routes and adds them to the flow.
+func (c *Component) writeNetworkAttributes(flow *schema.FlowMessage, sourceRouting, destRouting provider.LookupResult) {
+	src := c.deriveNetworkAttributes(sourceRouting)
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnSrcNetTenant, []byte(src.Tenant.Value))
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnSrcNetRole, []byte(src.Role.Value))
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnSrcNetSite, []byte(src.Site.Value))
+	dst := c.deriveNetworkAttributes(destRouting)
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnDstNetTenant, []byte(dst.Tenant.Value))
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnDstNetRole, []byte(dst.Role.Value))
+	c.d.Schema.ProtobufAppendBytes(flow, schema.ColumnDstNetSite, []byte(dst.Site.Value))
+}
+
+type networkRulesParameters struct {
+	ASN              uint32   `form:"asn"`
+	Communities      []string `form:"community"`
+	LargeCommunities []string `form:"large-community"`
+}
+
+// NetworkRulesHTTPHandler applies network rules to the route described by the
+// query parameters and returns the derived attributes with the index of the
+// rule providing each of them. This is intended for debug only.
+func (c *Component) NetworkRulesHTTPHandler(gc *gin.Context) {
+	var params networkRulesParameters
+	if err := gc.ShouldBindQuery(&params); err != nil {
+		gc.JSON(http.StatusBadRequest, gin.H{"message": helpers.Capitalize(err.Error())})
+		return
+	}
+	route := provider.LookupResult{ASN: params.ASN}
+	for _, community := range params.Communities {
+		var asn, value uint16
+		if _, err := fmt.Sscanf(community, "%d:%d", &asn, &value); err != nil {
+			gc.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid community %q.", community)})
+			return
+		}
+		route.Communities = append(route.Communities, uint32(asn)<<16|uint32(value))
+	}
+	for _, community := range params.LargeCommunities {
+		var lc bgp.LargeCommunity
+		if _, err := fmt.Sscanf(community, "%d:%d:%d", &lc.ASN, &lc.LocalData1, &lc.LocalData2); err != nil {
+			gc.JSON(http.StatusBadRequest, gin.H{"message": fmt.Sprintf("Invalid large community %q.", community)})
+			return
+		}
+		route.LargeCommunities = append(route.LargeCommunities, lc)
+	}
+	gc.JSON(http.StatusOK, c.matchNetworkRules(route))
+}
@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+)
+
+func TestNetworkRulesHTTPHandler(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c := Component{
+		config: Configuration{
+			NetworkRules: []NetworkRule{
+				{Communities: []CommunityPattern{"0:1*"}, Tenant: "customer-a"},
+				{
+					LargeCommunities: []CommunityPattern{"64200:2:*"},
+					OriginASNs:       []uint32{174},
+					Tenant:           "ignored",
+					Role:             "customer",
+				},
+				{OriginASNs: []uint32{1299}, Role: "transit", Site: "paris"},
+			},
+		},
+	}
+	h.GinRouter.GET("/api/v0/inlet/network-rules", c.NetworkRulesHTTPHandler)
+
+	unset := gin.H{"value": "", "rule": -1}
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			Description: "no match",
+			URL:         "/api/v0/inlet/network-rules?asn=64500",
+			JSONOutput:  gin.H{"tenant": unset, "role": unset, "site": unset},
+		}, {
+			Description: "several rules",
+			URL:         "/api/v0/inlet/network-rules?asn=174&community=0:100&large-community=64200:2:3",
+			JSONOutput: gin.H{
+				"tenant": gin.H{"value": "customer-a", "rule": 0},
+				"role":   gin.H{"value": "customer", "rule": 1},
+				"site":   unset,
+			},
+		}, {
+			Description: "origin ASN",
+			URL:         "/api/v0/inlet/network-rules?asn=1299",
+			JSONOutput: gin.H{
+				"tenant": unset,
+				"role":   gin.H{"value": "transit", "rule": 2},
+				"site":   gin.H{"value": "paris", "rule": 2},
+			},
+		}, {
+			Description: "invalid community",
+			URL:         "/api/v0/inlet/network-rules?community=65536:1",
+			StatusCode:  400,
+			JSONOutput:  gin.H{"message": `Invalid community "65536:1".`},
+		},
+	})
+}
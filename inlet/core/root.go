@@ -5,6 +5,7 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,7 @@ import (
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
+	"akvorado/inlet/archive"
 	"akvorado/inlet/flow"
 	"akvorado/inlet/kafka"
 	"akvorado/inlet/metadata"
@@ -39,6 +41,9 @@ type Component struct {
 	classifierExporterCache  *cache.Cache[exporterInfo, exporterClassification]
 	classifierInterfaceCache *cache.Cache[exporterAndInterfaceInfo, interfaceClassification]
 	classifierErrLogger      reporter.Logger
+
+	sidecar          *sidecarClient
+	sidecarErrLogger reporter.Logger
 }
 
 // Dependencies define the dependencies of the HTTP component.
@@ -47,13 +52,24 @@ type Dependencies struct {
 	Flow     *flow.Component
 	Metadata *metadata.Component
 	Routing  *routing.Component
-	Kafka    *kafka.Component
+	Kafka    *kafka.Component   // may be nil
+	Archive  *archive.Component // may be nil
 	HTTP     *httpserver.Component
 	Schema   *schema.Component
 }
 
 // New creates a new core component.
 func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	if configuration.Deduplication.Authoritative.program != nil && configuration.Deduplication.Action == "tag" {
+		if column, _ := dependencies.Schema.LookupColumnByKey(schema.ColumnDuplicate); column.Disabled {
+			return nil, errors.New("tagging duplicate flows requires the Duplicate column to be enabled")
+		}
+	}
+	if len(configuration.NetworkRules) > 0 {
+		if column, _ := dependencies.Schema.LookupColumnByKey(schema.ColumnSrcNetTenant); !column.ClickHouseSelfGenerated {
+			return nil, errors.New("network rules require network attributes from routes to be enabled in the schema")
+		}
+	}
 	c := Component{
 		r:      r,
 		d:      &dependencies,
@@ -67,6 +83,14 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		classifierExporterCache:  cache.New[exporterInfo, exporterClassification](),
 		classifierInterfaceCache: cache.New[exporterAndInterfaceInfo, interfaceClassification](),
 		classifierErrLogger:      r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+		sidecarErrLogger:         r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+	}
+	if configuration.Sidecar.Target != "" {
+		sidecar, err := newSidecarClient(configuration.Sidecar, dependencies.Schema)
+		if err != nil {
+			return nil, err
+		}
+		c.sidecar = sidecar
 	}
 	c.d.Daemon.Track(&c.t, "inlet/core")
 	c.initMetrics()
@@ -99,6 +123,7 @@ func (c *Component) Start() error {
 
 	c.r.RegisterHealthcheck("core", c.channelHealthcheck())
 	c.d.HTTP.GinRouter.GET("/api/v0/inlet/flows", c.FlowsHTTPHandler)
+	c.d.HTTP.GinRouter.GET("/api/v0/inlet/network-rules", c.NetworkRulesHTTPHandler)
 	return nil
 }
 
@@ -106,18 +131,45 @@ func (c *Component) Start() error {
 func (c *Component) runWorker(workerID int) error {
 	c.r.Debug().Int("worker", workerID).Msg("starting core worker")
 
+	// When using a sidecar, flows are batched
+	var batch []sidecarFlow
+	var batchTimer *time.Timer
+	var batchTimerC <-chan time.Time
+	if c.sidecar != nil {
+		batch = make([]sidecarFlow, 0, c.config.Sidecar.BatchSize)
+		batchTimer = time.NewTimer(c.config.Sidecar.BatchDelay)
+		batchTimer.Stop()
+		batchTimerC = batchTimer.C
+	}
+	flushBatch := func() {
+		if len(batch) == 0 {
+			return
+		}
+		batchTimer.Stop()
+		c.sidecarEnrich(batch)
+		for _, bf := range batch {
+			c.forwardFlow(bf.exporter, bf.flow)
+		}
+		clear(batch)
+		batch = batch[:0]
+	}
+
 	for {
 		select {
 		case <-c.t.Dying():
 			c.r.Debug().Int("worker", workerID).Msg("stopping core worker")
+			flushBatch()
 			return nil
 		case cb, ok := <-c.healthy:
 			if ok {
 				cb(reporter.HealthcheckOK, fmt.Sprintf("worker %d ok", workerID))
 			}
+		case <-batchTimerC:
+			flushBatch()
 		case flow := <-c.d.Flow.Flows():
 			if flow == nil {
 				c.r.Info().Int("worker", workerID).Msg("no more flow available, stopping")
+				flushBatch()
 				return nil
 			}
 
@@ -130,22 +182,44 @@ func (c *Component) runWorker(workerID int) error {
 				continue
 			}
 
-			// Serialize flow to Protobuf
-			buf := c.d.Schema.ProtobufMarshal(flow)
-
-			// Forward to Kafka. This could block and buf is now owned by the
-			// Kafka subsystem!
-			c.metrics.flowsForwarded.WithLabelValues(exporter).Inc()
-			c.d.Kafka.Send(exporter, buf)
-
-			// If we have HTTP clients, send to them too
-			if atomic.LoadUint32(&c.httpFlowClients) > 0 {
-				select {
-				case c.httpFlowChannel <- flow: // OK
-				default: // Overflow, best effort and ignore
+			// External enrichment
+			if c.sidecar != nil {
+				batch = append(batch, sidecarFlow{exporter, flow})
+				if len(batch) == 1 {
+					batchTimer.Reset(c.config.Sidecar.BatchDelay)
+				}
+				if len(batch) >= c.config.Sidecar.BatchSize {
+					flushBatch()
 				}
+				continue
 			}
 
+			c.forwardFlow(exporter, flow)
+		}
+	}
+}
+
+// forwardFlow serializes a flow and forwards it to Kafka, the archive, and
+// HTTP clients.
+func (c *Component) forwardFlow(exporter string, flow *schema.FlowMessage) {
+	// Serialize flow to Protobuf
+	buf := c.d.Schema.ProtobufMarshal(flow)
+
+	// Forward to Kafka and/or to the archive. This could block and buf is now
+	// shared (read-only) by these subsystems!
+	c.metrics.flowsForwarded.WithLabelValues(exporter).Inc()
+	if c.d.Archive != nil {
+		c.d.Archive.Send(exporter, buf)
+	}
+	if c.d.Kafka != nil {
+		c.d.Kafka.Send(exporter, buf)
+	}
+
+	// If we have HTTP clients, send to them too
+	if atomic.LoadUint32(&c.httpFlowClients) > 0 {
+		select {
+		case c.httpFlowChannel <- flow: // OK
+		default: // Overflow, best effort and ignore
 		}
 	}
 }
@@ -155,6 +229,9 @@ func (c *Component) Stop() error {
 	defer func() {
 		close(c.httpFlowChannel)
 		close(c.healthy)
+		if c.sidecar != nil {
+			c.sidecar.conn.Close()
+		}
 		c.r.Info().Msg("core component stopped")
 	}()
 	c.r.Info().Msg("stopping core component")
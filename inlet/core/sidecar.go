@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	_ "embed" // for protocol definition
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"akvorado/common/helpers/grpcdynamic"
+	"akvorado/common/schema"
+)
+
+// SidecarProtocolDefinition is the protobuf definition of the protocol used to
+// query an enrichment sidecar.
+//
+//go:embed sidecar.proto
+var SidecarProtocolDefinition string
+
+// sidecarEnrichMethod is the full name of the gRPC method to query an
+// enrichment sidecar.
+const sidecarEnrichMethod = "/akvorado.enrichment.v1.Enrichment/Enrich"
+
+// sidecarProtocolDescriptors contains the descriptors from the protocol
+// definition.
+type sidecarProtocolDescriptors struct {
+	enrichRequest  protoreflect.MessageDescriptor
+	enrichResponse protoreflect.MessageDescriptor
+	flow           protoreflect.MessageDescriptor
+	value          protoreflect.MessageDescriptor
+}
+
+// loadSidecarProtocol parses the protocol definition on first use.
+var loadSidecarProtocol = sync.OnceValues(func() (sidecarProtocolDescriptors, error) {
+	messages, err := grpcdynamic.Parse("sidecar.proto", SidecarProtocolDefinition,
+		"EnrichRequest", "EnrichResponse", "Flow", "Value")
+	if err != nil {
+		return sidecarProtocolDescriptors{}, err
+	}
+	return sidecarProtocolDescriptors{
+		enrichRequest:  messages[0],
+		enrichResponse: messages[1],
+		flow:           messages[2],
+		value:          messages[3],
+	}, nil
+})
+
+// sidecarClient queries an enrichment sidecar.
+type sidecarClient struct {
+	conn     *grpc.ClientConn
+	protocol sidecarProtocolDescriptors
+	timeout  time.Duration
+	inputs   []*schema.Column
+	outputs  map[string]*schema.Column
+}
+
+// sidecarFlow is a flow waiting to be enriched by the sidecar.
+type sidecarFlow struct {
+	exporter string
+	flow     *schema.FlowMessage
+}
+
+// sidecarTypedColumns are the columns stored directly in schema.FlowMessage.
+// They are only appended to the protobuf representation when serializing. They
+// can be used as input but not as output.
+var sidecarTypedColumns = map[schema.ColumnKey]bool{
+	schema.ColumnTimeReceived:    true,
+	schema.ColumnSamplingRate:    true,
+	schema.ColumnExporterAddress: true,
+	schema.ColumnSrcAddr:         true,
+	schema.ColumnDstAddr:         true,
+	schema.ColumnNextHop:         true,
+	schema.ColumnSrcAS:           true,
+	schema.ColumnDstAS:           true,
+	schema.ColumnSrcNetMask:      true,
+	schema.ColumnDstNetMask:      true,
+	schema.ColumnSrcVlan:         true,
+	schema.ColumnDstVlan:         true,
+}
+
+var (
+	errSidecarUnknownColumn = errors.New("unknown column")
+	errSidecarInvalidValue  = errors.New("invalid value")
+)
+
+// newSidecarClient creates a client for the configured sidecar.
+func newSidecarClient(config SidecarConfiguration, sch *schema.Component) (*sidecarClient, error) {
+	client := sidecarClient{
+		timeout: config.Timeout,
+		outputs: map[string]*schema.Column{},
+	}
+	for _, key := range config.InputColumns {
+		column, ok := sch.LookupColumnByKey(key)
+		if !ok || column.Disabled || column.ProtobufIndex <= 0 {
+			return nil, fmt.Errorf("sidecar input column %s is not enabled", key)
+		}
+		client.inputs = append(client.inputs, column)
+	}
+	for _, key := range config.OutputColumns {
+		column, ok := sch.LookupColumnByKey(key)
+		if !ok || column.Disabled || column.ProtobufIndex <= 0 {
+			return nil, fmt.Errorf("sidecar output column %s is not enabled", key)
+		}
+		if sidecarTypedColumns[key] || column.ProtobufRepeated {
+			return nil, fmt.Errorf("sidecar output column %s cannot be set", key)
+		}
+		switch column.ProtobufType {
+		case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.Uint32Kind, protoreflect.Uint64Kind:
+		default:
+			return nil, fmt.Errorf("sidecar output column %s has an unsupported type", key)
+		}
+		client.outputs[column.Name] = column
+	}
+
+	var err error
+	client.protocol, err = loadSidecarProtocol()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load sidecar protocol: %w", err)
+	}
+	client.conn, err = grpcdynamic.NewClient(config.Target, config.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create client for sidecar: %w", err)
+	}
+	return &client, nil
+}
+
+// sidecarValue returns the value of the provided column for a flow. The
+// second return value is false if there is no value.
+func (c *Component) sidecarValue(flow *schema.FlowMessage, column *schema.Column) (protoreflect.Value, bool) {
+	ip := func(addr netip.Addr) (protoreflect.Value, bool) {
+		if !addr.IsValid() {
+			return protoreflect.Value{}, false
+		}
+		return protoreflect.ValueOfString(addr.Unmap().String()), true
+	}
+	integer := func(v uint64) (protoreflect.Value, bool) {
+		return protoreflect.ValueOfUint64(v), true
+	}
+	switch column.Key {
+	case schema.ColumnTimeReceived:
+		return integer(flow.TimeReceived)
+	case schema.ColumnSamplingRate:
+		return integer(uint64(flow.SamplingRate))
+	case schema.ColumnExporterAddress:
+		return ip(flow.ExporterAddress)
+	case schema.ColumnSrcAddr:
+		return ip(flow.SrcAddr)
+	case schema.ColumnDstAddr:
+		return ip(flow.DstAddr)
+	case schema.ColumnNextHop:
+		return ip(flow.NextHop)
+	case schema.ColumnSrcAS:
+		return integer(uint64(flow.SrcAS))
+	case schema.ColumnDstAS:
+		return integer(uint64(flow.DstAS))
+	case schema.ColumnSrcNetMask:
+		return integer(uint64(flow.SrcNetMask))
+	case schema.ColumnDstNetMask:
+		return integer(uint64(flow.DstNetMask))
+	case schema.ColumnSrcVlan:
+		return integer(uint64(flow.SrcVlan))
+	case schema.ColumnDstVlan:
+		return integer(uint64(flow.DstVlan))
+	}
+	value, ok := c.d.Schema.ProtobufLookup(flow, column.Key)
+	if !ok {
+		return protoreflect.Value{}, false
+	}
+	switch value := value.(type) {
+	case uint64:
+		if column.ProtobufType == protoreflect.EnumKind {
+			return protoreflect.ValueOfString(strings.ToLower(column.ProtobufEnum[int(value)])), true
+		}
+		return integer(value)
+	case []byte:
+		if column.ProtobufType == protoreflect.BytesKind {
+			addr, _ := netip.AddrFromSlice(value)
+			return ip(addr)
+		}
+		return protoreflect.ValueOfString(string(value)), true
+	}
+	return protoreflect.Value{}, false
+}
+
+// sidecarEnrich sends a batch of flows to the sidecar and adds the received
+// values to them. On error, flows are left untouched.
+func (c *Component) sidecarEnrich(batch []sidecarFlow) {
+	request := dynamicpb.NewMessage(c.sidecar.protocol.enrichRequest)
+	flows := request.Mutable(c.sidecar.protocol.enrichRequest.Fields().ByName("flows")).List()
+	columnsField := c.sidecar.protocol.flow.Fields().ByName("columns")
+	uintField := c.sidecar.protocol.value.Fields().ByName("uint")
+	stringField := c.sidecar.protocol.value.Fields().ByName("string")
+	for _, bf := range batch {
+		flow := dynamicpb.NewMessage(c.sidecar.protocol.flow)
+		columns := flow.Mutable(columnsField).Map()
+		for _, column := range c.sidecar.inputs {
+			v, ok := c.sidecarValue(bf.flow, column)
+			if !ok {
+				continue
+			}
+			value := dynamicpb.NewMessage(c.sidecar.protocol.value)
+			if _, ok := v.Interface().(string); ok {
+				value.Set(stringField, v)
+			} else {
+				value.Set(uintField, v)
+			}
+			columns.Set(protoreflect.ValueOfString(column.Name).MapKey(), protoreflect.ValueOfMessage(value))
+		}
+		flows.Append(protoreflect.ValueOfMessage(flow))
+	}
+	response := dynamicpb.NewMessage(c.sidecar.protocol.enrichResponse)
+
+	ctx, cancel := context.WithTimeout(c.t.Context(nil), c.sidecar.timeout)
+	defer cancel()
+	start := time.Now()
+	err := c.sidecar.conn.Invoke(ctx, sidecarEnrichMethod, request, response)
+	c.metrics.sidecarLatency.Observe(time.Since(start).Seconds())
+	if err != nil {
+		c.metrics.sidecarErrors.WithLabelValues(status.Code(err).String()).Inc()
+		c.metrics.sidecarFlows.WithLabelValues("fallback").Add(float64(len(batch)))
+		c.sidecarErrLogger.Err(err).Int("flows", len(batch)).Msg("cannot enrich flows with sidecar")
+		return
+	}
+
+	flows = response.Get(c.sidecar.protocol.enrichResponse.Fields().ByName("flows")).List()
+	if flows.Len() > len(batch) {
+		c.metrics.sidecarErrors.WithLabelValues("too many flows").Inc()
+	}
+	for i, bf := range batch {
+		if i >= flows.Len() {
+			c.metrics.sidecarFlows.WithLabelValues("unchanged").Add(float64(len(batch) - i))
+			break
+		}
+		columns := flows.Get(i).Message().Get(columnsField).Map()
+		if columns.Len() == 0 {
+			c.metrics.sidecarFlows.WithLabelValues("unchanged").Inc()
+			continue
+		}
+		columns.Range(func(k protoreflect.MapKey, v protoreflect.Value) bool {
+			if err := c.sidecarApply(bf.flow, k.String(), v.Message()); err != nil {
+				c.metrics.sidecarErrors.WithLabelValues(err.Error()).Inc()
+			}
+			return true
+		})
+		c.metrics.sidecarFlows.WithLabelValues("enriched").Inc()
+	}
+}
+
+// sidecarApply adds a value received from the sidecar to a flow.
+func (c *Component) sidecarApply(flow *schema.FlowMessage, name string, value protoreflect.Message) error {
+	column, ok := c.sidecar.outputs[name]
+	if !ok {
+		return errSidecarUnknownColumn
+	}
+	field := value.WhichOneof(c.sidecar.protocol.value.Oneofs().ByName("value"))
+	if field == nil {
+		return nil
+	}
+	v := value.Get(field)
+	switch {
+	case column.ProtobufType == protoreflect.BytesKind && field.Kind() == protoreflect.StringKind:
+		addr, err := netip.ParseAddr(v.String())
+		if err != nil {
+			return errSidecarInvalidValue
+		}
+		column.ProtobufAppendIP(flow, netip.AddrFrom16(addr.As16()))
+	case column.ProtobufType == protoreflect.StringKind && field.Kind() == protoreflect.StringKind:
+		column.ProtobufAppendBytes(flow, []byte(v.String()))
+	case column.ProtobufType == protoreflect.Uint32Kind && field.Kind() == protoreflect.Uint64Kind:
+		if v.Uint() > uint64(^uint32(0)) {
+			return errSidecarInvalidValue
+		}
+		column.ProtobufAppendVarint(flow, v.Uint())
+	case column.ProtobufType == protoreflect.Uint64Kind && field.Kind() == protoreflect.Uint64Kind:
+		column.ProtobufAppendVarint(flow, v.Uint())
+	default:
+		return errSidecarInvalidValue
+	}
+	return nil
+}
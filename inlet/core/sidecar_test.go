@@ -0,0 +1,262 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow"
+	"akvorado/inlet/kafka"
+	"akvorado/inlet/metadata"
+	"akvorado/inlet/routing"
+)
+
+// startSidecar starts an enrichment sidecar answering with the provided
+// function. The function receives the request encoded as JSON and should
+// return the response encoded as JSON.
+func startSidecar(t *testing.T, handler func(request string) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	protocol, err := loadSidecarProtocol()
+	if err != nil {
+		t.Fatalf("loadSidecarProtocol() error:\n%+v", err)
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "akvorado.enrichment.v1.Enrichment",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Enrich",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				request := dynamicpb.NewMessage(protocol.enrichRequest)
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				requestJSON, err := protojson.Marshal(request)
+				if err != nil {
+					return nil, err
+				}
+				response := dynamicpb.NewMessage(protocol.enrichResponse)
+				if err := protojson.Unmarshal([]byte(handler(string(requestJSON))), response); err != nil {
+					return nil, err
+				}
+				return response, nil
+			},
+		}},
+	}, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// compactJSON normalizes a JSON document.
+func compactJSON(t *testing.T, input string) string {
+	t.Helper()
+	var parsed any
+	if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+		t.Fatalf("json.Unmarshal() error:\n%+v", err)
+	}
+	output, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("json.Marshal() error:\n%+v", err)
+	}
+	return string(output)
+}
+
+func TestSidecar(t *testing.T) {
+	requests := make(chan string, 10)
+	target := startSidecar(t, func(request string) string {
+		requests <- request
+		var parsed struct {
+			Flows []struct {
+				Columns map[string]struct {
+					Uint   string
+					String string
+				}
+			}
+		}
+		if err := json.Unmarshal([]byte(request), &parsed); err != nil {
+			t.Errorf("json.Unmarshal() error:\n%+v", err)
+		}
+		response := `{"flows": [`
+		for i, flow := range parsed.Flows {
+			if i > 0 {
+				response += ","
+			}
+			if flow.Columns["SrcPort"].Uint == "9999" {
+				time.Sleep(200 * time.Millisecond)
+			}
+			response += fmt.Sprintf(`{"columns": {"ExporterTenant": {"string": "tenant-%s"}}}`,
+				flow.Columns["InIfName"].String)
+		}
+		return response + "]}"
+	})
+
+	r := reporter.NewMock(t)
+	daemonComponent := daemon.NewMock(t)
+	metadataComponent := metadata.NewMock(t, r, metadata.DefaultConfiguration(),
+		metadata.Dependencies{Daemon: daemonComponent})
+	flowComponent := flow.NewMock(t, r, flow.DefaultConfiguration())
+	kafkaComponent, kafkaProducer := kafka.NewMock(t, r, kafka.DefaultConfiguration())
+	httpComponent := httpserver.NewMock(t, r)
+	routingComponent := routing.NewMock(t, r)
+	routingComponent.PopulateRIB(t)
+
+	sch := schema.NewMock(t)
+	config := DefaultConfiguration()
+	config.Workers = 1
+	config.Sidecar.Target = target
+	config.Sidecar.Timeout = 100 * time.Millisecond
+	config.Sidecar.BatchSize = 2
+	config.Sidecar.BatchDelay = 10 * time.Millisecond
+	config.Sidecar.InputColumns = []schema.ColumnKey{schema.ColumnSrcAddr, schema.ColumnSrcPort, schema.ColumnInIfName}
+	config.Sidecar.OutputColumns = []schema.ColumnKey{schema.ColumnExporterTenant}
+	c, err := New(r, config, Dependencies{
+		Daemon:   daemonComponent,
+		Flow:     flowComponent,
+		Metadata: metadataComponent,
+		Kafka:    kafkaComponent,
+		HTTP:     httpComponent,
+		Routing:  routingComponent,
+		Schema:   sch,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	flowMessage := func(in uint32, srcPort uint64) *schema.FlowMessage {
+		msg := &schema.FlowMessage{
+			TimeReceived:    200,
+			SamplingRate:    1000,
+			ExporterAddress: netip.MustParseAddr("192.0.2.142"),
+			InIf:            in,
+			OutIf:           677,
+			SrcAddr:         netip.MustParseAddr("67.43.156.77"),
+			DstAddr:         netip.MustParseAddr("2.125.160.216"),
+		}
+		sch.ProtobufAppendVarint(msg, schema.ColumnBytes, 6765)
+		sch.ProtobufAppendVarint(msg, schema.ColumnPackets, 4)
+		sch.ProtobufAppendVarint(msg, schema.ColumnSrcPort, srcPort)
+		return msg
+	}
+	// expectTenants injects the provided flows and checks the tenant of each
+	// flow sent to Kafka.
+	expectTenants := func(flows []*schema.FlowMessage, tenants []string) {
+		t.Helper()
+		received := make(chan bool, len(tenants))
+		for _, tenant := range tenants {
+			kafkaProducer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				defer func() { received <- true }()
+				b, err := msg.Value.Encode()
+				if err != nil {
+					t.Fatalf("Kafka message encoding error:\n%+v", err)
+				}
+				got := sch.ProtobufDecode(t, b)
+				gotTenant, _ := got.ProtobufDebug[schema.ColumnExporterTenant].(string)
+				if gotTenant != tenant {
+					t.Errorf("ExporterTenant (-got, +want):\n-%s\n+%s", gotTenant, tenant)
+				}
+				return nil
+			})
+		}
+		for _, flow := range flows {
+			flowComponent.Inject(flow)
+		}
+		for range tenants {
+			select {
+			case <-received:
+			case <-time.After(time.Second):
+				t.Fatal("Kafka message not received")
+			}
+		}
+	}
+
+	// First flows are cache misses from the metadata component
+	flowComponent.Inject(flowMessage(434, 80))
+	flowComponent.Inject(flowMessage(435, 80))
+	time.Sleep(20 * time.Millisecond)
+
+	// Full batch
+	expectTenants(
+		[]*schema.FlowMessage{flowMessage(434, 80), flowMessage(435, 443)},
+		[]string{"tenant-Gi0/0/434", "tenant-Gi0/0/435"})
+	select {
+	case request := <-requests:
+		expected := `{"flows":[` +
+			`{"columns":{"InIfName":{"string":"Gi0/0/434"},"SrcAddr":{"string":"67.43.156.77"},"SrcPort":{"uint":"80"}}},` +
+			`{"columns":{"InIfName":{"string":"Gi0/0/435"},"SrcAddr":{"string":"67.43.156.77"},"SrcPort":{"uint":"443"}}}]}`
+		if diff := helpers.Diff(compactJSON(t, request), expected); diff != "" {
+			t.Errorf("Enrich() request (-got, +want):\n%s", diff)
+		}
+	default:
+		t.Fatal("no request received by sidecar")
+	}
+
+	// Partial batch sent after the delay
+	expectTenants(
+		[]*schema.FlowMessage{flowMessage(435, 80)},
+		[]string{"tenant-Gi0/0/435"})
+	<-requests
+
+	// Timeout
+	expectTenants(
+		[]*schema.FlowMessage{flowMessage(434, 9999)},
+		[]string{""})
+	<-requests
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_core_sidecar_", "-request_seconds")
+	expectedMetrics := map[string]string{
+		`errors_total{error="DeadlineExceeded"}`: "1",
+		`flows_total{outcome="enriched"}`:        "3",
+		`flows_total{outcome="fallback"}`:        "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestSidecarInvalidColumns(t *testing.T) {
+	sch := schema.NewMock(t)
+	cases := []struct {
+		Description string
+		Inputs      []schema.ColumnKey
+		Outputs     []schema.ColumnKey
+	}{
+		{"disabled input", []schema.ColumnKey{schema.ColumnSrcVlan}, []schema.ColumnKey{schema.ColumnExporterTenant}},
+		{"typed output", []schema.ColumnKey{schema.ColumnSrcPort}, []schema.ColumnKey{schema.ColumnSrcAS}},
+		{"alias output", []schema.ColumnKey{schema.ColumnSrcPort}, []schema.ColumnKey{schema.ColumnSrcNetName}},
+		{"enum output", []schema.ColumnKey{schema.ColumnSrcPort}, []schema.ColumnKey{schema.ColumnInIfBoundary}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			config := DefaultConfiguration().Sidecar
+			config.Target = "127.0.0.1:1"
+			config.InputColumns = tc.Inputs
+			config.OutputColumns = tc.Outputs
+			if _, err := newSidecarClient(config, sch); err == nil {
+				t.Fatal("newSidecarClient() did not error")
+			}
+		})
+	}
+}
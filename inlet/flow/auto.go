@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"encoding/binary"
+
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+)
+
+// detectProtocol detects the protocol of a datagram from its first bytes. It
+// returns the name of the decoder to use (empty if the protocol is unknown)
+// and the name of the protocol.
+func detectProtocol(payload []byte) (string, string) {
+	// sFlow starts with a 32-bit version while NetFlow and IPFIX start with
+	// a 16-bit version.
+	if len(payload) >= 4 && binary.BigEndian.Uint32(payload) == 5 {
+		return "sflow", "sflow-v5"
+	}
+	if len(payload) >= 2 {
+		switch binary.BigEndian.Uint16(payload) {
+		case 5:
+			return "netflow", "netflow-v5"
+		case 9:
+			return "netflow", "netflow-v9"
+		case 10:
+			return "netflow", "ipfix"
+		}
+	}
+	return "", "unknown"
+}
+
+// autoDecoder decodes datagrams by dispatching them to the decoder matching
+// the detected protocol.
+type autoDecoder struct {
+	c        *Component
+	decoders map[string]decoder.Decoder
+}
+
+// Decode detects the protocol of a datagram and decodes it.
+func (ad *autoDecoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
+	name, protocol := detectProtocol(in.Payload)
+	ad.c.metrics.autoDatagrams.WithLabelValues(in.Source.String(), protocol).Inc()
+	dec, ok := ad.decoders[name]
+	if !ok {
+		ad.c.metrics.decoderErrors.WithLabelValues(ad.Name()).Inc()
+		return nil
+	}
+	return dec.Decode(in)
+}
+
+// Name returns the name of the decoder.
+func (ad *autoDecoder) Name() string {
+	return "auto"
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/input/file"
+)
+
+func TestDetectProtocol(t *testing.T) {
+	cases := []struct {
+		Payload  []byte
+		Name     string
+		Protocol string
+	}{
+		{[]byte{0, 5, 0, 1}, "netflow", "netflow-v5"},
+		{[]byte{0, 9, 0, 1}, "netflow", "netflow-v9"},
+		{[]byte{0, 10, 0, 1}, "netflow", "ipfix"},
+		{[]byte{0, 0, 0, 5}, "sflow", "sflow-v5"},
+		{[]byte{0, 0, 0, 4}, "", "unknown"},
+		{[]byte{0, 1}, "", "unknown"},
+		{[]byte{0}, "", "unknown"},
+		{nil, "", "unknown"},
+	}
+	for _, tc := range cases {
+		name, protocol := detectProtocol(tc.Payload)
+		if name != tc.Name || protocol != tc.Protocol {
+			t.Errorf("detectProtocol(%v) == %q, %q, expected %q, %q",
+				tc.Payload, name, protocol, tc.Name, tc.Protocol)
+		}
+	}
+}
+
+func TestAutoDecoder(t *testing.T) {
+	_, src, _, _ := runtime.Caller(0)
+	base := path.Join(path.Dir(src), "decoder")
+	outDir := t.TempDir()
+	outFiles := []string{}
+	for idx, payload := range [][]byte{
+		helpers.ReadPcapL4(t, path.Join(base, "netflow", "testdata", "nfv5.pcap")),
+		helpers.ReadPcapL4(t, path.Join(base, "sflow", "testdata", "data-1140.pcap")),
+		[]byte("hello world!"),
+	} {
+		outFile := path.Join(outDir, "data-"+strconv.Itoa(idx))
+		if err := os.WriteFile(outFile, payload, 0o666); err != nil {
+			t.Fatalf("WriteFile(%q) error:\n%+v", outFile, err)
+		}
+		outFiles = append(outFiles, outFile)
+	}
+
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.Inputs = []InputConfiguration{
+		{
+			Decoder: "auto",
+			Config: &file.Configuration{
+				Paths: outFiles,
+			},
+		},
+	}
+	c := NewMock(t, r, config)
+
+	// Files are read in a loop. Wait for a few rounds.
+	for range 100 {
+		select {
+		case <-c.Flows():
+		case <-time.After(100 * time.Millisecond):
+			t.Fatalf("no flow received")
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_decoder_")
+	for _, metric := range []string{
+		`auto_datagrams_total{exporter="127.0.0.1",protocol="netflow-v5"}`,
+		`auto_datagrams_total{exporter="127.0.0.1",protocol="sflow-v5"}`,
+		`auto_datagrams_total{exporter="127.0.0.1",protocol="unknown"}`,
+		`errors_total{name="auto"}`,
+		`flows_total{name="netflow"}`,
+		`flows_total{name="sflow"}`,
+	} {
+		if gotMetrics[metric] == "" {
+			t.Errorf("missing metric %s:\n%v", metric, gotMetrics)
+		}
+	}
+}
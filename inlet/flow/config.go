@@ -10,6 +10,8 @@ import (
 	"akvorado/inlet/flow/decoder"
 	"akvorado/inlet/flow/input"
 	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/flow/input/kafka"
+	"akvorado/inlet/flow/input/tcp"
 	"akvorado/inlet/flow/input/udp"
 )
 
@@ -56,8 +58,10 @@ func (ic InputConfiguration) MarshalYAML() (interface{}, error) {
 }
 
 var inputs = map[string](func() input.Configuration){
-	"udp":  udp.DefaultConfiguration,
-	"file": file.DefaultConfiguration,
+	"udp":   udp.DefaultConfiguration,
+	"tcp":   tcp.DefaultConfiguration,
+	"kafka": kafka.DefaultConfiguration,
+	"file":  file.DefaultConfiguration,
 }
 
 func init() {
@@ -40,6 +40,14 @@ func (wd *wrappedDecoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 			f.ExporterAddress = exporterAddress
 		}
 	}
+	if wd.c.d.Capture != nil {
+		for _, f := range decoded {
+			if f.SampledHeader != nil {
+				wd.c.d.Capture.Capture(f)
+				f.SampledHeader = nil
+			}
+		}
+	}
 
 	wd.c.metrics.decoderStats.WithLabelValues(wd.orig.Name()).
 		Inc()
@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"net/netip"
+	"sync"
+)
+
+// totalCountersTTL is the time after which the state for a flow using total
+// counters is discarded when not updated.
+const totalCountersTTL uint64 = 15 * 60
+
+// totalCountersMaxEntries is the maximum number of flows using total counters
+// tracked for an exporter. When reached, stale flows are expired and, if this
+// is not enough, arbitrary flows are evicted.
+const totalCountersMaxEntries = 100_000
+
+// totalCountersKey identifies a flow using total counters.
+type totalCountersKey struct {
+	obsDomainID uint32
+	flowID      uint64
+	srcAddr     netip.Addr
+	dstAddr     netip.Addr
+	srcPort     uint16
+	dstPort     uint16
+	proto       uint8
+	inIf        uint32
+	outIf       uint32
+}
+
+// totalCountersEntry is the last known state for a flow using total counters.
+type totalCountersEntry struct {
+	octets   uint64
+	packets  uint64
+	lastSeen uint64
+}
+
+// totalCountersSystem keeps the state of flows using total counters for an
+// exporter to turn them into delta counters.
+type totalCountersSystem struct {
+	nd  *Decoder
+	key string
+
+	lock        sync.Mutex
+	since       uint64
+	lastExpired uint64
+	maxEntries  int
+	entries     map[totalCountersKey]totalCountersEntry
+}
+
+func newTotalCountersSystem(nd *Decoder, key string, now uint64) *totalCountersSystem {
+	return &totalCountersSystem{
+		nd:          nd,
+		key:         key,
+		since:       now,
+		lastExpired: now,
+		maxEntries:  totalCountersMaxEntries,
+		entries:     map[totalCountersKey]totalCountersEntry{},
+	}
+}
+
+// Delta returns the delta counters for the provided flow, using the total
+// counters from the previous report. When there is no previous report, the
+// total counters are returned only if the flow is known to have started after
+// we started tracking the exporter (flowStart is 0 when unknown). Otherwise,
+// the flow is ignored until the next report. The last return value is false
+// when the flow should be ignored. The flow start is not part of the key as,
+// with NetFlow v9, it is derived from the system uptime and drifts between
+// reports. Therefore, a reused key is only detected when the counters
+// decrease.
+func (s *totalCountersSystem) Delta(key totalCountersKey, flowStart, octets, packets, now uint64) (uint64, uint64, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	previous, ok := s.entries[key]
+	if !ok && len(s.entries) >= s.maxEntries {
+		s.evict(now)
+	}
+	s.entries[key] = totalCountersEntry{
+		octets:   octets,
+		packets:  packets,
+		lastSeen: now,
+	}
+	switch {
+	case !ok && flowStart != 0 && flowStart >= s.since:
+		s.nd.metrics.totalCounters.WithLabelValues(s.key, "new").Inc()
+		return octets, packets, true
+	case !ok:
+		s.nd.metrics.totalCounters.WithLabelValues(s.key, "missing-state").Inc()
+		return 0, 0, false
+	case octets < previous.octets || packets < previous.packets:
+		// Counter reset or reused key, count from zero
+		s.nd.metrics.totalCounters.WithLabelValues(s.key, "reset").Inc()
+		return octets, packets, true
+	}
+	s.nd.metrics.totalCounters.WithLabelValues(s.key, "update").Inc()
+	return octets - previous.octets, packets - previous.packets, true
+}
+
+// Expire removes flows not updated recently. To keep things cheap, this is
+// only done once per TTL.
+func (s *totalCountersSystem) Expire(now uint64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if now < s.lastExpired+totalCountersTTL {
+		return
+	}
+	s.expire(now)
+}
+
+// expire removes flows not updated recently. The lock should be held.
+func (s *totalCountersSystem) expire(now uint64) {
+	s.lastExpired = now
+	for key, entry := range s.entries {
+		if entry.lastSeen+totalCountersTTL < now {
+			delete(s.entries, key)
+			s.nd.metrics.totalCounters.WithLabelValues(s.key, "expired").Inc()
+		}
+	}
+}
+
+// evict makes room for a new flow when the state is full. Stale flows are
+// expired first. If this is not enough, a tenth of the flows are evicted,
+// in no particular order. The lock should be held.
+func (s *totalCountersSystem) evict(now uint64) {
+	s.expire(now)
+	if len(s.entries) < s.maxEntries {
+		return
+	}
+	toEvict := max(s.maxEntries/10, 1)
+	for key := range s.entries {
+		if toEvict == 0 {
+			break
+		}
+		delete(s.entries, key)
+		s.nd.metrics.totalCounters.WithLabelValues(s.key, "evicted").Inc()
+		toEvict--
+	}
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package netflow
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/netsampler/goflow2/v2/decoders/netflow"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+)
+
+func TestTotalCounters(t *testing.T) {
+	r := reporter.NewMock(t)
+	nfdecoder := New(r, decoder.Dependencies{Schema: schema.NewMock(t)}, decoder.Option{TimestampSource: decoder.TimestampSourceUDP}).(*Decoder)
+	var now uint64 = 1_700_000_000
+	counters := newTotalCountersSystem(nfdecoder, "127.0.0.1", now)
+	sampling := &samplingRateSystem{rates: map[samplingRateKey]uint32{}}
+
+	u32 := func(v uint32) []byte {
+		return binary.BigEndian.AppendUint32(nil, v)
+	}
+	record := func(srcPort uint16, octets, packets uint32, start uint32) []netflow.DataField {
+		fields := []netflow.DataField{
+			{Type: netflow.IPFIX_FIELD_sourceIPv4Address, Value: []byte{192, 0, 2, 1}},
+			{Type: netflow.IPFIX_FIELD_destinationIPv4Address, Value: []byte{198, 51, 100, 1}},
+			{Type: netflow.IPFIX_FIELD_sourceTransportPort, Value: binary.BigEndian.AppendUint16(nil, srcPort)},
+			{Type: netflow.IPFIX_FIELD_destinationTransportPort, Value: []byte{0, 80}},
+			{Type: netflow.IPFIX_FIELD_protocolIdentifier, Value: []byte{6}},
+			{Type: netflow.IPFIX_FIELD_octetTotalCount, Value: u32(octets)},
+			{Type: netflow.IPFIX_FIELD_packetTotalCount, Value: u32(packets)},
+		}
+		if start != 0 {
+			fields = append(fields, netflow.DataField{Type: netflow.IPFIX_FIELD_flowStartSeconds, Value: u32(start)})
+		}
+		return fields
+	}
+	decode := func(fields []netflow.DataField) []uint64 {
+		t.Helper()
+		flow := nfdecoder.decodeRecord(10, 1, sampling, counters, fields, now, 0)
+		if flow == nil {
+			return nil
+		}
+		return []uint64{
+			flow.ProtobufDebug[schema.ColumnBytes].(uint64),
+			flow.ProtobufDebug[schema.ColumnPackets].(uint64),
+		}
+	}
+
+	cases := []struct {
+		description string
+		fields      []netflow.DataField
+		expected    []uint64
+	}{
+		{"new flow", record(1000, 1000, 10, uint32(now)+10), []uint64{1000, 10}},
+		{"update", record(1000, 1500, 15, uint32(now)+10), []uint64{500, 5}},
+		{"no change", record(1000, 1500, 15, uint32(now)+10), nil},
+		{"reset", record(1000, 200, 2, uint32(now)+10), []uint64{200, 2}},
+		{"reused 5-tuple", record(1000, 100, 1, uint32(now)+50), []uint64{100, 1}},
+		{"flow started before", record(1001, 5000, 50, uint32(now)-100), nil},
+		{"flow started before, update", record(1001, 6000, 60, uint32(now)-100), []uint64{1000, 10}},
+		{"flow without start", record(1002, 5000, 50, 0), nil},
+	}
+	for _, tc := range cases {
+		now += 60
+		if diff := helpers.Diff(decode(tc.fields), tc.expected); diff != "" {
+			t.Fatalf("decodeRecord(%s) (-got, +want):\n%s", tc.description, diff)
+		}
+	}
+
+	// Expire everything
+	now += totalCountersTTL + 1
+	counters.Expire(now)
+	if len(counters.entries) != 0 {
+		t.Fatalf("Expire() kept %d entries", len(counters.entries))
+	}
+	if diff := helpers.Diff(decode(record(1000, 300, 3, 0)), []uint64(nil)); diff != "" {
+		t.Fatalf("decodeRecord(after expiration) (-got, +want):\n%s", diff)
+	}
+
+	// Fill the state
+	counters.maxEntries = 10
+	for port := range uint16(15) {
+		decode(record(2000+port, 100, 1, uint32(now)+1))
+	}
+	if len(counters.entries) > counters.maxEntries {
+		t.Fatalf("Delta() kept %d entries, limit is %d", len(counters.entries), counters.maxEntries)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_decoder_netflow_", "total_counters_")
+	expectedMetrics := map[string]string{
+		`total_counters_records_total{exporter="127.0.0.1",outcome="evicted"}`:       "6",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="expired"}`:       "3",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="missing-state"}`: "3",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="new"}`:           "16",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="reset"}`:         "2",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="update"}`:        "3",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics after total counters (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTotalCountersNetFlowV9Drift(t *testing.T) {
+	r := reporter.NewMock(t)
+	nfdecoder := New(r, decoder.Dependencies{Schema: schema.NewMock(t)}, decoder.Option{TimestampSource: decoder.TimestampSourceUDP}).(*Decoder)
+	var now uint64 = 1_700_000_000
+	counters := newTotalCountersSystem(nfdecoder, "127.0.0.1", now)
+	sampling := &samplingRateSystem{rates: map[samplingRateKey]uint32{}}
+
+	u32 := func(v uint32) []byte {
+		return binary.BigEndian.AppendUint32(nil, v)
+	}
+	// The flow started when the system uptime was 100 seconds. The exporter
+	// clock and its uptime drift, therefore the derived flow start moves by
+	// one second between exports.
+	record := func(octets, packets uint32) []netflow.DataField {
+		return []netflow.DataField{
+			{Type: netflow.NFV9_FIELD_IPV4_SRC_ADDR, Value: []byte{192, 0, 2, 1}},
+			{Type: netflow.NFV9_FIELD_IPV4_DST_ADDR, Value: []byte{198, 51, 100, 1}},
+			{Type: netflow.NFV9_FIELD_L4_SRC_PORT, Value: []byte{3, 232}},
+			{Type: netflow.NFV9_FIELD_L4_DST_PORT, Value: []byte{0, 80}},
+			{Type: netflow.NFV9_FIELD_PROTOCOL, Value: []byte{6}},
+			{Type: netflow.NFV9_FIELD_FIRST_SWITCHED, Value: u32(100_000)},
+			{Type: netflow.IPFIX_FIELD_octetTotalCount, Value: u32(octets)},
+			{Type: netflow.IPFIX_FIELD_packetTotalCount, Value: u32(packets)},
+		}
+	}
+	decode := func(fields []netflow.DataField, ts, sysUptime uint64) []uint64 {
+		t.Helper()
+		flow := nfdecoder.decodeRecord(9, 1, sampling, counters, fields, ts, sysUptime)
+		if flow == nil {
+			return nil
+		}
+		return []uint64{
+			flow.ProtobufDebug[schema.ColumnBytes].(uint64),
+			flow.ProtobufDebug[schema.ColumnPackets].(uint64),
+		}
+	}
+
+	cases := []struct {
+		description string
+		fields      []netflow.DataField
+		ts          uint64
+		sysUptime   uint64
+		expected    []uint64
+	}{
+		{"new flow", record(1000, 10), now + 10, 105_400, []uint64{1000, 10}},
+		{"update with drift", record(1500, 15), now + 70, 164_600, []uint64{500, 5}},
+		{"update with drift back", record(2500, 25), now + 130, 225_100, []uint64{1000, 10}},
+	}
+	for _, tc := range cases {
+		if diff := helpers.Diff(decode(tc.fields, tc.ts, tc.sysUptime), tc.expected); diff != "" {
+			t.Fatalf("decodeRecord(%s) (-got, +want):\n%s", tc.description, diff)
+		}
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_decoder_netflow_", "total_counters_")
+	expectedMetrics := map[string]string{
+		`total_counters_records_total{exporter="127.0.0.1",outcome="new"}`:    "1",
+		`total_counters_records_total{exporter="127.0.0.1",outcome="update"}`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics after total counters (-got, +want):\n%s", diff)
+	}
+}
@@ -58,7 +58,7 @@ func (nd *Decoder) decodeNFv5(packet *netflowlegacy.PacketNetFlowV5, ts, sysUpti
 	return flowMessageSet
 }
 
-func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets []interface{}, samplingRateSys *samplingRateSystem, ts, sysUptime uint64) []*schema.FlowMessage {
+func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets []interface{}, samplingRateSys *samplingRateSystem, countersSys *totalCountersSystem, ts, sysUptime uint64) []*schema.FlowMessage {
 	flowMessageSet := []*schema.FlowMessage{}
 
 	// Look for sampling rate in option data flowsets
@@ -96,7 +96,7 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 			}
 		case netflow.DataFlowSet:
 			for _, record := range tFlowSet.Records {
-				flow := nd.decodeRecord(version, obsDomainID, samplingRateSys, record.Values, ts, sysUptime)
+				flow := nd.decodeRecord(version, obsDomainID, samplingRateSys, countersSys, record.Values, ts, sysUptime)
 				if flow != nil {
 					flowMessageSet = append(flowMessageSet, flow)
 				}
@@ -107,10 +107,12 @@ func (nd *Decoder) decodeNFv9IPFIX(version uint16, obsDomainID uint32, flowSets
 	return flowMessageSet
 }
 
-func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, samplingRateSys *samplingRateSystem, fields []netflow.DataField, ts, sysUptime uint64) *schema.FlowMessage {
+func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, samplingRateSys *samplingRateSystem, countersSys *totalCountersSystem, fields []netflow.DataField, ts, sysUptime uint64) *schema.FlowMessage {
 	var etype, dstPort, srcPort uint16
 	var proto, icmpType, icmpCode uint8
 	var foundIcmpTypeCode bool
+	var foundDeltaCounters, foundTotalCounters bool
+	var octetTotal, packetTotal, flowStart, flowID uint64
 	bf := &schema.FlowMessage{}
 	dataLinkFrameSectionIdx := -1
 	for idx, field := range fields {
@@ -123,8 +125,22 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, samplingRate
 		// Statistics
 		case netflow.IPFIX_FIELD_octetDeltaCount, netflow.IPFIX_FIELD_postOctetDeltaCount, netflow.IPFIX_FIELD_initiatorOctets, netflow.IPFIX_FIELD_responderOctets:
 			nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnBytes, decodeUNumber(v))
+			foundDeltaCounters = true
 		case netflow.IPFIX_FIELD_packetDeltaCount, netflow.IPFIX_FIELD_postPacketDeltaCount:
 			nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnPackets, decodeUNumber(v))
+			foundDeltaCounters = true
+		case netflow.IPFIX_FIELD_octetTotalCount, netflow.IPFIX_FIELD_postOctetTotalCount:
+			if octetTotal == 0 {
+				octetTotal = decodeUNumber(v)
+			}
+			foundTotalCounters = true
+		case netflow.IPFIX_FIELD_packetTotalCount, netflow.IPFIX_FIELD_postPacketTotalCount:
+			if packetTotal == 0 {
+				packetTotal = decodeUNumber(v)
+			}
+			foundTotalCounters = true
+		case netflow.IPFIX_FIELD_flowId:
+			flowID = decodeUNumber(v)
 		case netflow.IPFIX_FIELD_samplingInterval, netflow.IPFIX_FIELD_samplerRandomInterval:
 			bf.SamplingRate = uint32(decodeUNumber(v))
 		case netflow.IPFIX_FIELD_samplerId, netflow.IPFIX_FIELD_selectorId:
@@ -187,6 +203,20 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, samplingRate
 		case netflow.IPFIX_FIELD_forwardingStatus:
 			nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnForwardingStatus, decodeUNumber(v))
 		default:
+			// Flow start, used for total counters
+			switch field.Type {
+			case netflow.NFV9_FIELD_FIRST_SWITCHED:
+				if first := decodeUNumber(v); version == 9 && sysUptime >= first {
+					flowStart = ts - (sysUptime-first)/1000
+				}
+			case netflow.IPFIX_FIELD_flowStartSeconds:
+				flowStart = decodeUNumber(v)
+			case netflow.IPFIX_FIELD_flowStartMilliseconds:
+				flowStart = decodeUNumber(v) / 1000
+			case netflow.IPFIX_FIELD_flowStartMicroseconds:
+				flowStart = decodeUNumber(v) / 1_000_000
+			}
+
 			if nd.useTsFromFirstSwitched {
 				switch field.Type {
 				case netflow.NFV9_FIELD_FIRST_SWITCHED:
@@ -268,11 +298,34 @@ func (nd *Decoder) decodeRecord(version uint16, obsDomainID uint32, samplingRate
 	}
 	if dataLinkFrameSectionIdx >= 0 {
 		data := fields[dataLinkFrameSectionIdx].Value.([]byte)
+		if nd.captureHeaders {
+			bf.SampledHeader = data
+			bf.SampledHeaderType = schema.SampledHeaderEthernet
+		}
 		if l3Length := decoder.ParseEthernet(nd.d.Schema, bf, data); l3Length > 0 {
 			nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnBytes, l3Length)
 			nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnPackets, 1)
 		}
 	}
+	if foundTotalCounters && !foundDeltaCounters && dataLinkFrameSectionIdx < 0 {
+		// Turn total counters into delta counters
+		octets, packets, ok := countersSys.Delta(totalCountersKey{
+			obsDomainID: obsDomainID,
+			flowID:      flowID,
+			srcAddr:     bf.SrcAddr,
+			dstAddr:     bf.DstAddr,
+			srcPort:     srcPort,
+			dstPort:     dstPort,
+			proto:       proto,
+			inIf:        bf.InIf,
+			outIf:       bf.OutIf,
+		}, flowStart, octetTotal, packetTotal, ts)
+		if !ok || (octets == 0 && packets == 0) {
+			return nil
+		}
+		nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnBytes, octets)
+		nd.d.Schema.ProtobufAppendVarint(bf, schema.ColumnPackets, packets)
+	}
 	if !nd.d.Schema.IsDisabled(schema.ColumnGroupL3L4) && (proto == 1 || proto == 58) {
 		// ICMP
 		if !foundIcmpTypeCode {
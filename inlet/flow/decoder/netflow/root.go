@@ -31,6 +31,7 @@ type Decoder struct {
 	systemsLock sync.RWMutex
 	templates   map[string]*templateSystem
 	sampling    map[string]*samplingRateSystem
+	counters    map[string]*totalCountersSystem
 
 	metrics struct {
 		errors             *reporter.CounterVec
@@ -38,9 +39,11 @@ type Decoder struct {
 		setRecordsStatsSum *reporter.CounterVec
 		setStatsSum        *reporter.CounterVec
 		templatesStats     *reporter.CounterVec
+		totalCounters      *reporter.CounterVec
 	}
 	useTsFromNetflowsPacket bool
 	useTsFromFirstSwitched  bool
+	captureHeaders          bool
 }
 
 // New instantiates a new netflow decoder.
@@ -51,8 +54,10 @@ func New(r *reporter.Reporter, dependencies decoder.Dependencies, option decoder
 		errLogger:               r.Sample(reporter.BurstSampler(30*time.Second, 3)),
 		templates:               map[string]*templateSystem{},
 		sampling:                map[string]*samplingRateSystem{},
+		counters:                map[string]*totalCountersSystem{},
 		useTsFromNetflowsPacket: option.TimestampSource == decoder.TimestampSourceNetflowPacket,
 		useTsFromFirstSwitched:  option.TimestampSource == decoder.TimestampSourceNetflowFirstSwitched,
+		captureHeaders:          option.CaptureHeaders,
 	}
 
 	nd.metrics.errors = nd.r.CounterVec(
@@ -90,6 +95,13 @@ func New(r *reporter.Reporter, dependencies decoder.Dependencies, option decoder
 		},
 		[]string{"exporter", "version", "obs_domain_id", "template_id", "type"},
 	)
+	nd.metrics.totalCounters = nd.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "total_counters_records_total",
+			Help: "Netflows records with total counters.",
+		},
+		[]string{"exporter", "outcome"},
+	)
 
 	return nd
 }
@@ -174,9 +186,11 @@ func (nd *Decoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 		return nil
 	}
 	key := in.Source.String()
+	ts := uint64(in.TimeReceived.UTC().Unix())
 	nd.systemsLock.RLock()
 	templates, tok := nd.templates[key]
 	sampling, sok := nd.sampling[key]
+	counters, cok := nd.counters[key]
 	nd.systemsLock.RUnlock()
 	if !tok {
 		templates = &templateSystem{
@@ -196,6 +210,13 @@ func (nd *Decoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 		nd.sampling[key] = sampling
 		nd.systemsLock.Unlock()
 	}
+	if !cok {
+		counters = newTotalCountersSystem(nd, key, ts)
+		nd.systemsLock.Lock()
+		nd.counters[key] = counters
+		nd.systemsLock.Unlock()
+	}
+	counters.Expire(ts)
 
 	var (
 		sysUptime      uint64
@@ -206,7 +227,6 @@ func (nd *Decoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 	)
 	version := binary.BigEndian.Uint16(in.Payload[:2])
 	buf := bytes.NewBuffer(in.Payload[2:])
-	ts := uint64(in.TimeReceived.UTC().Unix())
 
 	switch version {
 	case 5:
@@ -239,11 +259,11 @@ func (nd *Decoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 		versionStr = "9"
 		flowSets = packetNFv9.FlowSets
 		obsDomainID = packetNFv9.SourceId
+		sysUptime = uint64(packetNFv9.SystemUptime)
 		if nd.useTsFromNetflowsPacket || nd.useTsFromFirstSwitched {
 			ts = uint64(packetNFv9.UnixSeconds)
-			sysUptime = uint64(packetNFv9.SystemUptime)
 		}
-		flowMessageSet = nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, sampling, ts, sysUptime)
+		flowMessageSet = nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, sampling, counters, ts, sysUptime)
 	case 10:
 		var packetIPFIX netflow.IPFIXPacket
 		if err := netflow.DecodeMessageIPFIX(buf, templates, &packetIPFIX); err != nil {
@@ -261,7 +281,7 @@ func (nd *Decoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
 		if nd.useTsFromNetflowsPacket {
 			ts = uint64(packetIPFIX.ExportTime)
 		}
-		flowMessageSet = nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, sampling, ts, sysUptime)
+		flowMessageSet = nd.decodeNFv9IPFIX(version, obsDomainID, flowSets, sampling, counters, ts, sysUptime)
 	default:
 		nd.metrics.stats.WithLabelValues(key, "unknown").
 			Inc()
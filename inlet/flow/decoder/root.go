@@ -28,6 +28,8 @@ type Decoder interface {
 type Option struct {
 	// TimestampSource is a selector for how to set the TimeReceived.
 	TimestampSource TimestampSource
+	// CaptureHeaders tells to keep raw sampled headers in flows.
+	CaptureHeaders bool
 }
 
 // Dependencies are the dependencies for the decoder
@@ -71,6 +71,9 @@ func (nd *Decoder) decode(packet sflow.Packet) []*schema.FlowMessage {
 		for _, record := range records {
 			switch recordData := record.Data.(type) {
 			case sflow.SampledHeader:
+				if nd.captureHeaders {
+					nd.captureSampledHeader(bf, &recordData)
+				}
 				// Only process this header if:
 				//  - we don't have a sampled IPv4 header nor a sampled IPv4 header, or
 				//  - we need L2 data and we don't have sampled ethernet header or we don't have extended switch record
@@ -171,3 +174,19 @@ func (nd *Decoder) parseSampledHeader(bf *schema.FlowMessage, header *sflow.Samp
 	}
 	return 0
 }
+
+// captureSampledHeader keeps a reference to the raw sampled header in the
+// flow.
+func (nd *Decoder) captureSampledHeader(bf *schema.FlowMessage, header *sflow.SampledHeader) {
+	switch header.Protocol {
+	case 1: // Ethernet
+		bf.SampledHeaderType = schema.SampledHeaderEthernet
+	case 11: // IPv4
+		bf.SampledHeaderType = schema.SampledHeaderIPv4
+	case 12: // IPv6
+		bf.SampledHeaderType = schema.SampledHeaderIPv6
+	default:
+		return
+	}
+	bf.SampledHeader = header.HeaderData
+}
@@ -40,14 +40,16 @@ type Decoder struct {
 		sampleRecordsStatsSum *reporter.CounterVec
 		sampleStatsSum        *reporter.CounterVec
 	}
+	captureHeaders bool
 }
 
 // New instantiates a new sFlow decoder.
-func New(r *reporter.Reporter, dependencies decoder.Dependencies, _ decoder.Option) decoder.Decoder {
+func New(r *reporter.Reporter, dependencies decoder.Dependencies, option decoder.Option) decoder.Decoder {
 	nd := &Decoder{
-		r:         r,
-		d:         dependencies,
-		errLogger: r.Sample(reporter.BurstSampler(30*time.Second, 3)),
+		r:              r,
+		d:              dependencies,
+		errLogger:      r.Sample(reporter.BurstSampler(30*time.Second, 3)),
+		captureHeaders: option.CaptureHeaders,
 	}
 
 	nd.metrics.errors = nd.r.CounterVec(
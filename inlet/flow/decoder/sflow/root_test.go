@@ -541,3 +541,22 @@ func TestDecodeSamples(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeCaptureHeaders(t *testing.T) {
+	r := reporter.NewMock(t)
+	sdecoder := New(r, decoder.Dependencies{Schema: schema.NewMock(t)}, decoder.Option{CaptureHeaders: true})
+
+	data := helpers.ReadPcapL4(t, filepath.Join("testdata", "data-sflow-raw-ipv4.pcap"))
+	got := sdecoder.Decode(decoder.RawFlow{Payload: data, Source: net.ParseIP("127.0.0.1")})
+	if len(got) == 0 {
+		t.Fatalf("Decode() error on data")
+	}
+	for _, f := range got {
+		if f.SampledHeaderType != schema.SampledHeaderIPv4 {
+			t.Errorf("Decode() SampledHeaderType = %d, expected %d", f.SampledHeaderType, schema.SampledHeaderIPv4)
+		}
+		if len(f.SampledHeader) < 20 || f.SampledHeader[0]>>4 != 4 {
+			t.Errorf("Decode() SampledHeader = %x, expected an IPv4 packet", f.SampledHeader)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"akvorado/common/kafka"
+	"akvorado/inlet/flow/input"
+)
+
+// Configuration describes Kafka input configuration.
+type Configuration struct {
+	// Topic defines the topic to read datagrams from.
+	Topic string `validate:"required"`
+	// Brokers is the list of brokers to connect to.
+	Brokers []string `validate:"min=1,dive,listen"`
+	// Version is the version of Kafka we assume to work
+	Version kafka.Version
+	// TLS defines TLS configuration
+	TLS kafka.TLSAndSASLConfiguration
+	// ConsumerGroup is the name of the consumer group to use.
+	ConsumerGroup string `validate:"required"`
+	// QueueSize defines the size of the channel used to
+	// communicate incoming flows. When full, consuming from
+	// Kafka is paused.
+	QueueSize uint
+}
+
+// DefaultConfiguration is the default configuration for this input
+func DefaultConfiguration() input.Configuration {
+	defaultKafka := kafka.DefaultConfiguration()
+	return &Configuration{
+		Topic:         "relayed-flows",
+		Brokers:       defaultKafka.Brokers,
+		Version:       defaultKafka.Version,
+		TLS:           defaultKafka.TLS,
+		ConsumerGroup: "akvorado-inlet",
+		QueueSize:     100000,
+	}
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package kafka
+
+import (
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/kafka"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+)
+
+func TestRealKafka(t *testing.T) {
+	client, brokers := kafka.SetupKafkaBroker(t)
+
+	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Topic = topicName
+	configuration.Brokers = brokers
+	configuration.ConsumerGroup = fmt.Sprintf("test-group-%d", rand.Int())
+	r := reporter.NewMock(t)
+	in, err := configuration.New(r, daemon.NewMock(t), &decoder.DummyDecoder{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	in.(*Input).kafkaConfig.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	// Send a message
+	producer, err := sarama.NewSyncProducerFromClient(client)
+	if err != nil {
+		t.Fatalf("NewSyncProducerFromClient() error:\n%+v", err)
+	}
+	defer producer.Close()
+	key := netip.MustParseAddr("::ffff:192.0.2.1").As16()
+	if _, _, err := producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topicName,
+		Key:   sarama.ByteEncoder(key[:]),
+		Value: sarama.StringEncoder("hello world!"),
+	}); err != nil {
+		t.Fatalf("SendMessage() error:\n%+v", err)
+	}
+
+	ch, err := in.Start()
+	if err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	defer func() {
+		if err := in.Stop(); err != nil {
+			t.Fatalf("Stop() error:\n%+v", err)
+		}
+	}()
+
+	var got []*schema.FlowMessage
+	select {
+	case got = <-ch:
+		if len(got) == 0 {
+			t.Fatalf("empty decoded flows received")
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("no decoded flows received")
+	}
+	expected := []*schema.FlowMessage{
+		{
+			TimeReceived:    got[0].TimeReceived,
+			ExporterAddress: netip.MustParseAddr("::ffff:192.0.2.1"),
+			ProtobufDebug: map[schema.ColumnKey]interface{}{
+				schema.ColumnBytes:           12,
+				schema.ColumnPackets:         1,
+				schema.ColumnInIfDescription: []byte("hello world!"),
+			},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Input data (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_kafka_")
+	expectedMetrics := map[string]string{
+		fmt.Sprintf(`bytes_total{topic="%s"}`, topicName):         "12",
+		fmt.Sprintf(`decoded_flows_total{topic="%s"}`, topicName): "1",
+		fmt.Sprintf(`messages_total{topic="%s"}`, topicName):      "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package kafka handles Kafka consumers. Each Kafka message is handled as a
+// datagram. This is used to receive datagrams from relays.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/kafka"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+	"akvorado/inlet/flow/input"
+)
+
+// Input represents the state of a Kafka consumer.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config *Configuration
+
+	metrics struct {
+		bytes        *reporter.CounterVec
+		messages     *reporter.CounterVec
+		errors       *reporter.CounterVec
+		decodedFlows *reporter.CounterVec
+	}
+
+	kafkaConfig *sarama.Config
+	ch          chan []*schema.FlowMessage // channel to send flows to
+	decoder     decoder.Decoder            // decoder to use
+}
+
+// New instantiate a new Kafka consumer from the provided configuration.
+func (configuration *Configuration) New(r *reporter.Reporter, daemon daemon.Component, dec decoder.Decoder) (input.Input, error) {
+	kafkaConfig, err := kafka.NewConfig(kafka.Configuration{
+		Topic:   configuration.Topic,
+		Brokers: configuration.Brokers,
+		Version: configuration.Version,
+		TLS:     configuration.TLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	kafkaConfig.Consumer.Return.Errors = true
+	kafkaConfig.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if err := kafkaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
+	}
+
+	input := &Input{
+		r:           r,
+		config:      configuration,
+		kafkaConfig: kafkaConfig,
+		ch:          make(chan []*schema.FlowMessage, configuration.QueueSize),
+		decoder:     dec,
+	}
+
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"topic"},
+	)
+	input.metrics.messages = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "messages_total",
+			Help: "Messages received by the application.",
+		},
+		[]string{"topic"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving messages by the application.",
+		},
+		[]string{"topic", "error"},
+	)
+	input.metrics.decodedFlows = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "decoded_flows_total",
+			Help: "Number of flows decoded and written to the internal queue",
+		},
+		[]string{"topic"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/kafka")
+	return input, nil
+}
+
+// Start starts consuming from the provided Kafka topic and producing flows.
+func (in *Input) Start() (<-chan []*schema.FlowMessage, error) {
+	in.r.Info().Str("topic", in.config.Topic).Msg("starting Kafka input")
+
+	consumerGroup, err := sarama.NewConsumerGroup(in.config.Brokers, in.config.ConsumerGroup, in.kafkaConfig)
+	if err != nil {
+		in.r.Err(err).
+			Str("brokers", strings.Join(in.config.Brokers, ",")).
+			Msg("unable to create consumer group")
+		return nil, fmt.Errorf("unable to create Kafka consumer group: %w", err)
+	}
+
+	errLogger := in.r.Sample(reporter.BurstSampler(10*time.Second, 3))
+	in.t.Go(func() error {
+		for {
+			select {
+			case <-in.t.Dying():
+				return nil
+			case err, ok := <-consumerGroup.Errors():
+				if !ok {
+					return nil
+				}
+				in.metrics.errors.WithLabelValues(in.config.Topic, "consumer error").Inc()
+				errLogger.Err(err).Str("topic", in.config.Topic).Msg("Kafka consumer error")
+			}
+		}
+	})
+	in.t.Go(func() error {
+		defer consumerGroup.Close()
+		ctx := in.t.Context(context.Background())
+		handler := &consumerHandler{in: in}
+		for {
+			if err := consumerGroup.Consume(ctx, []string{in.config.Topic}, handler); err != nil {
+				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					return nil
+				}
+				in.metrics.errors.WithLabelValues(in.config.Topic, "cannot consume").Inc()
+				errLogger.Err(err).Str("topic", in.config.Topic).Msg("unable to consume")
+			}
+			select {
+			case <-in.t.Dying():
+				return nil
+			case <-time.After(time.Second):
+			}
+		}
+	})
+
+	return in.ch, nil
+}
+
+// consumerHandler handles the messages from a Kafka consumer group session.
+type consumerHandler struct {
+	in *Input
+}
+
+// Setup is run at the beginning of a new session.
+func (h *consumerHandler) Setup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// Cleanup is run at the end of a session.
+func (h *consumerHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim decodes the messages from a claim and sends the resulting
+// flows to the channel.
+func (h *consumerHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	in := h.in
+	topic := in.config.Topic
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			in.metrics.bytes.WithLabelValues(topic).Add(float64(len(msg.Value)))
+			in.metrics.messages.WithLabelValues(topic).Inc()
+
+			// The key may contain the exporter address.
+			var source net.IP
+			if len(msg.Key) == net.IPv4len || len(msg.Key) == net.IPv6len {
+				source = net.IP(msg.Key)
+			}
+			flows := in.decoder.Decode(decoder.RawFlow{
+				TimeReceived: msg.Timestamp,
+				Payload:      msg.Value,
+				Source:       source,
+			})
+			if len(flows) > 0 {
+				select {
+				case <-session.Context().Done():
+					return nil
+				case in.ch <- flows:
+					in.metrics.decodedFlows.WithLabelValues(topic).Add(float64(len(flows)))
+				}
+			}
+			session.MarkMessage(msg, "")
+		}
+	}
+}
+
+// Stop stops the Kafka consumer
+func (in *Input) Stop() error {
+	defer func() {
+		close(in.ch)
+		in.r.Info().Str("topic", in.config.Topic).Msg("Kafka input stopped")
+	}()
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
@@ -0,0 +1,28 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import "akvorado/inlet/flow/input"
+
+// Configuration describes TCP input configuration.
+type Configuration struct {
+	// Listen tells which port to listen to.
+	Listen string `validate:"required,listen"`
+	// QueueSize defines the size of the channel used to
+	// communicate incoming flows. When full, reading from the
+	// connections is paused.
+	QueueSize uint
+	// MaxMessageSize is the maximum size of a message. Larger
+	// messages are rejected and the connection is closed.
+	MaxMessageSize uint32 `validate:"min=1"`
+}
+
+// DefaultConfiguration is the default configuration for this input
+func DefaultConfiguration() input.Configuration {
+	return &Configuration{
+		Listen:         ":0",
+		QueueSize:      100000,
+		MaxMessageSize: 65536,
+	}
+}
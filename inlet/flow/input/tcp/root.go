@@ -0,0 +1,212 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package tcp handles TCP listeners. Each message is prefixed by its length as
+// a 32-bit big-endian integer. This is used to receive datagrams from relays.
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+	"akvorado/inlet/flow/input"
+)
+
+// Input represents the state of a TCP listener.
+type Input struct {
+	r      *reporter.Reporter
+	t      tomb.Tomb
+	config *Configuration
+
+	metrics struct {
+		connections  *reporter.GaugeVec
+		bytes        *reporter.CounterVec
+		messages     *reporter.CounterVec
+		errors       *reporter.CounterVec
+		decodedFlows *reporter.CounterVec
+	}
+
+	address net.Addr                   // listening address, for testing purpose
+	ch      chan []*schema.FlowMessage // channel to send flows to
+	decoder decoder.Decoder            // decoder to use
+}
+
+// New instantiate a new TCP listener from the provided configuration.
+func (configuration *Configuration) New(r *reporter.Reporter, daemon daemon.Component, dec decoder.Decoder) (input.Input, error) {
+	input := &Input{
+		r:       r,
+		config:  configuration,
+		ch:      make(chan []*schema.FlowMessage, configuration.QueueSize),
+		decoder: dec,
+	}
+
+	input.metrics.connections = r.GaugeVec(
+		reporter.GaugeOpts{
+			Name: "connections",
+			Help: "Number of active connections.",
+		},
+		[]string{"listener"},
+	)
+	input.metrics.bytes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "bytes_total",
+			Help: "Bytes received by the application.",
+		},
+		[]string{"listener", "peer"},
+	)
+	input.metrics.messages = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "messages_total",
+			Help: "Messages received by the application.",
+		},
+		[]string{"listener", "peer"},
+	)
+	input.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Errors while receiving messages by the application.",
+		},
+		[]string{"listener", "error"},
+	)
+	input.metrics.decodedFlows = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "decoded_flows_total",
+			Help: "Number of flows decoded and written to the internal queue",
+		},
+		[]string{"listener", "peer"},
+	)
+
+	daemon.Track(&input.t, "inlet/flow/input/tcp")
+	return input, nil
+}
+
+// Start starts listening to the provided TCP socket and producing flows.
+func (in *Input) Start() (<-chan []*schema.FlowMessage, error) {
+	in.r.Info().Str("listen", in.config.Listen).Msg("starting TCP input")
+
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(in.t.Context(context.Background()), "tcp", in.config.Listen)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen to %v: %w", in.config.Listen, err)
+	}
+	in.address = listener.Addr()
+	in.r.Info().Str("listen", in.address.String()).Msg("TCP input listening")
+
+	in.t.Go(func() error {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					return nil
+				}
+				in.r.Err(err).Str("listen", in.config.Listen).Msg("cannot accept new connection")
+				in.metrics.errors.WithLabelValues(in.config.Listen, "cannot accept").Inc()
+				continue
+			}
+			in.t.Go(func() error {
+				in.serveConnection(conn)
+				return nil
+			})
+		}
+	})
+
+	// Watch for termination and close on dying
+	in.t.Go(func() error {
+		<-in.t.Dying()
+		listener.Close()
+		return nil
+	})
+
+	return in.ch, nil
+}
+
+// serveConnection reads messages from a connection until it is closed.
+func (in *Input) serveConnection(conn net.Conn) {
+	listen := in.config.Listen
+	peerIP := conn.RemoteAddr().(*net.TCPAddr).IP
+	peer := peerIP.String()
+	l := in.r.With().Str("listen", listen).Str("peer", peer).Logger()
+	errLogger := l.Sample(reporter.BurstSampler(time.Minute, 1))
+	l.Info().Msg("new connection")
+	in.metrics.connections.WithLabelValues(listen).Inc()
+	defer in.metrics.connections.WithLabelValues(listen).Dec()
+
+	// Close the connection when dying
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-in.t.Dying():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				l.Info().Msg("connection closed")
+				return
+			}
+			errLogger.Err(err).Msg("unable to read message header")
+			in.metrics.errors.WithLabelValues(listen, "cannot read").Inc()
+			return
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > in.config.MaxMessageSize {
+			errLogger.Error().Msgf("message too large (%d bytes)", size)
+			in.metrics.errors.WithLabelValues(listen, "message too large").Inc()
+			return
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			errLogger.Err(err).Msg("unable to read message")
+			in.metrics.errors.WithLabelValues(listen, "cannot read").Inc()
+			return
+		}
+		in.metrics.bytes.WithLabelValues(listen, peer).Add(float64(size + 4))
+		in.metrics.messages.WithLabelValues(listen, peer).Inc()
+
+		flows := in.decoder.Decode(decoder.RawFlow{
+			TimeReceived: time.Now(),
+			Payload:      payload,
+			Source:       peerIP,
+		})
+		if len(flows) == 0 {
+			continue
+		}
+		// Unlike UDP, we can apply backpressure to the sender.
+		select {
+		case <-in.t.Dying():
+			return
+		case in.ch <- flows:
+			in.metrics.decodedFlows.WithLabelValues(listen, peer).
+				Add(float64(len((flows))))
+		}
+	}
+}
+
+// Stop stops the TCP listener
+func (in *Input) Stop() error {
+	l := in.r.With().Str("listen", in.config.Listen).Logger()
+	defer func() {
+		close(in.ch)
+		l.Info().Msg("TCP listener stopped")
+	}()
+	in.t.Kill(nil)
+	return in.t.Wait()
+}
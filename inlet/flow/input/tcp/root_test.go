@@ -0,0 +1,128 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package tcp
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+)
+
+func TestTCPInput(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	in, err := configuration.New(r, daemon.NewMock(t), &decoder.DummyDecoder{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	ch, err := in.Start()
+	if err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	defer func() {
+		if err := in.Stop(); err != nil {
+			t.Fatalf("Stop() error:\n%+v", err)
+		}
+	}()
+
+	// Connect
+	conn, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+
+	// Send data
+	payload := []byte("hello world!")
+	message := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	message = append(message, payload...)
+	if _, err := conn.Write(message); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+
+	// Get it back
+	var got []*schema.FlowMessage
+	select {
+	case got = <-ch:
+		if len(got) == 0 {
+			t.Fatalf("empty decoded flows received")
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("no decoded flows received")
+	}
+
+	expected := []*schema.FlowMessage{
+		{
+			TimeReceived:    got[0].TimeReceived,
+			ExporterAddress: netip.MustParseAddr("::ffff:127.0.0.1"),
+			ProtobufDebug: map[schema.ColumnKey]interface{}{
+				schema.ColumnBytes:           12,
+				schema.ColumnPackets:         1,
+				schema.ColumnInIfDescription: []byte("hello world!"),
+			},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Input data (-got, +want):\n%s", diff)
+	}
+
+	// Check metrics
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_")
+	expectedMetrics := map[string]string{
+		`bytes_total{listener="127.0.0.1:0",peer="127.0.0.1"}`:         "16",
+		`connections{listener="127.0.0.1:0"}`:                          "1",
+		`decoded_flows_total{listener="127.0.0.1:0",peer="127.0.0.1"}`: "1",
+		`messages_total{listener="127.0.0.1:0",peer="127.0.0.1"}`:      "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPInputMessageTooLarge(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration().(*Configuration)
+	configuration.Listen = "127.0.0.1:0"
+	configuration.MaxMessageSize = 10
+	in, err := configuration.New(r, daemon.NewMock(t), &decoder.DummyDecoder{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	if _, err := in.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	defer func() {
+		if err := in.Stop(); err != nil {
+			t.Fatalf("Stop() error:\n%+v", err)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", in.(*Input).address.String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write(binary.BigEndian.AppendUint32(nil, 11)); err != nil {
+		t.Fatalf("Write() error:\n%+v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_input_tcp_", "errors_total", "connections")
+	expectedMetrics := map[string]string{
+		`connections{listener="127.0.0.1:0"}`:                            "0",
+		`errors_total{error="message too large",listener="127.0.0.1:0"}`: "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Input metrics (-got, +want):\n%s", diff)
+	}
+}
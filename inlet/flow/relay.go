@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+	"akvorado/inlet/relay"
+)
+
+// relayingDecoder does not decode flows but hands them to the relay
+// component. It is used when the inlet is running in relay mode.
+type relayingDecoder struct {
+	c     *Component
+	relay *relay.Component
+	name  string
+}
+
+// Decode sends the raw flow to the relay component. It never returns any flow.
+// With the auto decoder, the protocol is detected before relaying the flow.
+func (rd *relayingDecoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
+	name := rd.name
+	if name == "auto" {
+		var protocol string
+		name, protocol = detectProtocol(in.Payload)
+		rd.c.metrics.autoDatagrams.WithLabelValues(in.Source.String(), protocol).Inc()
+		if name == "" {
+			rd.c.metrics.decoderErrors.WithLabelValues(rd.name).Inc()
+			return nil
+		}
+	}
+	rd.relay.Send(name, in)
+	return nil
+}
+
+// Name returns the name of the decoder the flows are relayed for.
+func (rd *relayingDecoder) Name() string {
+	return rd.name
+}
+
+// relayedDecoder decodes datagrams received from a relay by dispatching them
+// to the decoder specified in the datagram.
+type relayedDecoder struct {
+	c        *Component
+	decoders map[string]decoder.Decoder
+}
+
+// Decode decodes a datagram from a relay.
+func (rd *relayedDecoder) Decode(in decoder.RawFlow) []*schema.FlowMessage {
+	name, raw, err := relay.DecodeDatagram(in.Payload)
+	if err != nil {
+		rd.c.metrics.decoderErrors.WithLabelValues(rd.Name()).Inc()
+		return nil
+	}
+	dec, ok := rd.decoders[name]
+	if !ok {
+		rd.c.metrics.decoderErrors.WithLabelValues(rd.Name()).Inc()
+		return nil
+	}
+	return dec.Decode(raw)
+}
+
+// Name returns the name of the decoder.
+func (rd *relayedDecoder) Name() string {
+	return "relay"
+}
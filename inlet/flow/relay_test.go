@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package flow
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/httpserver"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/flow/decoder"
+	"akvorado/inlet/flow/input/file"
+	"akvorado/inlet/relay"
+)
+
+func TestRelayedFlows(t *testing.T) {
+	_, src, _, _ := runtime.Caller(0)
+	base := path.Join(path.Dir(src), "decoder", "netflow", "testdata")
+	outDir := t.TempDir()
+	outFiles := []string{}
+	received := time.Date(2025, time.January, 10, 10, 0, 0, 0, time.UTC)
+	for idx, f := range []string{
+		"options-template.pcap",
+		"options-data.pcap",
+		"template.pcap",
+		"data.pcap",
+	} {
+		outFile := path.Join(outDir, fmt.Sprintf("data-%d", idx))
+		datagram := relay.EncodeDatagram("netflow", decoder.RawFlow{
+			TimeReceived: received,
+			Payload:      helpers.ReadPcapL4(t, path.Join(base, f)),
+			Source:       net.ParseIP("192.0.2.10"),
+		})
+		if err := os.WriteFile(outFile, datagram, 0o666); err != nil {
+			t.Fatalf("WriteFile(%q) error:\n%+v", outFile, err)
+		}
+		outFiles = append(outFiles, outFile)
+	}
+
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.Inputs = []InputConfiguration{
+		{
+			Decoder:                   "relay",
+			UseSrcAddrForExporterAddr: true,
+			TimestampSource:           decoder.TimestampSourceUDP,
+			Config: &file.Configuration{
+				Paths: outFiles,
+			},
+		},
+	}
+	c := NewMock(t, r, config)
+
+	select {
+	case flow := <-c.Flows():
+		if diff := helpers.Diff(flow.ExporterAddress, netip.MustParseAddr("::ffff:192.0.2.10")); diff != "" {
+			t.Errorf("ExporterAddress (-got, +want):\n%s", diff)
+		}
+		if flow.TimeReceived != uint64(received.Unix()) {
+			t.Errorf("TimeReceived == %d, expected %d", flow.TimeReceived, received.Unix())
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("no flow received")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_flow_decoder_", "flows_total")
+	if gotMetrics[`flows_total{name="netflow"}`] == "" {
+		t.Errorf("no flows decoded by the netflow decoder:\n%v", gotMetrics)
+	}
+}
+
+func TestRelayDecoderInRelayMode(t *testing.T) {
+	r := reporter.NewMock(t)
+	relayComponent, err := relay.New(r, relay.DefaultConfiguration(), relay.Dependencies{
+		Daemon: daemon.NewMock(t),
+	})
+	if err != nil {
+		t.Fatalf("relay.New() error:\n%+v", err)
+	}
+	config := DefaultConfiguration()
+	config.Inputs = []InputConfiguration{
+		{
+			Decoder: "relay",
+			Config: &file.Configuration{
+				Paths: []string{"/dev/null"},
+			},
+		},
+	}
+	_, err = New(r, config, Dependencies{
+		Daemon: daemon.NewMock(t),
+		HTTP:   httpserver.NewMock(t, r),
+		Schema: schema.NewMock(t),
+		Relay:  relayComponent,
+	})
+	if err == nil {
+		t.Fatal("New() did not error")
+	}
+}
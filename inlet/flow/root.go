@@ -16,8 +16,10 @@ import (
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
+	"akvorado/inlet/capture"
 	"akvorado/inlet/flow/decoder"
 	"akvorado/inlet/flow/input"
+	"akvorado/inlet/relay"
 )
 
 // Component represents the flow component.
@@ -30,6 +32,7 @@ type Component struct {
 	metrics struct {
 		decoderStats  *reporter.CounterVec
 		decoderErrors *reporter.CounterVec
+		autoDatagrams *reporter.CounterVec
 	}
 
 	// Channel for sending flows out of the package.
@@ -44,9 +47,11 @@ type Component struct {
 
 // Dependencies are the dependencies of the flow component.
 type Dependencies struct {
-	Daemon daemon.Component
-	HTTP   *httpserver.Component
-	Schema *schema.Component
+	Daemon  daemon.Component
+	HTTP    *httpserver.Component
+	Schema  *schema.Component
+	Relay   *relay.Component   // optional, when running as a relay
+	Capture *capture.Component // optional, when capturing sampled headers
 }
 
 // New creates a new flow component.
@@ -66,20 +71,70 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 
 	// Initialize decoders (at most once each)
 	alreadyInitialized := map[string]decoder.Decoder{}
+	newDecoder := func(name string, input InputConfiguration) (decoder.Decoder, error) {
+		dec, ok := alreadyInitialized[name]
+		if !ok {
+			decoderfunc, ok := decoders[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown decoder %q", name)
+			}
+			dec = decoderfunc(r, decoder.Dependencies{Schema: c.d.Schema}, decoder.Option{
+				TimestampSource: input.TimestampSource,
+				CaptureHeaders:  c.d.Capture != nil,
+			})
+			alreadyInitialized[name] = dec
+		}
+		return c.wrapDecoder(dec, input.UseSrcAddrForExporterAddr), nil
+	}
 	decs := make([]decoder.Decoder, len(configuration.Inputs))
 	for idx, input := range c.config.Inputs {
-		dec, ok := alreadyInitialized[input.Decoder]
-		if ok {
+		switch {
+		case input.Decoder == "relay" && c.d.Relay != nil:
+			return nil, errors.New("cannot use relay decoder when running as a relay")
+		case input.Decoder == "relay":
+			// Datagrams from a relay embed the name of the decoder to use.
+			relayed := &relayedDecoder{
+				c:        &c,
+				decoders: make(map[string]decoder.Decoder, len(decoders)),
+			}
+			for name := range decoders {
+				dec, err := newDecoder(name, input)
+				if err != nil {
+					return nil, err
+				}
+				relayed.decoders[name] = dec
+			}
+			decs[idx] = relayed
+		case c.d.Relay != nil:
+			if _, ok := decoders[input.Decoder]; !ok && input.Decoder != "auto" {
+				return nil, fmt.Errorf("unknown decoder %q", input.Decoder)
+			}
+			decs[idx] = &relayingDecoder{
+				c:     &c,
+				relay: c.d.Relay,
+				name:  input.Decoder,
+			}
+		case input.Decoder == "auto":
+			// The protocol is detected for each datagram.
+			auto := &autoDecoder{
+				c:        &c,
+				decoders: make(map[string]decoder.Decoder, len(decoders)),
+			}
+			for name := range decoders {
+				dec, err := newDecoder(name, input)
+				if err != nil {
+					return nil, err
+				}
+				auto.decoders[name] = dec
+			}
+			decs[idx] = auto
+		default:
+			dec, err := newDecoder(input.Decoder, input)
+			if err != nil {
+				return nil, err
+			}
 			decs[idx] = dec
-			continue
 		}
-		decoderfunc, ok := decoders[input.Decoder]
-		if !ok {
-			return nil, fmt.Errorf("unknown decoder %q", input.Decoder)
-		}
-		dec = decoderfunc(r, decoder.Dependencies{Schema: c.d.Schema}, decoder.Option{TimestampSource: input.TimestampSource})
-		alreadyInitialized[input.Decoder] = dec
-		decs[idx] = c.wrapDecoder(dec, input.UseSrcAddrForExporterAddr)
 	}
 
 	// Initialize inputs
@@ -106,6 +161,13 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		},
 		[]string{"name"},
 	)
+	c.metrics.autoDatagrams = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "decoder_auto_datagrams_total",
+			Help: "Datagrams received by the auto decoder for each detected protocol.",
+		},
+		[]string{"exporter", "protocol"},
+	)
 
 	c.d.Daemon.Track(&c.t, "inlet/flow")
 
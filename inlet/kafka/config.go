@@ -26,6 +26,12 @@ type Configuration struct {
 	CompressionCodec CompressionCodec
 	// QueueSize defines the size of the channel used to send to Kafka.
 	QueueSize int `validate:"min=1"`
+	// HealthCheckInterval tells how often to check the health of the Kafka
+	// clusters when standby clusters are configured.
+	HealthCheckInterval time.Duration `validate:"min=1s"`
+	// HealthCheckTimeout is the timeout to check the health of a Kafka
+	// cluster.
+	HealthCheckTimeout time.Duration `validate:"min=100ms,ltefield=HealthCheckInterval"`
 }
 
 // DefaultConfiguration represents the default configuration for the Kafka exporter.
@@ -37,6 +43,9 @@ func DefaultConfiguration() Configuration {
 		MaxMessageBytes:  1000000,
 		CompressionCodec: CompressionCodec(sarama.CompressionNone),
 		QueueSize:        32,
+
+		HealthCheckInterval: 10 * time.Second,
+		HealthCheckTimeout:  5 * time.Second,
 	}
 }
 
@@ -19,6 +19,13 @@ type metrics struct {
 	bytesSent    *reporter.CounterVec
 	errors       *reporter.CounterVec
 
+	routeUpdatesSent *reporter.CounterVec
+
+	activeCluster      reporter.Gauge
+	clusterSwitches    reporter.Counter
+	messagesRedirected reporter.Counter
+	healthCheckErrors  *reporter.CounterVec
+
 	kafkaIncomingByteRate  *reporter.MetricDesc
 	kafkaOutgoingByteRate  *reporter.MetricDesc
 	kafkaRequestRate       *reporter.MetricDesc
@@ -50,6 +57,13 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"exporter"},
 	)
+	c.metrics.routeUpdatesSent = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sent_route_updates_total",
+			Help: "Number of route updates sent from a given BMP exporter.",
+		},
+		[]string{"exporter"},
+	)
 	c.metrics.errors = c.r.CounterVec(
 		reporter.CounterOpts{
 			Name: "errors_total",
@@ -57,6 +71,31 @@ func (c *Component) initMetrics() {
 		},
 		[]string{"error"},
 	)
+	c.metrics.activeCluster = c.r.Gauge(
+		reporter.GaugeOpts{
+			Name: "active_cluster",
+			Help: "Index of the Kafka cluster in use (0 is the primary one).",
+		},
+	)
+	c.metrics.clusterSwitches = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "cluster_switches_total",
+			Help: "Number of switches from one Kafka cluster to another.",
+		},
+	)
+	c.metrics.messagesRedirected = c.r.Counter(
+		reporter.CounterOpts{
+			Name: "redirected_messages_total",
+			Help: "Number of messages sent again to the active Kafka cluster after a switch.",
+		},
+	)
+	c.metrics.healthCheckErrors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "health_check_errors_total",
+			Help: "Number of failed health checks for a given Kafka cluster.",
+		},
+		[]string{"cluster"},
+	)
 
 	c.metrics.kafkaIncomingByteRate = c.r.MetricDesc(
 		"brokers_incoming_byte_rate",
@@ -128,7 +167,14 @@ func (m metrics) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect metrics
 func (m metrics) Collect(ch chan<- prometheus.Metric) {
-	m.c.kafkaConfig.MetricRegistry.Each(func(name string, gom interface{}) {
+	m.c.kafkaProducerLock.RLock()
+	cluster := 0
+	if m.c.kafkaProducer != nil {
+		cluster = m.c.kafkaProducer.cluster
+	}
+	m.c.kafkaProducerLock.RUnlock()
+	kafkaConfig := m.c.kafkaConfigs[cluster]
+	kafkaConfig.MetricRegistry.Each(func(name string, gom interface{}) {
 		// Broker-related
 		if broker := metricBroker(name, "incoming-byte-rate"); broker != "" {
 			gomMeter(ch, m.kafkaIncomingByteRate, gom, broker)
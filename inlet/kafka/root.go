@@ -8,7 +8,9 @@ import (
 	"encoding/binary"
 	"fmt"
 	"math/rand"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama"
@@ -28,12 +30,27 @@ type Component struct {
 	config Configuration
 
 	kafkaTopic          string
-	kafkaConfig         *sarama.Config
-	kafkaProducer       sarama.AsyncProducer
-	createKafkaProducer func() (sarama.AsyncProducer, error)
+	kafkaRoutesTopic    string
+	kafkaClusters       []kafka.Configuration
+	kafkaConfigs        []*sarama.Config
+	kafkaProducerLock   sync.RWMutex
+	kafkaProducer       *producerState
+	createKafkaProducer func(cluster int) (sarama.AsyncProducer, error)
+	checkKafkaCluster   func(cluster int) error
 	metrics             metrics
 }
 
+// producerState is a producer for one of the Kafka clusters.
+type producerState struct {
+	producer sarama.AsyncProducer
+	cluster  int
+	// retired is closed when the producer is not the active one anymore.
+	// Senders waiting for it should use the new active producer instead.
+	retired chan struct{}
+	// senders tracks the senders which may still use the producer.
+	senders sync.WaitGroup
+}
+
 // Dependencies define the dependencies of the Kafka exporter.
 type Dependencies struct {
 	Daemon daemon.Component
@@ -42,36 +59,43 @@ type Dependencies struct {
 
 // New creates a new Kafka exporter component.
 func New(reporter *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
-	// Build Kafka configuration
-	kafkaConfig, err := kafka.NewConfig(configuration.Configuration)
-	if err != nil {
-		return nil, err
-	}
-	kafkaConfig.Metadata.AllowAutoTopicCreation = true
-	kafkaConfig.Producer.MaxMessageBytes = configuration.MaxMessageBytes
-	kafkaConfig.Producer.Compression = sarama.CompressionCodec(configuration.CompressionCodec)
-	kafkaConfig.Producer.Return.Successes = false
-	kafkaConfig.Producer.Return.Errors = true
-	kafkaConfig.Producer.Flush.Bytes = configuration.FlushBytes
-	kafkaConfig.Producer.Flush.Frequency = configuration.FlushInterval
-	kafkaConfig.Producer.Partitioner = sarama.NewHashPartitioner
-	kafkaConfig.ChannelBufferSize = configuration.QueueSize
-	if err := kafkaConfig.Validate(); err != nil {
-		return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
-	}
-
 	c := Component{
 		r:      reporter,
 		d:      &dependencies,
 		config: configuration,
 
-		kafkaConfig: kafkaConfig,
-		kafkaTopic:  fmt.Sprintf("%s-%s", configuration.Topic, dependencies.Schema.ProtobufMessageHash()),
+		kafkaTopic:    fmt.Sprintf("%s-%s", configuration.Topic, dependencies.Schema.ProtobufMessageHash()),
+		kafkaClusters: configuration.Clusters(),
+
+		kafkaRoutesTopic: fmt.Sprintf("%s-routes", configuration.Topic),
 	}
+
+	// Build Kafka configuration for each cluster
+	for _, cluster := range c.kafkaClusters {
+		kafkaConfig, err := kafka.NewConfig(cluster)
+		if err != nil {
+			return nil, err
+		}
+		kafkaConfig.Metadata.AllowAutoTopicCreation = true
+		kafkaConfig.Producer.MaxMessageBytes = configuration.MaxMessageBytes
+		kafkaConfig.Producer.Compression = sarama.CompressionCodec(configuration.CompressionCodec)
+		kafkaConfig.Producer.Return.Successes = false
+		kafkaConfig.Producer.Return.Errors = true
+		kafkaConfig.Producer.Flush.Bytes = configuration.FlushBytes
+		kafkaConfig.Producer.Flush.Frequency = configuration.FlushInterval
+		kafkaConfig.Producer.Partitioner = sarama.NewHashPartitioner
+		kafkaConfig.ChannelBufferSize = configuration.QueueSize
+		if err := kafkaConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
+		}
+		c.kafkaConfigs = append(c.kafkaConfigs, kafkaConfig)
+	}
+
 	c.initMetrics()
-	c.createKafkaProducer = func() (sarama.AsyncProducer, error) {
-		return sarama.NewAsyncProducer(c.config.Brokers, c.kafkaConfig)
+	c.createKafkaProducer = func(cluster int) (sarama.AsyncProducer, error) {
+		return sarama.NewAsyncProducer(c.kafkaClusters[cluster].Brokers, c.kafkaConfigs[cluster])
 	}
+	c.checkKafkaCluster = c.defaultCheckKafkaCluster
 	c.d.Daemon.Track(&c.t, "inlet/kafka")
 	return &c, nil
 }
@@ -81,41 +105,164 @@ func (c *Component) Start() error {
 	c.r.Info().Msg("starting Kafka component")
 	kafka.GlobalKafkaLogger.Register(c.r)
 
-	// Create producer
-	kafkaProducer, err := c.createKafkaProducer()
-	if err != nil {
-		c.r.Err(err).
-			Str("brokers", strings.Join(c.config.Brokers, ",")).
-			Msg("unable to create async producer")
+	// Create producer for the first available cluster
+	var err error
+	for cluster := range c.kafkaClusters {
+		var kafkaProducer *producerState
+		kafkaProducer, err = c.startKafkaProducer(cluster)
+		if err != nil {
+			c.r.Err(err).
+				Str("brokers", strings.Join(c.kafkaClusters[cluster].Brokers, ",")).
+				Msg("unable to create async producer")
+			continue
+		}
+		c.kafkaProducer = kafkaProducer
+		c.metrics.activeCluster.Set(float64(cluster))
+		break
+	}
+	if c.kafkaProducer == nil {
 		return fmt.Errorf("unable to create Kafka async producer: %w", err)
 	}
-	c.kafkaProducer = kafkaProducer
 
 	// Main loop
 	c.t.Go(func() error {
-		defer kafkaProducer.Close()
-		defer c.kafkaConfig.MetricRegistry.UnregisterAll()
-		errLogger := c.r.Sample(reporter.BurstSampler(10*time.Second, 3))
+		defer func() {
+			for _, kafkaConfig := range c.kafkaConfigs {
+				kafkaConfig.MetricRegistry.UnregisterAll()
+			}
+		}()
+		defer func() {
+			c.kafkaProducerLock.Lock()
+			active := c.kafkaProducer
+			close(active.retired)
+			c.kafkaProducerLock.Unlock()
+			active.senders.Wait()
+			active.producer.Close()
+		}()
+		if len(c.kafkaClusters) == 1 {
+			<-c.t.Dying()
+			return nil
+		}
+		ticker := time.NewTicker(c.config.HealthCheckInterval)
+		defer ticker.Stop()
 		for {
 			select {
 			case <-c.t.Dying():
-				c.r.Debug().Msg("stop error logger")
+				c.r.Debug().Msg("stop health checker")
 				return nil
-			case msg := <-kafkaProducer.Errors():
-				if msg != nil {
-					c.metrics.errors.WithLabelValues(msg.Error()).Inc()
-					errLogger.Err(msg.Err).
-						Str("topic", msg.Msg.Topic).
-						Int64("offset", msg.Msg.Offset).
-						Int32("partition", msg.Msg.Partition).
-						Msg("Kafka producer error")
-				}
+			case <-ticker.C:
+				c.healthCheck()
 			}
 		}
 	})
 	return nil
 }
 
+// startKafkaProducer creates a producer for the provided cluster and starts a
+// goroutine to log its errors until it is closed. Once the producer is
+// retired, the messages it fails to deliver are sent to the active producer.
+func (c *Component) startKafkaProducer(cluster int) (*producerState, error) {
+	kafkaProducer, err := c.createKafkaProducer(cluster)
+	if err != nil {
+		return nil, err
+	}
+	state := &producerState{
+		producer: kafkaProducer,
+		cluster:  cluster,
+		retired:  make(chan struct{}),
+	}
+	c.t.Go(func() error {
+		errLogger := c.r.Sample(reporter.BurstSampler(10*time.Second, 3))
+		for msg := range kafkaProducer.Errors() {
+			if msg == nil {
+				continue
+			}
+			select {
+			case <-state.retired:
+				if c.send(&sarama.ProducerMessage{
+					Topic: msg.Msg.Topic,
+					Key:   msg.Msg.Key,
+					Value: msg.Msg.Value,
+				}) {
+					c.metrics.messagesRedirected.Inc()
+				}
+				continue
+			default:
+			}
+			c.metrics.errors.WithLabelValues(msg.Error()).Inc()
+			errLogger.Err(msg.Err).
+				Str("topic", msg.Msg.Topic).
+				Int64("offset", msg.Msg.Offset).
+				Int32("partition", msg.Msg.Partition).
+				Msg("Kafka producer error")
+		}
+		return nil
+	})
+	return state, nil
+}
+
+// healthCheck selects the first healthy Kafka cluster and switches the
+// producer to it if it is not the active one.
+func (c *Component) healthCheck() {
+	c.kafkaProducerLock.RLock()
+	active := c.kafkaProducer.cluster
+	c.kafkaProducerLock.RUnlock()
+
+	for cluster := range c.kafkaClusters {
+		l := c.r.With().
+			Int("cluster", cluster).
+			Str("brokers", strings.Join(c.kafkaClusters[cluster].Brokers, ",")).
+			Logger()
+		if err := c.checkKafkaCluster(cluster); err != nil {
+			c.metrics.healthCheckErrors.WithLabelValues(strconv.Itoa(cluster)).Inc()
+			l.Warn().Err(err).Msg("Kafka cluster is unhealthy")
+			continue
+		}
+		if cluster == active {
+			return
+		}
+		kafkaProducer, err := c.startKafkaProducer(cluster)
+		if err != nil {
+			l.Err(err).Msg("unable to create async producer")
+			continue
+		}
+		c.kafkaProducerLock.Lock()
+		previous := c.kafkaProducer
+		c.kafkaProducer = kafkaProducer
+		close(previous.retired)
+		c.kafkaProducerLock.Unlock()
+		// Once the senders waiting for the previous producer are redirected,
+		// it can be closed. Messages it cannot deliver are redirected too.
+		previous.senders.Wait()
+		previous.producer.AsyncClose()
+		c.metrics.activeCluster.Set(float64(cluster))
+		c.metrics.clusterSwitches.Inc()
+		l.Warn().Int("previous", active).Msg("switch to another Kafka cluster")
+		return
+	}
+	c.r.Error().Msg("no healthy Kafka cluster")
+}
+
+// defaultCheckKafkaCluster checks if the brokers of a Kafka cluster are
+// reachable and if they know about the flow topic.
+func (c *Component) defaultCheckKafkaCluster(cluster int) error {
+	kafkaConfig, err := kafka.NewConfig(c.kafkaClusters[cluster])
+	if err != nil {
+		return err
+	}
+	kafkaConfig.Net.DialTimeout = c.config.HealthCheckTimeout
+	kafkaConfig.Net.ReadTimeout = c.config.HealthCheckTimeout
+	kafkaConfig.Net.WriteTimeout = c.config.HealthCheckTimeout
+	kafkaConfig.Metadata.Retry.Max = 0
+	kafkaConfig.Metadata.AllowAutoTopicCreation = false
+	client, err := sarama.NewClient(c.kafkaClusters[cluster].Brokers, kafkaConfig)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.RefreshMetadata(c.kafkaTopic)
+}
+
 // Stop stops the Kafka component
 func (c *Component) Stop() error {
 	defer func() {
@@ -133,9 +280,51 @@ func (c *Component) Send(exporter string, payload []byte) {
 	c.metrics.messagesSent.WithLabelValues(exporter).Inc()
 	key := make([]byte, 4)
 	binary.BigEndian.PutUint32(key, rand.Uint32())
-	c.kafkaProducer.Input() <- &sarama.ProducerMessage{
+	c.send(&sarama.ProducerMessage{
 		Topic: c.kafkaTopic,
 		Key:   sarama.ByteEncoder(key),
 		Value: sarama.ByteEncoder(payload),
+	})
+}
+
+// SendRouteUpdate sends a route update to Kafka. The key is used to keep
+// updates for the same prefix in order.
+func (c *Component) SendRouteUpdate(exporter string, key []byte, payload []byte) {
+	c.metrics.routeUpdatesSent.WithLabelValues(exporter).Inc()
+	c.send(&sarama.ProducerMessage{
+		Topic: c.kafkaRoutesTopic,
+		Key:   sarama.ByteEncoder(key),
+		Value: sarama.ByteEncoder(payload),
+	})
+}
+
+// send sends a message to the active producer. The lock is not held while
+// waiting for the producer: when it is replaced, the message is sent to the
+// new one instead. The message is dropped when the component is stopping. It
+// returns true if the message was handed to a producer.
+func (c *Component) send(msg *sarama.ProducerMessage) bool {
+	for {
+		c.kafkaProducerLock.RLock()
+		active := c.kafkaProducer
+		select {
+		case <-active.retired:
+			// Retired without a replacement: we are stopping.
+			c.kafkaProducerLock.RUnlock()
+			return false
+		default:
+		}
+		active.senders.Add(1)
+		c.kafkaProducerLock.RUnlock()
+
+		select {
+		case active.producer.Input() <- msg:
+			active.senders.Done()
+			return true
+		case <-active.retired:
+			active.senders.Done()
+		case <-c.t.Dying():
+			active.senders.Done()
+			return false
+		}
 	}
 }
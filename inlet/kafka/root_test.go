@@ -6,14 +6,17 @@ package kafka
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
 	gometrics "github.com/rcrowley/go-metrics"
 
 	"akvorado/common/daemon"
 	"akvorado/common/helpers"
+	"akvorado/common/kafka"
 	"akvorado/common/reporter"
 	"akvorado/common/schema"
 )
@@ -54,6 +57,43 @@ func TestKafka(t *testing.T) {
 		`sent_bytes_total{exporter="127.0.0.1"}`: "26",
 		fmt.Sprintf(`errors_total{error="kafka: Failed to produce message to topic flows-%s: noooo"}`, c.d.Schema.ProtobufMessageHash()): "1",
 		`sent_messages_total{exporter="127.0.0.1"}`: "2",
+		`active_cluster`:            "0",
+		`cluster_switches_total`:    "0",
+		`redirected_messages_total`: "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestKafkaRouteUpdate(t *testing.T) {
+	r := reporter.NewMock(t)
+	c, mockProducer := NewMock(t, r, DefaultConfiguration())
+
+	received := make(chan bool)
+	mockProducer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(got *sarama.ProducerMessage) error {
+		defer close(received)
+		expected := sarama.ProducerMessage{
+			Topic:     "flows-routes",
+			Key:       sarama.ByteEncoder("key"),
+			Value:     sarama.ByteEncoder(`{"Prefix":"::ffff:192.0.2.0"}`),
+			Partition: got.Partition,
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("SendRouteUpdate() (-got, +want):\n%s", diff)
+		}
+		return nil
+	})
+	c.SendRouteUpdate("127.0.0.1", []byte("key"), []byte(`{"Prefix":"::ffff:192.0.2.0"}`))
+	select {
+	case <-received:
+	case <-time.After(1 * time.Second):
+		t.Fatal("Kafka message not received")
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_kafka_", "sent_")
+	expectedMetrics := map[string]string{
+		`sent_route_updates_total{exporter="127.0.0.1"}`: "1",
 	}
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
@@ -68,20 +108,20 @@ func TestKafkaMetrics(t *testing.T) {
 	}
 
 	// Manually put some metrics
-	gometrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-1111", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-1111", c.kafkaConfigs[0].MetricRegistry).
 		Mark(100)
-	gometrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-1112", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterMeter("incoming-byte-rate-for-broker-1112", c.kafkaConfigs[0].MetricRegistry).
 		Mark(200)
-	gometrics.GetOrRegisterMeter("outgoing-byte-rate-for-broker-1111", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterMeter("outgoing-byte-rate-for-broker-1111", c.kafkaConfigs[0].MetricRegistry).
 		Mark(199)
-	gometrics.GetOrRegisterMeter("outgoing-byte-rate-for-broker-1112", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterMeter("outgoing-byte-rate-for-broker-1112", c.kafkaConfigs[0].MetricRegistry).
 		Mark(20)
-	gometrics.GetOrRegisterHistogram("request-size-for-broker-1111", c.kafkaConfig.MetricRegistry,
+	gometrics.GetOrRegisterHistogram("request-size-for-broker-1111", c.kafkaConfigs[0].MetricRegistry,
 		gometrics.NewExpDecaySample(10, 1)).
 		Update(100)
-	gometrics.GetOrRegisterCounter("requests-in-flight-for-broker-1111", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterCounter("requests-in-flight-for-broker-1111", c.kafkaConfigs[0].MetricRegistry).
 		Inc(20)
-	gometrics.GetOrRegisterCounter("requests-in-flight-for-broker-1112", c.kafkaConfig.MetricRegistry).
+	gometrics.GetOrRegisterCounter("requests-in-flight-for-broker-1112", c.kafkaConfigs[0].MetricRegistry).
 		Inc(20)
 
 	gotMetrics := r.GetMetrics("akvorado_inlet_kafka_")
@@ -98,8 +138,262 @@ func TestKafkaMetrics(t *testing.T) {
 		`brokers_request_size_sum{broker="1111"}`:              "100",
 		`brokers_inflight_requests{broker="1111"}`:             "20",
 		`brokers_inflight_requests{broker="1112"}`:             "20",
+		`active_cluster`:            "0",
+		`cluster_switches_total`:    "0",
+		`redirected_messages_total`: "0",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestKafkaFailover(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration()
+	configuration.Standby = []kafka.ClusterConfiguration{kafka.DefaultClusterConfiguration()}
+	configuration.Standby[0].Brokers = []string{"127.0.0.1:9093"}
+	configuration.HealthCheckInterval = 20 * time.Millisecond
+	c, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t), Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// Primary cluster is unavailable at start
+	var healthyLock sync.Mutex
+	healthy := []bool{false, true}
+	producers := make(chan *mocks.AsyncProducer, 10)
+	c.createKafkaProducer = func(cluster int) (sarama.AsyncProducer, error) {
+		healthyLock.Lock()
+		defer healthyLock.Unlock()
+		if !healthy[cluster] {
+			return nil, errors.New("cluster unavailable")
+		}
+		producer := mocks.NewAsyncProducer(t, c.kafkaConfigs[cluster])
+		producers <- producer
+		return producer, nil
+	}
+	c.checkKafkaCluster = func(cluster int) error {
+		healthyLock.Lock()
+		defer healthyLock.Unlock()
+		if !healthy[cluster] {
+			return errors.New("cluster unavailable")
+		}
+		return nil
+	}
+	setHealthy := func(primary, standby bool) {
+		healthyLock.Lock()
+		healthy = []bool{primary, standby}
+		healthyLock.Unlock()
+	}
+	waitActive := func(cluster int) {
+		t.Helper()
+		for range 100 {
+			c.kafkaProducerLock.RLock()
+			active := c.kafkaProducer.cluster
+			c.kafkaProducerLock.RUnlock()
+			if active == cluster {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("active cluster is not %d", cluster)
+	}
+	send := func(producer *mocks.AsyncProducer) {
+		t.Helper()
+		received := make(chan bool)
+		producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(*sarama.ProducerMessage) error {
+			close(received)
+			return nil
+		})
+		c.Send("127.0.0.1", []byte("hello world!"))
+		select {
+		case <-received:
+		case <-time.After(time.Second):
+			t.Fatal("Kafka message not received")
+		}
+	}
+	helpers.StartStop(t, c)
+
+	// Messages should go to the standby cluster
+	waitActive(1)
+	send(<-producers)
+
+	// Primary cluster is back: failback
+	setHealthy(true, true)
+	primary := <-producers
+	waitActive(0)
+	send(primary)
+
+	// Primary cluster fails again: failover
+	setHealthy(false, true)
+	standby := <-producers
+	waitActive(1)
+	send(standby)
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_kafka_", "active_", "cluster_")
+	expectedMetrics := map[string]string{
+		`active_cluster`:         "1",
+		`cluster_switches_total`: "2",
 	}
 	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
 		t.Fatalf("Metrics (-got, +want):\n%s", diff)
 	}
 }
+
+// deadProducer is a producer for a dead cluster: its input queue is never
+// consumed. On close, queued messages are returned as errors.
+type deadProducer struct {
+	sarama.AsyncProducer
+	input  chan *sarama.ProducerMessage
+	errors chan *sarama.ProducerError
+}
+
+func newDeadProducer(queueSize int) *deadProducer {
+	return &deadProducer{
+		input:  make(chan *sarama.ProducerMessage, queueSize),
+		errors: make(chan *sarama.ProducerError),
+	}
+}
+
+func (p *deadProducer) Input() chan<- *sarama.ProducerMessage { return p.input }
+func (p *deadProducer) Errors() <-chan *sarama.ProducerError  { return p.errors }
+func (p *deadProducer) AsyncClose() {
+	go func() {
+		for {
+			select {
+			case msg := <-p.input:
+				p.errors <- &sarama.ProducerError{Msg: msg, Err: sarama.ErrOutOfBrokers}
+			default:
+				close(p.errors)
+				return
+			}
+		}
+	}()
+}
+
+func (p *deadProducer) Close() error {
+	p.AsyncClose()
+	for range p.errors {
+	}
+	return nil
+}
+
+func TestKafkaFailoverWithFullQueue(t *testing.T) {
+	r := reporter.NewMock(t)
+	configuration := DefaultConfiguration()
+	configuration.Standby = []kafka.ClusterConfiguration{kafka.DefaultClusterConfiguration()}
+	configuration.Standby[0].Brokers = []string{"127.0.0.1:9093"}
+	configuration.HealthCheckInterval = 20 * time.Millisecond
+	c, err := New(r, configuration, Dependencies{Daemon: daemon.NewMock(t), Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	// The primary cluster accepts 2 messages, then blocks.
+	const queued, blocked = 2, 3
+	var healthyLock sync.Mutex
+	healthy := []bool{true, true}
+	received := make(chan string, queued+blocked)
+	c.createKafkaProducer = func(cluster int) (sarama.AsyncProducer, error) {
+		if cluster == 0 {
+			return newDeadProducer(queued), nil
+		}
+		producer := mocks.NewAsyncProducer(t, c.kafkaConfigs[cluster])
+		for range queued + blocked {
+			producer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+				value, _ := msg.Value.Encode()
+				received <- string(value)
+				return nil
+			})
+		}
+		return producer, nil
+	}
+	c.checkKafkaCluster = func(cluster int) error {
+		healthyLock.Lock()
+		defer healthyLock.Unlock()
+		if !healthy[cluster] {
+			return errors.New("cluster unavailable")
+		}
+		return nil
+	}
+	helpers.StartStop(t, c)
+
+	// Fill the queue of the primary cluster and block some senders.
+	var wg sync.WaitGroup
+	for i := range queued + blocked {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Send("127.0.0.1", []byte(fmt.Sprintf("message %d", i)))
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// Failover should complete and all messages should go to the standby
+	// cluster.
+	healthyLock.Lock()
+	healthy = []bool{false, true}
+	healthyLock.Unlock()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Send() still blocked after failover")
+	}
+	got := map[string]bool{}
+	for range queued + blocked {
+		select {
+		case value := <-received:
+			got[value] = true
+		case <-time.After(time.Second):
+			t.Fatalf("Kafka messages not received, got %v", got)
+		}
+	}
+	expected := map[string]bool{}
+	for i := range queued + blocked {
+		expected[fmt.Sprintf("message %d", i)] = true
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Kafka messages (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_kafka_", "active_", "cluster_", "redirected_")
+	expectedMetrics := map[string]string{
+		`active_cluster`:            "1",
+		`cluster_switches_total`:    "1",
+		`redirected_messages_total`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestKafkaSendWhenStopped(t *testing.T) {
+	r := reporter.NewMock(t)
+	c, err := New(r, DefaultConfiguration(), Dependencies{Daemon: daemon.NewMock(t), Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	c.createKafkaProducer = func(cluster int) (sarama.AsyncProducer, error) {
+		producer := mocks.NewAsyncProducer(t, c.kafkaConfigs[cluster])
+		producer.ExpectInputAndSucceed()
+		return producer, nil
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error:\n%+v", err)
+	}
+	msg := &sarama.ProducerMessage{Topic: "flows", Value: sarama.StringEncoder("hello")}
+	if !c.send(msg) {
+		t.Error("send() == false while running")
+	}
+	if err := c.Stop(); err != nil {
+		t.Fatalf("Stop() error:\n%+v", err)
+	}
+	if c.send(msg) {
+		t.Error("send() == true after stop")
+	}
+}
@@ -31,8 +31,8 @@ func NewMock(t *testing.T, reporter *reporter.Reporter, configuration Configurat
 
 	// Use a mocked Kafka producer
 	var mockProducer *mocks.AsyncProducer
-	c.createKafkaProducer = func() (sarama.AsyncProducer, error) {
-		mockProducer = mocks.NewAsyncProducer(t, c.kafkaConfig)
+	c.createKafkaProducer = func(cluster int) (sarama.AsyncProducer, error) {
+		mockProducer = mocks.NewAsyncProducer(t, c.kafkaConfigs[cluster])
 		return mockProducer, nil
 	}
 
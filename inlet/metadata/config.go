@@ -8,6 +8,7 @@ import (
 
 	"akvorado/common/helpers"
 	"akvorado/inlet/metadata/provider"
+	"akvorado/inlet/metadata/provider/external"
 	"akvorado/inlet/metadata/provider/gnmi"
 	"akvorado/inlet/metadata/provider/snmp"
 	"akvorado/inlet/metadata/provider/static"
@@ -57,9 +58,10 @@ func (pc ProviderConfiguration) MarshalYAML() (interface{}, error) {
 }
 
 var providers = map[string](func() provider.Configuration){
-	"snmp":   snmp.DefaultConfiguration,
-	"gnmi":   gnmi.DefaultConfiguration,
-	"static": static.DefaultConfiguration,
+	"snmp":     snmp.DefaultConfiguration,
+	"gnmi":     gnmi.DefaultConfiguration,
+	"static":   static.DefaultConfiguration,
+	"external": external.DefaultConfiguration,
 }
 
 func init() {
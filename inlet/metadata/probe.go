@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"akvorado/common/reporter"
+	"akvorado/inlet/metadata/provider"
+)
+
+// ProbeResult is the result of probing a provider for an exporter.
+type ProbeResult struct {
+	// Provider is the type of the provider (snmp, gnmi, ...).
+	Provider string
+	// Description describes how the exporter was queried (agent,
+	// credentials), when the provider supports it.
+	Description map[string]string
+	// Skipped is true when the provider declined the query.
+	Skipped bool
+	// Answers are the answers received for each interface index.
+	Answers map[uint]provider.Answer
+	// Duration is the time spent querying the provider.
+	Duration time.Duration
+	// Err is the error returned by the provider, if any.
+	Err error
+}
+
+// probeRetryInterval is the interval between two queries to a provider
+// while some interfaces are still missing. Some providers, like gNMI, answer
+// asynchronously.
+const probeRetryInterval = 500 * time.Millisecond
+
+// Probe queries the configured providers for the provided exporter and
+// interface indexes, bypassing the cache. Like the metadata component,
+// providers are queried in order until one of them accepts the query. A
+// result is returned for each queried provider.
+func Probe(ctx context.Context, r *reporter.Reporter, configuration Configuration, exporterIP netip.Addr, ifIndexes []uint, timeout time.Duration) []ProbeResult {
+	exporterIP = netip.AddrFrom16(exporterIP.As16())
+	results := []ProbeResult{}
+	for _, pc := range configuration.Providers {
+		providerType := reflect.TypeOf(pc.Config)
+		if providerType.Kind() == reflect.Pointer {
+			providerType = providerType.Elem()
+		}
+		result := ProbeResult{
+			Provider: path.Base(providerType.PkgPath()),
+			Answers:  map[uint]provider.Answer{},
+		}
+		if describer, ok := pc.Config.(provider.Describer); ok {
+			result.Description = describer.Describe(exporterIP)
+		}
+		result.Duration, result.Skipped, result.Err = probeProvider(ctx, r, pc.Config,
+			exporterIP, ifIndexes, timeout, result.Answers)
+		results = append(results, result)
+		if !result.Skipped {
+			break
+		}
+	}
+	return results
+}
+
+// probeProvider queries a provider until all interfaces are answered or until
+// the timeout is reached.
+func probeProvider(ctx context.Context, r *reporter.Reporter, config provider.Configuration, exporterIP netip.Addr, ifIndexes []uint, timeout time.Duration, answers map[uint]provider.Answer) (time.Duration, bool, error) {
+	var lock sync.Mutex
+	p, err := config.New(r, func(update provider.Update) {
+		if update.ExporterIP != exporterIP {
+			return
+		}
+		lock.Lock()
+		answers[update.IfIndex] = update.Answer
+		lock.Unlock()
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	for {
+		err := p.Query(ctx, provider.BatchQuery{ExporterIP: exporterIP, IfIndexes: ifIndexes})
+		if errors.Is(err, provider.ErrSkipProvider) {
+			return time.Since(start), true, nil
+		} else if err != nil {
+			return time.Since(start), false, err
+		}
+		lock.Lock()
+		complete := len(answers) >= len(ifIndexes)
+		lock.Unlock()
+		if complete {
+			return time.Since(start), false, nil
+		}
+		select {
+		case <-ctx.Done():
+			if errReporter, ok := p.(provider.ErrorReporter); ok {
+				if err := errReporter.LastError(exporterIP); err != nil {
+					return time.Since(start), false, fmt.Errorf("%w (last error: %w)", ctx.Err(), err)
+				}
+			}
+			return time.Since(start), false, ctx.Err()
+		case <-time.After(probeRetryInterval):
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package external
+
+import (
+	"net/netip"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/inlet/metadata/provider"
+)
+
+// Configuration describes the configuration for the external provider.
+type Configuration struct {
+	// Target is the address of the external provider (host:port or
+	// unix:///path).
+	Target string `validate:"required"`
+	// TLS defines how to secure the connection to the external provider.
+	TLS helpers.TLSConfiguration
+	// Timeout tells how much time to wait for an answer.
+	Timeout time.Duration `validate:"min=10ms"`
+}
+
+// DefaultConfiguration represents the default configuration for the external
+// provider.
+func DefaultConfiguration() provider.Configuration {
+	return Configuration{
+		Timeout: time.Second,
+	}
+}
+
+// Describe returns the external provider queried for the provided exporter.
+func (configuration Configuration) Describe(_ netip.Addr) map[string]string {
+	return map[string]string{
+		"target": configuration.Target,
+	}
+}
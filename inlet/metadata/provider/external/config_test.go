@@ -0,0 +1,21 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package external
+
+import (
+	"testing"
+
+	"akvorado/common/helpers"
+)
+
+func TestValidation(t *testing.T) {
+	config := DefaultConfiguration().(Configuration)
+	if err := helpers.Validate.Struct(config); err == nil {
+		t.Fatal("validate.Struct() did not error without target")
+	}
+	config.Target = "unix:///run/akvorado/metadata.sock"
+	if err := helpers.Validate.Struct(config); err != nil {
+		t.Fatalf("validate.Struct() error:\n%+v", err)
+	}
+}
@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package external
+
+import (
+	_ "embed" // for protocol definition
+	"sync"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"akvorado/common/helpers/grpcdynamic"
+)
+
+// ProtocolDefinition is the protobuf definition of the protocol used to query
+// an external provider.
+//
+//go:embed metadata.proto
+var ProtocolDefinition string
+
+// queryMethod is the full name of the gRPC method to query an external
+// provider.
+const queryMethod = "/akvorado.metadata.v1.Metadata/Query"
+
+// protocolDescriptors contains the message descriptors from the protocol
+// definition.
+type protocolDescriptors struct {
+	queryRequest  protoreflect.MessageDescriptor
+	queryResponse protoreflect.MessageDescriptor
+}
+
+// loadProtocol parses the protocol definition on first use.
+var loadProtocol = sync.OnceValues(func() (protocolDescriptors, error) {
+	messages, err := grpcdynamic.Parse("metadata.proto", ProtocolDefinition,
+		"QueryRequest", "QueryResponse")
+	if err != nil {
+		return protocolDescriptors{}, err
+	}
+	return protocolDescriptors{
+		queryRequest:  messages[0],
+		queryResponse: messages[1],
+	}, nil
+})
@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package external is a metadata provider querying an external process using
+// gRPC. This allows metadata sources to be implemented in any language. The
+// protocol is described in metadata.proto.
+package external
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"akvorado/common/helpers/grpcdynamic"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/metadata/provider"
+)
+
+// Provider represents the external provider.
+type Provider struct {
+	r         *reporter.Reporter
+	config    *Configuration
+	conn      *grpc.ClientConn
+	protocol  protocolDescriptors
+	errLogger reporter.Logger
+
+	put func(provider.Update)
+
+	metrics struct {
+		successes *reporter.CounterVec
+		skipped   *reporter.CounterVec
+		errors    *reporter.CounterVec
+		times     *reporter.SummaryVec
+	}
+}
+
+// New creates a new external provider from configuration
+func (configuration Configuration) New(r *reporter.Reporter, put func(provider.Update)) (provider.Provider, error) {
+	protocol, err := loadProtocol()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load external provider protocol: %w", err)
+	}
+	conn, err := grpcdynamic.NewClient(configuration.Target, configuration.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create client for external provider: %w", err)
+	}
+
+	p := Provider{
+		r:         r,
+		config:    &configuration,
+		conn:      conn,
+		protocol:  protocol,
+		errLogger: r.Sample(reporter.BurstSampler(10*time.Second, 3)),
+
+		put: put,
+	}
+
+	p.metrics.successes = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "success_requests_total",
+			Help: "Number of successful requests.",
+		}, []string{"exporter"})
+	p.metrics.skipped = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "skipped_requests_total",
+			Help: "Number of requests skipped by the external provider.",
+		}, []string{"exporter"})
+	p.metrics.errors = r.CounterVec(
+		reporter.CounterOpts{
+			Name: "error_requests_total",
+			Help: "Number of failed requests.",
+		}, []string{"exporter", "error"})
+	p.metrics.times = r.SummaryVec(
+		reporter.SummaryOpts{
+			Name:       "request_seconds",
+			Help:       "Time to successfully query the external provider.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, []string{"exporter"})
+
+	return &p, nil
+}
+
+// Query queries the external provider.
+func (p *Provider) Query(ctx context.Context, query provider.BatchQuery) error {
+	exporterStr := query.ExporterIP.Unmap().String()
+	request := dynamicpb.NewMessage(p.protocol.queryRequest)
+	requestFields := p.protocol.queryRequest.Fields()
+	request.Set(requestFields.ByName("exporter_ip"), protoreflect.ValueOfString(exporterStr))
+	ifIndexes := request.Mutable(requestFields.ByName("if_indexes")).List()
+	for _, ifIndex := range query.IfIndexes {
+		ifIndexes.Append(protoreflect.ValueOfUint32(uint32(ifIndex)))
+	}
+	response := dynamicpb.NewMessage(p.protocol.queryResponse)
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.Timeout)
+	defer cancel()
+	start := time.Now()
+	if err := p.conn.Invoke(ctx, queryMethod, request, response); err != nil {
+		p.metrics.errors.WithLabelValues(exporterStr, status.Code(err).String()).Inc()
+		p.errLogger.Err(err).Str("exporter", exporterStr).Msg("cannot query external provider")
+		return err
+	}
+
+	responseFields := p.protocol.queryResponse.Fields()
+	if response.Get(responseFields.ByName("skip")).Bool() {
+		p.metrics.skipped.WithLabelValues(exporterStr).Inc()
+		return provider.ErrSkipProvider
+	}
+	exporter := decodeExporter(response.Get(responseFields.ByName("exporter")).Message())
+	interfaces := response.Get(responseFields.ByName("interfaces")).List()
+	for i := range interfaces.Len() {
+		ifIndex, iface := decodeInterface(interfaces.Get(i).Message())
+		p.put(provider.Update{
+			Query: provider.Query{
+				ExporterIP: query.ExporterIP,
+				IfIndex:    ifIndex,
+			},
+			Answer: provider.Answer{
+				Exporter:  exporter,
+				Interface: iface,
+			},
+		})
+	}
+	p.metrics.successes.WithLabelValues(exporterStr).Inc()
+	p.metrics.times.WithLabelValues(exporterStr).Observe(time.Since(start).Seconds())
+	return nil
+}
+
+func decodeExporter(msg protoreflect.Message) provider.Exporter {
+	fields := msg.Descriptor().Fields()
+	get := func(name protoreflect.Name) string {
+		return msg.Get(fields.ByName(name)).String()
+	}
+	return provider.Exporter{
+		Name:   get("name"),
+		Region: get("region"),
+		Role:   get("role"),
+		Tenant: get("tenant"),
+		Site:   get("site"),
+		Group:  get("group"),
+	}
+}
+
+func decodeInterface(msg protoreflect.Message) (uint, provider.Interface) {
+	fields := msg.Descriptor().Fields()
+	get := func(name protoreflect.Name) string {
+		return msg.Get(fields.ByName(name)).String()
+	}
+	return uint(msg.Get(fields.ByName("if_index")).Uint()), provider.Interface{
+		Name:         get("name"),
+		Description:  get("description"),
+		Speed:        uint(msg.Get(fields.ByName("speed")).Uint()),
+		Provider:     get("provider"),
+		Connectivity: get("connectivity"),
+		Boundary:     schema.InterfaceBoundary(msg.Get(fields.ByName("boundary")).Enum()),
+	}
+}
@@ -0,0 +1,192 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package external
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/common/schema"
+	"akvorado/inlet/metadata/provider"
+)
+
+// startServer starts an external provider answering with the provided
+// function.
+func startServer(t *testing.T, handler func(exporterIP string, ifIndexes []uint32) (string, error)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	protocol, err := loadProtocol()
+	if err != nil {
+		t.Fatalf("loadProtocol() error:\n%+v", err)
+	}
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "akvorado.metadata.v1.Metadata",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Query",
+			Handler: func(_ any, _ context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				request := dynamicpb.NewMessage(protocol.queryRequest)
+				if err := dec(request); err != nil {
+					return nil, err
+				}
+				fields := protocol.queryRequest.Fields()
+				ifIndexes := []uint32{}
+				list := request.Get(fields.ByName("if_indexes")).List()
+				for i := range list.Len() {
+					ifIndexes = append(ifIndexes, uint32(list.Get(i).Uint()))
+				}
+				answer, err := handler(request.Get(fields.ByName("exporter_ip")).String(), ifIndexes)
+				if err != nil {
+					return nil, err
+				}
+				response := dynamicpb.NewMessage(protocol.queryResponse)
+				if err := protojson.Unmarshal([]byte(answer), response); err != nil {
+					return nil, err
+				}
+				return response, nil
+			},
+		}},
+	}, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+func TestExternalProvider(t *testing.T) {
+	target := startServer(t, func(exporterIP string, ifIndexes []uint32) (string, error) {
+		switch exporterIP {
+		case "192.0.2.1":
+			if diff := helpers.Diff(ifIndexes, []uint32{641, 642, 643}); diff != "" {
+				t.Errorf("Query() if indexes (-got, +want):\n%s", diff)
+			}
+			return `{
+  "exporter": {"name": "edge1.example.com", "region": "eu", "site": "par"},
+  "interfaces": [
+    {"if_index": 641, "name": "Gi0/0/641", "description": "Transit: Cogent", "speed": 10000,
+     "provider": "cogent", "connectivity": "transit", "boundary": "BOUNDARY_EXTERNAL"},
+    {"if_index": 642, "name": "Gi0/0/642", "description": "Core", "speed": 100000}
+  ]
+}`, nil
+		case "192.0.2.2":
+			return `{"skip": true}`, nil
+		case "192.0.2.3":
+			time.Sleep(200 * time.Millisecond)
+			return `{}`, nil
+		}
+		return "", status.Error(codes.Unavailable, "CMDB is down")
+	})
+
+	r := reporter.NewMock(t)
+	got := []provider.Update{}
+	config := DefaultConfiguration().(Configuration)
+	config.Target = target
+	config.Timeout = 100 * time.Millisecond
+	p, err := config.New(r, func(update provider.Update) {
+		got = append(got, update)
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+
+	ctx := context.Background()
+	if err := p.Query(ctx, provider.BatchQuery{
+		ExporterIP: netip.MustParseAddr("::ffff:192.0.2.1"),
+		IfIndexes:  []uint{641, 642, 643},
+	}); err != nil {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if err := p.Query(ctx, provider.BatchQuery{
+		ExporterIP: netip.MustParseAddr("::ffff:192.0.2.2"),
+		IfIndexes:  []uint{641},
+	}); !errors.Is(err, provider.ErrSkipProvider) {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if err := p.Query(ctx, provider.BatchQuery{
+		ExporterIP: netip.MustParseAddr("::ffff:192.0.2.3"),
+		IfIndexes:  []uint{641},
+	}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+	if err := p.Query(ctx, provider.BatchQuery{
+		ExporterIP: netip.MustParseAddr("::ffff:192.0.2.4"),
+		IfIndexes:  []uint{641},
+	}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("Query() error:\n%+v", err)
+	}
+
+	exporter := provider.Exporter{Name: "edge1.example.com", Region: "eu", Site: "par"}
+	expected := []provider.Update{
+		{
+			Query: provider.Query{ExporterIP: netip.MustParseAddr("::ffff:192.0.2.1"), IfIndex: 641},
+			Answer: provider.Answer{
+				Exporter: exporter,
+				Interface: provider.Interface{
+					Name:         "Gi0/0/641",
+					Description:  "Transit: Cogent",
+					Speed:        10000,
+					Provider:     "cogent",
+					Connectivity: "transit",
+					Boundary:     schema.InterfaceBoundaryExternal,
+				},
+			},
+		}, {
+			Query: provider.Query{ExporterIP: netip.MustParseAddr("::ffff:192.0.2.1"), IfIndex: 642},
+			Answer: provider.Answer{
+				Exporter: exporter,
+				Interface: provider.Interface{
+					Name:        "Gi0/0/642",
+					Description: "Core",
+					Speed:       100000,
+				},
+			},
+		},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("Query() (-got, +want):\n%s", diff)
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_metadata_provider_external_", "-request_seconds")
+	expectedMetrics := map[string]string{
+		`success_requests_total{exporter="192.0.2.1"}`:                        "1",
+		`skipped_requests_total{exporter="192.0.2.2"}`:                        "1",
+		`error_requests_total{error="DeadlineExceeded",exporter="192.0.2.3"}`: "1",
+		`error_requests_total{error="Unavailable",exporter="192.0.2.4"}`:      "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestProtocolDefinition(t *testing.T) {
+	// Ensure the dynamic messages match what we expect from the definition.
+	protocol, err := loadProtocol()
+	if err != nil {
+		t.Fatalf("loadProtocol() error:\n%+v", err)
+	}
+	for _, md := range []protoreflect.MessageDescriptor{protocol.queryRequest, protocol.queryResponse} {
+		if md == nil {
+			t.Fatal("missing message descriptor")
+		}
+	}
+	if got := protocol.queryRequest.ParentFile().Services().ByName("Metadata").Methods().ByName("Query"); got == nil {
+		t.Fatal("missing Query method")
+	}
+}
@@ -24,6 +24,12 @@ type exporterState struct {
 	Name       string
 	Ready      bool
 	Interfaces map[uint]provider.Interface
+	// Parents maps an interface index to the index of its parent (the
+	// aggregated interface for a LAG member, the underlying interface for a
+	// subinterface)
+	Parents map[uint]uint
+	// LastError is the last error encountered by the collector, if any
+	LastError error
 }
 
 // update update a state with the received events.
@@ -36,9 +42,11 @@ func (state *exporterState) update(events []event, model Model) {
 	// properties, for example if the index is in the state hierarchy but the
 	// name is in the config hierarchy)
 	// - mapping from keys to speeds (same remark)
+	// - mapping from keys to aggregate names (same remark)
 	i := 0
 	indexes := map[string]uint{}
 	speeds := map[string]uint{}
+	aggregates := map[string]string{}
 outer1:
 	for _, event := range events {
 		for _, path := range model.SystemNamePaths {
@@ -65,6 +73,14 @@ outer1:
 				speeds[event.Keys] = speed
 			}
 		}
+		for _, path := range model.IfAggregateIDPaths {
+			if event.Path == path {
+				if event.Value != "" {
+					aggregates[event.Keys] = event.Value
+				}
+				continue outer1
+			}
+		}
 		events[i] = event
 		i++
 	}
@@ -130,6 +146,33 @@ outer2:
 		// Copy back
 		state.Interfaces[index] = iface
 	}
+
+	// Fourth pass: parents. A LAG member has the aggregated interface as
+	// parent. A subinterface has the interface whose keys are a prefix of its
+	// own as parent.
+	state.Parents = map[uint]uint{}
+	names := map[string]uint{}
+	for index, iface := range state.Interfaces {
+		names[iface.Name] = index
+	}
+	for keys, index := range indexes {
+		if _, ok := state.Interfaces[index]; !ok {
+			continue
+		}
+		if aggregate, ok := aggregates[keys]; ok {
+			if parent, ok := names[aggregate]; ok && parent != index {
+				state.Parents[index] = parent
+				continue
+			}
+		}
+		if idx := strings.LastIndex(keys, ","); idx > 0 {
+			if parent, ok := indexes[keys[:idx]]; ok && parent != index {
+				if _, ok := state.Interfaces[parent]; ok {
+					state.Parents[index] = parent
+				}
+			}
+		}
+	}
 }
 
 // startCollector starts a new gNMI collector with the given state. It should not be used with taking the lock.
@@ -168,6 +211,11 @@ retryConnect:
 	addIfNotEmpty(targetAuthParameters.TLSCert, api.TLSCert(targetAuthParameters.TLSCert))
 	addIfNotEmpty(targetAuthParameters.TLSKey, api.TLSKey(targetAuthParameters.TLSKey))
 
+	setError := func(err error) {
+		p.stateLock.Lock()
+		state.LastError = err
+		p.stateLock.Unlock()
+	}
 	waitBeforeRetry := func() bool {
 		next := time.NewTimer(retryInitBackoff.NextBackOff())
 		select {
@@ -184,6 +232,7 @@ retryConnect:
 	)
 	if err != nil {
 		l.Err(err).Msg("unable to create target")
+		setError(err)
 		p.metrics.errors.WithLabelValues(exporterStr, "cannot create target").Inc()
 		if !waitBeforeRetry() {
 			return
@@ -194,6 +243,7 @@ retryConnect:
 	err = tg.CreateGNMIClient(ctx)
 	if err != nil {
 		l.Err(err).Msg("unable to create client")
+		setError(err)
 		p.metrics.errors.WithLabelValues(exporterStr, "cannot create client").Inc()
 		if !waitBeforeRetry() {
 			return
@@ -207,6 +257,7 @@ retryDetect:
 	model, encoding, err := p.detectModelAndEncoding(ctx, tg)
 	if err != nil {
 		l.Err(err).Msg("unable to detect model")
+		setError(err)
 		p.metrics.errors.WithLabelValues(exporterStr, "cannot detect model").Inc()
 		if !waitBeforeRetry() {
 			return
@@ -250,6 +301,7 @@ retryDetect:
 			p.stateLock.Lock()
 			state.update(events, model)
 			state.Ready = true
+			state.LastError = nil
 			p.stateLock.Unlock()
 			l.Debug().Msg("state updated")
 			p.metrics.ready.WithLabelValues(exporterStr).Set(1)
@@ -279,6 +331,7 @@ retryDetect:
 		} else {
 			// On error, retry a bit later
 			l.Err(err).Msg("cannot poll")
+			setError(err)
 			p.metrics.errors.WithLabelValues(exporterStr, "cannot poll").Inc()
 			next := time.NewTimer(retryFetchBackoff.NextBackOff())
 			select {
@@ -27,6 +27,7 @@ func TestStateUpdate(t *testing.T) {
 	expected := exporterState{
 		Name:       "",
 		Interfaces: map[uint]provider.Interface{},
+		Parents:    map[uint]uint{},
 	}
 	if diff := helpers.Diff(state, expected); diff != "" {
 		t.Fatalf("udpate() (-got, +want):\n%s", diff)
@@ -44,6 +45,8 @@ func TestStateUpdate(t *testing.T) {
 		{"/interface/ethernet/port-speed", "name=ethernet-1/4", "25G"},
 		{"/interface/ethernet/port-speed", "name=mgmt0", "1G"},
 		{"/interface/lag/lag-speed", "name=lag1", "100000000"},
+		{"/interface/ethernet/aggregate-id", "name=ethernet-1/2", "lag1"},
+		{"/interface/ethernet/aggregate-id", "name=ethernet-1/3", "lag1"},
 		{"/interface/subinterface/description", "name=ethernet-1/4,index=1", "4th interface"},
 		{"/interface/subinterface/name", "name=ethernet-1/4,index=1", "ethernet-1/4.1"},
 		{"/interface/subinterface/name", "name=mgmt0,index=0", "mgmt0.0"},
@@ -89,6 +92,11 @@ func TestStateUpdate(t *testing.T) {
 				Speed:       100,
 			},
 		},
+		Parents: map[uint]uint{
+			101: 106,
+			102: 106,
+			105: 103,
+		},
 	}
 	if diff := helpers.Diff(state, expected); diff != "" {
 		t.Fatalf("udpate() (-got, +want):\n%s", diff)
@@ -4,8 +4,10 @@
 package gnmi
 
 import (
+	"net"
 	"net/netip"
 	"reflect"
+	"strconv"
 	"time"
 
 	"akvorado/common/helpers"
@@ -59,6 +61,7 @@ type Model struct {
 	IfNamePaths        []string      `validate:"required_without=IfNameKeys"`
 	IfDescriptionPaths []string      `validate:"min=1"`
 	IfSpeedPaths       []IfSpeedPath `validate:"min=1,dive"`
+	IfAggregateIDPaths []string
 }
 
 // IfSpeedPath defines a path for oper speed.
@@ -133,6 +136,9 @@ func DefaultModels() []Model {
 				{"/interface/ethernet/port-speed", SpeedHuman},
 				{"/interface/lag/lag-speed", SpeedBps},
 			},
+			IfAggregateIDPaths: []string{
+				"/interface/ethernet/aggregate-id",
+			},
 		}, {
 			Name:            "OpenConfig",
 			SystemNamePaths: []string{"/system/config/hostname"},
@@ -153,6 +159,9 @@ func DefaultModels() []Model {
 				{"/interfaces/interface/ethernet/state/negotiated-port-speed", SpeedEthernet},
 				{"/interfaces/interface/ethernet/state/port-speed", SpeedEthernet},
 			},
+			IfAggregateIDPaths: []string{
+				"/interfaces/interface/ethernet/state/aggregate-id",
+			},
 		}, {
 			Name:               "IETF",
 			SystemNamePaths:    []string{"/system/hostname"},
@@ -225,3 +234,29 @@ func init() {
 	helpers.RegisterSubnetMapValidation[AuthenticationParameter]()
 	helpers.RegisterSubnetMapValidation[netip.Addr]()
 }
+
+// Describe returns the gNMI target and the authentication parameters used for
+// the provided exporter. Passwords are not included.
+func (configuration Configuration) Describe(exporterIP netip.Addr) map[string]string {
+	targetIP, ok := configuration.Targets.Lookup(exporterIP)
+	if !ok {
+		targetIP = exporterIP
+	}
+	targetPort := configuration.Ports.LookupOrDefault(exporterIP, 57400)
+	description := map[string]string{
+		"target": net.JoinHostPort(targetIP.Unmap().String(), strconv.FormatUint(uint64(targetPort), 10)),
+	}
+	auth := configuration.AuthenticationParameters.LookupOrDefault(exporterIP, AuthenticationParameter{})
+	if auth.Username != "" {
+		description["username"] = auth.Username
+	}
+	switch {
+	case auth.Insecure:
+		description["transport"] = "insecure"
+	case auth.SkipVerify:
+		description["transport"] = "TLS without verification"
+	default:
+		description["transport"] = "TLS"
+	}
+	return description
+}
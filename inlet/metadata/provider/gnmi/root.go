@@ -58,6 +58,16 @@ func (p *Provider) Query(ctx context.Context, q provider.BatchQuery) error {
 	// cache.
 	if state.Ready {
 		for _, ifindex := range q.IfIndexes {
+			var parent provider.ParentInterface
+			if parentIndex, ok := state.Parents[ifindex]; ok {
+				parentIface := state.Interfaces[parentIndex]
+				parent = provider.ParentInterface{
+					IfIndex:     parentIndex,
+					Name:        parentIface.Name,
+					Description: parentIface.Description,
+					Speed:       parentIface.Speed,
+				}
+			}
 			p.put(provider.Update{
 				Query: provider.Query{
 					ExporterIP: q.ExporterIP,
@@ -68,6 +78,7 @@ func (p *Provider) Query(ctx context.Context, q provider.BatchQuery) error {
 						Name: state.Name,
 					},
 					Interface: state.Interfaces[ifindex],
+					Parent:    parent,
 				},
 			})
 		}
@@ -79,3 +90,14 @@ func (p *Provider) Query(ctx context.Context, q provider.BatchQuery) error {
 	}
 	return nil
 }
+
+// LastError returns the last error encountered while collecting data from the
+// provided exporter.
+func (p *Provider) LastError(exporterIP netip.Addr) error {
+	p.stateLock.Lock()
+	defer p.stateLock.Unlock()
+	if state, ok := p.state[exporterIP]; ok {
+		return state.LastError
+	}
+	return nil
+}
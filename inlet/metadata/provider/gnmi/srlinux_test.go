@@ -767,7 +767,7 @@ commit now
 			`collector_ready_info{exporter="127.0.0.1"}`:               "1",
 			`encoding_info{encoding="json_ietf",exporter="127.0.0.1"}`: "1",
 			`model_info{exporter="127.0.0.1",model="Nokia SR Linux"}`:  "1",
-			`paths_count{exporter="127.0.0.1"}`:                        "82",
+			`paths_count{exporter="127.0.0.1"}`:                        "84",
 			`updates_total{exporter="127.0.0.1"}`:                      "1",
 		}
 		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
@@ -816,7 +816,7 @@ commit now
 			`collector_ready_info{exporter="127.0.0.1"}`:               "1",
 			`encoding_info{encoding="json_ietf",exporter="127.0.0.1"}`: "1",
 			`model_info{exporter="127.0.0.1",model="Nokia SR Linux"}`:  "1",
-			`paths_count{exporter="127.0.0.1"}`:                        "79",
+			`paths_count{exporter="127.0.0.1"}`:                        "81",
 			`updates_total{exporter="127.0.0.1"}`:                      "2",
 		}
 		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
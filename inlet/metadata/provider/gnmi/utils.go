@@ -25,6 +25,7 @@ func (m Model) gnmiOptions(options ...api.GNMIOption) []api.GNMIOption {
 	for _, path := range m.IfSpeedPaths {
 		options = append(options, api.Subscription(api.Path(path.Path)))
 	}
+	appendPaths(m.IfAggregateIDPaths)
 	return options
 }
 
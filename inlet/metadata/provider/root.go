@@ -55,10 +55,25 @@ type BatchQuery struct {
 	IfIndexes  []uint
 }
 
+// ParentInterface contains the information about the parent of an interface:
+// the aggregated interface for a LAG member or the underlying interface for a
+// subinterface.
+type ParentInterface struct {
+	// IfIndex is the index of the parent interface. It is 0 when the
+	// interface has no known parent.
+	IfIndex     uint
+	Name        string
+	Description string
+	// Speed is the speed of the parent interface. For an aggregated
+	// interface, this is the aggregated speed.
+	Speed uint
+}
+
 // Answer is the answer received from a provider.
 type Answer struct {
 	Exporter  Exporter
 	Interface Interface
+	Parent    ParentInterface
 }
 
 // Update is an update received from a provider.
@@ -78,3 +93,20 @@ type Configuration interface {
 	// New instantiates a new provider from its configuration.
 	New(r *reporter.Reporter, put func(Update)) (Provider, error)
 }
+
+// Describer is an optional interface for a provider configuration. It
+// describes how an exporter would be queried (agent, credentials). This is
+// used for troubleshooting. Secrets are not included.
+type Describer interface {
+	// Describe returns a description for the provided exporter.
+	Describe(exporterIP netip.Addr) map[string]string
+}
+
+// ErrorReporter is an optional interface for a provider collecting data in
+// the background. It reports the last error encountered for an exporter. This
+// is used for troubleshooting.
+type ErrorReporter interface {
+	// LastError returns the last error encountered for the provided
+	// exporter, or nil.
+	LastError(exporterIP netip.Addr) error
+}
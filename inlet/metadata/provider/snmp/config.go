@@ -4,8 +4,11 @@
 package snmp
 
 import (
+	"fmt"
+	"net"
 	"net/netip"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +32,9 @@ type Configuration struct {
 	Agents map[netip.Addr]netip.Addr
 	// Ports is a mapping from exporter IPs to SNMP port
 	Ports *helpers.SubnetMap[uint16]
+	// DiscoverParents tells if we should also poll LAG membership and the
+	// interface stack to find the parent of each interface.
+	DiscoverParents bool
 }
 
 // Credentials describes credentials for SNMP (both SNMPv2 and SNMPv3 USM security parameters).
@@ -204,3 +210,36 @@ func init() {
 	helpers.RegisterSubnetMapValidation[Credentials]()
 	helpers.RegisterSubnetMapValidation[uint16]()
 }
+
+// Describe returns the SNMP agent and the credentials used for the provided
+// exporter. Communities and passphrases are not included.
+func (configuration Configuration) Describe(exporterIP netip.Addr) map[string]string {
+	configuration.Agents = normalizeAgents(configuration.Agents)
+	g, communities := configuration.session(exporterIP)
+	description := map[string]string{
+		"agent": net.JoinHostPort(g.Target, strconv.Itoa(int(g.Port))),
+	}
+	if g.Version == gosnmp.Version3 {
+		params := g.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+		// Unset protocols are handled as none
+		authProtocol := AuthProtocol(params.AuthenticationProtocol)
+		privProtocol := PrivProtocol(params.PrivacyProtocol)
+		if authProtocol == 0 {
+			authProtocol = AuthProtocolNone
+		}
+		if privProtocol == 0 {
+			privProtocol = PrivProtocolNone
+		}
+		description["credentials"] = fmt.Sprintf("SNMPv3, user %s, authentication %s, privacy %s",
+			params.UserName, authProtocol, privProtocol)
+		if g.ContextName != "" {
+			description["credentials"] += fmt.Sprintf(", context %s", g.ContextName)
+		}
+	} else if len(communities) == 1 {
+		description["credentials"] = "SNMPv2c, single community"
+	} else {
+		description["credentials"] = fmt.Sprintf("SNMPv2c, %d communities tried in order",
+			len(communities))
+	}
+	return description
+}
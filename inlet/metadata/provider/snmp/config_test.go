@@ -4,6 +4,7 @@
 package snmp
 
 import (
+	"net/netip"
 	"testing"
 	"time"
 
@@ -384,3 +385,38 @@ func TestConfigurationUnmarshallerHook(t *testing.T) {
 		},
 	})
 }
+
+func TestDescribe(t *testing.T) {
+	config := DefaultConfiguration().(Configuration)
+	config.Credentials = helpers.MustNewSubnetMap(map[string]Credentials{
+		"::/0":                   {Communities: []string{"private", "public"}},
+		"::ffff:192.0.2.0/120":   {UserName: "alfred", AuthenticationProtocol: AuthProtocolSHA, AuthenticationPassphrase: "hello"},
+		"::ffff:203.0.113.0/120": {Communities: []string{"private"}},
+	})
+	config.Agents = map[netip.Addr]netip.Addr{
+		netip.MustParseAddr("192.0.2.1"): netip.MustParseAddr("192.0.2.10"),
+	}
+	cases := []struct {
+		Exporter string
+		Expected map[string]string
+	}{
+		{"::ffff:192.0.2.1", map[string]string{
+			"agent":       "192.0.2.10:161",
+			"credentials": "SNMPv3, user alfred, authentication sha, privacy none",
+		}},
+		{"::ffff:198.51.100.1", map[string]string{
+			"agent":       "198.51.100.1:161",
+			"credentials": "SNMPv2c, 2 communities tried in order",
+		}},
+		{"::ffff:203.0.113.1", map[string]string{
+			"agent":       "203.0.113.1:161",
+			"credentials": "SNMPv2c, single community",
+		}},
+	}
+	for _, tc := range cases {
+		got := config.Describe(netip.MustParseAddr(tc.Exporter))
+		if diff := helpers.Diff(got, tc.Expected); diff != "" {
+			t.Errorf("Describe(%s) (-got, +want):\n%s", tc.Exporter, diff)
+		}
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"net/netip"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gosnmp/gosnmp"
@@ -17,51 +19,24 @@ import (
 	"akvorado/inlet/metadata/provider"
 )
 
-// Poll polls the SNMP provider for the requested interface indexes.
-func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port uint16, ifIndexes []uint, put func(provider.Update)) error {
-	// Check if already have a request running
-	exporterStr := exporter.Unmap().String()
-	filteredIfIndexes := make([]uint, 0, len(ifIndexes))
-	keys := make([]string, 0, len(ifIndexes))
-	p.pendingRequestsLock.Lock()
-	for _, ifIndex := range ifIndexes {
-		key := fmt.Sprintf("%s@%d", exporterStr, ifIndex)
-		_, ok := p.pendingRequests[key]
-		if !ok {
-			p.pendingRequests[key] = struct{}{}
-			filteredIfIndexes = append(filteredIfIndexes, ifIndex)
-			keys = append(keys, key)
-		}
-	}
-	p.pendingRequestsLock.Unlock()
-	if len(filteredIfIndexes) == 0 {
-		return nil
+// session returns an SNMP session to query the provided exporter, using the
+// configured agent, port and credentials, as well as the communities to try in
+// order when using SNMPv2c. The agents should have been normalized.
+func (configuration *Configuration) session(exporter netip.Addr) (*gosnmp.GoSNMP, []string) {
+	agent, ok := configuration.Agents[exporter]
+	if !ok {
+		agent = exporter
 	}
-	ifIndexes = filteredIfIndexes
-	defer func() {
-		p.pendingRequestsLock.Lock()
-		for _, key := range keys {
-			delete(p.pendingRequests, key)
-		}
-		p.pendingRequestsLock.Unlock()
-	}()
-
-	// Instantiate an SNMP state
 	g := &gosnmp.GoSNMP{
-		Context:                 ctx,
 		Target:                  agent.Unmap().String(),
-		Port:                    port,
-		Retries:                 p.config.PollerRetries,
-		Timeout:                 p.config.PollerTimeout,
+		Port:                    configuration.Ports.LookupOrDefault(exporter, 161),
+		Retries:                 configuration.PollerRetries,
+		Timeout:                 configuration.PollerTimeout,
 		UseUnconnectedUDPSocket: true,
-		Logger:                  gosnmp.NewLogger(&goSNMPLogger{p.r}),
-		OnRetry: func(*gosnmp.GoSNMP) {
-			p.metrics.retries.WithLabelValues(exporterStr).Inc()
-		},
-		Version: gosnmp.Version2c,
+		Version:                 gosnmp.Version2c,
 	}
 	communities := []string{"public"}
-	if credentials, ok := p.config.Credentials.Lookup(exporter); ok {
+	if credentials, ok := configuration.Credentials.Lookup(exporter); ok {
 		if credentials.UserName != "" {
 			g.Version = gosnmp.Version3
 			g.SecurityModel = gosnmp.UserSecurityModel
@@ -93,6 +68,45 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 			communities = credentials.Communities
 		}
 	}
+	return g, communities
+}
+
+// Poll polls the SNMP provider for the requested interface indexes.
+func (p *Provider) Poll(ctx context.Context, exporter netip.Addr, ifIndexes []uint, put func(provider.Update)) error {
+	// Check if already have a request running
+	exporterStr := exporter.Unmap().String()
+	filteredIfIndexes := make([]uint, 0, len(ifIndexes))
+	keys := make([]string, 0, len(ifIndexes))
+	p.pendingRequestsLock.Lock()
+	for _, ifIndex := range ifIndexes {
+		key := fmt.Sprintf("%s@%d", exporterStr, ifIndex)
+		_, ok := p.pendingRequests[key]
+		if !ok {
+			p.pendingRequests[key] = struct{}{}
+			filteredIfIndexes = append(filteredIfIndexes, ifIndex)
+			keys = append(keys, key)
+		}
+	}
+	p.pendingRequestsLock.Unlock()
+	if len(filteredIfIndexes) == 0 {
+		return nil
+	}
+	ifIndexes = filteredIfIndexes
+	defer func() {
+		p.pendingRequestsLock.Lock()
+		for _, key := range keys {
+			delete(p.pendingRequests, key)
+		}
+		p.pendingRequestsLock.Unlock()
+	}()
+
+	// Instantiate an SNMP state
+	g, communities := p.config.session(exporter)
+	g.Context = ctx
+	g.Logger = gosnmp.NewLogger(&goSNMPLogger{p.r})
+	g.OnRetry = func(*gosnmp.GoSNMP) {
+		p.metrics.retries.WithLabelValues(exporterStr).Inc()
+	}
 
 	start := time.Now()
 	if err := g.Connect(); err != nil {
@@ -100,14 +114,19 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 		p.errLogger.Err(err).Str("exporter", exporterStr).Msg("unable to connect")
 	}
 	requests := []string{"1.3.6.1.2.1.1.5.0"}
+	ifRequests := 4
+	if p.config.DiscoverParents {
+		ifRequests = 6
+	}
 	for _, ifIndex := range ifIndexes {
-		moreRequests := []string{
-			fmt.Sprintf("1.3.6.1.2.1.2.2.1.2.%d", ifIndex),     // ifDescr
-			fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.1.%d", ifIndex),  // ifName
-			fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.18.%d", ifIndex), // ifAlias
-			fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.15.%d", ifIndex), // ifSpeed
+		requests = append(requests, interfaceRequests(ifIndex)...)
+		if p.config.DiscoverParents {
+			moreRequests := []string{
+				fmt.Sprintf("1.3.6.1.2.1.2.2.1.3.%d", ifIndex),             // ifType
+				fmt.Sprintf("1.2.840.10006.300.43.1.2.1.1.13.%d", ifIndex), // dot3adAggPortAttachedAggID
+			}
+			requests = append(requests, moreRequests...)
 		}
-		requests = append(requests, moreRequests...)
 	}
 	var results []gosnmp.SnmpPDU
 	success := false
@@ -160,81 +179,40 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 	}
 	p.metrics.times.WithLabelValues(exporterStr).Observe(time.Now().Sub(start).Seconds())
 
-	processStr := func(idx int, what string) (string, bool) {
-		switch results[idx].Type {
-		case gosnmp.OctetString:
-			return string(results[idx].Value.([]byte)), true
-		case gosnmp.NoSuchInstance, gosnmp.NoSuchObject, gosnmp.Null:
-			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s missing", what)).Inc()
-			return "", false
-		default:
-			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s unknown type", what)).Inc()
-			return "", false
-		}
-	}
-	processUint := func(idx int, what string) (uint, bool) {
-		switch results[idx].Type {
-		case gosnmp.Gauge32:
-			return results[idx].Value.(uint), true
-		case gosnmp.NoSuchInstance, gosnmp.NoSuchObject, gosnmp.Null:
-			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s missing", what)).Inc()
-			return 0, false
-		default:
-			p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s unknown type", what)).Inc()
-			return 0, false
-		}
-	}
-	sysNameVal, ok := processStr(0, "sysname")
+	sysNameVal, ok := p.processStr(exporterStr, results[0], "sysname")
 	if !ok {
 		return errors.New("unable to get sysName")
 	}
-	for idx := 1; idx < len(requests)-3; idx += 4 {
-		var (
-			name, description string
-			speed             uint
-		)
-		ifIndex := ifIndexes[(idx-1)/4]
+	updates := make([]provider.Update, 0, len(ifIndexes))
+	candidates := []uint{}
+	parents := map[uint]uint{}
+	for idx := 1; idx < len(requests)-ifRequests+1; idx += ifRequests {
+		var iface provider.Interface
+		ifIndex := ifIndexes[(idx-1)/ifRequests]
 		ok := true
 		// We do not process results when index is 0 (this can happen for local
 		// traffic, we only care for exporter name).
 		if ifIndex > 0 {
-			ifDescrVal, okDescr := processStr(idx, "ifdescr")
-			ifNameVal, okName := processStr(idx+1, "ifname")
-			ifAliasVal, okAlias := processStr(idx+2, "ifalias")
-			ifSpeedVal, okSpeed := processUint(idx+3, "ifspeed")
-
-			// Many equipments are using ifDescr for the interface name and
-			// ifAlias for the description, which is counter-intuitive. We want
-			// both the name and the description.
-
-			if okName {
-				// If we have ifName, use ifDescr if it is different and ifAlias
-				// is not. Otherwise, keep description empty.
-				name = ifNameVal
-				if okDescr && ifDescrVal != ifNameVal {
-					description = ifDescrVal
-				} else if okAlias {
-					description = ifAliasVal
-				}
-			} else {
-				// Don't handle the other case yet. It would be unexpected to
-				// have ifAlias and not ifName. And if we have only ifDescr, we
-				// can't really know what this is.
-				ok = false
-			}
-
-			// Speed is mandatory
-			ok = ok && okSpeed
-			speed = ifSpeedVal
+			iface, ok = p.processInterface(exporterStr, results[idx:idx+4])
 		}
 		if ok {
 			p.metrics.successes.WithLabelValues(exporterStr).Inc()
 		} else {
-			name = ""
-			description = ""
-			speed = 0
+			iface = provider.Interface{}
+		}
+		if ok && ifIndex > 0 && p.config.DiscoverParents {
+			// A LAG member has the aggregated interface as parent. Otherwise,
+			// we check the interface stack, unless this is the aggregated
+			// interface itself (its lower layers are the members).
+			ifType, _ := processInt(results[idx+4])
+			aggIndex, _ := processInt(results[idx+5])
+			if aggIndex > 0 && uint(aggIndex) != ifIndex {
+				parents[ifIndex] = uint(aggIndex)
+			} else if ifType != ifTypeIEEE8023adLag {
+				candidates = append(candidates, ifIndex)
+			}
 		}
-		put(provider.Update{
+		updates = append(updates, provider.Update{
 			Query: provider.Query{
 				ExporterIP: exporter,
 				IfIndex:    ifIndex,
@@ -243,18 +221,186 @@ func (p *Provider) Poll(ctx context.Context, exporter, agent netip.Addr, port ui
 				Exporter: provider.Exporter{
 					Name: sysNameVal,
 				},
-				Interface: provider.Interface{
-					Name:        name,
-					Description: description,
-					Speed:       speed,
-				},
+				Interface: iface,
 			},
 		})
 	}
 
+	if p.config.DiscoverParents {
+		// Subinterfaces have the underlying interface as a lower layer in the
+		// interface stack.
+		if len(candidates) > 0 {
+			stackRequests := make([]string, 0, len(candidates))
+			for _, ifIndex := range candidates {
+				stackRequests = append(stackRequests, fmt.Sprintf("%s.%d", ifStackStatusOID, ifIndex))
+			}
+			stackResults, err := g.GetNext(stackRequests)
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			if err != nil {
+				p.metrics.errors.WithLabelValues(exporterStr, "getnext").Inc()
+				p.errLogger.Err(err).
+					Str("exporter", exporterStr).
+					Msgf("unable to GETNEXT (%d OIDs)", len(stackRequests))
+			} else {
+				for idx, result := range stackResults.Variables {
+					if idx >= len(candidates) {
+						break
+					}
+					prefix := fmt.Sprintf(".%s.%d.", ifStackStatusOID, candidates[idx])
+					if !strings.HasPrefix(result.Name, prefix) {
+						continue
+					}
+					lower, err := strconv.ParseUint(strings.TrimPrefix(result.Name, prefix), 10, 32)
+					if err != nil || lower == 0 {
+						continue
+					}
+					parents[candidates[idx]] = uint(lower)
+				}
+			}
+		}
+
+		// Fetch information about parents
+		parentIndexes := []uint{}
+		for _, parent := range parents {
+			if !slices.Contains(parentIndexes, parent) {
+				parentIndexes = append(parentIndexes, parent)
+			}
+		}
+		if len(parentIndexes) > 0 {
+			parentRequests := []string{}
+			for _, ifIndex := range parentIndexes {
+				parentRequests = append(parentRequests, interfaceRequests(ifIndex)...)
+			}
+			parentResults, err := g.Get(parentRequests)
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			if err != nil || len(parentResults.Variables) != len(parentRequests) {
+				if err == nil {
+					err = errors.New("SNMP mismatch on variable lengths")
+				}
+				p.metrics.errors.WithLabelValues(exporterStr, "get").Inc()
+				p.errLogger.Err(err).
+					Str("exporter", exporterStr).
+					Msgf("unable to GET parents (%d OIDs)", len(parentRequests))
+			} else {
+				parentInterfaces := map[uint]provider.ParentInterface{}
+				for idx, ifIndex := range parentIndexes {
+					iface, ok := p.processInterface(exporterStr, parentResults.Variables[idx*4:idx*4+4])
+					if ok {
+						parentInterfaces[ifIndex] = provider.ParentInterface{
+							IfIndex:     ifIndex,
+							Name:        iface.Name,
+							Description: iface.Description,
+							Speed:       iface.Speed,
+						}
+					}
+				}
+				for idx := range updates {
+					if parent, ok := parents[updates[idx].IfIndex]; ok {
+						updates[idx].Parent = parentInterfaces[parent]
+					}
+				}
+			}
+		}
+	}
+
+	for _, update := range updates {
+		put(update)
+	}
+
 	return nil
 }
 
+const (
+	// ifStackStatusOID is the OID of ifStackStatus. Its index is the higher
+	// layer followed by the lower layer.
+	ifStackStatusOID = "1.3.6.1.2.1.31.1.2.1.3"
+	// ifTypeIEEE8023adLag is the ifType of an aggregated interface.
+	ifTypeIEEE8023adLag = 161
+)
+
+// interfaceRequests returns the OIDs to request to get information about an
+// interface.
+func interfaceRequests(ifIndex uint) []string {
+	return []string{
+		fmt.Sprintf("1.3.6.1.2.1.2.2.1.2.%d", ifIndex),     // ifDescr
+		fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.1.%d", ifIndex),  // ifName
+		fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.18.%d", ifIndex), // ifAlias
+		fmt.Sprintf("1.3.6.1.2.1.31.1.1.1.15.%d", ifIndex), // ifSpeed
+	}
+}
+
+// processInterface turns the results for the OIDs returned by
+// interfaceRequests into an interface.
+func (p *Provider) processInterface(exporterStr string, results []gosnmp.SnmpPDU) (provider.Interface, bool) {
+	var iface provider.Interface
+	ifDescrVal, okDescr := p.processStr(exporterStr, results[0], "ifdescr")
+	ifNameVal, okName := p.processStr(exporterStr, results[1], "ifname")
+	ifAliasVal, okAlias := p.processStr(exporterStr, results[2], "ifalias")
+	ifSpeedVal, okSpeed := p.processUint(exporterStr, results[3], "ifspeed")
+
+	// Many equipments are using ifDescr for the interface name and
+	// ifAlias for the description, which is counter-intuitive. We want
+	// both the name and the description.
+
+	if !okName {
+		// Don't handle the other case yet. It would be unexpected to
+		// have ifAlias and not ifName. And if we have only ifDescr, we
+		// can't really know what this is.
+		return iface, false
+	}
+	// If we have ifName, use ifDescr if it is different and ifAlias
+	// is not. Otherwise, keep description empty.
+	iface.Name = ifNameVal
+	if okDescr && ifDescrVal != ifNameVal {
+		iface.Description = ifDescrVal
+	} else if okAlias {
+		iface.Description = ifAliasVal
+	}
+
+	// Speed is mandatory
+	iface.Speed = ifSpeedVal
+	return iface, okSpeed
+}
+
+func (p *Provider) processStr(exporterStr string, result gosnmp.SnmpPDU, what string) (string, bool) {
+	switch result.Type {
+	case gosnmp.OctetString:
+		return string(result.Value.([]byte)), true
+	case gosnmp.NoSuchInstance, gosnmp.NoSuchObject, gosnmp.Null:
+		p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s missing", what)).Inc()
+		return "", false
+	default:
+		p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s unknown type", what)).Inc()
+		return "", false
+	}
+}
+
+func (p *Provider) processUint(exporterStr string, result gosnmp.SnmpPDU, what string) (uint, bool) {
+	switch result.Type {
+	case gosnmp.Gauge32:
+		return result.Value.(uint), true
+	case gosnmp.NoSuchInstance, gosnmp.NoSuchObject, gosnmp.Null:
+		p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s missing", what)).Inc()
+		return 0, false
+	default:
+		p.metrics.errors.WithLabelValues(exporterStr, fmt.Sprintf("%s unknown type", what)).Inc()
+		return 0, false
+	}
+}
+
+// processInt returns the value of an integer. These values are optional and
+// their absence is not an error.
+func processInt(result gosnmp.SnmpPDU) (int, bool) {
+	if result.Type != gosnmp.Integer {
+		return 0, false
+	}
+	return result.Value.(int), true
+}
+
 type goSNMPLogger struct {
 	r *reporter.Reporter
 }
@@ -311,3 +311,114 @@ func TestPoller(t *testing.T) {
 		})
 	}
 }
+
+func TestPollerParents(t *testing.T) {
+	r := reporter.NewMock(t)
+	str := func(oid string, value string) *GoSNMPServer.PDUValueControlItem {
+		return &GoSNMPServer.PDUValueControlItem{
+			OID:   oid,
+			Type:  gosnmp.OctetString,
+			OnGet: func() (interface{}, error) { return value, nil },
+		}
+	}
+	gauge := func(oid string, value uint) *GoSNMPServer.PDUValueControlItem {
+		return &GoSNMPServer.PDUValueControlItem{
+			OID:   oid,
+			Type:  gosnmp.Gauge32,
+			OnGet: func() (interface{}, error) { return value, nil },
+		}
+	}
+	integer := func(oid string, value int) *GoSNMPServer.PDUValueControlItem {
+		return &GoSNMPServer.PDUValueControlItem{
+			OID:   oid,
+			Type:  gosnmp.Integer,
+			OnGet: func() (interface{}, error) { return value, nil },
+		}
+	}
+	master := GoSNMPServer.MasterAgent{
+		SecurityConfig: GoSNMPServer.SecurityConfig{
+			AuthoritativeEngineBoots: 10,
+		},
+		SubAgents: []*GoSNMPServer.SubAgent{
+			{
+				CommunityIDs: []string{"public"},
+				OIDs: []*GoSNMPServer.PDUValueControlItem{
+					str("1.3.6.1.2.1.1.5.0", "exporter62"),
+					// 10 and 11: LAG members
+					str("1.3.6.1.2.1.31.1.1.1.1.10", "et-0/0/0"),
+					str("1.3.6.1.2.1.31.1.1.1.18.10", "Member 1"),
+					gauge("1.3.6.1.2.1.31.1.1.1.15.10", 100000),
+					integer("1.3.6.1.2.1.2.2.1.3.10", 6),
+					integer("1.2.840.10006.300.43.1.2.1.1.13.10", 20),
+					str("1.3.6.1.2.1.31.1.1.1.1.11", "et-0/0/1"),
+					str("1.3.6.1.2.1.31.1.1.1.18.11", "Member 2"),
+					gauge("1.3.6.1.2.1.31.1.1.1.15.11", 100000),
+					integer("1.3.6.1.2.1.2.2.1.3.11", 6),
+					integer("1.2.840.10006.300.43.1.2.1.1.13.11", 20),
+					// 20: LAG
+					str("1.3.6.1.2.1.31.1.1.1.1.20", "ae0"),
+					str("1.3.6.1.2.1.31.1.1.1.18.20", "Transit: Cogent"),
+					gauge("1.3.6.1.2.1.31.1.1.1.15.20", 200000),
+					integer("1.3.6.1.2.1.2.2.1.3.20", 161),
+					// 30: subinterface of the LAG
+					str("1.3.6.1.2.1.31.1.1.1.1.30", "ae0.100"),
+					str("1.3.6.1.2.1.31.1.1.1.18.30", "VLAN 100"),
+					gauge("1.3.6.1.2.1.31.1.1.1.15.30", 200000),
+					integer("1.3.6.1.2.1.2.2.1.3.30", 135),
+					// Interface stack
+					integer("1.3.6.1.2.1.31.1.2.1.3.10.0", 1),
+					integer("1.3.6.1.2.1.31.1.2.1.3.11.0", 1),
+					integer("1.3.6.1.2.1.31.1.2.1.3.20.10", 1),
+					integer("1.3.6.1.2.1.31.1.2.1.3.20.11", 1),
+					integer("1.3.6.1.2.1.31.1.2.1.3.30.20", 1),
+				},
+			},
+		},
+	}
+	server := GoSNMPServer.NewSNMPServer(master)
+	if err := server.ListenUDP("udp", "127.0.0.1:0"); err != nil {
+		t.Fatalf("ListenUDP() err:\n%+v", err)
+	}
+	_, portStr, err := net.SplitHostPort(server.Address().String())
+	if err != nil {
+		panic(err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		panic(err)
+	}
+	go server.ServeForever()
+	defer server.Shutdown()
+
+	config := DefaultConfiguration().(Configuration)
+	config.PollerTimeout = 100 * time.Millisecond
+	config.DiscoverParents = true
+	config.Ports = helpers.MustNewSubnetMap(map[string]uint16{
+		"::/0": uint16(port),
+	})
+	got := []string{}
+	put := func(update provider.Update) {
+		got = append(got, fmt.Sprintf("%d %s %s %d → %d %s %s %d",
+			update.IfIndex, update.Interface.Name, update.Interface.Description, update.Interface.Speed,
+			update.Parent.IfIndex, update.Parent.Name, update.Parent.Description, update.Parent.Speed))
+	}
+	p, err := config.New(r, put)
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	// The mock agent only answers the last variable of a GETNEXT request,
+	// therefore, we only have one interface to look up in the stack.
+	p.Query(context.Background(), provider.BatchQuery{
+		ExporterIP: netip.MustParseAddr("::ffff:127.0.0.1"),
+		IfIndexes:  []uint{10, 11, 20, 30},
+	})
+	time.Sleep(50 * time.Millisecond)
+	if diff := helpers.Diff(got, []string{
+		"10 et-0/0/0 Member 1 100000 → 20 ae0 Transit: Cogent 200000",
+		"11 et-0/0/1 Member 2 100000 → 20 ae0 Transit: Cogent 200000",
+		"20 ae0 Transit: Cogent 200000 → 0   0",
+		"30 ae0.100 VLAN 100 200000 → 20 ae0 Transit: Cogent 200000",
+	}); diff != "" {
+		t.Fatalf("Poll() (-got, +want):\n%s", diff)
+	}
+}
@@ -37,14 +37,7 @@ type Provider struct {
 
 // New creates a new SNMP provider from configuration
 func (configuration Configuration) New(r *reporter.Reporter, put func(provider.Update)) (provider.Provider, error) {
-	for exporterIP, agentIP := range configuration.Agents {
-		if exporterIP.Is4() || agentIP.Is4() {
-			delete(configuration.Agents, exporterIP)
-			exporterIP = netip.AddrFrom16(exporterIP.As16())
-			agentIP = netip.AddrFrom16(agentIP.As16())
-			configuration.Agents[exporterIP] = agentIP
-		}
-	}
+	configuration.Agents = normalizeAgents(configuration.Agents)
 
 	p := Provider{
 		r:      r,
@@ -92,11 +85,15 @@ func (configuration Configuration) New(r *reporter.Reporter, put func(provider.U
 
 // Query queries exporter to get information through SNMP.
 func (p *Provider) Query(ctx context.Context, query provider.BatchQuery) error {
-	// Avoid querying too much exporters with errors
-	agentIP, ok := p.config.Agents[query.ExporterIP]
-	if !ok {
-		agentIP = query.ExporterIP
+	return p.Poll(ctx, query.ExporterIP, query.IfIndexes, p.put)
+}
+
+// normalizeAgents returns a copy of the provided mapping from exporter IPs to
+// agent IPs with IPv4 addresses mapped to IPv6.
+func normalizeAgents(agents map[netip.Addr]netip.Addr) map[netip.Addr]netip.Addr {
+	normalized := make(map[netip.Addr]netip.Addr, len(agents))
+	for exporterIP, agentIP := range agents {
+		normalized[netip.AddrFrom16(exporterIP.As16())] = netip.AddrFrom16(agentIP.As16())
 	}
-	agentPort := p.config.Ports.LookupOrDefault(query.ExporterIP, 161)
-	return p.Poll(ctx, query.ExporterIP, agentIP, agentPort, query.IfIndexes, p.put)
+	return normalized
 }
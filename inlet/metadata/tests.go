@@ -35,6 +35,15 @@ func (mp mockProvider) Query(_ context.Context, query provider.BatchQuery) error
 			answer.Interface.Description = fmt.Sprintf("Interface %d", ifIndex)
 			answer.Interface.Speed = 1000
 		}
+		// LAG member
+		if ifIndex == 1100 {
+			answer.Parent = provider.ParentInterface{
+				IfIndex:     1000,
+				Name:        "Po1000",
+				Description: "Transit: Cogent",
+				Speed:       2000,
+			}
+		}
 		// in iface with  metadata (overriden by out iface)
 		if ifIndex == 1010 {
 			answer.Exporter.Group = "metadata group"
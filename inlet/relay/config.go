@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"akvorado/common/helpers"
+	"akvorado/common/kafka"
+	inletkafka "akvorado/inlet/kafka"
+)
+
+// Configuration describes the configuration for the relay component.
+type Configuration struct {
+	// Enable turns the inlet into a relay: flows are not decoded
+	// but forwarded as is to the configured output.
+	Enable bool
+	// QueueSize defines the number of datagrams to buffer locally
+	// when the output is not able to keep up or is unavailable.
+	QueueSize int `validate:"min=1"`
+	// Output defines where to send the received datagrams.
+	Output OutputConfiguration
+}
+
+// DefaultConfiguration represents the default configuration for the relay component.
+func DefaultConfiguration() Configuration {
+	return Configuration{
+		Enable:    false,
+		QueueSize: 100000,
+		Output: OutputConfiguration{
+			Config: DefaultKafkaOutputConfiguration(),
+		},
+	}
+}
+
+// OutputConfiguration represents the configuration for an output.
+type OutputConfiguration struct {
+	// Config is the actual configuration of the output.
+	Config OutputDriver
+}
+
+// OutputDriver is the interface to instantiate an output from its configuration.
+type OutputDriver interface {
+	newOutput(c *Component) (output, error)
+}
+
+// KafkaOutputConfiguration describes the configuration for the Kafka output.
+type KafkaOutputConfiguration struct {
+	// Topic defines the topic to write datagrams to.
+	Topic string `validate:"required"`
+	// Brokers is the list of brokers to connect to.
+	Brokers []string `validate:"min=1,dive,listen"`
+	// Version is the version of Kafka we assume to work
+	Version kafka.Version
+	// TLS defines TLS configuration
+	TLS kafka.TLSAndSASLConfiguration
+	// FlushInterval tells how often to flush pending data to Kafka.
+	FlushInterval time.Duration `validate:"min=100ms"`
+	// CompressionCodec defines the compression to use.
+	CompressionCodec inletkafka.CompressionCodec
+}
+
+// DefaultKafkaOutputConfiguration represents the default configuration for the Kafka output.
+func DefaultKafkaOutputConfiguration() OutputDriver {
+	defaultKafka := kafka.DefaultConfiguration()
+	return &KafkaOutputConfiguration{
+		Topic:            "relayed-flows",
+		Brokers:          defaultKafka.Brokers,
+		Version:          defaultKafka.Version,
+		TLS:              defaultKafka.TLS,
+		FlushInterval:    time.Second,
+		CompressionCodec: inletkafka.CompressionCodec(sarama.CompressionNone),
+	}
+}
+
+// TCPOutputConfiguration describes the configuration for the TCP output.
+type TCPOutputConfiguration struct {
+	// Remote is the address of the TCP input of the central inlet.
+	Remote string `validate:"required,listen"`
+	// DialTimeout is the timeout when connecting to the remote end.
+	DialTimeout time.Duration `validate:"min=100ms"`
+	// FlushInterval tells how often to flush pending data.
+	FlushInterval time.Duration `validate:"min=10ms"`
+}
+
+// DefaultTCPOutputConfiguration represents the default configuration for the TCP output.
+func DefaultTCPOutputConfiguration() OutputDriver {
+	return &TCPOutputConfiguration{
+		DialTimeout:   5 * time.Second,
+		FlushInterval: 100 * time.Millisecond,
+	}
+}
+
+// MarshalYAML undoes ConfigurationUnmarshallerHook().
+func (oc OutputConfiguration) MarshalYAML() (interface{}, error) {
+	return helpers.ParametrizedConfigurationMarshalYAML(oc, outputs)
+}
+
+var outputs = map[string](func() OutputDriver){
+	"kafka": DefaultKafkaOutputConfiguration,
+	"tcp":   DefaultTCPOutputConfiguration,
+}
+
+func init() {
+	helpers.RegisterMapstructureUnmarshallerHook(
+		helpers.ParametrizedConfigurationUnmarshallerHook(OutputConfiguration{}, outputs))
+}
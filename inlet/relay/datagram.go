@@ -0,0 +1,74 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"time"
+
+	"akvorado/inlet/flow/decoder"
+)
+
+// Datagrams are encoded with the following format (integers are big-endian):
+//
+//   - version (1 byte, currently 1)
+//   - length of the decoder name (1 byte)
+//   - decoder name
+//   - receive time as nanoseconds since epoch (8 bytes)
+//   - exporter address as an IPv6 address (16 bytes)
+//   - payload
+const datagramVersion = 1
+
+var (
+	errDatagramTooShort       = errors.New("datagram too short")
+	errDatagramUnknownVersion = errors.New("unknown datagram version")
+)
+
+// EncodeDatagram encodes a raw flow and the name of the decoder to use to
+// decode it into a datagram to be sent to a central inlet.
+func EncodeDatagram(decoderName string, in decoder.RawFlow) []byte {
+	name := decoderName
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	buf := make([]byte, 0, 2+len(name)+8+16+len(in.Payload))
+	buf = append(buf, datagramVersion, byte(len(name)))
+	buf = append(buf, name...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(in.TimeReceived.UnixNano()))
+	source := in.Source.To16()
+	if source == nil {
+		source = net.IPv6unspecified
+	}
+	buf = append(buf, source...)
+	buf = append(buf, in.Payload...)
+	return buf
+}
+
+// DecodeDatagram decodes a datagram produced by EncodeDatagram. It returns the
+// name of the decoder and the raw flow. The payload of the raw flow references
+// the provided buffer.
+func DecodeDatagram(buf []byte) (string, decoder.RawFlow, error) {
+	if len(buf) < 2 {
+		return "", decoder.RawFlow{}, errDatagramTooShort
+	}
+	if buf[0] != datagramVersion {
+		return "", decoder.RawFlow{}, errDatagramUnknownVersion
+	}
+	nameLen := int(buf[1])
+	buf = buf[2:]
+	if len(buf) < nameLen+8+16 {
+		return "", decoder.RawFlow{}, errDatagramTooShort
+	}
+	name := string(buf[:nameLen])
+	buf = buf[nameLen:]
+	received := time.Unix(0, int64(binary.BigEndian.Uint64(buf[:8])))
+	source := net.IP(buf[8:24])
+	return name, decoder.RawFlow{
+		TimeReceived: received,
+		Source:       source,
+		Payload:      buf[24:],
+	}, nil
+}
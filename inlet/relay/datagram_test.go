@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/inlet/flow/decoder"
+)
+
+func TestDatagramRoundTrip(t *testing.T) {
+	cases := []struct {
+		Description string
+		Name        string
+		Flow        decoder.RawFlow
+	}{
+		{
+			Description: "IPv4 source",
+			Name:        "netflow",
+			Flow: decoder.RawFlow{
+				TimeReceived: time.Date(2025, time.January, 10, 10, 0, 0, 1234, time.UTC),
+				Payload:      []byte("hello world!"),
+				Source:       net.ParseIP("192.0.2.1").To16(),
+			},
+		}, {
+			Description: "IPv6 source",
+			Name:        "sflow",
+			Flow: decoder.RawFlow{
+				TimeReceived: time.Date(2025, time.January, 10, 10, 0, 0, 0, time.UTC),
+				Payload:      []byte("hello world!"),
+				Source:       net.ParseIP("2001:db8::1"),
+			},
+		}, {
+			Description: "empty payload",
+			Name:        "netflow",
+			Flow: decoder.RawFlow{
+				TimeReceived: time.Date(2025, time.January, 10, 10, 0, 0, 0, time.UTC),
+				Payload:      []byte{},
+				Source:       net.ParseIP("2001:db8::1"),
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			name, got, err := DecodeDatagram(EncodeDatagram(tc.Name, tc.Flow))
+			if err != nil {
+				t.Fatalf("DecodeDatagram() error:\n%+v", err)
+			}
+			if name != tc.Name {
+				t.Errorf("DecodeDatagram() name == %q, expected %q", name, tc.Name)
+			}
+			got.TimeReceived = got.TimeReceived.UTC()
+			if diff := helpers.Diff(got, tc.Flow); diff != "" {
+				t.Errorf("DecodeDatagram() (-got, +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidDatagram(t *testing.T) {
+	cases := []struct {
+		Description string
+		Datagram    []byte
+		Error       error
+	}{
+		{"empty", []byte{}, errDatagramTooShort},
+		{"unknown version", []byte{2, 0}, errDatagramUnknownVersion},
+		{"truncated name", []byte{1, 7, 'n', 'e', 't'}, errDatagramTooShort},
+		{"truncated header", append([]byte{1, 1, 'n'}, make([]byte, 20)...), errDatagramTooShort},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			_, _, err := DecodeDatagram(tc.Datagram)
+			if !errors.Is(err, tc.Error) {
+				t.Fatalf("DecodeDatagram() error == %v, expected %v", err, tc.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/cenkalti/backoff/v4"
+
+	"akvorado/common/kafka"
+	"akvorado/common/reporter"
+)
+
+// kafkaOutput sends datagrams to a Kafka topic.
+type kafkaOutput struct {
+	c      *Component
+	config *KafkaOutputConfiguration
+
+	kafkaConfig         *sarama.Config
+	createKafkaProducer func() (sarama.AsyncProducer, error)
+}
+
+func (configuration *KafkaOutputConfiguration) newOutput(c *Component) (output, error) {
+	kafkaConfig, err := kafka.NewConfig(kafka.Configuration{
+		Topic:   configuration.Topic,
+		Brokers: configuration.Brokers,
+		Version: configuration.Version,
+		TLS:     configuration.TLS,
+	})
+	if err != nil {
+		return nil, err
+	}
+	kafkaConfig.Metadata.AllowAutoTopicCreation = true
+	kafkaConfig.Producer.Compression = sarama.CompressionCodec(configuration.CompressionCodec)
+	kafkaConfig.Producer.Return.Successes = false
+	kafkaConfig.Producer.Return.Errors = true
+	kafkaConfig.Producer.Flush.Frequency = configuration.FlushInterval
+	// Datagrams from the same exporter should stay in order (Netflow
+	// templates), use the exporter address as the key.
+	kafkaConfig.Producer.Partitioner = sarama.NewHashPartitioner
+	if err := kafkaConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
+	}
+
+	o := &kafkaOutput{
+		c:           c,
+		config:      configuration,
+		kafkaConfig: kafkaConfig,
+	}
+	o.createKafkaProducer = func() (sarama.AsyncProducer, error) {
+		return sarama.NewAsyncProducer(configuration.Brokers, o.kafkaConfig)
+	}
+	return o, nil
+}
+
+func (o *kafkaOutput) run() error {
+	c := o.c
+	kafka.GlobalKafkaLogger.Register(c.r)
+	defer kafka.GlobalKafkaLogger.Unregister()
+
+	// The central Kafka cluster may be unreachable. In this case, retry
+	// while datagrams accumulate in the local queue.
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.MaxElapsedTime = 0
+	retryBackoff.MaxInterval = time.Minute
+	retryBackoff.InitialInterval = time.Second
+	var producer sarama.AsyncProducer
+	for {
+		var err error
+		producer, err = o.createKafkaProducer()
+		if err == nil {
+			break
+		}
+		c.metrics.errors.WithLabelValues("cannot create producer").Inc()
+		c.r.Err(err).
+			Str("brokers", strings.Join(o.config.Brokers, ",")).
+			Msg("unable to create async producer")
+		select {
+		case <-c.t.Dying():
+			return nil
+		case <-time.After(retryBackoff.NextBackOff()):
+		}
+	}
+	defer producer.Close()
+
+	errLogger := c.r.Sample(reporter.BurstSampler(10*time.Second, 3))
+	c.t.Go(func() error {
+		for {
+			select {
+			case <-c.t.Dying():
+				return nil
+			case msg := <-producer.Errors():
+				if msg != nil {
+					c.metrics.errors.WithLabelValues(msg.Error()).Inc()
+					errLogger.Err(msg.Err).
+						Str("topic", msg.Msg.Topic).
+						Msg("Kafka producer error")
+				}
+			}
+		}
+	})
+	for {
+		select {
+		case <-c.t.Dying():
+			return nil
+		case msg := <-c.queue:
+			select {
+			case <-c.t.Dying():
+				return nil
+			case producer.Input() <- &sarama.ProducerMessage{
+				Topic: o.config.Topic,
+				Key:   sarama.ByteEncoder(msg.key),
+				Value: sarama.ByteEncoder(msg.payload),
+			}:
+				c.metrics.sentDatagrams.WithLabelValues(msg.exporter).Inc()
+				c.metrics.sentBytes.WithLabelValues(msg.exporter).Add(float64(len(msg.payload)))
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package relay forwards raw flow datagrams to a central inlet. This is
+// useful for remote sites where a full inlet is not desirable and where
+// sending UDP over a WAN link would lose packets.
+package relay
+
+import (
+	"net/netip"
+
+	"gopkg.in/tomb.v2"
+
+	"akvorado/common/daemon"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/decoder"
+)
+
+// Component represents the relay component.
+type Component struct {
+	r      *reporter.Reporter
+	d      *Dependencies
+	t      tomb.Tomb
+	config Configuration
+
+	queue  chan message
+	output output
+
+	metrics struct {
+		receivedDatagrams *reporter.CounterVec
+		droppedDatagrams  *reporter.CounterVec
+		sentDatagrams     *reporter.CounterVec
+		sentBytes         *reporter.CounterVec
+		errors            *reporter.CounterVec
+		queueLength       reporter.GaugeFunc
+	}
+}
+
+// Dependencies define the dependencies of the relay component.
+type Dependencies struct {
+	Daemon daemon.Component
+}
+
+// message is an encoded datagram waiting to be sent.
+type message struct {
+	exporter string
+	key      []byte
+	payload  []byte
+}
+
+// output is the interface an output should implement.
+type output interface {
+	// run sends the messages from the queue until the component is dying.
+	run() error
+}
+
+// New creates a new relay component.
+func New(r *reporter.Reporter, configuration Configuration, dependencies Dependencies) (*Component, error) {
+	c := Component{
+		r:      r,
+		d:      &dependencies,
+		config: configuration,
+		queue:  make(chan message, configuration.QueueSize),
+	}
+	c.initMetrics()
+
+	var err error
+	c.output, err = configuration.Output.Config.newOutput(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.d.Daemon.Track(&c.t, "inlet/relay")
+	return &c, nil
+}
+
+// Start starts the relay component.
+func (c *Component) Start() error {
+	c.r.Info().Msg("starting relay component")
+	c.t.Go(c.output.run)
+	return nil
+}
+
+// Stop stops the relay component.
+func (c *Component) Stop() error {
+	defer c.r.Info().Msg("relay component stopped")
+	c.r.Info().Msg("stopping relay component")
+	c.t.Kill(nil)
+	return c.t.Wait()
+}
+
+// Send queues a raw flow to be relayed. The decoder name is transmitted with
+// the flow to let the central inlet select the appropriate decoder. If the
+// queue is full, the flow is dropped.
+func (c *Component) Send(decoderName string, in decoder.RawFlow) {
+	exporterAddress, _ := netip.AddrFromSlice(in.Source.To16())
+	exporter := exporterAddress.Unmap().String()
+	c.metrics.receivedDatagrams.WithLabelValues(exporter, decoderName).Inc()
+	key := exporterAddress.As16()
+	select {
+	case c.queue <- message{
+		exporter: exporter,
+		key:      key[:],
+		payload:  EncodeDatagram(decoderName, in),
+	}:
+	default:
+		c.metrics.droppedDatagrams.WithLabelValues(exporter).Inc()
+	}
+}
+
+func (c *Component) initMetrics() {
+	c.metrics.receivedDatagrams = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "received_datagrams_total",
+			Help: "Number of datagrams received for relaying.",
+		},
+		[]string{"exporter", "decoder"},
+	)
+	c.metrics.droppedDatagrams = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "dropped_datagrams_total",
+			Help: "Number of datagrams dropped due to local queue full.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.sentDatagrams = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sent_datagrams_total",
+			Help: "Number of datagrams sent to the output.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.sentBytes = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "sent_bytes_total",
+			Help: "Number of bytes sent to the output.",
+		},
+		[]string{"exporter"},
+	)
+	c.metrics.errors = c.r.CounterVec(
+		reporter.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors when sending to the output.",
+		},
+		[]string{"error"},
+	)
+	c.metrics.queueLength = c.r.GaugeFunc(
+		reporter.GaugeOpts{
+			Name: "queue_length",
+			Help: "Number of datagrams waiting in the local queue.",
+		},
+		func() float64 {
+			return float64(len(c.queue))
+		},
+	)
+}
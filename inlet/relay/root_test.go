@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/inlet/flow/decoder"
+)
+
+func TestKafkaOutput(t *testing.T) {
+	r := reporter.NewMock(t)
+	c, err := New(r, DefaultConfiguration(), Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	output := c.output.(*kafkaOutput)
+	mockProducer := mocks.NewAsyncProducer(t, output.kafkaConfig)
+	output.createKafkaProducer = func() (sarama.AsyncProducer, error) {
+		return mockProducer, nil
+	}
+	helpers.StartStop(t, c)
+
+	flow := decoder.RawFlow{
+		TimeReceived: time.Now(),
+		Payload:      []byte("hello world!"),
+		Source:       net.ParseIP("192.0.2.1"),
+	}
+	received := make(chan bool)
+	mockProducer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(got *sarama.ProducerMessage) error {
+		defer close(received)
+		key := netip.MustParseAddr("::ffff:192.0.2.1").As16()
+		expected := sarama.ProducerMessage{
+			Topic:     "relayed-flows",
+			Key:       sarama.ByteEncoder(key[:]),
+			Value:     sarama.ByteEncoder(EncodeDatagram("netflow", flow)),
+			Partition: got.Partition,
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Errorf("Send() (-got, +want):\n%s", diff)
+		}
+		return nil
+	})
+	c.Send("netflow", flow)
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("Kafka message not received")
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	gotMetrics := r.GetMetrics("akvorado_inlet_relay_", "received_", "sent_")
+	expectedMetrics := map[string]string{
+		`received_datagrams_total{decoder="netflow",exporter="192.0.2.1"}`: "1",
+		`sent_bytes_total{exporter="192.0.2.1"}`:                           "45",
+		`sent_datagrams_total{exporter="192.0.2.1"}`:                       "1",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
+
+func TestTCPOutput(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error:\n%+v", err)
+	}
+	defer listener.Close()
+
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.Output.Config = &TCPOutputConfiguration{
+		Remote:        listener.Addr().String(),
+		DialTimeout:   time.Second,
+		FlushInterval: 10 * time.Millisecond,
+	}
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	flow := decoder.RawFlow{
+		TimeReceived: time.Date(2025, time.January, 10, 10, 0, 0, 0, time.UTC),
+		Payload:      []byte("hello world!"),
+		Source:       net.ParseIP("2001:db8::1"),
+	}
+	c.Send("sflow", flow)
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error:\n%+v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("ReadFull() error:\n%+v", err)
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		t.Fatalf("ReadFull() error:\n%+v", err)
+	}
+	name, got, err := DecodeDatagram(payload)
+	if err != nil {
+		t.Fatalf("DecodeDatagram() error:\n%+v", err)
+	}
+	if name != "sflow" {
+		t.Errorf("DecodeDatagram() name == %q, expected %q", name, "sflow")
+	}
+	got.TimeReceived = got.TimeReceived.UTC()
+	if diff := helpers.Diff(got, flow); diff != "" {
+		t.Errorf("DecodeDatagram() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestQueueFull(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration()
+	config.QueueSize = 2
+	c, err := New(r, config, Dependencies{Daemon: daemon.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	// Not started: nothing consumes the queue.
+	for range 5 {
+		c.Send("netflow", decoder.RawFlow{
+			TimeReceived: time.Now(),
+			Payload:      []byte("hello world!"),
+			Source:       net.ParseIP("192.0.2.1"),
+		})
+	}
+
+	gotMetrics := r.GetMetrics("akvorado_inlet_relay_", "dropped_", "queue_")
+	expectedMetrics := map[string]string{
+		`dropped_datagrams_total{exporter="192.0.2.1"}`: "3",
+		`queue_length`: "2",
+	}
+	if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+		t.Fatalf("Metrics (-got, +want):\n%s", diff)
+	}
+}
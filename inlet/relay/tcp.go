@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"akvorado/common/reporter"
+)
+
+// tcpOutput sends datagrams over a TCP connection. Each datagram is prefixed
+// by its length as a 32-bit big-endian integer.
+type tcpOutput struct {
+	c      *Component
+	config *TCPOutputConfiguration
+}
+
+func (configuration *TCPOutputConfiguration) newOutput(c *Component) (output, error) {
+	return &tcpOutput{
+		c:      c,
+		config: configuration,
+	}, nil
+}
+
+func (o *tcpOutput) run() error {
+	c := o.c
+	l := c.r.With().Str("remote", o.config.Remote).Logger()
+	errLogger := l.Sample(reporter.BurstSampler(time.Minute, 1))
+	retryBackoff := backoff.NewExponentialBackOff()
+	retryBackoff.MaxElapsedTime = 0
+	retryBackoff.MaxInterval = time.Minute
+	retryBackoff.InitialInterval = time.Second
+	dialer := net.Dialer{Timeout: o.config.DialTimeout}
+	for {
+		conn, err := dialer.DialContext(c.t.Context(context.Background()), "tcp", o.config.Remote)
+		if err != nil {
+			if !c.t.Alive() {
+				return nil
+			}
+			c.metrics.errors.WithLabelValues("cannot connect").Inc()
+			errLogger.Err(err).Msg("unable to connect to remote end")
+			select {
+			case <-c.t.Dying():
+				return nil
+			case <-time.After(retryBackoff.NextBackOff()):
+				continue
+			}
+		}
+		retryBackoff.Reset()
+		l.Info().Msg("connected to remote end")
+		err = o.send(conn)
+		conn.Close()
+		if err == nil {
+			return nil
+		}
+		c.metrics.errors.WithLabelValues("cannot send").Inc()
+		errLogger.Err(err).Msg("unable to send to remote end")
+	}
+}
+
+// send sends datagrams from the queue to the provided connection until the
+// component is dying (returning nil) or an error happens.
+func (o *tcpOutput) send(conn net.Conn) error {
+	c := o.c
+	w := bufio.NewWriter(conn)
+	flush := func() error {
+		if w.Buffered() == 0 {
+			return nil
+		}
+		conn.SetWriteDeadline(time.Now().Add(o.config.DialTimeout))
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("unable to flush: %w", err)
+		}
+		return nil
+	}
+	ticker := time.NewTicker(o.config.FlushInterval)
+	defer ticker.Stop()
+	header := make([]byte, 4)
+	for {
+		select {
+		case <-c.t.Dying():
+			flush()
+			return nil
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		case msg := <-c.queue:
+			binary.BigEndian.PutUint32(header, uint32(len(msg.payload)))
+			conn.SetWriteDeadline(time.Now().Add(o.config.DialTimeout))
+			if _, err := w.Write(header); err != nil {
+				return fmt.Errorf("unable to write: %w", err)
+			}
+			if _, err := w.Write(msg.payload); err != nil {
+				return fmt.Errorf("unable to write: %w", err)
+			}
+			c.metrics.sentDatagrams.WithLabelValues(msg.exporter).Inc()
+			c.metrics.sentBytes.WithLabelValues(msg.exporter).Add(float64(len(msg.payload)))
+		}
+	}
+}
@@ -22,6 +22,9 @@ type Configuration struct {
 	CollectASPaths bool
 	// CollectCommunities is true when we want to collect communities
 	CollectCommunities bool
+	// RouteHistory tells if route announcements and withdrawals should be
+	// exported to Kafka to keep an history in ClickHouse.
+	RouteHistory bool
 	// Keep tells how long to keep routes from a BMP client when it goes down
 	Keep time.Duration `validate:"min=1s"`
 	// RIBPeerRemovalMaxTime tells the maximum time the removal worker should run to remove a peer
@@ -92,6 +92,7 @@ func (p *Provider) addPeer(pkey peerKey) *peerInfo {
 		reference: p.lastPeerReference,
 	}
 	p.peers[pkey] = pinfo
+	p.peerKeys[pinfo.reference] = pkey
 	return pinfo
 }
 
@@ -231,6 +232,16 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 		return
 	}
 
+	// Route updates are exported once the lock is released.
+	var history routeUpdate
+	var updates []routeUpdate
+	if p.config.RouteHistory {
+		history = p.newRouteUpdate(pkey)
+		defer func() {
+			p.exportRouteUpdates(pkey.exporter.Addr().Unmap().String(), updates)
+		}()
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -261,11 +272,20 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			if p.config.CollectASNs || p.config.CollectASPaths {
 				rta.asPath = asPathFlat(attr)
 			}
+			if p.config.RouteHistory {
+				history.ASPath = asPathFlat(attr)
+			}
 		case *bgp.PathAttributeCommunities:
 			if p.config.CollectCommunities {
 				rta.communities = attr.Value
 			}
+			if p.config.RouteHistory {
+				history.Communities = attr.Value
+			}
 		case *bgp.PathAttributeLargeCommunities:
+			if p.config.RouteHistory {
+				history.LargeCommunities = largeCommunitiesToStrings(attr.Values)
+			}
 			if p.config.CollectCommunities {
 				rta.largeCommunities = make([]bgp.LargeCommunity, len(attr.Values))
 				for idx, c := range attr.Values {
@@ -301,6 +321,9 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			}
 			pf, _ := netip.AddrFromSlice(prefix)
 			rta.plen = uint8(plen)
+			if p.config.RouteHistory {
+				updates = append(updates, history.withPrefix(pf, plen, pkey.distinguisher, nh, false))
+			}
 			added += p.rib.addPrefix(pf, plen, route{
 				peer: pinfo.reference,
 				nlri: p.rib.nlris.Put(nlri{
@@ -325,6 +348,9 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 				path:   ipprefix.PathIdentifier(),
 				rd:     pkey.distinguisher,
 			}); ok {
+				if p.config.RouteHistory {
+					updates = append(updates, history.withPrefix(pf, plen, pkey.distinguisher, nh, true))
+				}
 				removed += p.rib.removePrefix(pf, plen, route{
 					peer: pinfo.reference,
 					nlri: nlriRef,
@@ -395,6 +421,9 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 			switch attr.(type) {
 			case *bgp.PathAttributeMpReachNLRI:
 				rta.plen = uint8(plen)
+				if p.config.RouteHistory {
+					updates = append(updates, history.withPrefix(pf, plen, rd, nh, false))
+				}
 				added += p.rib.addPrefix(pf, plen, route{
 					peer: pinfo.reference,
 					nlri: p.rib.nlris.Put(nlri{
@@ -411,6 +440,9 @@ func (p *Provider) handleRouteMonitoring(pkey peerKey, body *bmp.BMPRouteMonitor
 					rd:     rd,
 					path:   ipprefix.PathIdentifier(),
 				}); ok {
+					if p.config.RouteHistory {
+						updates = append(updates, history.withPrefix(pf, plen, rd, nh, true))
+					}
 					removed += p.rib.removePrefix(pf, plen, route{
 						peer: pinfo.reference,
 						nlri: nlriRef,
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bmp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+
+	"github.com/osrg/gobgp/v3/pkg/packet/bgp"
+)
+
+// routeUpdate is a route announcement or withdrawal exported to Kafka when
+// route history is enabled. Field names match the columns of the routes table
+// in ClickHouse. Addresses are always IPv6 addresses and prefix lengths are
+// expressed for an IPv6 address.
+type routeUpdate struct {
+	TimeReceived     int64
+	ExporterAddress  string
+	PeerAddress      string
+	PeerAS           uint32
+	RD               string
+	Prefix           string
+	PrefixLen        uint8
+	Withdrawn        bool
+	NextHop          string
+	ASPath           []uint32
+	Communities      []uint32
+	LargeCommunities []string
+}
+
+// newRouteUpdate builds the template for route updates for the provided peer.
+func (p *Provider) newRouteUpdate(pkey peerKey) routeUpdate {
+	return routeUpdate{
+		TimeReceived:    p.d.Clock.Now().Unix(),
+		ExporterAddress: to16(pkey.exporter.Addr()).String(),
+		PeerAddress:     to16(pkey.ip).String(),
+		PeerAS:          pkey.asn,
+		ASPath:          []uint32{},
+		Communities:     []uint32{},
+	}
+}
+
+// withPrefix returns a copy of the route update for the provided prefix.
+func (u routeUpdate) withPrefix(pf netip.Addr, plen int, rd RD, nh netip.Addr, withdrawn bool) routeUpdate {
+	u.Prefix = to16(pf).String()
+	u.PrefixLen = uint8(plen)
+	u.RD = rd.String()
+	u.Withdrawn = withdrawn
+	if withdrawn || !nh.IsValid() {
+		u.NextHop = "::"
+		u.ASPath = []uint32{}
+		u.Communities = []uint32{}
+		u.LargeCommunities = nil
+	} else {
+		u.NextHop = to16(nh).String()
+	}
+	if u.LargeCommunities == nil {
+		u.LargeCommunities = []string{}
+	}
+	return u
+}
+
+// exportRouteUpdates sends the provided route updates to Kafka. It should be
+// called without holding the lock as sending may block.
+func (p *Provider) exportRouteUpdates(exporter string, updates []routeUpdate) {
+	for _, update := range updates {
+		payload, err := json.Marshal(update)
+		if err != nil {
+			p.r.Err(err).Msg("cannot encode route update")
+			continue
+		}
+		p.d.Kafka.SendRouteUpdate(exporter,
+			[]byte(fmt.Sprintf("%s/%d", update.Prefix, update.PrefixLen)),
+			payload)
+	}
+}
+
+// largeCommunitiesToStrings turns large communities into their textual
+// representation.
+func largeCommunitiesToStrings(communities []*bgp.LargeCommunity) []string {
+	result := make([]string, len(communities))
+	for idx, c := range communities {
+		result[idx] = fmt.Sprintf("%d:%d:%d", c.ASN, c.LocalData1, c.LocalData2)
+	}
+	return result
+}
+
+// to16 converts an address to its IPv6 form.
+func to16(addr netip.Addr) netip.Addr {
+	if !addr.IsValid() {
+		return netip.IPv6Unspecified()
+	}
+	return netip.AddrFrom16(addr.As16())
+}
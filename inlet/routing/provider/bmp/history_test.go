@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package bmp
+
+import (
+	"encoding/json"
+	"net"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/benbjohnson/clock"
+
+	"akvorado/common/daemon"
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+	"akvorado/inlet/kafka"
+)
+
+func TestRouteHistoryWithoutKafka(t *testing.T) {
+	r := reporter.NewMock(t)
+	config := DefaultConfiguration().(Configuration)
+	config.RouteHistory = true
+	if _, err := config.New(r, Dependencies{Daemon: daemon.NewMock(t)}); err == nil {
+		t.Fatal("New() did not error")
+	}
+}
+
+func TestRouteHistory(t *testing.T) {
+	r := reporter.NewMock(t)
+	kafkaComponent, mockProducer := kafka.NewMock(t, r, kafka.DefaultConfiguration())
+
+	// Collect route updates
+	const expectedUpdates = 33
+	var mu sync.Mutex
+	got := []routeUpdate{}
+	done := make(chan struct{})
+	for range expectedUpdates {
+		mockProducer.ExpectInputWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+			if msg.Topic != "flows-routes" {
+				t.Errorf("Topic: got %q, expected %q", msg.Topic, "flows-routes")
+			}
+			payload, _ := msg.Value.Encode()
+			var update routeUpdate
+			if err := json.Unmarshal(payload, &update); err != nil {
+				t.Errorf("json.Unmarshal() error:\n%+v", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, update)
+			if len(got) == expectedUpdates {
+				close(done)
+			}
+			return nil
+		})
+	}
+
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, time.March, 10, 10, 0, 0, 0, time.UTC))
+	config := DefaultConfiguration().(Configuration)
+	config.Listen = "127.0.0.1:0"
+	config.RouteHistory = true
+	pi, err := config.New(r, Dependencies{
+		Daemon: daemon.NewMock(t),
+		Clock:  mockClock,
+		Kafka:  kafkaComponent,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	p := pi.(*Provider)
+	helpers.StartStop(t, p)
+	conn, err := net.Dial("tcp", p.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("Dial() error:\n%+v", err)
+	}
+	defer conn.Close()
+	for _, pcap := range []string{
+		"bmp-init.pcap", "bmp-peers-up.pcap", "bmp-eor.pcap",
+		"bmp-reach.pcap", "bmp-unreach.pcap",
+	} {
+		if _, err := conn.Write(helpers.ReadPcapL4(t, path.Join("testdata", pcap))); err != nil {
+			t.Fatalf("Write() error:\n%+v", err)
+		}
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		mu.Lock()
+		defer mu.Unlock()
+		t.Fatalf("received %d route updates, expected %d", len(got), expectedUpdates)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	announced := 0
+	withdrawn := 0
+	for _, update := range got {
+		if update.Withdrawn {
+			withdrawn++
+		} else {
+			announced++
+		}
+	}
+	if announced != 17 || withdrawn != 16 {
+		t.Errorf("got %d announcements and %d withdrawals, expected 17 and 16", announced, withdrawn)
+	}
+	expected := routeUpdate{
+		TimeReceived:     1741600800,
+		ExporterAddress:  "::ffff:127.0.0.1",
+		PeerAddress:      "::ffff:192.0.2.1",
+		PeerAS:           65011,
+		RD:               "0:0",
+		Prefix:           "::ffff:192.0.2.0",
+		PrefixLen:        96 + 31,
+		NextHop:          "::ffff:192.0.2.1",
+		ASPath:           []uint32{65011},
+		Communities:      []uint32{},
+		LargeCommunities: []string{},
+	}
+	if diff := helpers.Diff(got[0], expected); diff != "" {
+		t.Errorf("first route update (-got, +want):\n%s", diff)
+	}
+}
@@ -69,12 +69,21 @@ func (p *Provider) Lookup(_ context.Context, ip netip.Addr, nh netip.Addr, _ net
 	if ip.Is4() || ip.Is4In6() {
 		plen = plen - 96
 	}
-	return LookupResult{
+	result := LookupResult{
 		ASN:              attributes.asn,
 		ASPath:           attributes.asPath,
 		Communities:      attributes.communities,
 		LargeCommunities: attributes.largeCommunities,
 		NetMask:          plen,
 		NextHop:          nh,
-	}, nil
+	}
+	// The peer tells where the route was learned: the exporter is the exit
+	// router and the peer is the exit peer (not known for Loc-RIB).
+	if pkey, ok := p.peerKeys[route.peer]; ok {
+		result.ExitRouter = pkey.exporter.Addr()
+		if pkey.ip.IsValid() && !pkey.ip.IsUnspecified() {
+			result.ExitPeer = pkey.ip
+		}
+	}
+	return result, nil
 }
@@ -38,6 +38,7 @@ func (p *Provider) peerRemovalWorker() error {
 					if done {
 						// Run was complete, remove the peer (we need the lock)
 						delete(p.peers, pkey)
+						delete(p.peerKeys, pinfo.reference)
 					}
 					return removed, done, false
 				}()
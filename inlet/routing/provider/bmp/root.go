@@ -6,6 +6,7 @@
 package bmp
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -34,6 +35,7 @@ type Provider struct {
 	// RIB management with peers
 	rib               *rib
 	peers             map[peerKey]*peerInfo
+	peerKeys          map[uint32]peerKey
 	peerRemovalChan   chan peerKey
 	lastPeerReference uint32
 	staleTimer        *clock.Timer
@@ -48,6 +50,9 @@ func (configuration Configuration) New(r *reporter.Reporter, dependencies Depend
 	if dependencies.Clock == nil {
 		dependencies.Clock = clock.New()
 	}
+	if configuration.RouteHistory && dependencies.Kafka == nil {
+		return nil, errors.New("route history requires Kafka")
+	}
 	p := Provider{
 		r:      r,
 		d:      &dependencies,
@@ -55,6 +60,7 @@ func (configuration Configuration) New(r *reporter.Reporter, dependencies Depend
 
 		rib:             newRIB(),
 		peers:           make(map[peerKey]*peerInfo),
+		peerKeys:        make(map[uint32]peerKey),
 		peerRemovalChan: make(chan peerKey, configuration.RIBPeerRemovalMaxQueue),
 	}
 	if len(p.config.RDs) > 0 {
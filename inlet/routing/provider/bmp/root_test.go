@@ -1155,10 +1155,12 @@ func TestBMP(t *testing.T) {
 			netip.MustParseAddr("::ffff:192.168.145.10"),
 			netip.MustParseAddr("::ffff:203.0.113.14"), netip.Addr{})
 		expected := provider.LookupResult{
-			ASN:     1234,
-			ASPath:  []uint32{1234},
-			NetMask: 22,
-			NextHop: netip.MustParseAddr("::ffff:203.0.113.15"),
+			ASN:        1234,
+			ASPath:     []uint32{1234},
+			NetMask:    22,
+			NextHop:    netip.MustParseAddr("::ffff:203.0.113.15"),
+			ExitRouter: netip.MustParseAddr("::ffff:127.0.0.1"),
+			ExitPeer:   netip.MustParseAddr("::ffff:203.0.113.4"),
 		}
 		if diff := helpers.Diff(lookup, expected); diff != "" {
 			t.Errorf("Lookup() (-got, +want):\n%s", diff)
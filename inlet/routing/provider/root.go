@@ -11,6 +11,7 @@ import (
 
 	"akvorado/common/daemon"
 	"akvorado/common/reporter"
+	"akvorado/inlet/kafka"
 
 	"github.com/benbjohnson/clock"
 	"github.com/osrg/gobgp/v3/pkg/packet/bgp"
@@ -24,12 +25,19 @@ type LookupResult struct {
 	LargeCommunities []bgp.LargeCommunity
 	NetMask          uint8
 	NextHop          netip.Addr
+	// ExitRouter is the address of the router that learned the route (when
+	// known, for example the BMP exporter).
+	ExitRouter netip.Addr
+	// ExitPeer is the address of the BGP peer the route was learned from
+	// (when known).
+	ExitPeer netip.Addr
 }
 
 // Dependencies are the dependencies for a provider.
 type Dependencies struct {
 	Daemon daemon.Component
 	Clock  clock.Clock
+	Kafka  *kafka.Component // optional, used to export route updates
 }
 
 // Provider is the interface a provider should implement.
@@ -12,6 +12,7 @@ import (
 	"akvorado/common/clickhousedb"
 	"akvorado/common/helpers"
 	"akvorado/common/kafka"
+	"akvorado/common/schema"
 
 	"github.com/mitchellh/mapstructure"
 )
@@ -29,6 +30,12 @@ type Configuration struct {
 	// MaxPartitions define the number of partitions to have for a
 	// consolidated flow tables when full.
 	MaxPartitions int `validate:"isdefault|min=1"`
+	// SampleBy tells if the main flows table should be created with a
+	// sampling key to allow approximate queries.
+	SampleBy bool
+	// RouteHistoryTTL is how long to keep the history of BGP routes received
+	// through BMP. A value of 0 means the history is not stored.
+	RouteHistoryTTL time.Duration `validate:"isdefault|min=1h"`
 	// SystemLogTTL is the TTL to set for system log tables.
 	SystemLogTTL time.Duration `validate:"isdefault|min=1m"`
 	// PrometheusEndpoint defines the endpoint ClickHouse can use to expose
@@ -48,6 +55,13 @@ type Configuration struct {
 	// NetworkSourceTimeout tells how long to wait for network
 	// sources to be ready. 503 is returned when not.
 	NetworkSourcesTimeout time.Duration `validate:"min=0"`
+	// MACVendorSources defines a set of remote sources mapping OUIs to
+	// vendors. It is used to instantiate the SrcMACVendor and DstMACVendor
+	// columns. The results override the builtin IEEE registry.
+	MACVendorSources map[string]remotedatasourcefetcher.RemoteDataSource `validate:"dive"`
+	// MACVendorsFile is the path to a local copy of the IEEE registry (in
+	// CSV format). When set, it replaces the builtin one.
+	MACVendorsFile string
 	// OrchestratorURL allows one to override URL to reach
 	// orchestrator from ClickHouse
 	OrchestratorURL string `validate:"isdefault|url"`
@@ -72,6 +86,12 @@ type ResolutionConfiguration struct {
 	// TTL is how long to keep data for this resolution. A
 	// value of 0 means to never expire.
 	TTL time.Duration `validate:"isdefault|min=1h"`
+	// IncludeColumns is the list of columns otherwise only present in the
+	// main table to also keep in this consolidated table.
+	IncludeColumns []schema.ColumnKey
+	// ExcludeColumns is the list of columns to not keep in this
+	// consolidated table.
+	ExcludeColumns []schema.ColumnKey
 }
 
 // KafkaConfiguration describes Kafka-specific configuration
@@ -96,10 +116,10 @@ func DefaultConfiguration() Configuration {
 			GroupName: "clickhouse",
 		},
 		Resolutions: []ResolutionConfiguration{
-			{0, 15 * 24 * time.Hour},                   // 15 days
-			{time.Minute, 7 * 24 * time.Hour},          // 7 days
-			{5 * time.Minute, 3 * 30 * 24 * time.Hour}, // 90 days
-			{time.Hour, 12 * 30 * 24 * time.Hour},      // 1 year
+			{Interval: 0, TTL: 15 * 24 * time.Hour},                   // 15 days
+			{Interval: time.Minute, TTL: 7 * 24 * time.Hour},          // 7 days
+			{Interval: 5 * time.Minute, TTL: 3 * 30 * 24 * time.Hour}, // 90 days
+			{Interval: time.Hour, TTL: 12 * 30 * 24 * time.Hour},      // 1 year
 		},
 		MaxPartitions:         50,
 		NetworkSourcesTimeout: 10 * time.Second,
@@ -23,6 +23,7 @@ var (
 	//go:embed data/asns.csv
 	//go:embed data/tcp.csv
 	//go:embed data/udp.csv
+	//go:embed data/oui.csv
 	data           embed.FS
 	initShTemplate = template.Must(template.New("initsh").Parse(`#!/bin/sh
 
@@ -237,6 +238,10 @@ func (c *Component) registerHTTPHandlers() error {
 			}))
 	}
 
+	// macvendors.csv
+	c.d.HTTP.AddHandler("/api/v0/orchestrator/clickhouse/macvendors.csv",
+		http.HandlerFunc(c.macVendorsHandlerFunc))
+
 	// Static CSV files
 	entries, err := data.ReadDir("data")
 	if err != nil {
@@ -249,6 +254,9 @@ func (c *Component) registerHTTPHandlers() error {
 		if entry.Name() == "asns.csv" && len(c.config.ASNs) != 0 {
 			continue
 		}
+		if entry.Name() == "oui.csv" {
+			continue
+		}
 		url := fmt.Sprintf("/api/v0/orchestrator/clickhouse/%s", entry.Name())
 		path := fmt.Sprintf("data/%s", entry.Name())
 		c.addHandlerEmbedded(url, path)
@@ -67,6 +67,12 @@ func TestHTTPEndpoints(t *testing.T) {
 				`"asn","name"`,
 				`1,"Level 3 Communications"`,
 			},
+		}, {
+			URL:         "/api/v0/orchestrator/clickhouse/macvendors.csv",
+			ContentType: "text/csv; charset=utf-8",
+			FirstLines: []string{
+				`oui,vendor`,
+			},
 		}, {
 			URL:         "/api/v0/orchestrator/clickhouse/networks.csv",
 			ContentType: "text/csv; charset=utf-8",
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"akvorado/common/remotedatasourcefetcher"
+	"akvorado/common/schema"
+)
+
+// OUI is an organizationally unique identifier (the first 3 bytes of a MAC
+// address).
+type OUI uint32
+
+var errInvalidOUI = errors.New("invalid OUI")
+
+// UnmarshalText parses an OUI. Hexadecimal digits may be separated by colons,
+// dashes, or dots (00:00:0C, 00-00-0C, 00000C).
+func (oui *OUI) UnmarshalText(input []byte) error {
+	text := strings.NewReplacer(":", "", "-", "", ".", "").Replace(string(input))
+	if len(text) != 6 {
+		return errInvalidOUI
+	}
+	value, err := strconv.ParseUint(text, 16, 32)
+	if err != nil {
+		return errInvalidOUI
+	}
+	*oui = OUI(value)
+	return nil
+}
+
+// String turns an OUI into a string.
+func (oui OUI) String() string {
+	return fmt.Sprintf("%02X:%02X:%02X", byte(oui>>16), byte(oui>>8), byte(oui))
+}
+
+// MarshalText turns an OUI into a string.
+func (oui OUI) MarshalText() ([]byte, error) {
+	return []byte(oui.String()), nil
+}
+
+type externalMACVendor struct {
+	OUI    OUI
+	Vendor string
+}
+
+// UpdateMACVendorSource updates a remote MAC vendor source. It returns the
+// number of OUIs retrieved.
+func (c *Component) UpdateMACVendorSource(ctx context.Context, name string, source remotedatasourcefetcher.RemoteDataSource) (int, error) {
+	results, err := c.macVendorSourcesFetcher.Fetch(ctx, name, source)
+	if err != nil {
+		return 0, err
+	}
+	c.macVendorSourcesLock.Lock()
+	c.macVendorSources[name] = results
+	c.macVendorSourcesLock.Unlock()
+
+	select {
+	case <-c.migrationsDone:
+		if err := c.ReloadDictionary(ctx, schema.DictionaryMACVendors); err != nil {
+			c.r.Err(err).Msg("failed to refresh MAC vendors dictionary")
+		}
+	default:
+	}
+	return len(results), nil
+}
+
+// macVendorsHandlerFunc serves the MAC vendors dictionary. Entries from remote
+// sources override the IEEE registry (builtin or from a local file).
+func (c *Component) macVendorsHandlerFunc(w http.ResponseWriter, _ *http.Request) {
+	path := "data/oui.csv"
+	var f io.ReadCloser
+	var err error
+	if c.config.MACVendorsFile != "" {
+		path = c.config.MACVendorsFile
+		f, err = os.Open(path)
+	} else {
+		f, err = data.Open(path)
+	}
+	if err != nil {
+		c.r.Err(err).Msgf("unable to open %s", path)
+		http.Error(w, "Unable to open OUI file.", http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	rd := csv.NewReader(f)
+	rd.ReuseRecord = true
+	rd.FieldsPerRecord = 4
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	wr := csv.NewWriter(w)
+	wr.Write([]string{"oui", "vendor"})
+
+	// Vendors from remote sources
+	seen := map[OUI]struct{}{}
+	c.macVendorSourcesLock.RLock()
+	for _, vendors := range c.macVendorSources {
+		for _, vendor := range vendors {
+			if _, ok := seen[vendor.OUI]; ok {
+				continue
+			}
+			seen[vendor.OUI] = struct{}{}
+			wr.Write([]string{strconv.FormatUint(uint64(vendor.OUI), 10), vendor.Vendor})
+		}
+	}
+	c.macVendorSourcesLock.RUnlock()
+
+	// Builtin vendors
+	for count := 0; ; count++ {
+		record, err := rd.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.r.Err(err).Msgf("unable to parse %s (line %d)", path, count)
+			continue
+		}
+		if count == 0 {
+			continue
+		}
+		var oui OUI
+		if err := oui.UnmarshalText([]byte(record[1])); err != nil {
+			c.r.Err(err).Msgf("invalid OUI (line %d)", count)
+			continue
+		}
+		if _, ok := seen[oui]; !ok {
+			wr.Write([]string{strconv.FormatUint(uint64(oui), 10), record[2]})
+		}
+	}
+	wr.Flush()
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"akvorado/common/helpers"
+	"akvorado/common/reporter"
+)
+
+func TestOUIUnmarshalText(t *testing.T) {
+	cases := []struct {
+		Input    string
+		Expected OUI
+		Error    bool
+	}{
+		{"00000C", 0x00000C, false},
+		{"00:00:0c", 0x00000C, false},
+		{"AC-1F-6B", 0xAC1F6B, false},
+		{"ac1f.6b", 0xAC1F6B, false},
+		{"AC1F6B00", 0, true},
+		{"ZZ1F6B", 0, true},
+		{"", 0, true},
+	}
+	for _, tc := range cases {
+		var got OUI
+		err := got.UnmarshalText([]byte(tc.Input))
+		if err != nil && !tc.Error {
+			t.Errorf("UnmarshalText(%q) error:\n%+v", tc.Input, err)
+		} else if err == nil && tc.Error {
+			t.Errorf("UnmarshalText(%q) did not error", tc.Input)
+		} else if got != tc.Expected {
+			t.Errorf("UnmarshalText(%q) == %s, expected %s", tc.Input, got, tc.Expected)
+		}
+	}
+}
+
+func TestMACVendorsCSV(t *testing.T) {
+	c := Component{
+		r: reporter.NewMock(t),
+		macVendorSources: map[string][]externalMACVendor{
+			"local": {
+				{OUI: 0x00000C, Vendor: "Cisco"},
+				{OUI: 0x020000, Vendor: "Lab equipment"},
+			},
+		},
+	}
+	w := httptest.NewRecorder()
+	c.macVendorsHandlerFunc(w, httptest.NewRequest("GET", "/api/v0/orchestrator/clickhouse/macvendors.csv", nil))
+	body := w.Body.String()
+	got := strings.Split(body, "\n")[:3]
+	expected := []string{
+		"oui,vendor",
+		"12,Cisco",
+		"131072,Lab equipment",
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("macvendors.csv (-got, +want):\n%s", diff)
+	}
+	// Builtin entries are overridden by remote sources
+	if count := strings.Count(body, "\n12,"); count != 1 {
+		t.Fatalf("macvendors.csv has %d entries for 00:00:0C, expected 1", count)
+	}
+}
+
+func TestMACVendorsCSVFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oui.csv")
+	if err := os.WriteFile(path, []byte(`Registry,Assignment,Organization Name,Organization Address
+MA-L,00000C,"Cisco Systems, Inc",
+MA-L,AC1F6B,"Super Micro Computer, Inc.",
+`), 0o644); err != nil {
+		t.Fatalf("WriteFile() error:\n%+v", err)
+	}
+	c := Component{
+		r:      reporter.NewMock(t),
+		config: Configuration{MACVendorsFile: path},
+	}
+	w := httptest.NewRecorder()
+	c.macVendorsHandlerFunc(w, httptest.NewRequest("GET", "/api/v0/orchestrator/clickhouse/macvendors.csv", nil))
+	got := strings.Split(w.Body.String(), "\n")
+	expected := []string{
+		"oui,vendor",
+		`12,"Cisco Systems, Inc"`,
+		`11280235,"Super Micro Computer, Inc."`,
+		"",
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("macvendors.csv (-got, +want):\n%s", diff)
+	}
+}
@@ -98,6 +98,9 @@ func (c *Component) migrateDatabase() error {
 		}, func(ctx context.Context) error {
 			return c.createDictionary(ctx, schema.DictionaryUDP, "hashed",
 				"`port` UInt16 INJECTIVE, `name` String", "port")
+		}, func(ctx context.Context) error {
+			return c.createDictionary(ctx, schema.DictionaryMACVendors, "hashed",
+				"`oui` UInt32, `vendor` String", "oui")
 		})
 	if err != nil {
 		return err
@@ -156,20 +159,51 @@ func (c *Component) migrateDatabase() error {
 		}
 	}
 
-	// Remaining tables
-	err = c.wrapMigrations(ctx,
+	// Remaining tables. Tables consuming from Kafka are created for each
+	// Kafka cluster.
+	clusters := len(c.config.Kafka.Clusters())
+	migrations := []func(context.Context) error{
 		c.createExportersTable,
 		c.createExportersConsumerView,
-		c.createRawFlowsTable,
-		c.createRawFlowsConsumerView,
+	}
+	for cluster := range clusters {
+		migrations = append(migrations,
+			func(ctx context.Context) error {
+				return c.createRawFlowsTable(ctx, cluster)
+			}, func(ctx context.Context) error {
+				return c.createRawFlowsConsumerView(ctx, cluster)
+			})
+	}
+	migrations = append(migrations,
 		c.createRawFlowsErrors,
 		func(ctx context.Context) error {
 			return c.createDistributedTable(ctx, "flows_raw_errors")
-		},
-		c.createRawFlowsErrorsConsumerView,
+		})
+	for cluster := range clusters {
+		migrations = append(migrations,
+			func(ctx context.Context) error {
+				return c.createRawFlowsErrorsConsumerView(ctx, cluster)
+			})
+	}
+	migrations = append(migrations,
 		c.deleteOldRawFlowsErrorsView,
-	)
-	if err != nil {
+		c.createRoutesTable,
+		func(ctx context.Context) error {
+			if c.config.RouteHistoryTTL == 0 {
+				return errSkipStep
+			}
+			return c.createDistributedTable(ctx, "routes")
+		})
+	for cluster := range clusters {
+		migrations = append(migrations,
+			func(ctx context.Context) error {
+				return c.createRawRoutesTable(ctx, cluster)
+			}, func(ctx context.Context) error {
+				return c.createRawRoutesConsumerView(ctx, cluster)
+			})
+	}
+	migrations = append(migrations, c.deleteOldStandbyTables)
+	if err := c.wrapMigrations(ctx, migrations...); err != nil {
 		return err
 	}
 
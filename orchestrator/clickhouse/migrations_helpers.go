@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 	"time"
@@ -22,6 +23,10 @@ import (
 
 var errSkipStep = errors.New("migration: skip this step")
 
+// flowsSamplingKey is the sampling key used for the main flows table when
+// approximate queries are enabled.
+const flowsSamplingKey = "cityHash64(SrcAddr, DstAddr)"
+
 // wrapMigrations can be used to wrap migration functions. It will keep the
 // metrics up-to-date as long as the migration function returns `errSkipStep`
 // when a step is skipped.
@@ -279,13 +284,22 @@ CREATE MATERIALIZED VIEW exporters_consumer TO %s AS %s
 	return nil
 }
 
-// createRawFlowsTable creates the raw flow table
-func (c *Component) createRawFlowsTable(ctx context.Context) error {
+// kafkaClusterSuffix returns the suffix to use for tables consuming from the
+// provided Kafka cluster. The primary cluster does not use a suffix.
+func kafkaClusterSuffix(cluster int) string {
+	if cluster == 0 {
+		return ""
+	}
+	return fmt.Sprintf("_standby%d", cluster)
+}
+
+// createRawFlowsTable creates the raw flow table for the provided Kafka cluster
+func (c *Component) createRawFlowsTable(ctx context.Context, cluster int) error {
 	hash := c.d.Schema.ProtobufMessageHash()
-	tableName := fmt.Sprintf("flows_%s_raw", hash)
+	tableName := fmt.Sprintf("flows_%s_raw%s", hash, kafkaClusterSuffix(cluster))
 	kafkaSettings := []string{
 		fmt.Sprintf(`kafka_broker_list = %s`,
-			quoteString(strings.Join(c.config.Kafka.Brokers, ","))),
+			quoteString(strings.Join(c.config.Kafka.Clusters()[cluster].Brokers, ","))),
 		fmt.Sprintf(`kafka_topic_list = %s`,
 			quoteString(fmt.Sprintf("%s-%s", c.config.Kafka.Topic, hash))),
 		fmt.Sprintf(`kafka_group_name = %s`, quoteString(c.config.Kafka.GroupName)),
@@ -320,12 +334,12 @@ func (c *Component) createRawFlowsTable(ctx context.Context) error {
 	if ok, err := c.tableAlreadyExists(ctx, tableName, "create_table_query", createQuery); err != nil {
 		return err
 	} else if ok {
-		c.r.Info().Msg("raw flows table already exists, skip migration")
+		c.r.Info().Msgf("raw flows table %s already exists, skip migration", tableName)
 		return errSkipStep
 	}
 
 	// Drop table if it exists as well as all the dependents and recreate the raw table
-	c.r.Info().Msg("create raw flows table")
+	c.r.Info().Msgf("create raw flows table %s", tableName)
 	for _, table := range []string{
 		fmt.Sprintf("%s_consumer", tableName),
 		fmt.Sprintf("%s_errors", tableName),
@@ -347,8 +361,8 @@ func (c *Component) createRawFlowsTable(ctx context.Context) error {
 
 var dictionaryNetworksLookupRegex = regexp.MustCompile(`\bc_(Src|Dst)Networks\[([[:lower:]]+)\]\B`)
 
-func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
-	tableName := fmt.Sprintf("flows_%s_raw", c.d.Schema.ProtobufMessageHash())
+func (c *Component) createRawFlowsConsumerView(ctx context.Context, cluster int) error {
+	tableName := fmt.Sprintf("flows_%s_raw%s", c.d.Schema.ProtobufMessageHash(), kafkaClusterSuffix(cluster))
 	viewName := fmt.Sprintf("%s_consumer", tableName)
 
 	// Build SELECT query
@@ -427,12 +441,12 @@ func (c *Component) createRawFlowsConsumerView(ctx context.Context) error {
 	if ok, err := c.tableAlreadyExists(ctx, viewName, "as_select", selectQuery); err != nil {
 		return err
 	} else if ok {
-		c.r.Info().Msg("raw flows consumer view already exists, skip migration")
+		c.r.Info().Msgf("raw flows consumer view %s already exists, skip migration", viewName)
 		return errSkipStep
 	}
 
 	// Drop and create
-	c.r.Info().Msg("create raw flows consumer view")
+	c.r.Info().Msgf("create raw flows consumer view %s", viewName)
 	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
@@ -480,9 +494,9 @@ TTL timestamp + toIntervalDay(1)
 	return nil
 }
 
-func (c *Component) createRawFlowsErrorsConsumerView(ctx context.Context) error {
-	source := fmt.Sprintf("flows_%s_raw", c.d.Schema.ProtobufMessageHash())
-	viewName := "flows_raw_errors_consumer"
+func (c *Component) createRawFlowsErrorsConsumerView(ctx context.Context, cluster int) error {
+	source := fmt.Sprintf("flows_%s_raw%s", c.d.Schema.ProtobufMessageHash(), kafkaClusterSuffix(cluster))
+	viewName := fmt.Sprintf("flows_raw_errors%s_consumer", kafkaClusterSuffix(cluster))
 
 	// Build SELECT query
 	selectQuery, err := stemplate(`
@@ -506,12 +520,12 @@ WHERE length(_error) > 0`, gin.H{
 	if ok, err := c.tableAlreadyExists(ctx, viewName, "as_select", selectQuery); err != nil {
 		return err
 	} else if ok {
-		c.r.Info().Msg("raw flows errors view already exists, skip migration")
+		c.r.Info().Msgf("raw flows errors view %s already exists, skip migration", viewName)
 		return errSkipStep
 	}
 
 	// Drop and create
-	c.r.Info().Msg("create raw flows errors view")
+	c.r.Info().Msgf("create raw flows errors view %s", viewName)
 	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
 		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
 	}
@@ -566,17 +580,26 @@ func (c *Component) createOrUpdateFlowsTable(ctx context.Context, resolution Res
 		var createQuery string
 		var err error
 		if resolution.Interval == 0 {
+			sortingKey := "toStartOfFiveMinutes(TimeReceived), ExporterAddress, InIfName, OutIfName"
+			samplingKey := ""
+			if c.config.SampleBy {
+				sortingKey = fmt.Sprintf("%s, %s", sortingKey, flowsSamplingKey)
+				samplingKey = flowsSamplingKey
+			}
 			createQuery, err = stemplate(`
 CREATE TABLE {{ .Table }} ({{ .Schema }})
 ENGINE = {{ .Engine }}
 PARTITION BY toYYYYMMDDhhmmss(toStartOfInterval(TimeReceived, INTERVAL {{ .PartitionInterval }} second))
-ORDER BY (toStartOfFiveMinutes(TimeReceived), ExporterAddress, InIfName, OutIfName)
+ORDER BY ({{ .SortingKey }}){{ if .SamplingKey }}
+SAMPLE BY {{ .SamplingKey }}{{ end }}
 TTL TimeReceived + toIntervalSecond({{ .TTL }})
 SETTINGS {{ .Settings }}
 `, gin.H{
 				"Table":             tableName,
 				"Schema":            c.d.Schema.ClickHouseCreateTable(),
 				"PartitionInterval": partitionInterval,
+				"SortingKey":        sortingKey,
+				"SamplingKey":       samplingKey,
 				"TTL":               ttl,
 				"Engine":            c.mergeTreeEngine(tableName, ""),
 				"Settings":          settings,
@@ -592,10 +615,10 @@ TTL TimeReceived + toIntervalSecond({{ .TTL }})
 SETTINGS {{ .Settings }}
 `, gin.H{
 				"Table":             tableName,
-				"Schema":            c.d.Schema.ClickHouseCreateTable(schema.ClickHouseSkipMainOnlyColumns),
+				"Schema":            c.resolutionCreateTable(resolution),
 				"PartitionInterval": partitionInterval,
 				"PrimaryKey":        strings.Join(c.d.Schema.ClickHousePrimaryKeys(), ", "),
-				"SortingKey":        strings.Join(c.d.Schema.ClickHouseSortingKeys(), ", "),
+				"SortingKey":        strings.Join(c.resolutionSortingKeys(resolution), ", "),
 				"TTL":               ttl,
 				"Engine":            c.mergeTreeEngine(tableName, "Summing", "(Bytes, Packets)"),
 				"Settings":          settings,
@@ -635,7 +658,13 @@ ORDER BY position ASC
 	previousColumn := ""
 outer:
 	for _, wantedColumn := range c.d.Schema.Columns() {
-		if resolution.Interval > 0 && wantedColumn.ClickHouseMainOnly {
+		if !resolutionHasColumn(resolution, wantedColumn) {
+			for _, existingColumn := range existingColumns {
+				if wantedColumn.Name == existingColumn.Name {
+					c.r.Warn().Msgf("column %s is present in %s but not expected, table should be recreated to remove it",
+						wantedColumn.Name, tableName)
+				}
+			}
 			continue
 		}
 		// Check if the column already exists
@@ -708,7 +737,7 @@ outer:
 		// Also update ORDER BY
 		if resolution.Interval > 0 {
 			modifications = append(modifications,
-				fmt.Sprintf("MODIFY ORDER BY (%s)", strings.Join(c.d.Schema.ClickHouseSortingKeys(), ", ")))
+				fmt.Sprintf("MODIFY ORDER BY (%s)", strings.Join(c.resolutionSortingKeys(resolution), ", ")))
 		}
 		c.r.Info().Msgf("apply %d modifications to %s", len(modifications), tableName)
 		if resolution.Interval > 0 {
@@ -751,6 +780,28 @@ outer:
 		modified = true
 	}
 
+	// Check if we need to add a sampling key. This is only possible if the
+	// sampling key is already part of the primary key.
+	if resolution.Interval == 0 && c.config.SampleBy {
+		if ok, err := c.tableAlreadyExists(ctx, tableName, "sampling_key", flowsSamplingKey); err != nil {
+			return err
+		} else if !ok {
+			primaryKeyLike := fmt.Sprintf("CAST(primary_key LIKE '%%%s%%', 'String')", flowsSamplingKey)
+			if ok, err := c.tableAlreadyExists(ctx, tableName, primaryKeyLike, "1"); err != nil {
+				return err
+			} else if !ok {
+				c.r.Warn().Msgf("cannot add sampling key to %s, table should be recreated", tableName)
+			} else {
+				c.r.Info().Msgf("add sampling key to %s", tableName)
+				if err := c.d.ClickHouse.ExecOnCluster(ctx,
+					fmt.Sprintf("ALTER TABLE %s MODIFY SAMPLE BY %s", tableName, flowsSamplingKey)); err != nil {
+					return fmt.Errorf("cannot modify sampling key for table %s: %w", tableName, err)
+				}
+				modified = true
+			}
+		}
+	}
+
 	if modified {
 		return nil
 	}
@@ -774,10 +825,7 @@ FROM {{ .Database }}.{{ .Table }}`, gin.H{
 		"Database": c.config.Database,
 		"Table":    c.localTable("flows"),
 		"Seconds":  uint64(resolution.Interval.Seconds()),
-		"Columns": strings.Join(c.d.Schema.ClickHouseSelectColumns(
-			schema.ClickHouseSkipTimeReceived,
-			schema.ClickHouseSkipMainOnlyColumns,
-			schema.ClickHouseSkipAliasedColumns), ",\n "),
+		"Columns":  strings.Join(c.resolutionSelectColumns(resolution), ",\n "),
 	})
 	if err != nil {
 		return fmt.Errorf("cannot build select statement for consumer %s: %w", viewName, err)
@@ -874,3 +922,223 @@ ENGINE = Distributed('{{ .Cluster }}', '{{ .Database}}', '{{ .Source }}', rand()
 	}
 	return nil
 }
+
+// createRoutesTable creates the table storing the history of BGP routes. If
+// the table already exists, only its TTL is updated: the table is never
+// recreated to not lose the history.
+func (c *Component) createRoutesTable(ctx context.Context) error {
+	if c.config.RouteHistoryTTL == 0 {
+		return errSkipStep
+	}
+	ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"allow_suspicious_low_cardinality_types": 1,
+	}))
+	name := c.localTable("routes")
+	ttl := uint64(c.config.RouteHistoryTTL.Seconds())
+
+	// Create table if it does not exist
+	if ok, err := c.tableAlreadyExists(ctx, name, "name", name); err != nil {
+		return err
+	} else if !ok {
+		createQuery, err := stemplate(`CREATE TABLE {{ .Database }}.{{ .Table }}
+(`+"`TimeReceived`"+` DateTime,
+ `+"`ExporterAddress`"+` LowCardinality(IPv6),
+ `+"`PeerAddress`"+` LowCardinality(IPv6),
+ `+"`PeerAS`"+` UInt32,
+ `+"`RD`"+` LowCardinality(String),
+ `+"`Prefix`"+` IPv6,
+ `+"`PrefixLen`"+` UInt8,
+ `+"`Withdrawn`"+` Bool,
+ `+"`NextHop`"+` LowCardinality(IPv6),
+ `+"`ASPath`"+` Array(UInt32),
+ `+"`Communities`"+` Array(UInt32),
+ `+"`LargeCommunities`"+` Array(String))
+ENGINE = {{ .Engine }}
+PARTITION BY toYYYYMMDD(TimeReceived)
+ORDER BY (Prefix, PrefixLen, TimeReceived)
+TTL TimeReceived + toIntervalSecond({{ .TTL }})
+`, gin.H{
+			"Table":    name,
+			"Database": c.config.Database,
+			"Engine":   c.mergeTreeEngine(name, ""),
+			"TTL":      ttl,
+		})
+		if err != nil {
+			return fmt.Errorf("cannot build query to create routes table: %w", err)
+		}
+		c.r.Info().Msgf("create table %s", name)
+		if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
+			return fmt.Errorf("cannot create table %s: %w", name, err)
+		}
+		return nil
+	}
+
+	// Check if we need to update the TTL
+	ttlClause := fmt.Sprintf("TTL TimeReceived + toIntervalSecond(%d)", ttl)
+	ttlClauseLike := fmt.Sprintf("CAST(engine_full LIKE '%% %s %%', 'String')", ttlClause)
+	if ok, err := c.tableAlreadyExists(ctx, name, ttlClauseLike, "1"); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("table %s already exists, skip migration", name)
+		return errSkipStep
+	}
+	c.r.Info().Msgf("updating TTL of %s", name)
+	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf("ALTER TABLE %s MODIFY %s", name, ttlClause)); err != nil {
+		return fmt.Errorf("cannot modify TTL for table %s: %w", name, err)
+	}
+	return nil
+}
+
+// createRawRoutesTable creates the table consuming route updates from the
+// provided Kafka cluster.
+func (c *Component) createRawRoutesTable(ctx context.Context, cluster int) error {
+	if c.config.RouteHistoryTTL == 0 {
+		return errSkipStep
+	}
+	tableName := fmt.Sprintf("routes_raw%s", kafkaClusterSuffix(cluster))
+	kafkaSettings := []string{
+		fmt.Sprintf(`kafka_broker_list = %s`,
+			quoteString(strings.Join(c.config.Kafka.Clusters()[cluster].Brokers, ","))),
+		fmt.Sprintf(`kafka_topic_list = %s`,
+			quoteString(fmt.Sprintf("%s-routes", c.config.Kafka.Topic))),
+		fmt.Sprintf(`kafka_group_name = %s`, quoteString(fmt.Sprintf("%s-routes", c.config.Kafka.GroupName))),
+		`kafka_format = 'JSONEachRow'`,
+		`kafka_num_consumers = 1`,
+	}
+	for _, setting := range c.config.Kafka.EngineSettings {
+		kafkaSettings = append(kafkaSettings, setting)
+	}
+	kafkaEngine := fmt.Sprintf("Kafka SETTINGS %s", strings.Join(kafkaSettings, ", "))
+
+	// Build CREATE query
+	createQuery, err := stemplate(`CREATE TABLE {{ .Database }}.{{ .Table }}
+(`+"`TimeReceived`"+` DateTime,
+ `+"`ExporterAddress`"+` IPv6,
+ `+"`PeerAddress`"+` IPv6,
+ `+"`PeerAS`"+` UInt32,
+ `+"`RD`"+` String,
+ `+"`Prefix`"+` IPv6,
+ `+"`PrefixLen`"+` UInt8,
+ `+"`Withdrawn`"+` Bool,
+ `+"`NextHop`"+` IPv6,
+ `+"`ASPath`"+` Array(UInt32),
+ `+"`Communities`"+` Array(UInt32),
+ `+"`LargeCommunities`"+` Array(String))
+ENGINE = {{ .Engine }}`,
+		gin.H{
+			"Database": c.config.Database,
+			"Table":    tableName,
+			"Engine":   kafkaEngine,
+		})
+	if err != nil {
+		return fmt.Errorf("cannot build query to create raw routes table: %w", err)
+	}
+
+	// Check if the table already exists with the right schema
+	if ok, err := c.tableAlreadyExists(ctx, tableName, "create_table_query", createQuery); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("raw routes table %s already exists, skip migration", tableName)
+		return errSkipStep
+	}
+
+	// Drop table if it exists as well as the consumer and recreate the raw table
+	c.r.Info().Msgf("create raw routes table %s", tableName)
+	for _, table := range []string{
+		fmt.Sprintf("%s_consumer", tableName),
+		tableName,
+	} {
+		if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, table)); err != nil {
+			return fmt.Errorf("cannot drop %s: %w", table, err)
+		}
+	}
+	if err := c.d.ClickHouse.ExecOnCluster(ctx, createQuery); err != nil {
+		return fmt.Errorf("cannot create raw routes table: %w", err)
+	}
+	return nil
+}
+
+// createRawRoutesConsumerView creates the view moving route updates from the
+// raw table of the provided Kafka cluster to the routes table.
+func (c *Component) createRawRoutesConsumerView(ctx context.Context, cluster int) error {
+	if c.config.RouteHistoryTTL == 0 {
+		return errSkipStep
+	}
+	tableName := fmt.Sprintf("routes_raw%s", kafkaClusterSuffix(cluster))
+	viewName := fmt.Sprintf("%s_consumer", tableName)
+
+	// Build SELECT query
+	selectQuery, err := stemplate(
+		`SELECT * FROM {{ .Database }}.{{ .Table }}`,
+		gin.H{
+			"Database": c.config.Database,
+			"Table":    tableName,
+		})
+	if err != nil {
+		return fmt.Errorf("cannot build select statement for raw routes: %w", err)
+	}
+
+	// Check the existing one
+	if ok, err := c.tableAlreadyExists(ctx, viewName, "as_select", selectQuery); err != nil {
+		return err
+	} else if ok {
+		c.r.Info().Msgf("raw routes view %s already exists, skip migration", viewName)
+		return errSkipStep
+	}
+
+	// Drop and create
+	c.r.Info().Msgf("create raw routes view %s", viewName)
+	if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, viewName)); err != nil {
+		return fmt.Errorf("cannot drop table %s: %w", viewName, err)
+	}
+	if err := c.d.ClickHouse.ExecOnCluster(ctx,
+		fmt.Sprintf(`CREATE MATERIALIZED VIEW %s TO %s AS %s`,
+			viewName, c.distributedTable("routes"), selectQuery)); err != nil {
+		return fmt.Errorf("cannot create raw routes view: %w", err)
+	}
+
+	return nil
+}
+
+var standbyTableRegex = regexp.MustCompile(`^(?:flows_[[:alnum:]]+_raw|flows_raw_errors|routes_raw)_standby(\d+)(_consumer)?$`)
+
+// deleteOldStandbyTables drops the tables and views consuming from a standby
+// Kafka cluster which is not configured anymore.
+func (c *Component) deleteOldStandbyTables(ctx context.Context) error {
+	var existing []string
+	if err := c.d.ClickHouse.Select(ctx, &existing, `
+SELECT name FROM system.tables
+WHERE database = $1 AND name LIKE '%standby%'
+`, c.config.Database); err != nil {
+		return fmt.Errorf("cannot query standby tables: %w", err)
+	}
+
+	// Views are dropped before the tables they consume from.
+	clusters := len(c.config.Kafka.Clusters())
+	var views, tables []string
+	for _, name := range existing {
+		matches := standbyTableRegex.FindStringSubmatch(name)
+		if matches == nil {
+			continue
+		}
+		if cluster, err := strconv.Atoi(matches[1]); err != nil || cluster < clusters {
+			continue
+		}
+		if matches[2] != "" {
+			views = append(views, name)
+		} else {
+			tables = append(tables, name)
+		}
+	}
+	if len(views)+len(tables) == 0 {
+		c.r.Debug().Msg("no old standby tables, skip migration")
+		return errSkipStep
+	}
+	for _, name := range append(views, tables...) {
+		c.r.Info().Msgf("delete old standby table %s", name)
+		if err := c.d.ClickHouse.ExecOnCluster(ctx, fmt.Sprintf(`DROP TABLE IF EXISTS %s SYNC`, name)); err != nil {
+			return fmt.Errorf("cannot drop table %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -130,13 +130,19 @@ func isOldTable(schema *schema.Component, table string) bool {
 
 // startTestComponent starts a test component and wait for migrations to be done
 func startTestComponent(t *testing.T, r *reporter.Reporter, chComponent *clickhousedb.Component, sch *schema.Component) *Component {
+	t.Helper()
+	return startTestComponentWithConfiguration(t, r, chComponent, sch, DefaultConfiguration())
+}
+
+func startTestComponentWithConfiguration(t *testing.T, r *reporter.Reporter, chComponent *clickhousedb.Component, sch *schema.Component, configuration Configuration) *Component {
 	t.Helper()
 	if sch == nil {
 		sch = schema.NewMock(t)
 	}
-	configuration := DefaultConfiguration()
 	configuration.OrchestratorURL = "http://127.0.0.1:0"
+	standby := configuration.Kafka.Standby
 	configuration.Kafka.Configuration = kafka.DefaultConfiguration()
+	configuration.Kafka.Standby = standby
 	// This is a bit hacky, in real setup, the same configuration block is
 	// used for both clickhousedb.Component and clickhouse.Component.
 	configuration.Cluster = chComponent.ClusterName()
@@ -270,6 +276,7 @@ WHERE database=currentDatabase() AND table NOT LIKE '.%'`)
 				"flows_raw_errors_consumer",
 				"flows_raw_errors_local",
 				schema.DictionaryICMP,
+				schema.DictionaryMACVendors,
 				schema.DictionaryNetworks,
 				schema.DictionaryProtocols,
 				schema.DictionaryTCP,
@@ -538,6 +545,203 @@ AND name LIKE $3`, "flows", ch.config.Database, "%DimensionAttribute")
 	})
 }
 
+func TestRouteHistoryMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+	configuration := DefaultConfiguration()
+	configuration.RouteHistoryTTL = 24 * time.Hour
+
+	_ = t.Run("create", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name FROM system.tables
+WHERE database = $1 AND name LIKE 'routes%'
+ORDER BY name`, ch.config.Database); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		expected := []string{"routes", "routes_raw", "routes_raw_consumer"}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("Tables (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("idempotency", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		expectedMetrics := map[string]string{`applied_steps_total`: "0"}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("modify TTL", func(t *testing.T) {
+		if err := chComponent.Exec(context.Background(),
+			`INSERT INTO routes (TimeReceived, Prefix, PrefixLen) VALUES (now(), toIPv6('2001:db8::'), 48)`); err != nil {
+			t.Fatalf("Exec() error:\n%+v", err)
+		}
+		r := reporter.NewMock(t)
+		configuration := configuration
+		configuration.RouteHistoryTTL = 48 * time.Hour
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var engine string
+		row := ch.d.ClickHouse.QueryRow(context.Background(), `
+SELECT engine_full FROM system.tables
+WHERE database = $1 AND name = 'routes'`, ch.config.Database)
+		if err := row.Scan(&engine); err != nil {
+			t.Fatalf("Scan() error:\n%+v", err)
+		}
+		if !strings.Contains(engine, "TTL TimeReceived + toIntervalSecond(172800)") {
+			t.Fatalf("TTL not updated:\n%s", engine)
+		}
+		var count uint64
+		row = ch.d.ClickHouse.QueryRow(context.Background(), `SELECT count() FROM routes`)
+		if err := row.Scan(&count); err != nil {
+			t.Fatalf("Scan() error:\n%+v", err)
+		}
+		if count != 1 {
+			t.Fatalf("SELECT count() FROM routes == %d, expected 1", count)
+		}
+	})
+}
+
+func TestStandbyKafkaMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+	configuration := DefaultConfiguration()
+	configuration.RouteHistoryTTL = 24 * time.Hour
+	configuration.Kafka.Standby = []kafka.ClusterConfiguration{kafka.DefaultClusterConfiguration()}
+	configuration.Kafka.Standby[0].Brokers = []string{"127.0.0.1:9093"}
+
+	_ = t.Run("create", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name FROM system.tables
+WHERE database = $1 AND name LIKE '%standby%'
+ORDER BY name`, ch.config.Database); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		hash := ch.d.Schema.ProtobufMessageHash()
+		expected := []string{
+			fmt.Sprintf("flows_%s_raw_standby1", hash),
+			fmt.Sprintf("flows_%s_raw_standby1_consumer", hash),
+			"flows_raw_errors_standby1_consumer",
+			"routes_raw_standby1",
+			"routes_raw_standby1_consumer",
+		}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("Tables (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("idempotency", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		expectedMetrics := map[string]string{`applied_steps_total`: "0"}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("remove standby", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		configuration := configuration
+		configuration.Kafka.Standby = nil
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name FROM system.tables
+WHERE database = $1 AND name LIKE '%standby%'
+ORDER BY name`, ch.config.Database); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		if len(got) > 0 {
+			t.Fatalf("Standby tables not dropped:\n%v", got)
+		}
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		expectedMetrics := map[string]string{`applied_steps_total`: "1"}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+}
+
+func TestResolutionAliasColumnsMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+	configuration := DefaultConfiguration()
+	configuration.Resolutions = []ResolutionConfiguration{
+		{Interval: 0, TTL: 360 * time.Hour},
+		{
+			Interval:       time.Minute,
+			TTL:            168 * time.Hour,
+			IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort, schema.ColumnSrcNetPrefix},
+		},
+	}
+
+	_ = t.Run("create", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT name FROM system.columns
+WHERE database = $1 AND table = 'flows_1m0s'
+AND name IN ('DstPort', 'SrcAddr', 'SrcNetMask', 'SrcNetPrefix', 'DstNetPrefix')
+ORDER BY name`, ch.config.Database); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		expected := []string{"DstPort", "SrcAddr", "SrcNetMask", "SrcNetPrefix"}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("Columns (-got, +want):\n%s", diff)
+		}
+		// The alias should be usable.
+		var prefixes []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &prefixes,
+			`SELECT SrcNetPrefix FROM flows_1m0s LIMIT 1`); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+	}) && t.Run("idempotency", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		expectedMetrics := map[string]string{`applied_steps_total`: "0"}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+}
+
+func TestSampleByMigration(t *testing.T) {
+	r := reporter.NewMock(t)
+	chComponent := clickhousedb.SetupClickHouse(t, r, false)
+	dropAllTables(t, chComponent)
+	configuration := DefaultConfiguration()
+	configuration.SampleBy = true
+
+	_ = t.Run("create", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		ch := startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		var got []string
+		if err := ch.d.ClickHouse.Select(context.Background(), &got, `
+SELECT sampling_key FROM system.tables
+WHERE database = $1 AND name = 'flows'`, ch.config.Database); err != nil {
+			t.Fatalf("Select() error:\n%+v", err)
+		}
+		expected := []string{flowsSamplingKey}
+		if diff := helpers.Diff(got, expected); diff != "" {
+			t.Fatalf("Sampling key (-got, +want):\n%s", diff)
+		}
+	}) && t.Run("idempotency", func(t *testing.T) {
+		r := reporter.NewMock(t)
+		startTestComponentWithConfiguration(t, r, chComponent, nil, configuration)
+		gotMetrics := r.GetMetrics("akvorado_orchestrator_clickhouse_migrations_", "applied_steps_total")
+		expectedMetrics := map[string]string{`applied_steps_total`: "0"}
+		if diff := helpers.Diff(gotMetrics, expectedMetrics); diff != "" {
+			t.Fatalf("Metrics (-got, +want):\n%s", diff)
+		}
+	})
+}
+
 func TestQuoteString(t *testing.T) {
 	cases := []struct {
 		s        string
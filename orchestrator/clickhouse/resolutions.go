@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"akvorado/common/schema"
+)
+
+// checkResolutionColumns checks the column selection for each resolution.
+// The columns needed by the aliased columns present in a consolidated table
+// are added to the included columns.
+func (c *Component) checkResolutionColumns() error {
+	primaryKeys := c.d.Schema.ClickHousePrimaryKeys()
+	for idx, resolution := range c.config.Resolutions {
+		if resolution.Interval == 0 {
+			if len(resolution.IncludeColumns) > 0 || len(resolution.ExcludeColumns) > 0 {
+				return fmt.Errorf("cannot include or exclude columns for the main table")
+			}
+			continue
+		}
+		for _, key := range resolution.ExcludeColumns {
+			if slices.Contains(primaryKeys, key.String()) {
+				return fmt.Errorf("resolution %s: column %q cannot be excluded (primary key)",
+					resolution.Interval, key)
+			}
+			if slices.Contains(resolution.IncludeColumns, key) {
+				return fmt.Errorf("resolution %s: column %q cannot be both included and excluded",
+					resolution.Interval, key)
+			}
+		}
+		resolution.IncludeColumns = slices.Clone(resolution.IncludeColumns)
+		for changed := true; changed; {
+			changed = false
+			for _, column := range c.d.Schema.Columns() {
+				if column.ClickHouseAlias == "" || !resolutionHasColumn(resolution, column) {
+					continue
+				}
+				for _, dependency := range c.aliasDependencies(column) {
+					if resolutionHasColumn(resolution, dependency) {
+						continue
+					}
+					if slices.Contains(resolution.ExcludeColumns, dependency.Key) {
+						return fmt.Errorf("resolution %s: column %q cannot be excluded (needed by %q)",
+							resolution.Interval, dependency.Key, column.Key)
+					}
+					resolution.IncludeColumns = append(resolution.IncludeColumns, dependency.Key)
+					changed = true
+				}
+			}
+		}
+		c.config.Resolutions[idx] = resolution
+	}
+	return nil
+}
+
+// identifierRegex matches an identifier in a ClickHouse expression.
+var identifierRegex = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+
+// aliasDependencies returns the columns used by the alias of the provided
+// column.
+func (c *Component) aliasDependencies(column schema.Column) []schema.Column {
+	dependencies := []schema.Column{}
+	for _, name := range identifierRegex.FindAllString(column.ClickHouseAlias, -1) {
+		dependency, ok := c.d.Schema.LookupColumnByName(name)
+		if !ok || dependency.Key == column.Key || dependency.Disabled {
+			continue
+		}
+		if !slices.ContainsFunc(dependencies, func(other schema.Column) bool {
+			return other.Key == dependency.Key
+		}) {
+			dependencies = append(dependencies, *dependency)
+		}
+	}
+	return dependencies
+}
+
+// resolutionHasColumn tells if the provided column should be present in the
+// flows table for the provided resolution.
+func resolutionHasColumn(resolution ResolutionConfiguration, column schema.Column) bool {
+	if resolution.Interval == 0 {
+		return true
+	}
+	if slices.Contains(resolution.ExcludeColumns, column.Key) {
+		return false
+	}
+	if column.ClickHouseMainOnly {
+		return slices.Contains(resolution.IncludeColumns, column.Key)
+	}
+	return true
+}
+
+// resolutionCreateTable returns the columns for the CREATE TABLE clause of
+// the flows table for the provided resolution.
+func (c *Component) resolutionCreateTable(resolution ResolutionConfiguration) string {
+	lines := []string{}
+	for _, column := range c.d.Schema.Columns() {
+		if resolutionHasColumn(resolution, column) {
+			lines = append(lines, column.ClickHouseDefinition())
+		}
+	}
+	return strings.Join(lines, ",\n")
+}
+
+// resolutionSelectColumns returns the columns to select from the main flows
+// table to populate the flows table for the provided resolution. The time
+// received and aliased columns are skipped.
+func (c *Component) resolutionSelectColumns(resolution ResolutionConfiguration) []string {
+	cols := []string{}
+	for _, column := range c.d.Schema.Columns() {
+		if column.Key == schema.ColumnTimeReceived || column.ClickHouseAlias != "" {
+			continue
+		}
+		if resolutionHasColumn(resolution, column) {
+			cols = append(cols, column.Name)
+		}
+	}
+	return cols
+}
+
+// resolutionSortingKeys returns the sorting keys for the flows table for the
+// provided resolution. As consolidated tables use the SummingMergeTree
+// engine, all columns except the ones explicitly marked otherwise are part of
+// the sorting key.
+func (c *Component) resolutionSortingKeys(resolution ResolutionConfiguration) []string {
+	cols := c.d.Schema.ClickHousePrimaryKeys()
+	for _, column := range c.d.Schema.Columns() {
+		if column.ClickHouseNotSortingKey || !resolutionHasColumn(resolution, column) {
+			continue
+		}
+		if !slices.Contains(cols, column.Name) {
+			cols = append(cols, column.Name)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2025 Free Mobile
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package clickhouse
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"akvorado/common/helpers"
+	"akvorado/common/schema"
+)
+
+func TestCheckResolutionColumns(t *testing.T) {
+	cases := []struct {
+		Description string
+		Resolutions []ResolutionConfiguration
+		Error       bool
+	}{
+		{
+			Description: "default",
+			Resolutions: DefaultConfiguration().Resolutions,
+		}, {
+			Description: "include and exclude",
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0},
+				{
+					Interval:       time.Minute,
+					IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort},
+					ExcludeColumns: []schema.ColumnKey{schema.ColumnDstCountry},
+				},
+			},
+		}, {
+			Description: "main table",
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0, IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort}},
+			},
+			Error: true,
+		}, {
+			Description: "primary key",
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0},
+				{Interval: time.Minute, ExcludeColumns: []schema.ColumnKey{schema.ColumnExporterAddress}},
+			},
+			Error: true,
+		}, {
+			Description: "both included and excluded",
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0},
+				{
+					Interval:       time.Minute,
+					IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort},
+					ExcludeColumns: []schema.ColumnKey{schema.ColumnDstPort},
+				},
+			},
+			Error: true,
+		}, {
+			Description: "excluded alias dependency",
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0},
+				{
+					Interval:       time.Minute,
+					IncludeColumns: []schema.ColumnKey{schema.ColumnSrcNetPrefix},
+					ExcludeColumns: []schema.ColumnKey{schema.ColumnSrcNetMask},
+				},
+			},
+			Error: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.Description, func(t *testing.T) {
+			c := Component{
+				config: Configuration{Resolutions: tc.Resolutions},
+				d:      &Dependencies{Schema: schema.NewMock(t)},
+			}
+			err := c.checkResolutionColumns()
+			if err == nil && tc.Error {
+				t.Fatal("checkResolutionColumns() did not error")
+			} else if err != nil && !tc.Error {
+				t.Fatalf("checkResolutionColumns() error:\n%+v", err)
+			}
+		})
+	}
+}
+
+func TestResolutionAliasDependencies(t *testing.T) {
+	c := Component{
+		config: Configuration{
+			Resolutions: []ResolutionConfiguration{
+				{Interval: 0},
+				{Interval: time.Minute},
+				{
+					Interval:       time.Hour,
+					IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort, schema.ColumnSrcNetPrefix},
+				},
+			},
+		},
+		d: &Dependencies{Schema: schema.NewMock(t)},
+	}
+	if err := c.checkResolutionColumns(); err != nil {
+		t.Fatalf("checkResolutionColumns() error:\n%+v", err)
+	}
+	got := [][]schema.ColumnKey{}
+	for _, resolution := range c.config.Resolutions {
+		got = append(got, resolution.IncludeColumns)
+	}
+	expected := [][]schema.ColumnKey{
+		nil,
+		{},
+		{schema.ColumnDstPort, schema.ColumnSrcNetPrefix, schema.ColumnSrcAddr, schema.ColumnSrcNetMask},
+	}
+	if diff := helpers.Diff(got, expected); diff != "" {
+		t.Fatalf("checkResolutionColumns() (-got, +want):\n%s", diff)
+	}
+}
+
+func TestResolutionColumns(t *testing.T) {
+	sch := schema.NewMock(t)
+	c := Component{d: &Dependencies{Schema: sch}}
+
+	// Without column selection, we should get the same columns as with the
+	// schema helpers.
+	resolution := ResolutionConfiguration{Interval: time.Minute}
+	if diff := helpers.Diff(c.resolutionCreateTable(resolution),
+		sch.ClickHouseCreateTable(schema.ClickHouseSkipMainOnlyColumns)); diff != "" {
+		t.Errorf("resolutionCreateTable() (-got, +want):\n%s", diff)
+	}
+	if diff := helpers.Diff(c.resolutionSelectColumns(resolution),
+		sch.ClickHouseSelectColumns(
+			schema.ClickHouseSkipTimeReceived,
+			schema.ClickHouseSkipMainOnlyColumns,
+			schema.ClickHouseSkipAliasedColumns)); diff != "" {
+		t.Errorf("resolutionSelectColumns() (-got, +want):\n%s", diff)
+	}
+	sortingKeys := c.resolutionSortingKeys(resolution)
+	primaryKeys := sch.ClickHousePrimaryKeys()
+	if diff := helpers.Diff(sortingKeys[:len(primaryKeys)], primaryKeys); diff != "" {
+		t.Errorf("resolutionSortingKeys() (-got, +want):\n%s", diff)
+	}
+	for _, column := range sch.Columns() {
+		if (column.ClickHouseMainOnly || column.ClickHouseNotSortingKey) &&
+			slices.Contains(sortingKeys, column.Name) {
+			t.Errorf("resolutionSortingKeys() contains %s", column.Name)
+		}
+	}
+
+	// With column selection
+	resolution = ResolutionConfiguration{
+		Interval:       time.Minute,
+		IncludeColumns: []schema.ColumnKey{schema.ColumnDstPort},
+		ExcludeColumns: []schema.ColumnKey{schema.ColumnDstCountry},
+	}
+	selected := c.resolutionSelectColumns(resolution)
+	if !slices.Contains(selected, "DstPort") {
+		t.Error("resolutionSelectColumns() does not contain DstPort")
+	}
+	if slices.Contains(selected, "SrcPort") {
+		t.Error("resolutionSelectColumns() contains SrcPort")
+	}
+	if slices.Contains(selected, "DstCountry") {
+		t.Error("resolutionSelectColumns() contains DstCountry")
+	}
+	sortingKeys = c.resolutionSortingKeys(resolution)
+	if !slices.Contains(sortingKeys, "DstPort") {
+		t.Error("resolutionSortingKeys() does not contain DstPort")
+	}
+	if slices.Contains(sortingKeys, "DstCountry") {
+		t.Error("resolutionSortingKeys() contains DstCountry")
+	}
+}
@@ -40,6 +40,10 @@ type Component struct {
 	networkSources        map[string][]externalNetworkAttributes
 	networkSourcesLock    sync.RWMutex
 
+	macVendorSourcesFetcher *remotedatasourcefetcher.Component[externalMACVendor]
+	macVendorSources        map[string][]externalMACVendor
+	macVendorSourcesLock    sync.RWMutex
+
 	networksCSVReady      chan bool // close when networks.csv was generated once
 	networksCSVUpdateChan chan bool // channel to write to to request updates
 	networksCSVFile       *os.File
@@ -64,6 +68,7 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		migrationsDone:        make(chan bool),
 		migrationsOnce:        make(chan bool),
 		networkSources:        make(map[string][]externalNetworkAttributes),
+		macVendorSources:      make(map[string][]externalMACVendor),
 		networksCSVReady:      make(chan bool),
 		networksCSVUpdateChan: make(chan bool, 1),
 	}
@@ -73,6 +78,11 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize remote data source fetcher component: %w", err)
 	}
+	c.macVendorSourcesFetcher, err = remotedatasourcefetcher.New[externalMACVendor](
+		r, c.UpdateMACVendorSource, "mac_vendor_source", configuration.MACVendorSources)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize remote data source fetcher component: %w", err)
+	}
 	c.initMetrics()
 
 	if err := c.registerHTTPHandlers(); err != nil {
@@ -86,6 +96,9 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 	if len(c.config.Resolutions) == 0 || c.config.Resolutions[0].Interval != 0 {
 		return nil, fmt.Errorf("resolutions need to be configured, including interval: 0")
 	}
+	if err := c.checkResolutionColumns(); err != nil {
+		return nil, err
+	}
 
 	c.d.Daemon.Track(&c.t, "orchestrator/clickhouse")
 
@@ -137,6 +150,11 @@ func (c *Component) Start() error {
 		return fmt.Errorf("unable to start network sources fetcher component: %w", err)
 	}
 
+	// MAC vendor sources update
+	if err := c.macVendorSourcesFetcher.Start(); err != nil {
+		return fmt.Errorf("unable to start MAC vendor sources fetcher component: %w", err)
+	}
+
 	// GeoIP updates
 	notifyChan := c.d.GeoIP.Notify()
 	c.t.Go(func() error {
@@ -3,6 +3,8 @@
 
 package orchestrator
 
+import "net/netip"
+
 // Configuration describes the configuration for the broker.
 type Configuration struct{}
 
@@ -10,3 +12,15 @@ type Configuration struct{}
 func DefaultConfiguration() Configuration {
 	return Configuration{}
 }
+
+// Selector describes how to select a named configuration from the attributes
+// of the host requesting a configuration. A host matches when one of the
+// hostname patterns or one of the subnets matches.
+type Selector struct {
+	// Hostnames is a list of glob patterns to match the hostname of the
+	// requesting host.
+	Hostnames []string
+	// Subnets is a list of subnets to match the address of the requesting
+	// host.
+	Subnets []netip.Prefix
+}
@@ -5,6 +5,9 @@ package orchestrator
 
 import (
 	"net/http"
+	"net/netip"
+	"path"
+	"sort"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -14,23 +17,33 @@ func (c *Component) configurationHandlerFunc(gc *gin.Context) {
 	service := gc.Param("service")
 	indexStr := gc.Param("index")
 	index, err := strconv.Atoi(indexStr)
-	if indexStr != "" && err != nil {
-		gc.JSON(http.StatusNotFound, gin.H{"message": "Invalid configuration index."})
-		return
-	}
 
 	c.serviceLock.Lock()
 	var configuration interface{}
-	serviceConfigurations, ok := c.serviceConfigurations[ServiceType(service)]
-	if ok {
-		l := len(serviceConfigurations)
-		switch {
-		case l == 0:
-			ok = false
-		case index < l:
-			configuration = serviceConfigurations[index]
-		default:
-			configuration = serviceConfigurations[0]
+	var ok bool
+	if indexStr != "" && err != nil {
+		// This is a named configuration
+		var named namedConfiguration
+		named, ok = c.namedServiceConfigurations[ServiceType(service)][indexStr]
+		configuration = named.configuration
+	} else {
+		if indexStr == "" {
+			configuration, ok = c.selectNamedConfiguration(ServiceType(service), gc)
+		}
+		if !ok {
+			var serviceConfigurations []interface{}
+			serviceConfigurations, ok = c.serviceConfigurations[ServiceType(service)]
+			if ok {
+				l := len(serviceConfigurations)
+				switch {
+				case l == 0:
+					ok = false
+				case index < l:
+					configuration = serviceConfigurations[index]
+				default:
+					configuration = serviceConfigurations[0]
+				}
+			}
 		}
 	}
 	c.serviceLock.Unlock()
@@ -41,3 +54,59 @@ func (c *Component) configurationHandlerFunc(gc *gin.Context) {
 	}
 	gc.YAML(http.StatusOK, configuration)
 }
+
+// selectNamedConfiguration selects a named configuration using the attributes
+// of the requesting host: its hostname, provided as a query parameter, and its
+// IP address. Hostnames are tried first. Named configurations are tried in
+// alphabetical order. The lock should be held.
+func (c *Component) selectNamedConfiguration(service ServiceType, gc *gin.Context) (interface{}, bool) {
+	namedConfigurations := c.namedServiceConfigurations[service]
+	if len(namedConfigurations) == 0 {
+		return nil, false
+	}
+	hostname := gc.Query("hostname")
+	address, _ := netip.ParseAddr(gc.RemoteIP())
+	address = address.Unmap()
+	names := make([]string, 0, len(namedConfigurations))
+	for name := range namedConfigurations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if hostname != "" {
+		for _, name := range names {
+			named := namedConfigurations[name]
+			for _, pattern := range named.selector.Hostnames {
+				if ok, _ := path.Match(pattern, hostname); ok {
+					return named.configuration, true
+				}
+			}
+		}
+	}
+	if address.IsValid() {
+		for _, name := range names {
+			named := namedConfigurations[name]
+			for _, subnet := range named.selector.Subnets {
+				if subnet.Contains(address) {
+					return named.configuration, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+func (c *Component) configurationNamesHandlerFunc(gc *gin.Context) {
+	service := gc.Param("service")
+	c.serviceLock.Lock()
+	names := []string{}
+	for name := range c.namedServiceConfigurations[ServiceType(service)] {
+		names = append(names, name)
+	}
+	count := len(c.serviceConfigurations[ServiceType(service)])
+	c.serviceLock.Unlock()
+	sort.Strings(names)
+	gc.JSON(http.StatusOK, gin.H{
+		"count": count,
+		"names": names,
+	})
+}
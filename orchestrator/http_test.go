@@ -4,8 +4,11 @@
 package orchestrator
 
 import (
+	"net/netip"
 	"testing"
 
+	"github.com/gin-gonic/gin"
+
 	"akvorado/common/helpers"
 	"akvorado/common/httpserver"
 	"akvorado/common/reporter"
@@ -65,3 +68,64 @@ func TestConfigurationEndpoint(t *testing.T) {
 		},
 	})
 }
+
+func TestNamedConfigurationEndpoint(t *testing.T) {
+	r := reporter.NewMock(t)
+	h := httpserver.NewMock(t, r)
+	c, err := New(r, DefaultConfiguration(), Dependencies{
+		HTTP: h,
+	})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	c.RegisterConfiguration(InletService, map[string]string{
+		"hello": "Hello world!",
+	})
+	c.RegisterNamedConfiguration(InletService, "paris", Selector{
+		Hostnames: []string{"inlet-paris-*"},
+	}, map[string]string{
+		"hello": "Bonjour !",
+	})
+	c.RegisterNamedConfiguration(InletService, "local", Selector{
+		Subnets: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+	}, map[string]string{
+		"hello": "Hello neighbour!",
+	})
+	c.RegisterNamedConfiguration(InletService, "london", Selector{
+		Hostnames: []string{"inlet-london-*"},
+	}, map[string]string{
+		"hello": "Hello mate!",
+	})
+
+	helpers.TestHTTPEndpoints(t, h.LocalAddr(), helpers.HTTPEndpointCases{
+		{
+			URL:         "/api/v0/orchestrator/configuration/inlet/paris",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{`hello: Bonjour !`},
+		}, {
+			URL:         "/api/v0/orchestrator/configuration/inlet/0",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{`hello: Hello world!`},
+		}, {
+			Description: "select by address",
+			URL:         "/api/v0/orchestrator/configuration/inlet",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{`hello: Hello neighbour!`},
+		}, {
+			Description: "select by hostname",
+			URL:         "/api/v0/orchestrator/configuration/inlet?hostname=inlet-london-1",
+			ContentType: "application/yaml; charset=utf-8",
+			FirstLines:  []string{`hello: Hello mate!`},
+		}, {
+			URL:         "/api/v0/orchestrator/configuration/inlet/berlin",
+			ContentType: "application/json; charset=utf-8",
+			StatusCode:  404,
+		}, {
+			URL: "/api/v0/orchestrator/configurations/inlet",
+			JSONOutput: gin.H{
+				"count": 1,
+				"names": []string{"local", "london", "paris"},
+			},
+		},
+	})
+}
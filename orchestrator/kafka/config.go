@@ -12,6 +12,9 @@ type Configuration struct {
 	kafka.Configuration `mapstructure:",squash" yaml:",inline"`
 	// TopicConfiguration describes the topic configuration.
 	TopicConfiguration TopicConfiguration
+	// RoutesTopic tells if the topic for BGP route updates should be
+	// provisioned too. It is set when ClickHouse stores the route history.
+	RoutesTopic bool `mapstructure:"-" yaml:"-"`
 }
 
 // TopicConfiguration describes the configuration for a topic
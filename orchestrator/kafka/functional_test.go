@@ -163,3 +163,46 @@ func TestTopicMorePartitions(t *testing.T) {
 			topic.NumPartitions, topic.ReplicationFactor)
 	}
 }
+
+func TestRoutesTopicCreation(t *testing.T) {
+	client, brokers := kafka.SetupKafkaBroker(t)
+
+	topicName := fmt.Sprintf("test-topic-%d", rand.Int())
+	expectedTopicName := fmt.Sprintf("%s-routes", topicName)
+
+	configuration := DefaultConfiguration()
+	configuration.Topic = topicName
+	configuration.RoutesTopic = true
+	configuration.TopicConfiguration = TopicConfiguration{
+		NumPartitions:     2,
+		ReplicationFactor: 1,
+		ConfigEntries:     map[string]*string{},
+	}
+	configuration.Brokers = brokers
+	configuration.Version = kafka.Version(sarama.V2_8_1_0)
+	c, err := New(reporter.NewMock(t), configuration, Dependencies{Schema: schema.NewMock(t)})
+	if err != nil {
+		t.Fatalf("New() error:\n%+v", err)
+	}
+	helpers.StartStop(t, c)
+
+	if err := client.RefreshMetadata(); err != nil {
+		t.Fatalf("RefreshMetadata() error:\n%+v", err)
+	}
+	adminClient, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		t.Fatalf("NewClusterAdmin() error:\n%+v", err)
+	}
+	topics, err := adminClient.ListTopics()
+	if err != nil {
+		t.Fatalf("ListTopics() error:\n%+v", err)
+	}
+	topic, ok := topics[expectedTopicName]
+	if !ok {
+		t.Fatal("ListTopics() did not find the routes topic")
+	}
+	if topic.NumPartitions != 2 || topic.ReplicationFactor != 1 {
+		t.Fatalf("Topic does not have 2/1 for partitions/replication but %d/%d",
+			topic.NumPartitions, topic.ReplicationFactor)
+	}
+}
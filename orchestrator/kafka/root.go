@@ -21,8 +21,9 @@ type Component struct {
 	d      Dependencies
 	config Configuration
 
-	kafkaConfig *sarama.Config
-	kafkaTopic  string
+	kafkaClusters []kafka.Configuration
+	kafkaConfigs  []*sarama.Config
+	kafkaTopics   []string
 }
 
 // Dependencies are the dependencies for the Kafka component
@@ -32,22 +33,28 @@ type Dependencies struct {
 
 // New creates a new Kafka configurator.
 func New(r *reporter.Reporter, config Configuration, dependencies Dependencies) (*Component, error) {
-	kafkaConfig, err := kafka.NewConfig(config.Configuration)
-	if err != nil {
-		return nil, err
-	}
-	if err := kafkaConfig.Validate(); err != nil {
-		return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
-	}
-
-	return &Component{
+	c := Component{
 		r:      r,
 		d:      dependencies,
 		config: config,
 
-		kafkaConfig: kafkaConfig,
-		kafkaTopic:  fmt.Sprintf("%s-%s", config.Topic, dependencies.Schema.ProtobufMessageHash()),
-	}, nil
+		kafkaClusters: config.Clusters(),
+		kafkaTopics:   []string{fmt.Sprintf("%s-%s", config.Topic, dependencies.Schema.ProtobufMessageHash())},
+	}
+	if config.RoutesTopic {
+		c.kafkaTopics = append(c.kafkaTopics, fmt.Sprintf("%s-routes", config.Topic))
+	}
+	for _, cluster := range c.kafkaClusters {
+		kafkaConfig, err := kafka.NewConfig(cluster)
+		if err != nil {
+			return nil, err
+		}
+		if err := kafkaConfig.Validate(); err != nil {
+			return nil, fmt.Errorf("cannot validate Kafka configuration: %w", err)
+		}
+		c.kafkaConfigs = append(c.kafkaConfigs, kafkaConfig)
+	}
+	return &c, nil
 }
 
 // Start starts Kafka configuration.
@@ -59,33 +66,51 @@ func (c *Component) Start() error {
 		c.r.Info().Msg("Kafka component stopped")
 	}()
 
-	// Create topic
-	admin, err := sarama.NewClusterAdmin(c.config.Brokers, c.kafkaConfig)
+	// Create topics on each cluster. Only the primary cluster is mandatory.
+	for cluster := range c.kafkaClusters {
+		for _, topic := range c.kafkaTopics {
+			if err := c.createOrUpdateTopic(cluster, topic); err != nil {
+				if cluster == 0 {
+					return err
+				}
+				c.r.Err(err).
+					Int("cluster", cluster).
+					Msg("unable to provision topic on standby cluster")
+			}
+		}
+	}
+	return nil
+}
+
+// createOrUpdateTopic creates or updates a topic on the provided cluster.
+func (c *Component) createOrUpdateTopic(cluster int, kafkaTopic string) error {
+	brokers := c.kafkaClusters[cluster].Brokers
+	admin, err := sarama.NewClusterAdmin(brokers, c.kafkaConfigs[cluster])
 	if err != nil {
 		c.r.Err(err).
-			Str("brokers", strings.Join(c.config.Brokers, ",")).
+			Str("brokers", strings.Join(brokers, ",")).
 			Msg("unable to get admin client for topic creation")
 		return fmt.Errorf("unable to get admin client for topic creation: %w", err)
 	}
 	defer admin.Close()
 	l := c.r.With().
-		Str("brokers", strings.Join(c.config.Brokers, ",")).
-		Str("topic", c.kafkaTopic).
+		Str("brokers", strings.Join(brokers, ",")).
+		Str("topic", kafkaTopic).
 		Logger()
 	topics, err := admin.ListTopics()
 	if err != nil {
 		l.Err(err).Msg("unable to get metadata for topics")
 		return fmt.Errorf("unable to get metadata for topics: %w", err)
 	}
-	if topic, ok := topics[c.kafkaTopic]; !ok {
-		if err := admin.CreateTopic(c.kafkaTopic,
+	if topic, ok := topics[kafkaTopic]; !ok {
+		if err := admin.CreateTopic(kafkaTopic,
 			&sarama.TopicDetail{
 				NumPartitions:     c.config.TopicConfiguration.NumPartitions,
 				ReplicationFactor: c.config.TopicConfiguration.ReplicationFactor,
 				ConfigEntries:     c.config.TopicConfiguration.ConfigEntries,
 			}, false); err != nil {
 			l.Err(err).Msg("unable to create topic")
-			return fmt.Errorf("unable to create topic %q: %w", c.kafkaTopic, err)
+			return fmt.Errorf("unable to create topic %q: %w", kafkaTopic, err)
 		}
 		l.Info().Msg("topic created")
 	} else {
@@ -94,10 +119,10 @@ func (c *Component) Start() error {
 				topic.NumPartitions, c.config.TopicConfiguration.NumPartitions)
 		} else if topic.NumPartitions < c.config.TopicConfiguration.NumPartitions {
 			nb := c.config.TopicConfiguration.NumPartitions
-			if err := admin.CreatePartitions(c.kafkaTopic, nb, nil, false); err != nil {
+			if err := admin.CreatePartitions(kafkaTopic, nb, nil, false); err != nil {
 				l.Err(err).Msg("unable to add more partitions")
 				return fmt.Errorf("unable to add more partitions to topic %q: %w",
-					c.kafkaTopic, err)
+					kafkaTopic, err)
 			}
 		}
 		if c.config.TopicConfiguration.ReplicationFactor != topic.ReplicationFactor {
@@ -106,10 +131,10 @@ func (c *Component) Start() error {
 				topic.ReplicationFactor, c.config.TopicConfiguration.ReplicationFactor)
 		}
 		if ShouldAlterConfiguration(c.config.TopicConfiguration.ConfigEntries, topic.ConfigEntries, c.config.TopicConfiguration.ConfigEntriesStrictSync) {
-			if err := admin.AlterConfig(sarama.TopicResource, c.kafkaTopic, c.config.TopicConfiguration.ConfigEntries, false); err != nil {
+			if err := admin.AlterConfig(sarama.TopicResource, kafkaTopic, c.config.TopicConfiguration.ConfigEntries, false); err != nil {
 				l.Err(err).Msg("unable to set topic configuration")
 				return fmt.Errorf("unable to set topic configuration for %q: %w",
-					c.kafkaTopic, err)
+					kafkaTopic, err)
 			}
 			l.Info().Msg("topic updated")
 		}
@@ -17,8 +17,15 @@ type Component struct {
 	d      *Dependencies
 	config Configuration
 
-	serviceLock           sync.Mutex
-	serviceConfigurations map[ServiceType][]interface{}
+	serviceLock                sync.Mutex
+	serviceConfigurations      map[ServiceType][]interface{}
+	namedServiceConfigurations map[ServiceType]map[string]namedConfiguration
+}
+
+// namedConfiguration is a configuration registered with a name.
+type namedConfiguration struct {
+	selector      Selector
+	configuration interface{}
 }
 
 // Dependencies define the dependencies of the broker.
@@ -47,11 +54,13 @@ func New(r *reporter.Reporter, configuration Configuration, dependencies Depende
 		d:      &dependencies,
 		config: configuration,
 
-		serviceConfigurations: map[ServiceType][]interface{}{},
+		serviceConfigurations:      map[ServiceType][]interface{}{},
+		namedServiceConfigurations: map[ServiceType]map[string]namedConfiguration{},
 	}
 
 	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/:service", c.configurationHandlerFunc)
 	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configuration/:service/:index", c.configurationHandlerFunc)
+	c.d.HTTP.GinRouter.GET("/api/v0/orchestrator/configurations/:service", c.configurationNamesHandlerFunc)
 
 	return &c, nil
 }
@@ -65,3 +74,18 @@ func (c *Component) RegisterConfiguration(service ServiceType, configuration int
 	c.serviceConfigurations[service] = append(c.serviceConfigurations[service], configuration)
 	c.serviceLock.Unlock()
 }
+
+// RegisterNamedConfiguration registers a named configuration for a service.
+// The configuration can be retrieved by its name or by the attributes of the
+// requesting host matching the provided selector.
+func (c *Component) RegisterNamedConfiguration(service ServiceType, name string, selector Selector, configuration interface{}) {
+	c.serviceLock.Lock()
+	if _, ok := c.namedServiceConfigurations[service]; !ok {
+		c.namedServiceConfigurations[service] = map[string]namedConfiguration{}
+	}
+	c.namedServiceConfigurations[service][name] = namedConfiguration{
+		selector:      selector,
+		configuration: configuration,
+	}
+	c.serviceLock.Unlock()
+}